@@ -4,18 +4,23 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
 )
 
 type LeptonEvents []LeptonComponentEvents
 type LeptonStates []LeptonComponentStates
 type LeptonMetrics []LeptonComponentMetrics
 type LeptonInfo []LeptonComponentInfo
+type LeptonHistory []LeptonComponentHistory
 
 type LeptonComponentEvents struct {
 	Component string             `json:"component"`
 	StartTime time.Time          `json:"startTime"`
 	EndTime   time.Time          `json:"endTime"`
 	Events    []components.Event `json:"events"`
+	// NextCursor is set if there are more events to read,
+	// to be passed as the "cursor" query parameter of the next request.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type LeptonComponentStates struct {
@@ -34,3 +39,137 @@ type LeptonComponentInfo struct {
 	EndTime   time.Time       `json:"endTime"`
 	Info      components.Info `json:"info"`
 }
+
+type LeptonComponentHistory struct {
+	Component   string             `json:"component"`
+	StartTime   time.Time          `json:"startTime"`
+	EndTime     time.Time          `json:"endTime"`
+	Transitions []HealthTransition `json:"transitions"`
+}
+
+// WatchEventKind is the kind of the change streamed by the watch endpoint.
+type WatchEventKind string
+
+const (
+	// WatchEventKindEvent is a component event insert.
+	WatchEventKindEvent WatchEventKind = "event"
+	// WatchEventKindState is a component state health transition.
+	WatchEventKindState WatchEventKind = "state"
+)
+
+// WatchEvent is a single change streamed by the watch endpoint.
+// Either "Event" or "State" is set, depending on the kind.
+type WatchEvent struct {
+	Kind      WatchEventKind    `json:"kind"`
+	Component string            `json:"component"`
+	Time      time.Time         `json:"time"`
+	Event     *components.Event `json:"event,omitempty"`
+	State     *StateTransition  `json:"state,omitempty"`
+}
+
+// StateTransition is the health transition of a component state.
+type StateTransition struct {
+	// Name is the component state name.
+	Name           string `json:"name"`
+	PreviousHealth string `json:"previousHealth"`
+	Health         string `json:"health"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// HealthTransition is the recorded health transition of a component state.
+type HealthTransition struct {
+	Time time.Time `json:"time"`
+	StateTransition
+}
+
+// NodeHealth is the node-level health verdict aggregated from all the components.
+type NodeHealth struct {
+	Time time.Time `json:"time"`
+	// Health is the verdict, one of "Healthy", "Degraded", and "Unhealthy".
+	Health string `json:"health"`
+	// Healthy is true only if the verdict is "Healthy".
+	Healthy bool `json:"healthy"`
+	// Reason is the human-readable summary of the verdict.
+	Reason string `json:"reason"`
+	// Score is the sum of the weighted component health scores
+	// (0 for healthy, 0.5 for degraded, 1 for unhealthy).
+	Score float64 `json:"score"`
+
+	// Components are the components contributing to the verdict
+	// (i.e., not healthy and with a non-zero weight).
+	Components []ComponentHealth `json:"components,omitempty"`
+	// SuggestedActions are merged from the contributing components.
+	SuggestedActions *common.SuggestedActions `json:"suggested_actions,omitempty"`
+}
+
+// ComponentHealth is the aggregated health of a component.
+type ComponentHealth struct {
+	Component string  `json:"component"`
+	Health    string  `json:"health"`
+	Weight    float64 `json:"weight"`
+	Reason    string  `json:"reason,omitempty"`
+
+	SuggestedActions *common.SuggestedActions `json:"suggested_actions,omitempty"`
+}
+
+// MetricsAggregation is the metric aggregated into the fixed-size steps,
+// computed on the server not to pull the raw metrics.
+type MetricsAggregation struct {
+	MetricName string `json:"metricName"`
+	// Func is the aggregate function, one of "avg", "min", "max",
+	// "p50", "p95", "p99", "rate", and "delta".
+	Func        string    `json:"func"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	StepSeconds int64     `json:"stepSeconds"`
+	// Series are the aggregated series, one per secondary name
+	// (e.g., GPU UUID, mount point).
+	Series []AggregatedSeries `json:"series"`
+}
+
+// AggregatedSeries is the aggregated metric of a secondary name,
+// in the ascending order of time, without the steps with no data.
+type AggregatedSeries struct {
+	MetricSecondaryName string            `json:"metricSecondaryName,omitempty"`
+	Points              []AggregatedPoint `json:"points"`
+}
+
+// AggregatedPoint is the aggregated value of the step starting at the time.
+type AggregatedPoint struct {
+	UnixSeconds int64   `json:"unixSeconds"`
+	Value       float64 `json:"value"`
+}
+
+// Incident is an Xid error grouped with the related events of the other components
+// (e.g., the NVSwitch SXid, the ECC errors, the row remapping, the HW slowdown)
+// within the correlation window, to triage them as one.
+type Incident struct {
+	// ID is derived from the first Xid event, stable across the requests.
+	ID string `json:"id"`
+	// Xids are the Xid codes of the incident, in the order of occurrence.
+	Xids []int `json:"xids"`
+	// Type is the most severe event type of the Xids.
+	Type common.EventType `json:"type"`
+
+	// PCIBusID is the PCI bus ID of the GPU in the Xid message (e.g., "0000:05:00").
+	PCIBusID string `json:"pciBusID,omitempty"`
+	// GPUUUID is the UUID of the GPU, if resolved from the PCI bus ID.
+	GPUUUID string `json:"gpuUUID,omitempty"`
+	// PID and ProcessName are of the first Xid that named the process.
+	PID         int    `json:"pid,omitempty"`
+	ProcessName string `json:"processName,omitempty"`
+
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	// Timeline are the Xid and the related events in the ascending order of time.
+	Timeline []IncidentEvent `json:"timeline"`
+
+	// SuggestedActions are of the most severe Xid.
+	SuggestedActions *common.SuggestedActions `json:"suggestedActions,omitempty"`
+}
+
+// IncidentEvent is an event in the incident timeline.
+type IncidentEvent struct {
+	Component string           `json:"component"`
+	Event     components.Event `json:"event"`
+}
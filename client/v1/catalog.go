@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/leptonai/gpud/pkg/nvidia-query/catalog"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+// GetXIDCatalog returns the NVIDIA XID and SXID catalogs in effect, with the overlay file applied.
+func GetXIDCatalog(ctx context.Context, addr string, opts ...OpOption) (catalog.Catalog, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return catalog.Catalog{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1%s", addr, server.URLPathXIDCatalog), nil)
+	if err != nil {
+		return catalog.Catalog{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if op.requestContentType != "" {
+		req.Header.Set(server.RequestHeaderContentType, op.requestContentType)
+	}
+	if op.requestAcceptEncoding != "" {
+		req.Header.Set(server.RequestHeaderAcceptEncoding, op.requestAcceptEncoding)
+	}
+
+	resp, err := createDefaultHTTPClient().Do(req)
+	if err != nil {
+		return catalog.Catalog{}, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return catalog.Catalog{}, errors.New("server not ready, response not 200")
+	}
+
+	return ReadXIDCatalog(resp.Body, opts...)
+}
+
+// ReadXIDCatalog reads the XID and SXID catalogs from the response body.
+func ReadXIDCatalog(rd io.Reader, opts ...OpOption) (catalog.Catalog, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return catalog.Catalog{}, err
+	}
+
+	if op.requestAcceptEncoding == server.RequestHeaderEncodingGzip {
+		gr, err := gzip.NewReader(rd)
+		if err != nil {
+			return catalog.Catalog{}, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gr.Close()
+		rd = gr
+	}
+
+	var ct catalog.Catalog
+	switch op.requestContentType {
+	case server.RequestHeaderJSON, "":
+		if err := json.NewDecoder(rd).Decode(&ct); err != nil {
+			return catalog.Catalog{}, fmt.Errorf("failed to decode json: %w", err)
+		}
+	case server.RequestHeaderYAML:
+		b, err := io.ReadAll(rd)
+		if err != nil {
+			return catalog.Catalog{}, fmt.Errorf("failed to read yaml: %w", err)
+		}
+		if err := yaml.Unmarshal(b, &ct); err != nil {
+			return catalog.Catalog{}, fmt.Errorf("failed to unmarshal yaml: %w", err)
+		}
+	default:
+		return catalog.Catalog{}, fmt.Errorf("unsupported content type: %s", op.requestContentType)
+	}
+	return ct, nil
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/nvidia-query/catalog"
+	"github.com/leptonai/gpud/pkg/nvidia-query/sxid"
+	"github.com/leptonai/gpud/pkg/nvidia-query/xid"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+func TestGetXIDCatalog(t *testing.T) {
+	want := catalog.Catalog{
+		File:  "/etc/gpud/xid-catalog.yaml",
+		XIDs:  []xid.Detail{{Xid: 13, Name: "Graphics Engine Exception", EventType: common.EventTypeCritical}},
+		SXIDs: []sxid.Detail{{SXid: 11004, Name: "Ingress invalid ACL", EventType: common.EventTypeWarning}},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		opts        []OpOption
+	}{
+		{name: "json"},
+		{name: "yaml", contentType: server.RequestHeaderYAML, opts: []OpOption{WithRequestContentTypeYAML()}},
+		{name: "gzip", opts: []OpOption{WithAcceptEncodingGzip()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/catalog/xid", r.URL.Path)
+				require.Equal(t, tt.contentType, r.Header.Get(server.RequestHeaderContentType))
+
+				var b []byte
+				var err error
+				if tt.contentType == server.RequestHeaderYAML {
+					b, err = yaml.Marshal(want)
+				} else {
+					b, err = json.Marshal(want)
+				}
+				require.NoError(t, err)
+				if r.Header.Get(server.RequestHeaderAcceptEncoding) == server.RequestHeaderEncodingGzip {
+					b = gzipContent(t, b)
+				}
+				_, _ = w.Write(b)
+			}))
+			defer srv.Close()
+
+			got, err := GetXIDCatalog(context.Background(), srv.URL, tt.opts...)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		})
+	}
+}
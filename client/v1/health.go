@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+// GetHealth returns the node health verdict aggregated from all the components.
+func GetHealth(ctx context.Context, addr string, opts ...OpOption) (v1.NodeHealth, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return v1.NodeHealth{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1%s", addr, server.URLPathHealth), nil)
+	if err != nil {
+		return v1.NodeHealth{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if op.requestContentType != "" {
+		req.Header.Set(server.RequestHeaderContentType, op.requestContentType)
+	}
+	if op.requestAcceptEncoding != "" {
+		req.Header.Set(server.RequestHeaderAcceptEncoding, op.requestAcceptEncoding)
+	}
+
+	resp, err := createDefaultHTTPClient().Do(req)
+	if err != nil {
+		return v1.NodeHealth{}, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return v1.NodeHealth{}, errors.New("server not ready, response not 200")
+	}
+
+	return ReadHealth(resp.Body, opts...)
+}
+
+// ReadHealth reads the node health verdict from the response body.
+func ReadHealth(rd io.Reader, opts ...OpOption) (v1.NodeHealth, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return v1.NodeHealth{}, err
+	}
+
+	if op.requestAcceptEncoding == server.RequestHeaderEncodingGzip {
+		gr, err := gzip.NewReader(rd)
+		if err != nil {
+			return v1.NodeHealth{}, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gr.Close()
+		rd = gr
+	}
+
+	var h v1.NodeHealth
+	switch op.requestContentType {
+	case server.RequestHeaderJSON, "":
+		if err := json.NewDecoder(rd).Decode(&h); err != nil {
+			return v1.NodeHealth{}, fmt.Errorf("failed to decode json: %w", err)
+		}
+	case server.RequestHeaderYAML:
+		b, err := io.ReadAll(rd)
+		if err != nil {
+			return v1.NodeHealth{}, fmt.Errorf("failed to read yaml: %w", err)
+		}
+		if err := yaml.Unmarshal(b, &h); err != nil {
+			return v1.NodeHealth{}, fmt.Errorf("failed to unmarshal yaml: %w", err)
+		}
+	default:
+		return v1.NodeHealth{}, fmt.Errorf("unsupported content type: %s", op.requestContentType)
+	}
+	return h, nil
+}
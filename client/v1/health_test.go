@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+func TestGetHealth(t *testing.T) {
+	want := v1.NodeHealth{
+		Health: components.StateUnhealthy,
+		Reason: "1 unhealthy component(s): accelerator-nvidia-ecc",
+		Score:  1,
+		Components: []v1.ComponentHealth{
+			{Component: "accelerator-nvidia-ecc", Health: components.StateUnhealthy, Weight: 1},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		opts        []OpOption
+	}{
+		{name: "json"},
+		{name: "yaml", contentType: server.RequestHeaderYAML, opts: []OpOption{WithRequestContentTypeYAML()}},
+		{name: "gzip", opts: []OpOption{WithAcceptEncodingGzip()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/health", r.URL.Path)
+				require.Equal(t, tt.contentType, r.Header.Get(server.RequestHeaderContentType))
+
+				var b []byte
+				var err error
+				if tt.contentType == server.RequestHeaderYAML {
+					b, err = yaml.Marshal(want)
+				} else {
+					b, err = json.Marshal(want)
+				}
+				require.NoError(t, err)
+				if r.Header.Get(server.RequestHeaderAcceptEncoding) == server.RequestHeaderEncodingGzip {
+					b = gzipContent(t, b)
+				}
+				_, _ = w.Write(b)
+			}))
+			defer srv.Close()
+
+			got, err := GetHealth(context.Background(), srv.URL, tt.opts...)
+			require.NoError(t, err)
+			require.Equal(t, want.Health, got.Health)
+			require.Equal(t, want.Reason, got.Reason)
+			require.Equal(t, want.Components, got.Components)
+		})
+	}
+}
+
+func TestGetHealthNotOK(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	_, err := GetHealth(context.Background(), srv.URL)
+	require.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+// GetIncidents returns the NVIDIA Xid errors correlated with the related events
+// of the other components (e.g., SXid, ECC errors, remapped rows, HW slowdown).
+// Use "WithSince" to query the Xid errors since the time,
+// and "WithIncidentWindow" for the correlation window.
+func GetIncidents(ctx context.Context, addr string, opts ...OpOption) ([]v1.Incident, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	if !op.since.IsZero() {
+		q.Set("startTime", strconv.FormatInt(op.since.Unix(), 10))
+	}
+	if op.incidentWindow > 0 {
+		q.Set("window", op.incidentWindow.String())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1%s?%s", addr, server.URLPathIncidents, q.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if op.requestContentType != "" {
+		req.Header.Set(server.RequestHeaderContentType, op.requestContentType)
+	}
+	if op.requestAcceptEncoding != "" {
+		req.Header.Set(server.RequestHeaderAcceptEncoding, op.requestAcceptEncoding)
+	}
+
+	resp, err := createDefaultHTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("invalid incidents request: %s", string(b))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("server not ready, response not 200")
+	}
+
+	return ReadIncidents(resp.Body, opts...)
+}
+
+// ReadIncidents reads the Xid incidents from the response body.
+func ReadIncidents(rd io.Reader, opts ...OpOption) ([]v1.Incident, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return nil, err
+	}
+
+	if op.requestAcceptEncoding == server.RequestHeaderEncodingGzip {
+		gr, err := gzip.NewReader(rd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gr.Close()
+		rd = gr
+	}
+
+	var incidents []v1.Incident
+	switch op.requestContentType {
+	case server.RequestHeaderJSON, "":
+		if err := json.NewDecoder(rd).Decode(&incidents); err != nil {
+			return nil, fmt.Errorf("failed to decode json: %w", err)
+		}
+	case server.RequestHeaderYAML:
+		b, err := io.ReadAll(rd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read yaml: %w", err)
+		}
+		if err := yaml.Unmarshal(b, &incidents); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal yaml: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported content type: %s", op.requestContentType)
+	}
+	return incidents, nil
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+func TestGetIncidents(t *testing.T) {
+	start := time.Unix(1700000000, 0).UTC()
+	want := []v1.Incident{
+		{
+			ID:        "xid-79-0000:05:00-1700000000",
+			Xids:      []int{79},
+			Type:      common.EventTypeFatal,
+			PCIBusID:  "0000:05:00",
+			GPUUUID:   "GPU-a",
+			StartTime: start,
+			EndTime:   start.Add(time.Minute),
+			Timeline: []v1.IncidentEvent{
+				{Component: "accelerator-nvidia-error-xid", Event: components.Event{Time: metav1.Time{Time: start}, Name: "error_xid"}},
+				{Component: "accelerator-nvidia-error-sxid", Event: components.Event{Time: metav1.Time{Time: start.Add(time.Minute)}, Name: "error_sxid"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		opts        []OpOption
+	}{
+		{name: "json"},
+		{name: "yaml", contentType: server.RequestHeaderYAML, opts: []OpOption{WithRequestContentTypeYAML()}},
+		{name: "gzip", opts: []OpOption{WithAcceptEncodingGzip()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/incidents", r.URL.Path)
+				require.Equal(t, "1700000000", r.URL.Query().Get("startTime"))
+				require.Equal(t, "10m0s", r.URL.Query().Get("window"))
+				require.Equal(t, tt.contentType, r.Header.Get(server.RequestHeaderContentType))
+
+				var b []byte
+				var err error
+				if tt.contentType == server.RequestHeaderYAML {
+					b, err = yaml.Marshal(want)
+				} else {
+					b, err = json.Marshal(want)
+				}
+				require.NoError(t, err)
+				if r.Header.Get(server.RequestHeaderAcceptEncoding) == server.RequestHeaderEncodingGzip {
+					b = gzipContent(t, b)
+				}
+				_, _ = w.Write(b)
+			}))
+			defer srv.Close()
+
+			opts := append([]OpOption{WithSince(start), WithIncidentWindow(10 * time.Minute)}, tt.opts...)
+			got, err := GetIncidents(context.Background(), srv.URL, opts...)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		})
+	}
+}
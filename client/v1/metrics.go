@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+// AggregateMetrics returns the metric aggregated by the function
+// (e.g., "avg", "p95", "rate") into the steps, computed on the server.
+// Use "WithSince" and "WithUntil" for the time range,
+// which defaults to the last 30 minutes.
+func AggregateMetrics(ctx context.Context, addr string, metricName string, fn string, opts ...OpOption) (v1.MetricsAggregation, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return v1.MetricsAggregation{}, err
+	}
+
+	q := url.Values{}
+	q.Set("name", metricName)
+	if fn != "" {
+		q.Set("func", fn)
+	}
+	if op.metricSecondaryName != "" {
+		q.Set("secondaryName", op.metricSecondaryName)
+	}
+	if !op.since.IsZero() {
+		q.Set("startTime", strconv.FormatInt(op.since.Unix(), 10))
+	}
+	if !op.until.IsZero() {
+		q.Set("endTime", strconv.FormatInt(op.until.Unix(), 10))
+	}
+	if op.aggregateStep > 0 {
+		q.Set("step", op.aggregateStep.String())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1%s?%s", addr, server.URLPathMetricsAggregate, q.Encode()), nil)
+	if err != nil {
+		return v1.MetricsAggregation{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	if op.requestContentType != "" {
+		req.Header.Set(server.RequestHeaderContentType, op.requestContentType)
+	}
+	if op.requestAcceptEncoding != "" {
+		req.Header.Set(server.RequestHeaderAcceptEncoding, op.requestAcceptEncoding)
+	}
+
+	resp, err := createDefaultHTTPClient().Do(req)
+	if err != nil {
+		return v1.MetricsAggregation{}, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return v1.MetricsAggregation{}, fmt.Errorf("invalid metrics aggregation: %s", string(b))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return v1.MetricsAggregation{}, errors.New("server not ready, response not 200")
+	}
+
+	return ReadMetricsAggregation(resp.Body, opts...)
+}
+
+// ReadMetricsAggregation reads the metrics aggregation from the response body.
+func ReadMetricsAggregation(rd io.Reader, opts ...OpOption) (v1.MetricsAggregation, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return v1.MetricsAggregation{}, err
+	}
+
+	if op.requestAcceptEncoding == server.RequestHeaderEncodingGzip {
+		gr, err := gzip.NewReader(rd)
+		if err != nil {
+			return v1.MetricsAggregation{}, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gr.Close()
+		rd = gr
+	}
+
+	var agg v1.MetricsAggregation
+	switch op.requestContentType {
+	case server.RequestHeaderJSON, "":
+		if err := json.NewDecoder(rd).Decode(&agg); err != nil {
+			return v1.MetricsAggregation{}, fmt.Errorf("failed to decode json: %w", err)
+		}
+	case server.RequestHeaderYAML:
+		b, err := io.ReadAll(rd)
+		if err != nil {
+			return v1.MetricsAggregation{}, fmt.Errorf("failed to read yaml: %w", err)
+		}
+		if err := yaml.Unmarshal(b, &agg); err != nil {
+			return v1.MetricsAggregation{}, fmt.Errorf("failed to unmarshal yaml: %w", err)
+		}
+	default:
+		return v1.MetricsAggregation{}, fmt.Errorf("unsupported content type: %s", op.requestContentType)
+	}
+	return agg, nil
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+func TestAggregateMetrics(t *testing.T) {
+	since := time.Unix(1700000000, 0)
+	want := v1.MetricsAggregation{
+		MetricName:  "power_usage",
+		Func:        "p95",
+		StepSeconds: 3600,
+		Series: []v1.AggregatedSeries{
+			{MetricSecondaryName: "GPU-0", Points: []v1.AggregatedPoint{{UnixSeconds: since.Unix(), Value: 650}}},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1"+server.URLPathMetricsAggregate, r.URL.Path)
+		q := r.URL.Query()
+		require.Equal(t, "power_usage", q.Get("name"))
+		require.Equal(t, "p95", q.Get("func"))
+		require.Equal(t, "GPU-0", q.Get("secondaryName"))
+		require.Equal(t, "1700000000", q.Get("startTime"))
+		require.Empty(t, q.Get("endTime"))
+		require.Equal(t, "1h0m0s", q.Get("step"))
+
+		b, err := json.Marshal(want)
+		require.NoError(t, err)
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	got, err := AggregateMetrics(context.Background(), srv.URL, "power_usage", "p95",
+		WithMetricSecondaryName("GPU-0"),
+		WithSince(since),
+		WithAggregateStep(time.Hour),
+	)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestAggregateMetricsBadRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message":"unknown aggregate function"}`))
+	}))
+	defer srv.Close()
+
+	_, err := AggregateMetrics(context.Background(), srv.URL, "power_usage", "p42")
+	require.ErrorContains(t, err, "unknown aggregate function")
+}
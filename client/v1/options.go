@@ -2,6 +2,10 @@
 package v1
 
 import (
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/common"
 	"github.com/leptonai/gpud/pkg/server"
 )
 
@@ -9,6 +13,25 @@ type Op struct {
 	requestContentType    string
 	requestAcceptEncoding string
 	components            map[string]any
+	gpu                   string
+	watchKinds            map[string]any
+	eventTypes            map[string]any
+
+	// event query filters and pagination
+	since           time.Time
+	until           time.Time
+	eventNames      map[string]any
+	extraInfo       map[string]string
+	messageContains string
+	limit           int
+	cursor          string
+
+	// metric aggregation
+	metricSecondaryName string
+	aggregateStep       time.Duration
+
+	// xid incident correlation
+	incidentWindow time.Duration
 }
 
 type OpOption func(*Op)
@@ -50,3 +73,110 @@ func WithComponent(component string) OpOption {
 		op.components[component] = nil
 	}
 }
+
+// WithGPU returns only the per-GPU states of the device,
+// identified by its UUID, PCI bus ID, or minor number.
+func WithGPU(gpu string) OpOption {
+	return func(op *Op) {
+		op.gpu = gpu
+	}
+}
+
+// WithWatchKind filters the watch events by the kind.
+func WithWatchKind(kind v1.WatchEventKind) OpOption {
+	return func(op *Op) {
+		if op.watchKinds == nil {
+			op.watchKinds = make(map[string]any)
+		}
+		op.watchKinds[string(kind)] = nil
+	}
+}
+
+// WithEventType filters the events (or the watched event inserts) by the event type.
+func WithEventType(eventType common.EventType) OpOption {
+	return func(op *Op) {
+		if op.eventTypes == nil {
+			op.eventTypes = make(map[string]any)
+		}
+		op.eventTypes[string(eventType)] = nil
+	}
+}
+
+// WithSince sets the exclusive lower bound of the event time to query.
+func WithSince(t time.Time) OpOption {
+	return func(op *Op) {
+		op.since = t
+	}
+}
+
+// WithUntil sets the inclusive upper bound of the event time to query.
+func WithUntil(t time.Time) OpOption {
+	return func(op *Op) {
+		op.until = t
+	}
+}
+
+// WithEventName filters the events by the event name.
+func WithEventName(name string) OpOption {
+	return func(op *Op) {
+		if op.eventNames == nil {
+			op.eventNames = make(map[string]any)
+		}
+		op.eventNames[name] = nil
+	}
+}
+
+// WithExtraInfo filters the events by the extra info key-value pair
+// (e.g., "device_uuid").
+func WithExtraInfo(key, value string) OpOption {
+	return func(op *Op) {
+		if op.extraInfo == nil {
+			op.extraInfo = make(map[string]string)
+		}
+		op.extraInfo[key] = value
+	}
+}
+
+// WithMessageContains filters the events whose message contains the substring.
+func WithMessageContains(s string) OpOption {
+	return func(op *Op) {
+		op.messageContains = s
+	}
+}
+
+// WithLimit sets the maximum number of events per component to query.
+func WithLimit(limit int) OpOption {
+	return func(op *Op) {
+		op.limit = limit
+	}
+}
+
+// WithCursor resumes the event query from the "NextCursor" of the previous page.
+func WithCursor(cursor string) OpOption {
+	return func(op *Op) {
+		op.cursor = cursor
+	}
+}
+
+// WithMetricSecondaryName aggregates only the series of the secondary name
+// (e.g., GPU UUID, mount point).
+func WithMetricSecondaryName(name string) OpOption {
+	return func(op *Op) {
+		op.metricSecondaryName = name
+	}
+}
+
+// WithAggregateStep sets the step of the metric aggregation.
+func WithAggregateStep(step time.Duration) OpOption {
+	return func(op *Op) {
+		op.aggregateStep = step
+	}
+}
+
+// WithIncidentWindow sets the window before and after the Xid errors
+// to correlate the related events within.
+func WithIncidentWindow(window time.Duration) OpOption {
+	return func(op *Op) {
+		op.incidentWindow = window
+	}
+}
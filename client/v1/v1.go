@@ -10,6 +10,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 
 	"sigs.k8s.io/yaml"
@@ -217,6 +219,9 @@ func GetStates(ctx context.Context, addr string, opts ...OpOption) (v1.LeptonSta
 		}
 		q.Add("components", strings.Join(components, ","))
 	}
+	if op.gpu != "" {
+		q.Add("gpu", op.gpu)
+	}
 	reqURL.RawQuery = q.Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
@@ -301,13 +306,23 @@ func ReadStates(rd io.Reader, opts ...OpOption) (v1.LeptonStates, error) {
 	return states, nil
 }
 
+// GetEvents queries the component events.
+// Use "WithComponent", "WithSince", "WithUntil", "WithEventType", "WithEventName",
+// "WithExtraInfo", and "WithMessageContains" to filter, and "WithLimit" and
+// "WithCursor" to paginate with the "NextCursor" of each component.
 func GetEvents(ctx context.Context, addr string, opts ...OpOption) (v1.LeptonEvents, error) {
 	op := &Op{}
 	if err := op.applyOpts(opts); err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/events", addr), nil)
+	reqURL, err := url.Parse(fmt.Sprintf("%s/v1/events", addr))
+	if err != nil {
+		return nil, err
+	}
+	reqURL.RawQuery = op.eventQuery().Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -330,6 +345,44 @@ func GetEvents(ctx context.Context, addr string, opts ...OpOption) (v1.LeptonEve
 	return ReadEvents(resp.Body, opts...)
 }
 
+// eventQuery encodes the event query options as the query parameters.
+func (op *Op) eventQuery() url.Values {
+	q := url.Values{}
+	if len(op.components) > 0 {
+		q.Add("components", joinKeys(op.components))
+	}
+	if !op.since.IsZero() {
+		q.Add("startTime", strconv.FormatInt(op.since.Unix(), 10))
+	}
+	if !op.until.IsZero() {
+		q.Add("endTime", strconv.FormatInt(op.until.Unix(), 10))
+	}
+	if len(op.eventTypes) > 0 {
+		q.Add("eventTypes", joinKeys(op.eventTypes))
+	}
+	if len(op.eventNames) > 0 {
+		q.Add("names", joinKeys(op.eventNames))
+	}
+	keys := make([]string, 0, len(op.extraInfo))
+	for k := range op.extraInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		q.Add("extraInfo", k+"="+op.extraInfo[k])
+	}
+	if op.messageContains != "" {
+		q.Add("message", op.messageContains)
+	}
+	if op.limit > 0 {
+		q.Add("limit", strconv.Itoa(op.limit))
+	}
+	if op.cursor != "" {
+		q.Add("cursor", op.cursor)
+	}
+	return q
+}
+
 func ReadEvents(rd io.Reader, opts ...OpOption) (v1.LeptonEvents, error) {
 	op := &Op{}
 	if err := op.applyOpts(opts); err != nil {
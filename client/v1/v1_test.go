@@ -17,6 +17,7 @@ import (
 
 	v1 "github.com/leptonai/gpud/api/v1"
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
 	"github.com/leptonai/gpud/pkg/errdefs"
 	"github.com/leptonai/gpud/pkg/server"
 )
@@ -289,6 +290,7 @@ func TestGetStates(t *testing.T) {
 	tests := []struct {
 		name           string
 		components     []string
+		gpu            string
 		serverResponse []byte
 		contentType    string
 		acceptEncoding string
@@ -304,6 +306,13 @@ func TestGetStates(t *testing.T) {
 			statusCode:     http.StatusOK,
 			expectedResult: testStates,
 		},
+		{
+			name:           "gpu filter",
+			gpu:            "GPU-a",
+			serverResponse: mustMarshalJSON(t, testStates),
+			statusCode:     http.StatusOK,
+			expectedResult: testStates,
+		},
 		{
 			name:           "not found error",
 			serverResponse: []byte(`not found`),
@@ -321,6 +330,7 @@ func TestGetStates(t *testing.T) {
 				if tt.components != nil {
 					assert.Contains(t, r.URL.RawQuery, "components=")
 				}
+				assert.Equal(t, tt.gpu, r.URL.Query().Get("gpu"))
 
 				if tt.contentType != "" {
 					assert.Equal(t, tt.contentType, r.Header.Get(server.RequestHeaderContentType))
@@ -353,6 +363,9 @@ func TestGetStates(t *testing.T) {
 			for _, comp := range tt.components {
 				opts = append(opts, WithComponent(comp))
 			}
+			if tt.gpu != "" {
+				opts = append(opts, WithGPU(tt.gpu))
+			}
 
 			result, err := GetStates(context.Background(), srv.URL, opts...)
 			if tt.expectedError != "" {
@@ -450,3 +463,51 @@ func mustMarshalYAML(t *testing.T, v interface{}) []byte {
 	require.NoError(t, err)
 	return data
 }
+
+func TestGetEventsQuery(t *testing.T) {
+	want := v1.LeptonEvents{
+		{
+			Component:  "accelerator-nvidia-error-xid",
+			Events:     []components.Event{{Name: "error_xid", Type: common.EventTypeCritical}},
+			NextCursor: "next",
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/events", r.URL.Path)
+
+		q := r.URL.Query()
+		require.Equal(t, "accelerator-nvidia-error-xid", q.Get("components"))
+		require.Equal(t, "1700000000", q.Get("startTime"))
+		require.Equal(t, "1700003600", q.Get("endTime"))
+		require.Equal(t, "Critical,Fatal", q.Get("eventTypes"))
+		require.Equal(t, "error_xid", q.Get("names"))
+		require.Equal(t, []string{"device_uuid=GPU-0", "xid=79"}, q["extraInfo"])
+		require.Equal(t, "fallen off", q.Get("message"))
+		require.Equal(t, "100", q.Get("limit"))
+		require.Equal(t, "prev", q.Get("cursor"))
+
+		b, err := json.Marshal(want)
+		require.NoError(t, err)
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	got, err := GetEvents(context.Background(), srv.URL,
+		WithComponent("accelerator-nvidia-error-xid"),
+		WithSince(time.Unix(1700000000, 0)),
+		WithUntil(time.Unix(1700003600, 0)),
+		WithEventType(common.EventTypeFatal),
+		WithEventType(common.EventTypeCritical),
+		WithEventName("error_xid"),
+		WithExtraInfo("xid", "79"),
+		WithExtraInfo("device_uuid", "GPU-0"),
+		WithMessageContains("fallen off"),
+		WithLimit(100),
+		WithCursor("prev"),
+	)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, want[0].Events, got[0].Events)
+	require.Equal(t, "next", got[0].NextCursor)
+}
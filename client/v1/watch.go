@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+// Watch streams the component event inserts and state health transitions.
+// Use "WithComponent", "WithWatchKind", and "WithEventType" to filter.
+// The returned channel is closed when the context is canceled
+// or the server closes the stream.
+func Watch(ctx context.Context, addr string, opts ...OpOption) (<-chan v1.WatchEvent, error) {
+	op := &Op{}
+	if err := op.applyOpts(opts); err != nil {
+		return nil, err
+	}
+
+	reqURL, err := url.Parse(fmt.Sprintf("%s/v1/watch", addr))
+	if err != nil {
+		return nil, err
+	}
+	q := reqURL.Query()
+	if len(op.components) > 0 {
+		q.Add("components", joinKeys(op.components))
+	}
+	if len(op.watchKinds) > 0 {
+		q.Add("kinds", joinKeys(op.watchKinds))
+	}
+	if len(op.eventTypes) > 0 {
+		q.Add("eventTypes", joinKeys(op.eventTypes))
+	}
+	reqURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set(server.RequestHeaderAccept, server.RequestHeaderNDJSON)
+
+	resp, err := createDefaultHTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New("server not ready, response not 200")
+	}
+
+	ch := make(chan v1.WatchEvent)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		_ = ReadWatchEvents(ctx, resp.Body, ch)
+	}()
+	return ch, nil
+}
+
+// ReadWatchEvents reads the newline-delimited JSON watch events
+// and sends them to the channel, until the reader is closed or the context is canceled.
+// The empty (keep-alive) lines are skipped.
+func ReadWatchEvents(ctx context.Context, rd io.Reader, ch chan<- v1.WatchEvent) error {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var ev v1.WatchEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return fmt.Errorf("failed to decode json: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- ev:
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+func joinKeys(m map[string]any) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/server"
+)
+
+func TestWatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/watch", r.URL.Path)
+		require.Equal(t, "cpu,memory", r.URL.Query().Get("components"))
+		require.Equal(t, "event", r.URL.Query().Get("kinds"))
+		require.Equal(t, "Critical", r.URL.Query().Get("eventTypes"))
+		require.Equal(t, server.RequestHeaderNDJSON, r.Header.Get(server.RequestHeaderAccept))
+
+		w.Header().Set(server.RequestHeaderContentType, server.RequestHeaderNDJSON)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, `{"kind":"event","component":"cpu","time":"2025-01-01T00:00:00Z","event":{"time":"2025-01-01T00:00:00Z","name":"a","type":"Critical"}}`)
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, `{"kind":"event","component":"memory","time":"2025-01-01T00:00:00Z","event":{"time":"2025-01-01T00:00:00Z","name":"b","type":"Critical"}}`)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ch, err := Watch(ctx, srv.URL,
+		WithComponent("memory"),
+		WithComponent("cpu"),
+		WithWatchKind(v1.WatchEventKindEvent),
+		WithEventType(common.EventTypeCritical),
+	)
+	require.NoError(t, err)
+
+	var evs []v1.WatchEvent
+	for ev := range ch {
+		evs = append(evs, ev)
+	}
+	require.Len(t, evs, 2)
+	require.Equal(t, "cpu", evs[0].Component)
+	require.Equal(t, "a", evs[0].Event.Name)
+	require.Equal(t, "memory", evs[1].Component)
+}
+
+func TestWatchServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	_, err := Watch(context.Background(), srv.URL)
+	require.Error(t, err)
+}
+
+func TestReadWatchEventsInvalid(t *testing.T) {
+	ch := make(chan v1.WatchEvent, 1)
+	err := ReadWatchEvents(context.Background(), strings.NewReader("invalid\n"), ch)
+	require.Error(t, err)
+}
@@ -19,11 +19,13 @@ sudo gpud up
 `
 
 var (
-	logLevel string
-	logFile  string
+	logLevel   string
+	logFile    string
+	configFile string
 
-	statusWatch bool
-	uid         string
+	statusWatch  bool
+	statusHealth bool
+	uid          string
 
 	annotations   string
 	listenAddress string
@@ -185,6 +187,11 @@ sudo rm /etc/systemd/system/gpud.service
 					Destination: &listenAddress,
 					Value:       fmt.Sprintf("0.0.0.0:%d", config.DefaultGPUdPort),
 				},
+				&cli.StringFlag{
+					Name:        "config-file",
+					Usage:       "set the configuration file path (reloaded on change or SIGHUP, the other flags take precedence)",
+					Destination: &configFile,
+				},
 				&cli.StringFlag{
 					Name:        "annotations",
 					Usage:       "set the annotations",
@@ -449,6 +456,11 @@ sudo rm /etc/systemd/system/gpud.service
 					Usage:       "watch for package install status",
 					Destination: &statusWatch,
 				},
+				&cli.BoolFlag{
+					Name:        "health",
+					Usage:       "print the node health verdict aggregated from all components (exits non-zero if unhealthy)",
+					Destination: &statusHealth,
+				},
 			},
 		},
 
@@ -574,6 +586,73 @@ sudo gpud join
 				},
 			},
 		},
+		{
+			Name:  "state",
+			Usage: "manages the gpud state database",
+			Subcommands: []cli.Command{
+				{
+					Name:  "export",
+					Usage: "exports the events, metrics, and machine metadata as a portable bundle",
+					UsageText: `# to export the state of this machine (e.g., before RMA)
+sudo gpud state export --output gpud-state.tar.gz
+`,
+					Action: cmdStateExport,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "state-file",
+							Usage: "state file to export (leave empty for default)",
+						},
+						cli.StringFlag{
+							Name:  "output,o",
+							Usage: "bundle file path to write (default: gpud-state-<machine id>-<unix seconds>.tar.gz)",
+						},
+					},
+				},
+				{
+					Name:  "import",
+					Usage: "imports a bundle exported by 'gpud state export'",
+					UsageText: `# to inspect the events of another machine locally,
+# import into a separate state file
+gpud state import --input gpud-state.tar.gz --state-file /tmp/replay.state
+
+# and run gpud with "state: /tmp/replay.state" in the config file
+gpud run --config-file /tmp/replay.yaml
+`,
+					Action: cmdStateImport,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "state-file",
+							Usage: "state file to import into (leave empty for default)",
+						},
+						cli.StringFlag{
+							Name:  "input,i",
+							Usage: "bundle file path to read",
+						},
+					},
+				},
+				{
+					Name:  "migrate",
+					Usage: "applies the pending schema migrations to the state file (run automatically on gpud start)",
+					UsageText: `# to list the pending schema migrations without applying them
+gpud state migrate --dry-run
+
+# to back up the state file and apply the pending schema migrations
+sudo gpud state migrate
+`,
+					Action: cmdStateMigrate,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "state-file",
+							Usage: "state file to migrate (leave empty for default)",
+						},
+						cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "only list the pending migrations (default: false)",
+						},
+					},
+				},
+			},
+		},
 	}
 
 	return app
@@ -40,6 +40,86 @@ func cmdRun(cliContext *cli.Context) error {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	cfg, err := loadRunConfig()
+	if err != nil {
+		return err
+	}
+
+	rootCtx, rootCancel := context.WithCancel(context.Background())
+	defer rootCancel()
+	start := time.Now()
+
+	signals := make(chan os.Signal, 2048)
+	serverC := make(chan *lepServer.Server, 1)
+
+	log.Logger.Infof("starting gpud %v", version.Version)
+
+	reloadC := make(chan struct{}, 1)
+	reload := func() {
+		select {
+		case reloadC <- struct{}{}:
+		default: // reload already pending
+		}
+	}
+
+	done := handleSignals(rootCtx, rootCancel, signals, serverC, reload)
+	// start the signal handler as soon as we can to make sure that
+	// we don't miss any signals during boot
+	signal.Notify(signals, handledSignals...)
+	m, err := gpud_manager.New()
+	if err != nil {
+		return err
+	}
+	m.Start(rootCtx)
+
+	server, err := lepServer.New(rootCtx, cfg, cliContext.String("endpoint"), uid, m)
+	if err != nil {
+		return err
+	}
+	serverC <- server
+
+	if configFile != "" {
+		if err := config.WatchConfigFile(rootCtx, configFile, reload); err != nil {
+			return err
+		}
+	}
+	go func() {
+		for {
+			select {
+			case <-rootCtx.Done():
+				return
+			case <-reloadC:
+			}
+
+			cfg, err := loadRunConfig()
+			if err != nil {
+				log.Logger.Errorw("failed to load config -- keeping the current config", "error", err)
+				continue
+			}
+			if err := server.ReloadConfig(rootCtx, cfg); err != nil {
+				log.Logger.Errorw("failed to reload config", "error", err)
+				continue
+			}
+			log.Logger.Infow("successfully reloaded config")
+		}
+	}()
+
+	if pkd_systemd.SystemctlExists() {
+		if err := notifyReady(rootCtx); err != nil {
+			log.Logger.Warnw("notify ready failed")
+		}
+	} else {
+		log.Logger.Debugw("skipped sd notify as systemd is not available")
+	}
+
+	log.Logger.Infow("successfully booted", "tookSeconds", time.Since(start).Seconds())
+	<-done
+	return nil
+}
+
+// loadRunConfig loads the default configuration, the configuration file (if any),
+// and then applies the flags.
+func loadRunConfig() (*config.Config, error) {
 	configOpts := []config.OpOption{
 		config.WithFilesToCheck(filesToCheck...),
 		config.WithDockerIgnoreConnectionErrors(dockerIgnoreConnectionErrors),
@@ -51,13 +131,19 @@ func cmdRun(cliContext *cli.Context) error {
 	cfg, err := config.DefaultConfig(ctx, configOpts...)
 	cancel()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if configFile != "" {
+		if err := config.LoadConfig(configFile, cfg); err != nil {
+			return nil, err
+		}
 	}
 
 	if annotations != "" {
 		annot := make(map[string]string)
 		if err := json.Unmarshal([]byte(annotations), &annot); err != nil {
-			return err
+			return nil, err
 		}
 		cfg.Annotations = annot
 	}
@@ -86,43 +172,7 @@ func cmdRun(cliContext *cli.Context) error {
 	cfg.AutoUpdateExitCode = autoUpdateExitCode
 
 	if err := cfg.Validate(); err != nil {
-		return err
+		return nil, err
 	}
-
-	rootCtx, rootCancel := context.WithCancel(context.Background())
-	defer rootCancel()
-	start := time.Now()
-
-	signals := make(chan os.Signal, 2048)
-	serverC := make(chan *lepServer.Server, 1)
-
-	log.Logger.Infof("starting gpud %v", version.Version)
-
-	done := handleSignals(rootCtx, rootCancel, signals, serverC)
-	// start the signal handler as soon as we can to make sure that
-	// we don't miss any signals during boot
-	signal.Notify(signals, handledSignals...)
-	m, err := gpud_manager.New()
-	if err != nil {
-		return err
-	}
-	m.Start(rootCtx)
-
-	server, err := lepServer.New(rootCtx, cfg, cliContext.String("endpoint"), uid, m)
-	if err != nil {
-		return err
-	}
-	serverC <- server
-
-	if pkd_systemd.SystemctlExists() {
-		if err := notifyReady(rootCtx); err != nil {
-			log.Logger.Warnw("notify ready failed")
-		}
-	} else {
-		log.Logger.Debugw("skipped sd notify as systemd is not available")
-	}
-
-	log.Logger.Infow("successfully booted", "tookSeconds", time.Since(start).Seconds())
-	<-done
-	return nil
+	return cfg, nil
 }
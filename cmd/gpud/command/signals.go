@@ -19,10 +19,11 @@ var handledSignals = []os.Signal{
 	unix.SIGTERM,
 	unix.SIGINT,
 	unix.SIGUSR1,
+	unix.SIGHUP,
 	unix.SIGPIPE,
 }
 
-func handleSignals(ctx context.Context, cancel context.CancelFunc, signals chan os.Signal, serverC chan *server.Server) chan struct{} {
+func handleSignals(ctx context.Context, cancel context.CancelFunc, signals chan os.Signal, serverC chan *server.Server, reload func()) chan struct{} {
 	done := make(chan struct{}, 1)
 	go func() {
 		var server *server.Server
@@ -42,6 +43,8 @@ func handleSignals(ctx context.Context, cancel context.CancelFunc, signals chan
 				switch s {
 				case unix.SIGUSR1:
 					dumpStacks(true)
+				case unix.SIGHUP:
+					reload()
 				default:
 					cancel()
 
@@ -0,0 +1,225 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/config"
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	gpud_state "github.com/leptonai/gpud/pkg/gpud-state"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/urfave/cli"
+)
+
+func cmdStateExport(cliContext *cli.Context) error {
+	stateFile, err := stateFileFromFlag(cliContext)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		return fmt.Errorf("failed to find state file: %w", err)
+	}
+
+	dbRO, err := sqlite.Open(stateFile, sqlite.WithReadOnly(true))
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer dbRO.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	output := cliContext.String("output")
+	if output == "" {
+		machineID, err := gpud_state.GetMachineID(ctx, dbRO)
+		if err != nil {
+			machineID = "unknown"
+		}
+		output = fmt.Sprintf("gpud-state-%s-%d.tar.gz", machineID, time.Now().Unix())
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle file: %w", err)
+	}
+	defer f.Close()
+
+	manifest, err := gpud_state.ExportBundle(ctx, dbRO, metrics_state.NewSQLiteStore(nil, dbRO, metrics_state.DefaultTableName), f)
+	if err != nil {
+		return fmt.Errorf("failed to export state: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	events := 0
+	for _, n := range manifest.EventTables {
+		events += n
+	}
+	rollups := 0
+	for _, n := range manifest.Rollups {
+		rollups += n
+	}
+	fmt.Printf("%s exported %d events from %d tables, %d metrics and %d metrics rollups to %s\n", checkMark, events, len(manifest.EventTables), manifest.Metrics, rollups, output)
+	return nil
+}
+
+func cmdStateImport(cliContext *cli.Context) error {
+	input := cliContext.String("input")
+	if input == "" {
+		return errors.New("--input is required")
+	}
+	stateFile, err := stateFileFromFlag(cliContext)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(input)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle file: %w", err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	imported, err := importState(ctx, stateFile, f)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s imported %d events, %d metrics and %d metrics rollups of machine %q (exported at %s by gpud %s) to %s\n",
+		checkMark,
+		imported.EventsInserted,
+		imported.MetricsInserted,
+		imported.RollupsInserted,
+		imported.Manifest.MachineID,
+		imported.Manifest.CreatedAt.Format(time.RFC3339),
+		imported.Manifest.GPUdVersion,
+		stateFile,
+	)
+	return nil
+}
+
+// importState imports the bundle into the state file, after backing up
+// and migrating the existing state file (as the server does on start),
+// so that the events are written to the tables of the current schema.
+func importState(ctx context.Context, stateFile string, r io.Reader) (gpud_state.BundleImport, error) {
+	_, err := os.Stat(stateFile)
+	stateFileExists := err == nil
+
+	dbRW, err := sqlite.Open(stateFile)
+	if err != nil {
+		return gpud_state.BundleImport{}, fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer dbRW.Close()
+
+	dbRO, err := sqlite.Open(stateFile, sqlite.WithReadOnly(true))
+	if err != nil {
+		return gpud_state.BundleImport{}, fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer dbRO.Close()
+
+	pending, err := gpud_state.PendingMigrations(ctx, dbRO)
+	if err != nil {
+		return gpud_state.BundleImport{}, fmt.Errorf("failed to read schema migrations: %w", err)
+	}
+	if len(pending) > 0 && stateFileExists {
+		backup, err := gpud_state.BackupStateFile(ctx, dbRW, stateFile)
+		if err != nil {
+			return gpud_state.BundleImport{}, err
+		}
+		fmt.Printf("%s backed up %s to %s\n", checkMark, stateFile, backup)
+	}
+	if _, err := gpud_state.Migrate(ctx, dbRW); err != nil {
+		return gpud_state.BundleImport{}, fmt.Errorf("failed to migrate state schema: %w", err)
+	}
+
+	if err := metrics_state.CreateTableMetrics(ctx, dbRW, metrics_state.DefaultTableName); err != nil {
+		return gpud_state.BundleImport{}, fmt.Errorf("failed to create metrics table: %w", err)
+	}
+	metricsStore := metrics_state.NewSQLiteStore(dbRW, dbRO, metrics_state.DefaultTableName)
+
+	imported, err := gpud_state.ImportBundle(ctx, dbRW, dbRO, metricsStore, r)
+	if err != nil {
+		return imported, fmt.Errorf("failed to import state: %w", err)
+	}
+	return imported, nil
+}
+
+func cmdStateMigrate(cliContext *cli.Context) error {
+	stateFile, err := stateFileFromFlag(cliContext)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		return fmt.Errorf("failed to find state file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if cliContext.Bool("dry-run") {
+		dbRO, err := sqlite.Open(stateFile, sqlite.WithReadOnly(true))
+		if err != nil {
+			return fmt.Errorf("failed to open state file: %w", err)
+		}
+		defer dbRO.Close()
+
+		pending, err := gpud_state.PendingMigrations(ctx, dbRO)
+		if err != nil {
+			return fmt.Errorf("failed to read schema migrations: %w", err)
+		}
+		if len(pending) == 0 {
+			fmt.Printf("%s no pending migration in %s\n", checkMark, stateFile)
+			return nil
+		}
+		fmt.Printf("%d pending migration(s) in %s (dry-run, not applied):\n", len(pending), stateFile)
+		for _, m := range pending {
+			fmt.Printf("  %d: %s\n", m.Version, m.Description)
+		}
+		return nil
+	}
+
+	dbRW, err := sqlite.Open(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to open state file: %w", err)
+	}
+	defer dbRW.Close()
+
+	pending, err := gpud_state.PendingMigrations(ctx, dbRW)
+	if err != nil {
+		return fmt.Errorf("failed to read schema migrations: %w", err)
+	}
+	if len(pending) == 0 {
+		fmt.Printf("%s no pending migration in %s\n", checkMark, stateFile)
+		return nil
+	}
+
+	backup, err := gpud_state.BackupStateFile(ctx, dbRW, stateFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s backed up %s to %s\n", checkMark, stateFile, backup)
+
+	applied, err := gpud_state.Migrate(ctx, dbRW)
+	for _, m := range applied {
+		fmt.Printf("%s applied migration %d: %s\n", checkMark, m.Version, m.Description)
+	}
+	return err
+}
+
+func stateFileFromFlag(cliContext *cli.Context) (string, error) {
+	if stateFile := cliContext.String("state-file"); stateFile != "" {
+		return stateFile, nil
+	}
+	stateFile, err := config.DefaultStateFile()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state file: %w", err)
+	}
+	return stateFile, nil
+}
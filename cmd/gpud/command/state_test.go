@@ -0,0 +1,117 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	gpud_state "github.com/leptonai/gpud/pkg/gpud-state"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestImportStateOlderSchema(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// the bundle exported from a node of the current schema
+	srcRW, srcRO, srcCleanup := sqlite.OpenTestDB(t)
+	defer srcCleanup()
+
+	if err := gpud_state.CreateTableMachineMetadata(ctx, srcRW); err != nil {
+		t.Fatalf("failed to create machine metadata table: %v", err)
+	}
+	if _, err := gpud_state.CreateMachineIDIfNotExist(ctx, srcRW, srcRO, "test-machine"); err != nil {
+		t.Fatalf("failed to create machine id: %v", err)
+	}
+
+	store, err := eventstore.New(srcRW, srcRO, 0)
+	if err != nil {
+		t.Fatalf("failed to create events store: %v", err)
+	}
+	bucket, err := store.Bucket("memory")
+	if err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	defer bucket.Close()
+	if err := bucket.Insert(ctx, components.Event{Time: metav1.Time{Time: time.Unix(1700000000, 0).UTC()}, Name: "oom", Type: common.EventTypeWarning}); err != nil {
+		t.Fatalf("failed to insert event: %v", err)
+	}
+	if err := metrics_state.CreateTableMetrics(ctx, srcRW, metrics_state.DefaultTableName); err != nil {
+		t.Fatalf("failed to create metrics table: %v", err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err := gpud_state.ExportBundle(ctx, srcRO, metrics_state.NewSQLiteStore(srcRW, srcRO, metrics_state.DefaultTableName), buf); err != nil {
+		t.Fatalf("failed to export bundle: %v", err)
+	}
+
+	// the state file of an older gpud, without the occurrence columns
+	stateFile := filepath.Join(t.TempDir(), "gpud.state")
+	dbRW, err := sqlite.Open(stateFile)
+	if err != nil {
+		t.Fatalf("failed to open state file: %v", err)
+	}
+	if _, err := dbRW.ExecContext(ctx, `CREATE TABLE components_memory_events_v0_4_0 (timestamp INTEGER NOT NULL, name TEXT NOT NULL, type TEXT NOT NULL, message TEXT, extra_info TEXT, suggested_actions TEXT);`); err != nil {
+		t.Fatalf("failed to create older event table: %v", err)
+	}
+	_ = dbRW.Close()
+
+	imported, err := importState(ctx, stateFile, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to import state: %v", err)
+	}
+	if imported.EventsInserted != 1 {
+		t.Fatalf("expected 1 event imported, got %d", imported.EventsInserted)
+	}
+
+	backups, err := filepath.Glob(stateFile + ".bak-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("expected the state file backed up before the migrations, got %v", backups)
+	}
+
+	dstRW, err := sqlite.Open(stateFile)
+	if err != nil {
+		t.Fatalf("failed to open state file: %v", err)
+	}
+	defer dstRW.Close()
+	dstRO, err := sqlite.Open(stateFile, sqlite.WithReadOnly(true))
+	if err != nil {
+		t.Fatalf("failed to open state file: %v", err)
+	}
+	defer dstRO.Close()
+
+	pending, err := gpud_state.PendingMigrations(ctx, dstRO)
+	if err != nil {
+		t.Fatalf("failed to read schema migrations: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending migration, got %d", len(pending))
+	}
+
+	dstStore, err := eventstore.New(dstRW, dstRO, 0)
+	if err != nil {
+		t.Fatalf("failed to create events store: %v", err)
+	}
+	dstBucket, err := dstStore.Bucket("memory")
+	if err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	defer dstBucket.Close()
+	page, err := dstBucket.Query(ctx, eventstore.Query{})
+	if err != nil {
+		t.Fatalf("failed to query events: %v", err)
+	}
+	if len(page.Events) != 1 || page.Events[0].Name != "oom" {
+		t.Fatalf("unexpected imported events: %+v", page.Events)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/urfave/cli"
 
 	client "github.com/leptonai/gpud/client/v1"
+	"github.com/leptonai/gpud/components"
 	"github.com/leptonai/gpud/pkg/config"
 	"github.com/leptonai/gpud/pkg/errdefs"
 	"github.com/leptonai/gpud/pkg/log"
@@ -51,6 +52,10 @@ func cmdStatus(cliContext *cli.Context) error {
 	}
 	fmt.Printf("%s successfully checked gpud health\n", checkMark)
 
+	if statusHealth {
+		return printNodeHealth(rootCtx)
+	}
+
 	for {
 		cctx, ccancel := context.WithTimeout(rootCtx, 15*time.Second)
 		packageStatus, err := client.GetPackageStatus(cctx, fmt.Sprintf("https://localhost:%d%s", config.DefaultGPUdPort, server.URLPathAdminPackages))
@@ -139,3 +144,38 @@ func checkNvidiaInfoComponent() error {
 
 	return nil
 }
+
+// ErrNodeUnhealthy is returned by "gpud status --health" if the node verdict is unhealthy.
+var ErrNodeUnhealthy = errors.New("node is unhealthy")
+
+func printNodeHealth(ctx context.Context) error {
+	cctx, ccancel := context.WithTimeout(ctx, 30*time.Second)
+	nodeHealth, err := client.GetHealth(cctx, fmt.Sprintf("https://localhost:%d", config.DefaultGPUdPort))
+	ccancel()
+	if err != nil {
+		fmt.Printf("%s failed to get node health: %v\n", warningSign, err)
+		return err
+	}
+
+	mark := checkMark
+	if !nodeHealth.Healthy {
+		mark = warningSign
+	}
+	fmt.Printf("%s node health: %s (%s)\n", mark, nodeHealth.Health, nodeHealth.Reason)
+	for _, ch := range nodeHealth.Components {
+		fmt.Printf("  - %s: %s (weight %.2f) %s\n", ch.Component, ch.Health, ch.Weight, ch.Reason)
+	}
+	if nodeHealth.SuggestedActions != nil {
+		if acts := nodeHealth.SuggestedActions.DescribeActions(); acts != "" {
+			fmt.Printf("suggested actions: %s\n", acts)
+		}
+		for _, desc := range nodeHealth.SuggestedActions.Descriptions {
+			fmt.Printf("  - %s\n", desc)
+		}
+	}
+
+	if nodeHealth.Health == components.StateUnhealthy {
+		return ErrNodeUnhealthy
+	}
+	return nil
+}
@@ -7,13 +7,25 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	bad_envs_id "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs/id"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	"github.com/leptonai/gpud/pkg/query"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        bad_envs_id.Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia_common.Config))
+		},
+	})
+}
+
 func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
@@ -3,13 +3,15 @@ package clockspeed
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_clock_speed_id "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed/id"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_metrics_clockspeed "github.com/leptonai/gpud/pkg/nvidia-query/metrics/clock-speed"
@@ -18,6 +20,17 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        nvidia_clock_speed_id.Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia_common.Config))
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
@@ -128,7 +141,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return nvidia_query_metrics_clockspeed.Register(reg, dbRW, dbRO, tableName)
+	return nvidia_query_metrics_clockspeed.Register(reg, store)
 }
@@ -0,0 +1,82 @@
+package nvidia
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/leptonai/gpud/components/registry"
+	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/pkg/log"
+	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
+	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
+)
+
+var (
+	detectMu        sync.Mutex
+	detected        bool
+	detectInstalled bool
+
+	// overridden in tests
+	detectFunc = detect
+)
+
+// DefaultEnabled is the default-enable predicate for the NVIDIA components.
+// Returns true if the host is linux and has the NVIDIA GPUs installed
+// with a supported driver.
+// The successful detection result is cached, since it runs "lspci" and loads NVML.
+// The errors (e.g., transient NVML failure, canceled context) are not cached,
+// so that the next call retries the detection.
+func DefaultEnabled(ctx context.Context) (bool, error) {
+	detectMu.Lock()
+	defer detectMu.Unlock()
+
+	if detected {
+		return detectInstalled, nil
+	}
+	installed, err := detectFunc(ctx)
+	if err != nil {
+		return false, err
+	}
+	detected, detectInstalled = true, installed
+	return installed, nil
+}
+
+func detect(ctx context.Context) (bool, error) {
+	if runtime.GOOS != "linux" {
+		log.Logger.Debugw("auto-detect nvidia not supported -- skipping", "os", runtime.GOOS)
+		return false, nil
+	}
+
+	nvidiaInstalled, err := nvidia_query.GPUsInstalled(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !nvidiaInstalled {
+		return false, nil
+	}
+
+	driverVersion, err := nvidia_query_nvml.GetDriverVersion()
+	if err != nil {
+		return false, err
+	}
+	if _, _, _, err := nvidia_query_nvml.ParseDriverVersion(driverVersion); err != nil {
+		return false, err
+	}
+
+	log.Logger.Debugw("auto-detected nvidia -- configuring nvidia components")
+	return true, nil
+}
+
+// ParseConfig parses the common NVIDIA component configuration.
+// Returns the default configuration with the GPUd state database
+// and tool overwrites, if the configuration is not set.
+func ParseConfig(cfg any, instance *registry.GPUdInstance) (any, error) {
+	if cfg == nil {
+		return &nvidia_common.Config{
+			Query:          instance.DefaultQueryConfig(),
+			ToolOverwrites: instance.NvidiaToolOverwrites,
+		}, nil
+	}
+	return nvidia_common.ParseConfig(cfg, instance.DBRW, instance.DBRO)
+}
@@ -0,0 +1,38 @@
+package nvidia
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultEnabledCachesOnlySuccess(t *testing.T) {
+	calls := 0
+	results := []error{errors.New("transient nvml error"), nil, nil}
+	detectFunc = func(ctx context.Context) (bool, error) {
+		err := results[calls]
+		calls++
+		return err == nil, err
+	}
+	defer func() {
+		detectFunc = detect
+		detected, detectInstalled = false, false
+	}()
+
+	enabled, err := DefaultEnabled(context.Background())
+	assert.Error(t, err)
+	assert.False(t, enabled)
+
+	// retried after the failure
+	enabled, err = DefaultEnabled(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	// cached after the success
+	enabled, err = DefaultEnabled(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, 2, calls)
+}
@@ -3,13 +3,16 @@ package ecc
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_ecc_id "github.com/leptonai/gpud/components/accelerator/nvidia/ecc/id"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_metrics_ecc "github.com/leptonai/gpud/pkg/nvidia-query/metrics/ecc"
@@ -18,7 +21,22 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        nvidia_ecc_id.Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, instance *registry.GPUdInstance) (components.Component, error) {
+			eventBucket, err := instance.EventStore.Bucket(nvidia_ecc_id.Name)
+			if err != nil {
+				return nil, err
+			}
+			return New(ctx, *cfg.(*nvidia_common.Config), eventBucket)
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
+func New(ctx context.Context, cfg nvidia_common.Config, eventBucket eventstore.Bucket) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
 	}
@@ -32,6 +50,8 @@ func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, e
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
+
+		eventBucket: eventBucket,
 	}, nil
 }
 
@@ -42,6 +62,8 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+
+	eventBucket eventstore.Bucket
 }
 
 func (c *component) Name() string { return nvidia_ecc_id.Name }
@@ -80,8 +102,20 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	return output.States()
 }
 
+// the events are recorded by the poller (see "nvidia_query.WithECCEventBucket")
+const (
+	EventNameAggregateTotalCorrectedIncrease   = nvidia_query.EventNameAggregateTotalCorrectedIncrease
+	EventNameAggregateTotalUncorrectedIncrease = nvidia_query.EventNameAggregateTotalUncorrectedIncrease
+)
+
+// Events returns the increases of the aggregate ECC error counts since the time,
+// an event per GPU at each poll that found the new errors.
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	return c.eventBucket.Get(ctx, since)
+}
+
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventBucket.Query(ctx, q)
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
@@ -152,7 +186,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return nvidia_query_metrics_ecc.Register(reg, dbRW, dbRO, tableName)
+	return nvidia_query_metrics_ecc.Register(reg, store)
 }
@@ -15,7 +15,7 @@ func TestComponentWithNoPoller(t *testing.T) {
 	defer cancel()
 
 	defaultPoller := nvidia_query.GetDefaultPoller()
-	_, err := New(ctx, nvidia_common.Config{})
+	_, err := New(ctx, nvidia_common.Config{}, nil)
 
 	if defaultPoller != nil {
 		// expects no error
@@ -10,12 +10,22 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	fabric_manager_id "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/components/systemd"
 	"github.com/leptonai/gpud/pkg/eventstore"
 	"github.com/leptonai/gpud/pkg/log"
 	pkg_systemd "github.com/leptonai/gpud/pkg/systemd"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: fabric_manager_id.Name,
+		New: func(ctx context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, instance.EventStore)
+		},
+	})
+}
+
 func New(ctx context.Context, eventStore eventstore.Store) (components.Component, error) {
 	return newComponent(ctx, fabricManagerExists, defaultWatchCommands, eventStore)
 }
@@ -27,7 +37,7 @@ func newComponent(ctx context.Context, checkFMExists func() bool, watchCommands
 	var llp *logLineProcessor
 	if checkFMExists() {
 		var err error
-		eventBucket, err = eventStore.Bucket(fabric_manager_id.Name)
+		eventBucket, err = eventStore.Bucket(fabric_manager_id.Name, eventstore.WithAggregateWindow(eventstore.DefaultAggregateWindow))
 		if err != nil {
 			ccancel()
 			return nil, err
@@ -3,11 +3,12 @@ package gpm
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
@@ -20,6 +21,34 @@ import (
 
 const Name = "accelerator-nvidia-gpm"
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia_common.Config)), nil
+		},
+		DefaultEnabled: func(ctx context.Context) (bool, error) {
+			enabled, err := nvidia.DefaultEnabled(ctx)
+			if err != nil || !enabled {
+				return false, err
+			}
+
+			gpmSupported, err := nvidia_query_nvml.GPMSupported()
+			if err != nil {
+				log.Logger.Warnw("failed to check gpm supported or not", "error", err)
+				return false, nil
+			}
+			if !gpmSupported {
+				log.Logger.Infow("auto-detected gpm not supported -- skipping")
+				return false, nil
+			}
+			log.Logger.Infow("auto-detected gpm supported")
+			return true, nil
+		},
+	})
+}
+
 func New(ctx context.Context, cfg nvidia_common.Config) components.Component {
 	cfg.Query.SetDefaultsIfNotSet()
 	setDefaultPoller(cfg)
@@ -171,7 +200,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return nvidia_query_metrics_gpm.Register(reg, dbRW, dbRO, tableName)
+	return nvidia_query_metrics_gpm.Register(reg, store)
 }
@@ -7,13 +7,26 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_gsp_firmware_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode/id"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	"github.com/leptonai/gpud/pkg/query"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        nvidia_gsp_firmware_mode_id.Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia_common.Config))
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
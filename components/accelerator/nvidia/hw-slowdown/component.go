@@ -3,7 +3,6 @@ package hwslowdown
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"strings"
 	"time"
@@ -12,13 +11,17 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_hw_slowdown_id "github.com/leptonai/gpud/components/accelerator/nvidia/hw-slowdown/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/common"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_metrics_clock "github.com/leptonai/gpud/pkg/nvidia-query/metrics/clock"
+	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
 	"github.com/leptonai/gpud/pkg/query"
 )
 
@@ -32,6 +35,54 @@ const (
 	DefaultStateHWSlowdownEventsThresholdFrequencyPerMinute = 0.6
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        nvidia_hw_slowdown_id.Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, instance *registry.GPUdInstance) (components.Component, error) {
+			eventBucket, err := instance.EventStore.Bucket(nvidia_hw_slowdown_id.Name)
+			if err != nil {
+				return nil, err
+			}
+			return New(ctx, *cfg.(*nvidia_common.Config), eventBucket)
+		},
+		DefaultEnabled: defaultEnabled,
+	})
+}
+
+// defaultEnabled returns true if the NVIDIA driver supports the clock events.
+func defaultEnabled(ctx context.Context) (bool, error) {
+	enabled, err := nvidia.DefaultEnabled(ctx)
+	if err != nil || !enabled {
+		return false, err
+	}
+
+	driverVersion, err := nvidia_query_nvml.GetDriverVersion()
+	if err != nil {
+		return false, err
+	}
+	major, _, _, err := nvidia_query_nvml.ParseDriverVersion(driverVersion)
+	if err != nil {
+		return false, err
+	}
+	if !nvidia_query_nvml.ClockEventsSupportedVersion(major) {
+		log.Logger.Warnw("old nvidia driver -- skipping clock events in the default config, see https://github.com/NVIDIA/go-nvml/pull/123", "version", driverVersion)
+		return false, nil
+	}
+
+	clockEventsSupported, err := nvidia_query_nvml.ClockEventsSupported()
+	if err != nil {
+		log.Logger.Warnw("failed to check clock events supported or not", "error", err)
+		return false, nil
+	}
+	if !clockEventsSupported {
+		log.Logger.Infow("auto-detected clock events not supported -- skipping", "driverVersion", driverVersion)
+		return false, nil
+	}
+	log.Logger.Infow("auto-detected clock events supported")
+	return true, nil
+}
+
 func New(ctx context.Context, cfg nvidia_common.Config, eventBucket eventstore.Bucket) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
@@ -170,6 +221,10 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 	return c.eventBucket.Get(ctx, since)
 }
 
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventBucket.Query(ctx, q)
+}
+
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
@@ -226,7 +281,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return nvidia_query_metrics_clock.Register(reg, dbRW, dbRO, tableName)
+	return nvidia_query_metrics_clock.Register(reg, store)
 }
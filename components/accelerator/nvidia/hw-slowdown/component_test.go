@@ -15,6 +15,7 @@ import (
 	"github.com/leptonai/gpud/pkg/common"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	"github.com/leptonai/gpud/pkg/sqlite"
 )
@@ -387,7 +388,7 @@ func TestComponentRegisterCollectors(t *testing.T) {
 	reg := prometheus.NewRegistry()
 	c := &component{}
 
-	err := c.RegisterCollectors(reg, dbRW, dbRO, "test_metrics")
+	err := c.RegisterCollectors(reg, components_metrics_state.NewSQLiteStore(dbRW, dbRO, "test_metrics"))
 	assert.NoError(t, err)
 	assert.Equal(t, reg, c.gatherer)
 }
@@ -584,7 +585,7 @@ func TestComponentMetrics(t *testing.T) {
 	reg := prometheus.NewRegistry()
 	c := &component{}
 
-	err := c.RegisterCollectors(reg, dbRW, dbRO, "test_metrics")
+	err := c.RegisterCollectors(reg, components_metrics_state.NewSQLiteStore(dbRW, dbRO, "test_metrics"))
 	assert.NoError(t, err)
 	assert.Equal(t, reg, c.gatherer)
 
@@ -12,7 +12,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_infiniband_id "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/common"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	"github.com/leptonai/gpud/pkg/dmesg"
@@ -44,8 +46,18 @@ func SetDefaultExpectedPortStates(states infiniband.ExpectedPortStates) {
 	defaultExpectedPortStates = states
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: nvidia_infiniband_id.Name,
+		New: func(ctx context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, instance.EventStore, instance.NvidiaToolOverwrites)
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, eventStore eventstore.Store, toolOverwrites nvidia_common.ToolOverwrites) (components.Component, error) {
-	eventBucket, err := eventStore.Bucket(nvidia_infiniband_id.Name)
+	eventBucket, err := eventStore.Bucket(nvidia_infiniband_id.Name, eventstore.WithAggregateWindow(eventstore.DefaultAggregateWindow))
 	if err != nil {
 		return nil, err
 	}
@@ -854,6 +854,19 @@ func (m *MockEventBucket) Get(ctx context.Context, since time.Time) ([]component
 	return result, nil
 }
 
+func (m *MockEventBucket) Query(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	select {
+	case <-ctx.Done():
+		return eventstore.Page{}, ctx.Err()
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return eventstore.FilterEvents(m.events, q)
+}
+
 func (m *MockEventBucket) Find(ctx context.Context, event components.Event) (*components.Event, error) {
 	select {
 	case <-ctx.Done():
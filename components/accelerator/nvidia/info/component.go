@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
@@ -15,6 +17,17 @@ import (
 
 const Name = "accelerator-nvidia-info"
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia_common.Config))
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
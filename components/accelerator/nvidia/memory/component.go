@@ -3,12 +3,13 @@ package memory
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
-	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
+	"github.com/leptonai/gpud/components/registry"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_metrics_memory "github.com/leptonai/gpud/pkg/nvidia-query/metrics/memory"
@@ -19,7 +20,18 @@ import (
 
 const Name = "accelerator-nvidia-memory"
 
-func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        Name,
+		ParseConfig: nvidia.ParseThresholdConfig,
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia.ThresholdConfig))
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
+func New(ctx context.Context, cfg nvidia.ThresholdConfig) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
 	}
@@ -30,9 +42,10 @@ func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, e
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, Name)
 
 	return &component{
-		rootCtx: ctx,
-		cancel:  ccancel,
-		poller:  nvidia_query.GetDefaultPoller(),
+		rootCtx:    ctx,
+		cancel:     ccancel,
+		poller:     nvidia_query.GetDefaultPoller(),
+		thresholds: cfg.Thresholds,
 	}, nil
 }
 
@@ -43,6 +56,8 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+
+	thresholds nvidia.Thresholds
 }
 
 func (c *component) Name() string { return Name }
@@ -78,6 +93,7 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	}
 
 	output := ToOutput(allOutput)
+	output.EvaluateThresholds(ctx, c.thresholds, nvidia_query_metrics_memory.UsedPercentAverager(), allOutput.Time)
 	return output.States()
 }
 
@@ -141,7 +157,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return nvidia_query_metrics_memory.Register(reg, dbRW, dbRO, tableName)
+	return nvidia_query_metrics_memory.Register(reg, store)
 }
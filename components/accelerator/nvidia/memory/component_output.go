@@ -1,9 +1,14 @@
 package memory
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
+	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
 
@@ -17,11 +22,12 @@ func ToOutput(i *nvidia_query.Output) *Output {
 		return &Output{}
 	}
 
-	o := &Output{}
+	o := &Output{GPUs: make(map[string]nvidia_query_nvml.GPU)}
 
 	if i.NVML != nil {
 		for _, device := range i.NVML.DeviceInfos {
 			o.UsagesNVML = append(o.UsagesNVML, device.Memory)
+			o.GPUs[device.UUID] = device.GPU()
 		}
 	}
 	return o
@@ -29,6 +35,13 @@ func ToOutput(i *nvidia_query.Output) *Output {
 
 type Output struct {
 	UsagesNVML []nvidia_query_nvml.Memory `json:"usages_nvml"`
+
+	// GPUs are the device identifiers, keyed by the GPU UUID.
+	GPUs map[string]nvidia_query_nvml.GPU `json:"gpus,omitempty"`
+
+	// Thresholds are the threshold evaluations of the GPUs
+	// with the configured thresholds, keyed by the GPU UUID.
+	Thresholds map[string]nvidia.ThresholdResult `json:"thresholds,omitempty"`
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -67,6 +80,30 @@ func (o *Output) Evaluate() (string, bool, error) {
 	return string(yb), true, nil
 }
 
+// EvaluateThresholds evaluates the memory used percent of each GPU at the thresholds,
+// with the sustained values read from the averager.
+func (o *Output) EvaluateThresholds(ctx context.Context, thresholds nvidia.Thresholds, averager components_metrics.Averager, now time.Time) {
+	o.Thresholds = nvidia.EvaluateThresholds(ctx, thresholds, averager, o.GPUs, o.UsagesNVML, func(u nvidia_query_nvml.Memory) (string, float64, bool) {
+		v, err := u.GetUsedPercent()
+		return u.UUID, v, err == nil // not supported on error
+	}, now)
+}
+
+// DeviceStates returns the per-GPU states.
+func (o *Output) DeviceStates() []components.State {
+	states := make([]components.State, 0, len(o.UsagesNVML))
+	for _, u := range o.UsagesNVML {
+		reason := fmt.Sprintf("%s memory usage is %s of %s (%s%%)", u.UUID, u.UsedHumanized, u.TotalHumanized, u.UsedPercent)
+		st := nvidia_query_nvml.DeviceState(StateNameMemoryUsage, nvidia.LookupGPU(o.GPUs, u.UUID), true, reason, u)
+		if r, ok := o.Thresholds[u.UUID]; ok {
+			r.Apply(&st, u.UUID, "memory used percent")
+		}
+		states = append(states, st)
+	}
+	return states
+}
+
+// States returns the roll-up state of all the GPUs, followed by the per-GPU states.
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
@@ -82,5 +119,7 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyMemoryUsageEncoding: StateValueMemoryUsageEncodingJSON,
 		},
 	}
-	return []components.State{state}, nil
+	deviceStates := o.DeviceStates()
+	nvidia.RollUp(&state, deviceStates)
+	return append([]components.State{state}, deviceStates...), nil
 }
@@ -7,15 +7,28 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_nccl_id "github.com/leptonai/gpud/components/accelerator/nvidia/nccl/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/dmesg"
 	"github.com/leptonai/gpud/pkg/eventstore"
 	"github.com/leptonai/gpud/pkg/kmsg"
 	"github.com/leptonai/gpud/pkg/log"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        nvidia_nccl_id.Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, instance.EventStore)
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, eventStore eventstore.Store) (components.Component, error) {
-	eventBucket, err := eventStore.Bucket(nvidia_nccl_id.Name)
+	eventBucket, err := eventStore.Bucket(nvidia_nccl_id.Name, eventstore.WithAggregateWindow(eventstore.DefaultAggregateWindow))
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +84,10 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 	return c.eventBucket.Get(ctx, since)
 }
 
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventBucket.Query(ctx, q)
+}
+
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
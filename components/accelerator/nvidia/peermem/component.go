@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_peermem_id "github.com/leptonai/gpud/components/accelerator/nvidia/peermem/id"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	"github.com/leptonai/gpud/pkg/dmesg"
 	"github.com/leptonai/gpud/pkg/eventstore"
@@ -18,8 +20,19 @@ import (
 	"github.com/leptonai/gpud/pkg/query"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        nvidia_peermem_id.Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia_common.Config), instance.EventStore)
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, cfg nvidia_common.Config, eventStore eventstore.Store) (components.Component, error) {
-	eventBucket, err := eventStore.Bucket(nvidia_peermem_id.Name)
+	eventBucket, err := eventStore.Bucket(nvidia_peermem_id.Name, eventstore.WithAggregateWindow(eventstore.DefaultAggregateWindow))
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +135,10 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 	return c.eventBucket.Get(ctx, since)
 }
 
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventBucket.Query(ctx, q)
+}
+
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
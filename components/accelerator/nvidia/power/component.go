@@ -3,13 +3,14 @@ package power
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_power_id "github.com/leptonai/gpud/components/accelerator/nvidia/power/id"
-	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/components/registry"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_metrics_power "github.com/leptonai/gpud/pkg/nvidia-query/metrics/power"
@@ -18,7 +19,18 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        nvidia_power_id.Name,
+		ParseConfig: nvidia.ParseThresholdConfig,
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia.ThresholdConfig))
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
+func New(ctx context.Context, cfg nvidia.ThresholdConfig) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
 	}
@@ -29,9 +41,10 @@ func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, e
 	nvidia_query.GetDefaultPoller().Start(cctx, cfg.Query, nvidia_power_id.Name)
 
 	return &component{
-		rootCtx: ctx,
-		cancel:  ccancel,
-		poller:  nvidia_query.GetDefaultPoller(),
+		rootCtx:    ctx,
+		cancel:     ccancel,
+		poller:     nvidia_query.GetDefaultPoller(),
+		thresholds: cfg.Thresholds,
 	}, nil
 }
 
@@ -42,6 +55,8 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+
+	thresholds nvidia.Thresholds
 }
 
 func (c *component) Name() string { return nvidia_power_id.Name }
@@ -77,6 +92,7 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	}
 
 	output := ToOutput(allOutput)
+	output.EvaluateThresholds(ctx, c.thresholds, nvidia_query_metrics_power.UsedPercentAverager(), allOutput.Time)
 	return output.States()
 }
 
@@ -140,7 +156,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return nvidia_query_metrics_power.Register(reg, dbRW, dbRO, tableName)
+	return nvidia_query_metrics_power.Register(reg, store)
 }
@@ -3,15 +3,18 @@ package processes
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_metrics_processes "github.com/leptonai/gpud/pkg/nvidia-query/metrics/processes"
+	"github.com/leptonai/gpud/pkg/pods"
 	"github.com/leptonai/gpud/pkg/query"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -19,6 +22,17 @@ import (
 
 const Name = "accelerator-nvidia-processes"
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*nvidia_common.Config))
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
@@ -78,6 +92,7 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	}
 
 	output := ToOutput(allOutput)
+	output.resolvePods(pods.Resolve)
 	return output.States()
 }
 
@@ -117,7 +132,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return nvidia_query_metrics_processes.Register(reg, dbRW, dbRO, tableName)
+	return nvidia_query_metrics_processes.Register(reg, store)
 }
@@ -2,10 +2,12 @@ package processes
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/leptonai/gpud/components"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
+	"github.com/leptonai/gpud/pkg/pods"
 
 	"sigs.k8s.io/yaml"
 )
@@ -30,6 +32,26 @@ func ToOutput(i *nvidia_query.Output) *Output {
 
 type Output struct {
 	Processes []nvidia_query_nvml.Processes `json:"processes"`
+
+	// Pods are the pods running the GPU processes, keyed by the process ID.
+	// The processes not running in a container are omitted.
+	Pods map[string]pods.Pod `json:"pods,omitempty"`
+}
+
+// resolvePods resolves the pods of the running processes of all devices.
+func (o *Output) resolvePods(resolve func(pid int) (pods.Pod, bool)) {
+	for _, procs := range o.Processes {
+		for _, proc := range procs.RunningProcesses {
+			pod, ok := resolve(int(proc.PID))
+			if !ok {
+				continue
+			}
+			if o.Pods == nil {
+				o.Pods = make(map[string]pods.Pod)
+			}
+			o.Pods[strconv.FormatUint(uint64(proc.PID), 10)] = pod
+		}
+	}
 }
 
 func (o *Output) JSON() ([]byte, error) {
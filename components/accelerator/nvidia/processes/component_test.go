@@ -6,6 +6,8 @@ import (
 
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
+	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
+	"github.com/leptonai/gpud/pkg/pods"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -25,3 +27,18 @@ func TestComponentWithNoPoller(t *testing.T) {
 		assert.Equal(t, err, nvidia_query.ErrDefaultPollerNotSet)
 	}
 }
+
+func TestOutputResolvePods(t *testing.T) {
+	o := &Output{
+		Processes: []nvidia_query_nvml.Processes{
+			{UUID: "GPU-0", RunningProcesses: []nvidia_query_nvml.Process{{PID: 100}, {PID: 200}}},
+		},
+	}
+	o.resolvePods(func(pid int) (pods.Pod, bool) {
+		if pid != 100 {
+			return pods.Pod{}, false
+		}
+		return pods.Pod{Namespace: "team-a", Name: "train-0"}, true
+	})
+	assert.Equal(t, map[string]pods.Pod{"100": {Namespace: "team-a", Name: "train-0"}}, o.Pods)
+}
@@ -3,12 +3,15 @@ package remappedrows
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
+	"github.com/leptonai/gpud/components/registry"
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_metrics_remapped_rows "github.com/leptonai/gpud/pkg/nvidia-query/metrics/remapped-rows"
@@ -19,7 +22,22 @@ import (
 
 const Name = "accelerator-nvidia-remapped-rows"
 
-func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, error) {
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name:        Name,
+		ParseConfig: nvidia.ParseConfig,
+		New: func(ctx context.Context, cfg any, instance *registry.GPUdInstance) (components.Component, error) {
+			eventBucket, err := instance.EventStore.Bucket(Name)
+			if err != nil {
+				return nil, err
+			}
+			return New(ctx, *cfg.(*nvidia_common.Config), eventBucket)
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
+func New(ctx context.Context, cfg nvidia_common.Config, eventBucket eventstore.Bucket) (components.Component, error) {
 	if nvidia_query.GetDefaultPoller() == nil {
 		return nil, nvidia_query.ErrDefaultPollerNotSet
 	}
@@ -33,6 +51,8 @@ func New(ctx context.Context, cfg nvidia_common.Config) (components.Component, e
 		rootCtx: ctx,
 		cancel:  ccancel,
 		poller:  nvidia_query.GetDefaultPoller(),
+
+		eventBucket: eventBucket,
 	}, nil
 }
 
@@ -43,6 +63,8 @@ type component struct {
 	cancel   context.CancelFunc
 	poller   query.Poller
 	gatherer prometheus.Gatherer
+
+	eventBucket eventstore.Bucket
 }
 
 func (c *component) Name() string { return Name }
@@ -81,8 +103,17 @@ func (c *component) States(ctx context.Context) ([]components.State, error) {
 	return output.States()
 }
 
+// the events are recorded by the poller (see "nvidia_query.WithRemappedRowsEventBucket")
+const EventNameRemappedDueToUncorrectableErrorsIncrease = nvidia_query.EventNameRemappedDueToUncorrectableErrorsIncrease
+
+// Events returns the increases of the rows remapped due to the uncorrectable errors since the time,
+// an event per GPU at each poll that found the newly remapped rows.
 func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return nil, nil
+	return c.eventBucket.Get(ctx, since)
+}
+
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventBucket.Query(ctx, q)
 }
 
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
@@ -141,7 +172,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return nvidia_query_metrics_remapped_rows.Register(reg, dbRW, dbRO, tableName)
+	return nvidia_query_metrics_remapped_rows.Register(reg, store)
 }
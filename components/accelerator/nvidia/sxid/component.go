@@ -4,6 +4,7 @@ package sxid
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"sort"
@@ -15,7 +16,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	os_id "github.com/leptonai/gpud/components/os/id"
+	"github.com/leptonai/gpud/components/registry"
 	pkg_dmesg "github.com/leptonai/gpud/pkg/dmesg"
 	"github.com/leptonai/gpud/pkg/eventstore"
 	"github.com/leptonai/gpud/pkg/kmsg"
@@ -47,6 +50,20 @@ type SXIDComponent struct {
 	kmsgWatcher kmsg.Watcher
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: Name,
+		New: func(ctx context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			c := New(ctx, instance.EventStore)
+			if c == nil {
+				return nil, errors.New("failed to initialize event bucket or kmsg watcher")
+			}
+			return c, nil
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, eventStore eventstore.Store) *SXIDComponent {
 	cctx, ccancel := context.WithCancel(ctx)
 
@@ -126,6 +143,17 @@ func (c *SXIDComponent) Events(ctx context.Context, since time.Time) ([]componen
 	return ret, nil
 }
 
+func (c *SXIDComponent) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	page, err := c.eventBucket.Query(ctx, q)
+	if err != nil {
+		return eventstore.Page{}, err
+	}
+	for i := range page.Events {
+		page.Events[i] = resolveSXIDEvent(page.Events[i])
+	}
+	return page, nil
+}
+
 func (c *SXIDComponent) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
@@ -1,11 +1,15 @@
 package temperature
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
+	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
 
@@ -19,11 +23,12 @@ func ToOutput(i *nvidia_query.Output) *Output {
 		return &Output{}
 	}
 
-	o := &Output{}
+	o := &Output{GPUs: make(map[string]nvidia_query_nvml.GPU)}
 
 	if i.NVML != nil {
 		for _, device := range i.NVML.DeviceInfos {
 			o.UsagesNVML = append(o.UsagesNVML, device.Temperature)
+			o.GPUs[device.UUID] = device.GPU()
 		}
 	}
 
@@ -32,6 +37,13 @@ func ToOutput(i *nvidia_query.Output) *Output {
 
 type Output struct {
 	UsagesNVML []nvidia_query_nvml.Temperature `json:"usages_nvml"`
+
+	// GPUs are the device identifiers, keyed by the GPU UUID.
+	GPUs map[string]nvidia_query_nvml.GPU `json:"gpus,omitempty"`
+
+	// Thresholds are the threshold evaluations of the GPUs
+	// with the configured thresholds, keyed by the GPU UUID.
+	Thresholds map[string]nvidia.ThresholdResult `json:"thresholds,omitempty"`
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -58,16 +70,8 @@ func (o *Output) Evaluate() (string, bool, error) {
 	memThresholdExceeded := []string{}
 	ts := make([]temp, len(o.UsagesNVML))
 	for i, u := range o.UsagesNVML {
-		// same logic as DCGM "VerifyHBMTemperature" that alerts  "DCGM_FR_TEMP_VIOLATION",
-		// use "DCGM_FI_DEV_MEM_MAX_OP_TEMP" to get the max HBM temperature threshold "NVML_TEMPERATURE_THRESHOLD_MEM_MAX"
-		if u.ThresholdCelsiusMemMax > 0 && u.CurrentCelsiusGPUCore > u.ThresholdCelsiusMemMax {
-			memThresholdExceeded = append(memThresholdExceeded,
-				fmt.Sprintf("%s current temperature is %d °C exceeding the HBM temperature threshold %d °C",
-					u.UUID,
-					u.CurrentCelsiusGPUCore,
-					u.ThresholdCelsiusMemMax,
-				),
-			)
+		if reason, healthy := evaluateDevice(u); !healthy {
+			memThresholdExceeded = append(memThresholdExceeded, reason)
 		}
 
 		ts[i] = temp{
@@ -89,6 +93,49 @@ func (o *Output) Evaluate() (string, bool, error) {
 	return string(yb), true, nil
 }
 
+// evaluateDevice returns the evaluation reason of a single GPU and its healthy-ness.
+func evaluateDevice(u nvidia_query_nvml.Temperature) (string, bool) {
+	// same logic as DCGM "VerifyHBMTemperature" that alerts  "DCGM_FR_TEMP_VIOLATION",
+	// use "DCGM_FI_DEV_MEM_MAX_OP_TEMP" to get the max HBM temperature threshold "NVML_TEMPERATURE_THRESHOLD_MEM_MAX"
+	if u.ThresholdCelsiusMemMax > 0 && u.CurrentCelsiusGPUCore > u.ThresholdCelsiusMemMax {
+		return fmt.Sprintf("%s current temperature is %d °C exceeding the HBM temperature threshold %d °C",
+			u.UUID,
+			u.CurrentCelsiusGPUCore,
+			u.ThresholdCelsiusMemMax,
+		), false
+	}
+	return fmt.Sprintf("%s current temperature is %d °C (%s%% of the slowdown threshold %d °C)",
+		u.UUID,
+		u.CurrentCelsiusGPUCore,
+		u.UsedPercentSlowdown,
+		u.ThresholdCelsiusSlowdown,
+	), true
+}
+
+// EvaluateThresholds evaluates the temperature of each GPU at the thresholds,
+// with the sustained values read from the averager.
+func (o *Output) EvaluateThresholds(ctx context.Context, thresholds nvidia.Thresholds, averager components_metrics.Averager, now time.Time) {
+	o.Thresholds = nvidia.EvaluateThresholds(ctx, thresholds, averager, o.GPUs, o.UsagesNVML, func(u nvidia_query_nvml.Temperature) (string, float64, bool) {
+		return u.UUID, float64(u.CurrentCelsiusGPUCore), true
+	}, now)
+}
+
+// DeviceStates returns the per-GPU states, so that only the bad GPU is marked unhealthy.
+func (o *Output) DeviceStates() []components.State {
+	states := make([]components.State, 0, len(o.UsagesNVML))
+	for _, u := range o.UsagesNVML {
+		reason, healthy := evaluateDevice(u)
+		st := nvidia_query_nvml.DeviceState(StateNameTemperature, nvidia.LookupGPU(o.GPUs, u.UUID), healthy, reason, u)
+		if r, ok := o.Thresholds[u.UUID]; ok {
+			r.Apply(&st, u.UUID, "temperature")
+		}
+		states = append(states, st)
+	}
+	return states
+}
+
+// States returns the roll-up state of all the GPUs (unhealthy if any GPU is),
+// followed by the per-GPU states.
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
@@ -104,5 +151,7 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyTemperatureEncoding: StateValueTemperatureEncodingJSON,
 		},
 	}
-	return []components.State{state}, nil
+	deviceStates := o.DeviceStates()
+	nvidia.RollUp(&state, deviceStates)
+	return append([]components.State{state}, deviceStates...), nil
 }
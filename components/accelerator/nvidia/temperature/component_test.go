@@ -4,8 +4,10 @@ import (
 	"context"
 	"testing"
 
-	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
+	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +17,7 @@ func TestComponentWithNoPoller(t *testing.T) {
 	defer cancel()
 
 	defaultPoller := nvidia_query.GetDefaultPoller()
-	_, err := New(ctx, nvidia_common.Config{})
+	_, err := New(ctx, nvidia.ThresholdConfig{})
 
 	if defaultPoller != nil {
 		// expects no error
@@ -25,3 +27,42 @@ func TestComponentWithNoPoller(t *testing.T) {
 		assert.Equal(t, err, nvidia_query.ErrDefaultPollerNotSet)
 	}
 }
+
+func TestOutputDeviceStates(t *testing.T) {
+	o := ToOutput(&nvidia_query.Output{
+		NVML: &nvidia_query_nvml.Output{
+			DeviceInfos: []*nvidia_query_nvml.DeviceInfo{
+				{
+					UUID:          "GPU-a",
+					MinorNumberID: 0,
+					PCIBusID:      "00000000:0A:00.0",
+					Temperature:   nvidia_query_nvml.Temperature{UUID: "GPU-a", CurrentCelsiusGPUCore: 50, ThresholdCelsiusMemMax: 95},
+				},
+				{
+					UUID:          "GPU-b",
+					MinorNumberID: 1,
+					PCIBusID:      "00000000:0B:00.0",
+					Temperature:   nvidia_query_nvml.Temperature{UUID: "GPU-b", CurrentCelsiusGPUCore: 100, ThresholdCelsiusMemMax: 95},
+				},
+			},
+		},
+	})
+
+	states, err := o.States()
+	assert.NoError(t, err)
+	assert.Len(t, states, 3)
+
+	// roll-up
+	assert.Equal(t, StateNameTemperature, states[0].Name)
+	assert.False(t, states[0].Healthy)
+
+	assert.Equal(t, "temperature_GPU-a", states[1].Name)
+	assert.True(t, states[1].Healthy)
+	assert.True(t, components.StateMatchesGPU(states[1], "00000000:0A:00.0"))
+
+	assert.Equal(t, "temperature_GPU-b", states[2].Name)
+	assert.False(t, states[2].Healthy)
+	assert.Equal(t, "1", states[2].ExtraInfo[components.StateKeyGPUMinorNumber])
+	assert.True(t, components.StateMatchesGPU(states[2], "GPU-b"))
+	assert.False(t, components.StateMatchesGPU(states[2], "GPU-a"))
+}
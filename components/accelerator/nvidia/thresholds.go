@@ -0,0 +1,318 @@
+package nvidia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/registry"
+	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
+	"github.com/leptonai/gpud/pkg/log"
+	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
+)
+
+// ThresholdConfig is the NVIDIA component configuration
+// with the health thresholds of its per-GPU metric
+// (e.g., the temperature in celsius).
+type ThresholdConfig struct {
+	nvidia_common.Config
+
+	Thresholds Thresholds `json:"thresholds"`
+}
+
+// ParseThresholdConfig parses the NVIDIA component configuration with the thresholds.
+// Returns the default configuration without any threshold, if the configuration is not set.
+func ParseThresholdConfig(cfg any, instance *registry.GPUdInstance) (any, error) {
+	if cfg == nil {
+		return &ThresholdConfig{
+			Config: nvidia_common.Config{
+				Query:          instance.DefaultQueryConfig(),
+				ToolOverwrites: instance.NvidiaToolOverwrites,
+			},
+		}, nil
+	}
+
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	parsed := new(ThresholdConfig)
+	if err := json.Unmarshal(raw, parsed); err != nil {
+		return nil, err
+	}
+	if parsed.Query.State != nil {
+		parsed.Query.State.DBRW = instance.DBRW
+		parsed.Query.State.DBRO = instance.DBRO
+	}
+	return parsed, nil
+}
+
+func (cfg ThresholdConfig) Validate() error {
+	if err := cfg.Config.Validate(); err != nil {
+		return err
+	}
+	return cfg.Thresholds.Validate()
+}
+
+// ThresholdLevels are the degraded and unhealthy levels of a per-GPU metric.
+// The GPU is degraded (or unhealthy) once the metric is at or above the level.
+// The zero level is disabled.
+type ThresholdLevels struct {
+	Degraded  float64 `json:"degraded,omitempty"`
+	Unhealthy float64 `json:"unhealthy,omitempty"`
+
+	// Sustained is how long the metric must stay at the level,
+	// not to alert on the short spikes.
+	// If set, both the current value and its average over the duration
+	// (or the EMA, see below) must be at the level.
+	// Zero to evaluate only the current value.
+	Sustained metav1.Duration `json:"sustained,omitempty"`
+	// EMA uses the exponential moving average over the sustained duration,
+	// instead of the average.
+	EMA bool `json:"ema,omitempty"`
+}
+
+// Enabled returns true if any of the levels is set.
+func (l ThresholdLevels) Enabled() bool {
+	return l.Degraded > 0 || l.Unhealthy > 0
+}
+
+func (l ThresholdLevels) Validate() error {
+	if l.Degraded < 0 || l.Unhealthy < 0 {
+		return errors.New("levels must be non-negative")
+	}
+	if l.Degraded > 0 && l.Unhealthy > 0 && l.Degraded > l.Unhealthy {
+		return errors.New("degraded level must not exceed unhealthy level")
+	}
+	if l.Sustained.Duration < 0 {
+		return errors.New("sustained duration must be non-negative")
+	}
+	return nil
+}
+
+// Health returns the health of the value at the levels,
+// and the level it reached (zero if healthy).
+func (l ThresholdLevels) Health(v float64) (string, float64) {
+	switch {
+	case l.Unhealthy > 0 && v >= l.Unhealthy:
+		return components.StateUnhealthy, l.Unhealthy
+	case l.Degraded > 0 && v >= l.Degraded:
+		return components.StateDegraded, l.Degraded
+	default:
+		return components.StateHealthy, 0
+	}
+}
+
+// Thresholds are the default threshold levels,
+// overridden per GPU product.
+type Thresholds struct {
+	ThresholdLevels
+
+	// ProductOverrides replace the default levels for the GPU products,
+	// matched as the case-insensitive substring of the device name
+	// (e.g., "H100" matches "NVIDIA H100 80GB HBM3").
+	// The longest match wins if multiple products match.
+	ProductOverrides map[string]ThresholdLevels `json:"product_overrides,omitempty"`
+}
+
+func (t Thresholds) Validate() error {
+	if err := t.ThresholdLevels.Validate(); err != nil {
+		return err
+	}
+	for product, l := range t.ProductOverrides {
+		if product == "" {
+			return errors.New("empty product name in overrides")
+		}
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("invalid thresholds for product %q: %w", product, err)
+		}
+	}
+	return nil
+}
+
+// ForProduct returns the threshold levels of the GPU product name.
+func (t Thresholds) ForProduct(name string) ThresholdLevels {
+	name = strings.ToLower(name)
+
+	matched, levels := "", t.ThresholdLevels
+	for product, l := range t.ProductOverrides {
+		if !strings.Contains(name, strings.ToLower(product)) {
+			continue
+		}
+		// sorted for the deterministic result on the ties
+		if len(product) > len(matched) || (len(product) == len(matched) && product < matched) {
+			matched, levels = product, l
+		}
+	}
+	return levels
+}
+
+// ThresholdResult is the threshold evaluation of a GPU.
+type ThresholdResult struct {
+	Health string `json:"health"`
+	// Value is the evaluated value, the lower of the current
+	// and the sustained values if the levels are sustained.
+	Value float64 `json:"value"`
+	// Level is the level the value reached, zero if healthy.
+	Level float64 `json:"level,omitempty"`
+	// Sustained is the sustained duration of the levels.
+	Sustained metav1.Duration `json:"sustained"`
+}
+
+// Reason returns the human-readable evaluation of the metric (e.g., "temperature").
+func (r ThresholdResult) Reason(gpuUUID string, metric string) string {
+	if r.Health == components.StateHealthy {
+		return ""
+	}
+	if r.Sustained.Duration > 0 {
+		return fmt.Sprintf("%s %s %.2f has been at or above the %s threshold %.2f for %s",
+			gpuUUID, metric, r.Value, strings.ToLower(r.Health), r.Level, r.Sustained.Duration)
+	}
+	return fmt.Sprintf("%s %s %.2f is at or above the %s threshold %.2f",
+		gpuUUID, metric, r.Value, strings.ToLower(r.Health), r.Level)
+}
+
+// Evaluate evaluates the current value of the GPU metric at the threshold levels of its product.
+// The sustained value is read from the averager of the metric, keyed by the GPU UUID.
+// If the sustained value fails to be read, the current value is evaluated instead,
+// so that a failed metrics read never fails the component states.
+// Returns false if no threshold is configured for the GPU.
+func (t Thresholds) Evaluate(ctx context.Context, averager components_metrics.Averager, gpu nvidia_query_nvml.GPU, current float64, now time.Time) (ThresholdResult, bool) {
+	levels := t.ForProduct(gpu.Name)
+	if !levels.Enabled() {
+		return ThresholdResult{}, false
+	}
+
+	v := current
+	if d := levels.Sustained.Duration; d > 0 {
+		// averaged over the available metrics, if tracked shorter than the duration
+		opts := []components_metrics.OpOption{
+			components_metrics.WithSince(now.Add(-d)),
+			components_metrics.WithMetricSecondaryName(gpu.UUID),
+		}
+
+		var sustained float64
+		var err error
+		if levels.EMA {
+			sustained, err = averager.EMA(ctx, append(opts, components_metrics.WithEMAPeriod(d))...)
+		} else {
+			sustained, err = averager.Avg(ctx, opts...)
+		}
+		if err != nil {
+			log.Logger.Warnw("failed to read the sustained value -- evaluating the current value", "gpu", gpu.UUID, "error", err)
+		} else if sustained < v {
+			v = sustained
+		}
+	}
+
+	health, level := levels.Health(v)
+	return ThresholdResult{
+		Health:    health,
+		Value:     v,
+		Level:     level,
+		Sustained: levels.Sustained,
+	}, true
+}
+
+// EvaluateThresholds evaluates the metric of each device at the thresholds (see "Evaluate").
+// "value" returns the GPU UUID and the current metric value of the device,
+// or false if the metric is not supported by the device.
+// Returns the results keyed by the GPU UUID, or nil if no threshold applies.
+func EvaluateThresholds[T any](
+	ctx context.Context,
+	thresholds Thresholds,
+	averager components_metrics.Averager,
+	gpus map[string]nvidia_query_nvml.GPU,
+	devices []T,
+	value func(T) (string, float64, bool),
+	now time.Time,
+) map[string]ThresholdResult {
+	var results map[string]ThresholdResult
+	for _, d := range devices {
+		uuid, v, ok := value(d)
+		if !ok {
+			continue
+		}
+		r, ok := thresholds.Evaluate(ctx, averager, LookupGPU(gpus, uuid), v, now)
+		if !ok {
+			continue
+		}
+		if results == nil {
+			results = make(map[string]ThresholdResult)
+		}
+		results[uuid] = r
+	}
+	return results
+}
+
+// LookupGPU returns the device identifiers of the GPU UUID,
+// or the GPU with the UUID only, if not found.
+func LookupGPU(gpus map[string]nvidia_query_nvml.GPU, uuid string) nvidia_query_nvml.GPU {
+	if gpu, ok := gpus[uuid]; ok {
+		return gpu
+	}
+	return nvidia_query_nvml.GPU{UUID: uuid}
+}
+
+// Apply marks the per-GPU state degraded or unhealthy, if the threshold is reached
+// and the state is not already worse.
+func (r ThresholdResult) Apply(s *components.State, gpuUUID string, metric string) {
+	if healthRank(r.Health) <= healthRank(stateHealth(*s)) {
+		return
+	}
+	s.Health = r.Health
+	s.Healthy = false
+	s.Reason = r.Reason(gpuUUID, metric)
+}
+
+// RollUp marks the roll-up state with the worst health of the per-GPU states,
+// with the reasons of the GPUs that are not healthy.
+// The roll-up state is left as is if all the GPUs are healthy.
+func RollUp(rollup *components.State, deviceStates []components.State) {
+	worst := components.StateHealthy
+	var reasons []string
+	for _, s := range deviceStates {
+		h := stateHealth(s)
+		if healthRank(h) == 0 {
+			continue
+		}
+		if healthRank(h) > healthRank(worst) {
+			worst = h
+		}
+		reasons = append(reasons, s.Reason)
+	}
+	if len(reasons) == 0 {
+		return
+	}
+	rollup.Health = worst
+	rollup.Healthy = false
+	rollup.Reason = strings.Join(reasons, ", ")
+}
+
+func stateHealth(s components.State) string {
+	if s.Health != "" {
+		return s.Health
+	}
+	if s.Healthy {
+		return components.StateHealthy
+	}
+	return components.StateUnhealthy
+}
+
+func healthRank(h string) int {
+	switch h {
+	case components.StateDegraded:
+		return 1
+	case components.StateUnhealthy:
+		return 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,161 @@
+package nvidia
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/components"
+	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
+	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
+)
+
+// fixedAverager returns the fixed average and EMA of the metrics.
+type fixedAverager struct {
+	components_metrics.Averager
+	avg float64
+	ema float64
+	err error
+}
+
+func (a *fixedAverager) Avg(ctx context.Context, opts ...components_metrics.OpOption) (float64, error) {
+	return a.avg, a.err
+}
+
+func (a *fixedAverager) EMA(ctx context.Context, opts ...components_metrics.OpOption) (float64, error) {
+	return a.ema, a.err
+}
+
+func TestThresholdsValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds Thresholds
+		wantErr    bool
+	}{
+		{name: "empty", thresholds: Thresholds{}},
+		{name: "valid", thresholds: Thresholds{ThresholdLevels: ThresholdLevels{Degraded: 80, Unhealthy: 90}}},
+		{name: "negative", thresholds: Thresholds{ThresholdLevels: ThresholdLevels{Degraded: -1}}, wantErr: true},
+		{name: "degraded above unhealthy", thresholds: Thresholds{ThresholdLevels: ThresholdLevels{Degraded: 90, Unhealthy: 80}}, wantErr: true},
+		{name: "negative sustained", thresholds: Thresholds{ThresholdLevels: ThresholdLevels{Sustained: metav1.Duration{Duration: -time.Second}}}, wantErr: true},
+		{name: "empty product", thresholds: Thresholds{ProductOverrides: map[string]ThresholdLevels{"": {}}}, wantErr: true},
+		{name: "invalid product", thresholds: Thresholds{ProductOverrides: map[string]ThresholdLevels{"H100": {Degraded: 90, Unhealthy: 80}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.thresholds.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, "Validate() error = %v", err)
+		})
+	}
+}
+
+func TestThresholdsForProduct(t *testing.T) {
+	thresholds := Thresholds{
+		ThresholdLevels: ThresholdLevels{Degraded: 80},
+		ProductOverrides: map[string]ThresholdLevels{
+			"H100":      {Degraded: 85},
+			"H100 80GB": {Degraded: 87},
+			"A100":      {Degraded: 75},
+		},
+	}
+	assert.Equal(t, 87.0, thresholds.ForProduct("NVIDIA H100 80GB HBM3").Degraded)
+	assert.Equal(t, 85.0, thresholds.ForProduct("NVIDIA H100 PCIe").Degraded)
+	assert.Equal(t, 75.0, thresholds.ForProduct("nvidia a100-sxm4-40gb").Degraded)
+	assert.Equal(t, 80.0, thresholds.ForProduct("NVIDIA L4").Degraded)
+}
+
+func TestThresholdsEvaluate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	h100 := nvidia_query_nvml.GPU{UUID: "GPU-a", Name: "NVIDIA H100 80GB HBM3"}
+	l4 := nvidia_query_nvml.GPU{UUID: "GPU-b", Name: "NVIDIA L4"}
+
+	thresholds := Thresholds{
+		ProductOverrides: map[string]ThresholdLevels{
+			"H100": {Degraded: 80, Unhealthy: 85, Sustained: metav1.Duration{Duration: 10 * time.Minute}},
+		},
+	}
+
+	// no threshold for the product
+	_, ok := thresholds.Evaluate(ctx, &fixedAverager{}, l4, 100, now)
+	assert.False(t, ok)
+
+	// warm but not sustained
+	r, ok := thresholds.Evaluate(ctx, &fixedAverager{avg: 70}, h100, 90, now)
+	assert.True(t, ok)
+	assert.Equal(t, components.StateHealthy, r.Health)
+	assert.Empty(t, r.Reason(h100.UUID, "temperature"))
+
+	// sustained
+	r, _ = thresholds.Evaluate(ctx, &fixedAverager{avg: 86}, h100, 90, now)
+	assert.Equal(t, components.StateUnhealthy, r.Health)
+	assert.Equal(t, 86.0, r.Value)
+	assert.Equal(t, 85.0, r.Level)
+	assert.Contains(t, r.Reason(h100.UUID, "temperature"), "for 10m0s")
+
+	// cooled down since
+	r, _ = thresholds.Evaluate(ctx, &fixedAverager{avg: 86}, h100, 82, now)
+	assert.Equal(t, components.StateDegraded, r.Health)
+
+	// EMA
+	thresholds.ProductOverrides["H100"] = ThresholdLevels{Degraded: 80, Sustained: metav1.Duration{Duration: time.Minute}, EMA: true}
+	r, _ = thresholds.Evaluate(ctx, &fixedAverager{avg: 70, ema: 81}, h100, 90, now)
+	assert.Equal(t, components.StateDegraded, r.Health)
+
+	// the current value, if the sustained value fails to be read
+	r, ok = thresholds.Evaluate(ctx, &fixedAverager{err: errors.New("no metrics table")}, h100, 90, now)
+	assert.True(t, ok)
+	assert.Equal(t, components.StateDegraded, r.Health)
+	assert.Equal(t, 90.0, r.Value)
+}
+
+func TestEvaluateThresholds(t *testing.T) {
+	gpus := map[string]nvidia_query_nvml.GPU{
+		"GPU-a": {UUID: "GPU-a", Name: "NVIDIA H100 80GB HBM3"},
+	}
+	thresholds := Thresholds{
+		ProductOverrides: map[string]ThresholdLevels{"H100": {Degraded: 80}},
+	}
+
+	// the unsupported metric and the GPU of no threshold are skipped
+	values := map[string]float64{"GPU-a": 90, "GPU-b": 90, "GPU-c": -1}
+	results := EvaluateThresholds(context.Background(), thresholds, &fixedAverager{}, gpus, []string{"GPU-a", "GPU-b", "GPU-c"},
+		func(uuid string) (string, float64, bool) {
+			return uuid, values[uuid], values[uuid] >= 0
+		}, time.Now())
+	assert.Len(t, results, 1)
+	assert.Equal(t, components.StateDegraded, results["GPU-a"].Health)
+
+	assert.Nil(t, EvaluateThresholds(context.Background(), Thresholds{}, &fixedAverager{}, gpus, []string{"GPU-a"},
+		func(uuid string) (string, float64, bool) { return uuid, 90, true }, time.Now()))
+}
+
+func TestRollUp(t *testing.T) {
+	devices := []components.State{
+		{Name: "temperature_GPU-a", Healthy: true, Health: components.StateHealthy},
+		{Name: "temperature_GPU-b", Healthy: true, Health: components.StateHealthy},
+	}
+
+	rollup := components.State{Name: "temperature", Healthy: true, Reason: "all good"}
+	RollUp(&rollup, devices)
+	assert.True(t, rollup.Healthy)
+	assert.Equal(t, "all good", rollup.Reason)
+
+	ThresholdResult{Health: components.StateDegraded, Value: 81, Level: 80}.Apply(&devices[0], "GPU-a", "temperature")
+	assert.False(t, devices[0].Healthy)
+	assert.Equal(t, components.StateDegraded, devices[0].Health)
+
+	// not downgraded from unhealthy
+	devices[1].Healthy, devices[1].Health, devices[1].Reason = false, components.StateUnhealthy, "HBM threshold exceeded"
+	ThresholdResult{Health: components.StateDegraded, Value: 81, Level: 80}.Apply(&devices[1], "GPU-b", "temperature")
+	assert.Equal(t, components.StateUnhealthy, devices[1].Health)
+	assert.Equal(t, "HBM threshold exceeded", devices[1].Reason)
+
+	RollUp(&rollup, devices)
+	assert.False(t, rollup.Healthy)
+	assert.Equal(t, components.StateUnhealthy, rollup.Health)
+	assert.Equal(t, "GPU-a temperature 81.00 is at or above the degraded threshold 80.00, HBM threshold exceeded", rollup.Reason)
+}
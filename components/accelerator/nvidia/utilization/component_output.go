@@ -1,9 +1,14 @@
 package utilization
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
+	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
 
@@ -17,11 +22,12 @@ func ToOutput(i *nvidia_query.Output) *Output {
 		return &Output{}
 	}
 
-	o := &Output{}
+	o := &Output{GPUs: make(map[string]nvidia_query_nvml.GPU)}
 
 	if i.NVML != nil {
 		for _, device := range i.NVML.DeviceInfos {
 			o.Utilizations = append(o.Utilizations, device.Utilization)
+			o.GPUs[device.UUID] = device.GPU()
 		}
 	}
 
@@ -30,6 +36,13 @@ func ToOutput(i *nvidia_query.Output) *Output {
 
 type Output struct {
 	Utilizations []nvidia_query_nvml.Utilization `json:"utilizations"`
+
+	// GPUs are the device identifiers, keyed by the GPU UUID.
+	GPUs map[string]nvidia_query_nvml.GPU `json:"gpus,omitempty"`
+
+	// Thresholds are the threshold evaluations of the GPUs
+	// with the configured thresholds, keyed by the GPU UUID.
+	Thresholds map[string]nvidia.ThresholdResult `json:"thresholds,omitempty"`
 }
 
 func (o *Output) JSON() ([]byte, error) {
@@ -53,6 +66,29 @@ func (o *Output) Evaluate() (string, bool, error) {
 	return string(yb), true, nil
 }
 
+// EvaluateThresholds evaluates the GPU utilization percent of each GPU at the thresholds,
+// with the sustained values read from the averager.
+func (o *Output) EvaluateThresholds(ctx context.Context, thresholds nvidia.Thresholds, averager components_metrics.Averager, now time.Time) {
+	o.Thresholds = nvidia.EvaluateThresholds(ctx, thresholds, averager, o.GPUs, o.Utilizations, func(u nvidia_query_nvml.Utilization) (string, float64, bool) {
+		return u.UUID, float64(u.GPUUsedPercent), true
+	}, now)
+}
+
+// DeviceStates returns the per-GPU states.
+func (o *Output) DeviceStates() []components.State {
+	states := make([]components.State, 0, len(o.Utilizations))
+	for _, u := range o.Utilizations {
+		reason := fmt.Sprintf("%s GPU utilization is %d%% (memory %d%%)", u.UUID, u.GPUUsedPercent, u.MemoryUsedPercent)
+		st := nvidia_query_nvml.DeviceState(StateNameUtilization, nvidia.LookupGPU(o.GPUs, u.UUID), true, reason, u)
+		if r, ok := o.Thresholds[u.UUID]; ok {
+			r.Apply(&st, u.UUID, "GPU utilization percent")
+		}
+		states = append(states, st)
+	}
+	return states
+}
+
+// States returns the roll-up state of all the GPUs, followed by the per-GPU states.
 func (o *Output) States() ([]components.State, error) {
 	outputReasons, healthy, err := o.Evaluate()
 	if err != nil {
@@ -68,5 +104,7 @@ func (o *Output) States() ([]components.State, error) {
 			StateKeyUtilizationEncoding: StateValueUtilizationEncodingJSON,
 		},
 	}
-	return []components.State{state}, nil
+	deviceStates := o.DeviceStates()
+	nvidia.RollUp(&state, deviceStates)
+	return append([]components.State{state}, deviceStates...), nil
 }
@@ -4,7 +4,7 @@ import (
 	"context"
 	"testing"
 
-	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 
 	"github.com/stretchr/testify/assert"
@@ -15,7 +15,7 @@ func TestComponentWithNoPoller(t *testing.T) {
 	defer cancel()
 
 	defaultPoller := nvidia_query.GetDefaultPoller()
-	_, err := New(ctx, nvidia_common.Config{})
+	_, err := New(ctx, nvidia.ThresholdConfig{})
 
 	if defaultPoller != nil {
 		// expects no error
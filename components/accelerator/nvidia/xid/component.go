@@ -5,6 +5,7 @@ package xid
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"sort"
@@ -16,11 +17,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	os_id "github.com/leptonai/gpud/components/os/id"
+	"github.com/leptonai/gpud/components/registry"
 	pkg_dmesg "github.com/leptonai/gpud/pkg/dmesg"
 	"github.com/leptonai/gpud/pkg/eventstore"
 	"github.com/leptonai/gpud/pkg/kmsg"
 	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/pods"
 )
 
 const Name = "accelerator-nvidia-error-xid"
@@ -31,6 +35,13 @@ const (
 	EventNameErrorXid    = "error_xid"
 	EventKeyErrorXidData = "data"
 	EventKeyDeviceUUID   = "device_uuid"
+	// EventKeyPID is the process ID named by the Xid message, if known.
+	// The pod running the process is set with the "pods.ExtraInfoKey*" keys.
+	EventKeyPID = "pid"
+	// EventKeyProcessName is the name of the process named by the Xid message, if known.
+	EventKeyProcessName = "process_name"
+	// EventKeyChannel is the GPU channel named by the Xid message, if any.
+	EventKeyChannel = "channel"
 
 	DefaultRetentionPeriod   = eventstore.DefaultRetention
 	DefaultStateUpdatePeriod = 30 * time.Second
@@ -44,10 +55,27 @@ type XIDComponent struct {
 	eventBucket  eventstore.Bucket
 	mu           sync.RWMutex
 
+	// resolves the pod of the process named by the Xid message
+	resolvePod func(pid int) (pods.Pod, bool)
+
 	// experimental
 	kmsgWatcher kmsg.Watcher
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: Name,
+		New: func(ctx context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			c := New(ctx, instance.EventStore)
+			if c == nil {
+				return nil, errors.New("failed to initialize event bucket or kmsg watcher")
+			}
+			return c, nil
+		},
+		DefaultEnabled: nvidia.DefaultEnabled,
+	})
+}
+
 func New(ctx context.Context, eventStore eventstore.Store) *XIDComponent {
 	cctx, ccancel := context.WithCancel(ctx)
 
@@ -76,6 +104,7 @@ func New(ctx context.Context, eventStore eventstore.Store) *XIDComponent {
 		cancel:       ccancel,
 		extraEventCh: extraEventCh,
 		eventBucket:  eventBucket,
+		resolvePod:   pods.Resolve,
 		kmsgWatcher:  kmsgWatcher,
 	}
 }
@@ -127,6 +156,17 @@ func (c *XIDComponent) Events(ctx context.Context, since time.Time) ([]component
 	return ret, nil
 }
 
+func (c *XIDComponent) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	page, err := c.eventBucket.Query(ctx, q)
+	if err != nil {
+		return eventstore.Page{}, err
+	}
+	for i := range page.Events {
+		page.Events[i] = resolveXIDEvent(page.Events[i])
+	}
+	return page, nil
+}
+
 func (c *XIDComponent) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
@@ -191,6 +231,22 @@ func (c *XIDComponent) start(watcher pkg_dmesg.Watcher, updatePeriod time.Durati
 					EventKeyDeviceUUID:   xidErr.DeviceUUID,
 				},
 			}
+			if xidErr.ProcessName != "" {
+				event.ExtraInfo[EventKeyProcessName] = xidErr.ProcessName
+			}
+			if xidErr.Channel != "" {
+				event.ExtraInfo[EventKeyChannel] = xidErr.Channel
+			}
+			if xidErr.PID > 0 {
+				event.ExtraInfo[EventKeyPID] = strconv.Itoa(xidErr.PID)
+				if c.resolvePod != nil {
+					if pod, ok := c.resolvePod(xidErr.PID); ok {
+						for k, v := range pod.ExtraInfo() {
+							event.ExtraInfo[k] = v
+						}
+					}
+				}
+			}
 			currEvent, err := c.eventBucket.Find(c.rootCtx, event)
 			if err != nil {
 				log.Logger.Errorw("failed to check event existence", "error", err)
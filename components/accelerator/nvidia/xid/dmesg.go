@@ -3,6 +3,7 @@ package xid
 import (
 	"regexp"
 	"strconv"
+	"strings"
 
 	"sigs.k8s.io/yaml"
 
@@ -22,11 +23,26 @@ const (
 	// Regex to extract PCI device ID from NVRM Xid messages
 	// Matches both formats: (0000:03:00) and (PCI:0000:05:00)
 	RegexNVRMXidDeviceUUID = `NVRM: Xid \(((?:PCI:)?[0-9a-fA-F:]+)\)`
+
+	// Regex to extract the process ID from NVRM Xid messages, if known
+	// e.g., "pid=1234, name=python" or "pid='1234', name=python"
+	RegexNVRMXidPID = `NVRM: Xid.*?, pid='?(\d+)'?,`
+
+	// Regex to extract the process name from NVRM Xid messages, if known
+	// e.g., "pid=1234, name=python, Ch 00000008"
+	RegexNVRMXidProcessName = `NVRM: Xid.*?, pid=[^,]*, name=([^,]+),`
+
+	// Regex to extract the GPU channel from NVRM Xid messages, if any
+	// e.g., "14, Channel 00000001" or "name=python, Ch 00000008, intr 10000000"
+	RegexNVRMXidChannel = `NVRM: Xid.*?, (?:Channel|Ch) ([0-9a-fA-F]+)`
 )
 
 var (
-	compiledRegexNVRMXidDmesg      = regexp.MustCompile(RegexNVRMXidDmesg)
-	compiledRegexNVRMXidDeviceUUID = regexp.MustCompile(RegexNVRMXidDeviceUUID)
+	compiledRegexNVRMXidDmesg       = regexp.MustCompile(RegexNVRMXidDmesg)
+	compiledRegexNVRMXidDeviceUUID  = regexp.MustCompile(RegexNVRMXidDeviceUUID)
+	compiledRegexNVRMXidPID         = regexp.MustCompile(RegexNVRMXidPID)
+	compiledRegexNVRMXidProcessName = regexp.MustCompile(RegexNVRMXidProcessName)
+	compiledRegexNVRMXidChannel     = regexp.MustCompile(RegexNVRMXidChannel)
 )
 
 // Extracts the nvidia Xid error code from the dmesg log line.
@@ -52,10 +68,54 @@ func ExtractNVRMXidDeviceUUID(line string) string {
 	return ""
 }
 
+// ExtractNVRMXidPID extracts the process ID from the NVRM Xid dmesg log line.
+// Returns 0 if the process ID is not found or unknown (e.g., "pid='<unknown>'").
+func ExtractNVRMXidPID(line string) int {
+	if match := compiledRegexNVRMXidPID.FindStringSubmatch(line); match != nil {
+		if pid, err := strconv.Atoi(match[1]); err == nil {
+			return pid
+		}
+	}
+	return 0
+}
+
+// ExtractNVRMXidPCIBusID extracts the PCI bus ID of the device from the NVRM Xid dmesg log line,
+// without the "PCI:" prefix (e.g., "0000:05:00").
+// Returns empty string if the device ID is not found.
+func ExtractNVRMXidPCIBusID(line string) string {
+	return strings.TrimPrefix(ExtractNVRMXidDeviceUUID(line), "PCI:")
+}
+
+// ExtractNVRMXidProcessName extracts the process name from the NVRM Xid dmesg log line.
+// Returns empty string if the process name is not found or unknown (e.g., "name=<unknown>").
+func ExtractNVRMXidProcessName(line string) string {
+	if match := compiledRegexNVRMXidProcessName.FindStringSubmatch(line); match != nil {
+		name := strings.Trim(strings.TrimSpace(match[1]), "'")
+		if name != "<unknown>" {
+			return name
+		}
+	}
+	return ""
+}
+
+// ExtractNVRMXidChannel extracts the GPU channel ID from the NVRM Xid dmesg log line.
+// Returns empty string if the channel is not found.
+func ExtractNVRMXidChannel(line string) string {
+	if match := compiledRegexNVRMXidChannel.FindStringSubmatch(line); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
 type XidError struct {
-	Xid        int         `json:"xid"`
-	DeviceUUID string      `json:"device_uuid"`
-	Detail     *xid.Detail `json:"detail,omitempty"`
+	Xid        int    `json:"xid"`
+	DeviceUUID string `json:"device_uuid"`
+	// PCIBusID is the device ID without the "PCI:" prefix (e.g., "0000:05:00").
+	PCIBusID    string      `json:"pci_bus_id,omitempty"`
+	PID         int         `json:"pid,omitempty"`
+	ProcessName string      `json:"process_name,omitempty"`
+	Channel     string      `json:"channel,omitempty"`
+	Detail      *xid.Detail `json:"detail,omitempty"`
 }
 
 func (xidErr XidError) YAML() ([]byte, error) {
@@ -75,8 +135,12 @@ func Match(line string) *XidError {
 	}
 	deviceUUID := ExtractNVRMXidDeviceUUID(line)
 	return &XidError{
-		Xid:        extractedID,
-		DeviceUUID: deviceUUID,
-		Detail:     detail,
+		Xid:         extractedID,
+		DeviceUUID:  deviceUUID,
+		PCIBusID:    strings.TrimPrefix(deviceUUID, "PCI:"),
+		PID:         ExtractNVRMXidPID(line),
+		ProcessName: ExtractNVRMXidProcessName(line),
+		Channel:     ExtractNVRMXidChannel(line),
+		Detail:      detail,
 	}
 }
@@ -111,6 +111,142 @@ func TestExtractNVRMXidDeviceUUID(t *testing.T) {
 	}
 }
 
+func TestExtractNVRMXidPID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{
+			name:     "pid",
+			input:    "NVRM: Xid (PCI:0000:05:00): 13, pid=1234, name=python, Graphics SM Warp Exception on (GPC 0, TPC 0, SM 0)",
+			expected: 1234,
+		},
+		{
+			name:     "quoted pid",
+			input:    "[...] NVRM: Xid (PCI:0000:05:00): 43, pid='5678', name=pt_main_thread, Ch 00000008",
+			expected: 5678,
+		},
+		{
+			name:     "unknown pid",
+			input:    "NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus.",
+			expected: 0,
+		},
+		{
+			name:     "no pid",
+			input:    "NVRM: Xid (0000:03:00): 14, Channel 00000001",
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExtractNVRMXidPID(tt.input)
+			if result != tt.expected {
+				t.Errorf("ExtractNVRMXidPID(%q) = %d, want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractNVRMXidProcessName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "process name",
+			input:    "NVRM: Xid (PCI:0000:05:00): 13, pid=1234, name=python, Graphics SM Warp Exception on (GPC 0, TPC 0, SM 0)",
+			expected: "python",
+		},
+		{
+			name:     "quoted pid",
+			input:    "[...] NVRM: Xid (PCI:0000:05:00): 43, pid='5678', name=pt_main_thread, Ch 00000008",
+			expected: "pt_main_thread",
+		},
+		{
+			name:     "unknown process",
+			input:    "NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus.",
+			expected: "",
+		},
+		{
+			name:     "no process",
+			input:    "NVRM: Xid (0000:03:00): 14, Channel 00000001",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExtractNVRMXidProcessName(tt.input)
+			if result != tt.expected {
+				t.Errorf("ExtractNVRMXidProcessName(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractNVRMXidChannel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "channel",
+			input:    "[...] NVRM: Xid (0000:03:00): 14, Channel 00000001",
+			expected: "00000001",
+		},
+		{
+			name:     "short channel",
+			input:    "NVRM: Xid (PCI:0000:b1:00): 31, pid=1234, name=python3, Ch 00000008, intr 10000000. MMU Fault: ENGINE GRAPHICS",
+			expected: "00000008",
+		},
+		{
+			name:     "no channel",
+			input:    "NVRM: Xid (PCI:0000:05:00): 79, pid='<unknown>', name=<unknown>, GPU has fallen off the bus.",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExtractNVRMXidChannel(tt.input)
+			if result != tt.expected {
+				t.Errorf("ExtractNVRMXidChannel(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchStructuredFields(t *testing.T) {
+	t.Parallel()
+
+	result := Match("[...] NVRM: Xid (PCI:0000:b1:00): 31, pid=1234, name=python3, Ch 00000008, intr 10000000. MMU Fault: ENGINE GRAPHICS")
+	if result == nil {
+		t.Fatal("Match() = nil, want non-nil")
+	}
+	expected := XidError{
+		Xid:         31,
+		DeviceUUID:  "PCI:0000:b1:00",
+		PCIBusID:    "0000:b1:00",
+		PID:         1234,
+		ProcessName: "python3",
+		Channel:     "00000008",
+		Detail:      result.Detail,
+	}
+	if !reflect.DeepEqual(*result, expected) {
+		t.Errorf("Match() = %+v, want %+v", *result, expected)
+	}
+}
+
 func TestMatch(t *testing.T) {
 	t.Parallel()
 
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/leptonai/gpud/pkg/common"
 	"github.com/leptonai/gpud/pkg/log"
 	nvidia_query_xid "github.com/leptonai/gpud/pkg/nvidia-query/xid"
+	"github.com/leptonai/gpud/pkg/pods"
 )
 
 const (
@@ -124,17 +126,27 @@ func resolveXIDEvent(event components.Event) components.Event {
 			}
 			ret.Type = detail.EventType
 			ret.Message = fmt.Sprintf("XID %d detected on %s", currXid, event.ExtraInfo[EventKeyDeviceUUID])
+			if podName := event.ExtraInfo[pods.ExtraInfoKeyPodName]; podName != "" {
+				ret.Message += fmt.Sprintf(" (pod %s/%s)", event.ExtraInfo[pods.ExtraInfoKeyPodNamespace], podName)
+			}
 			ret.SuggestedActions = detail.SuggestedActionsByGPUd
 
 			xidErr := xidErrorFromDmesg{
 				Time:                      event.Time,
 				DataSource:                "dmesg",
 				DeviceUUID:                event.ExtraInfo[EventKeyDeviceUUID],
+				PCIBusID:                  strings.TrimPrefix(event.ExtraInfo[EventKeyDeviceUUID], "PCI:"),
+				ProcessName:               event.ExtraInfo[EventKeyProcessName],
+				Channel:                   event.ExtraInfo[EventKeyChannel],
 				Xid:                       uint64(currXid),
 				SuggestedActionsByGPUd:    detail.SuggestedActionsByGPUd,
 				CriticalErrorMarkedByGPUd: detail.CriticalErrorMarkedByGPUd,
 			}
 
+			if pid, err := strconv.Atoi(event.ExtraInfo[EventKeyPID]); err == nil {
+				xidErr.PID = pid
+			}
+
 			raw, _ := xidErr.JSON()
 			ret.ExtraInfo[EventKeyErrorXidData] = string(raw)
 		}
@@ -152,6 +164,15 @@ type xidErrorFromDmesg struct {
 
 	// DeviceUUID is the UUID of the device that has the error.
 	DeviceUUID string `json:"device_uuid"`
+	// PCIBusID is the PCI bus ID of the device, without the "PCI:" prefix.
+	PCIBusID string `json:"pci_bus_id,omitempty"`
+
+	// PID is the ID of the process that has the error, if known.
+	PID int `json:"pid,omitempty"`
+	// ProcessName is the name of the process that has the error, if known.
+	ProcessName string `json:"process_name,omitempty"`
+	// Channel is the GPU channel that has the error, if any.
+	Channel string `json:"channel,omitempty"`
 
 	// Xid is the corresponding Xid from the raw event.
 	// The monitoring component can use this Xid to decide its own action.
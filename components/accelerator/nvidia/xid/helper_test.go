@@ -163,3 +163,25 @@ func TestXidErrorFromDmesgJSON(t *testing.T) {
 		assert.False(t, unmarshaled.CriticalErrorMarkedByGPUd)
 	})
 }
+
+func TestResolveXIDEventStructuredFields(t *testing.T) {
+	event := resolveXIDEvent(components.Event{
+		Time: metav1.Time{Time: time.Unix(1700000000, 0)},
+		Name: EventNameErrorXid,
+		ExtraInfo: map[string]string{
+			EventKeyErrorXidData: "31",
+			EventKeyDeviceUUID:   "PCI:0000:b1:00",
+			EventKeyPID:          "1234",
+			EventKeyProcessName:  "python3",
+			EventKeyChannel:      "00000008",
+		},
+	})
+
+	var xidErr xidErrorFromDmesg
+	assert.NoError(t, json.Unmarshal([]byte(event.ExtraInfo[EventKeyErrorXidData]), &xidErr))
+	assert.Equal(t, uint64(31), xidErr.Xid)
+	assert.Equal(t, "0000:b1:00", xidErr.PCIBusID)
+	assert.Equal(t, 1234, xidErr.PID)
+	assert.Equal(t, "python3", xidErr.ProcessName)
+	assert.Equal(t, "00000008", xidErr.Channel)
+}
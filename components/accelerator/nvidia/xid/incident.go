@@ -0,0 +1,191 @@
+package xid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	nvidia_ecc_id "github.com/leptonai/gpud/components/accelerator/nvidia/ecc/id"
+	nvidia_hw_slowdown_id "github.com/leptonai/gpud/components/accelerator/nvidia/hw-slowdown/id"
+	nvidia_remapped_rows "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
+	nvidia_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/sxid"
+	"github.com/leptonai/gpud/pkg/log"
+)
+
+// DefaultIncidentWindow is how long before the first and after the last Xid
+// of an incident the related events are grouped into the incident.
+const DefaultIncidentWindow = 5 * time.Minute
+
+// IncidentSources are the components whose events are correlated with the Xid errors.
+// Their events are read from the event store, where the NVIDIA poller records
+// the ECC errors and the remapped rows as soon as a poll finds the counters increased.
+var IncidentSources = []string{
+	nvidia_sxid.Name,
+	nvidia_ecc_id.Name,
+	nvidia_remapped_rows.Name,
+	nvidia_hw_slowdown_id.Name,
+}
+
+// Incidents reads the Xid events since the time and correlates them
+// with the events of the incident source components (see "Correlate").
+// "gpus" maps the PCI bus IDs to the GPU UUIDs, to match the per-GPU events.
+// The source components that are not running are skipped.
+func Incidents(ctx context.Context, since time.Time, window time.Duration, gpus map[string]string) ([]v1.Incident, error) {
+	xidComponent, err := components.GetComponent(Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get xid component: %w", err)
+	}
+	xidEvents, err := xidComponent.Events(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get xid events: %w", err)
+	}
+	if len(xidEvents) == 0 {
+		return nil, nil
+	}
+
+	related := make(v1.LeptonEvents, 0, len(IncidentSources))
+	for _, name := range IncidentSources {
+		c, err := components.GetComponent(name)
+		if err != nil {
+			log.Logger.Debugw("incident source component not found -- skipping", "component", name, "error", err)
+			continue
+		}
+
+		// the related events may precede the first Xid
+		evs, err := c.Events(ctx, since.Add(-window))
+		if err != nil {
+			log.Logger.Warnw("failed to get incident source events -- skipping", "component", name, "error", err)
+			continue
+		}
+		related = append(related, v1.LeptonComponentEvents{Component: name, Events: evs})
+	}
+
+	return Correlate(xidEvents, related, gpus, window), nil
+}
+
+// Correlate groups the Xid events into the incidents, and adds the related events
+// of the other components within the window of each incident to its timeline.
+// The incidents are returned in the ascending order of the start time.
+//
+// The Xids on the same GPU are grouped into one incident as long as each
+// follows the previous one within the window (e.g., Xid 48 followed by Xid 63).
+// The related events of a GPU (i.e., with the "gpu_uuid" extra info) are only added
+// to the incidents of the same GPU, if the GPU UUID of the incident is resolved
+// from "gpus", and the node-wide events (e.g., the NVSwitch SXid) to all the incidents.
+func Correlate(xidEvents []components.Event, related v1.LeptonEvents, gpus map[string]string, window time.Duration) []v1.Incident {
+	uuids := make(map[string]string, len(gpus))
+	for pciBusID, uuid := range gpus {
+		uuids[normalizePCIBusID(pciBusID)] = uuid
+	}
+
+	sorted := make([]components.Event, 0, len(xidEvents))
+	for _, ev := range xidEvents {
+		if ev.Name == EventNameErrorXid {
+			sorted = append(sorted, ev)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(&sorted[j].Time) })
+
+	var incidents []v1.Incident
+	// the Xid time range of each incident, to correlate the related events
+	var xidStarts, xidEnds []time.Time
+	// the index of the latest incident of each GPU
+	latest := make(map[string]int)
+	for _, ev := range sorted {
+		// no-op if already resolved (e.g., by "Events")
+		ev = resolveXIDEvent(ev)
+
+		var xidErr xidErrorFromDmesg
+		if err := json.Unmarshal([]byte(ev.ExtraInfo[EventKeyErrorXidData]), &xidErr); err != nil {
+			log.Logger.Debugw("failed to unmarshal xid event -- skipping", "event", ev.Name, "error", err)
+			continue
+		}
+
+		t := ev.Time.Time
+		pciBusID := normalizePCIBusID(xidErr.PCIBusID)
+		i, ok := latest[pciBusID]
+		if !ok || t.After(xidEnds[i].Add(window)) {
+			incidents = append(incidents, v1.Incident{
+				ID:       fmt.Sprintf("xid-%d-%s-%d", xidErr.Xid, pciBusID, t.Unix()),
+				PCIBusID: pciBusID,
+				GPUUUID:  uuids[pciBusID],
+			})
+			xidStarts = append(xidStarts, t)
+			xidEnds = append(xidEnds, t)
+			i = len(incidents) - 1
+			latest[pciBusID] = i
+		}
+		xidEnds[i] = t
+
+		inc := &incidents[i]
+		inc.Xids = append(inc.Xids, int(xidErr.Xid))
+		if inc.PID == 0 && xidErr.PID > 0 {
+			inc.PID, inc.ProcessName = xidErr.PID, xidErr.ProcessName
+		}
+		if inc.Type == "" || ev.Type.Severity() > inc.Type.Severity() {
+			inc.Type = ev.Type
+			inc.SuggestedActions = ev.SuggestedActions
+		}
+		inc.Timeline = append(inc.Timeline, v1.IncidentEvent{Component: Name, Event: ev})
+	}
+
+	for _, ce := range related {
+		for _, ev := range ce.Events {
+			gpuUUID := ev.ExtraInfo[components.StateKeyGPUUUID]
+			for i := range incidents {
+				if ev.Time.Time.Before(xidStarts[i].Add(-window)) || ev.Time.Time.After(xidEnds[i].Add(window)) {
+					continue
+				}
+				if gpuUUID != "" && incidents[i].GPUUUID != "" && !strings.EqualFold(gpuUUID, incidents[i].GPUUUID) {
+					continue
+				}
+				incidents[i].Timeline = append(incidents[i].Timeline, v1.IncidentEvent{Component: ce.Component, Event: ev})
+			}
+		}
+	}
+
+	for i := range incidents {
+		timeline := incidents[i].Timeline
+		sort.SliceStable(timeline, func(a, b int) bool { return timeline[a].Event.Time.Before(&timeline[b].Event.Time) })
+		incidents[i].StartTime = timeline[0].Event.Time.Time
+		incidents[i].EndTime = timeline[len(timeline)-1].Event.Time.Time
+	}
+	sort.SliceStable(incidents, func(i, j int) bool { return incidents[i].StartTime.Before(incidents[j].StartTime) })
+
+	return incidents
+}
+
+// normalizePCIBusID returns the "domain:bus:device" PCI bus ID in lower case,
+// to match the IDs of the same GPU in the Xid message (e.g., "PCI:0000:05:00")
+// and in NVML (e.g., "00000000:05:00.0").
+// Returns the lower-cased ID without the prefix, if not parsed.
+func normalizePCIBusID(id string) string {
+	id = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(id)), "pci:")
+	if i := strings.LastIndex(id, "."); i >= 0 {
+		id = id[:i]
+	}
+
+	parts := strings.Split(id, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return id
+	}
+
+	var vs [3]uint64
+	for i, p := range parts {
+		v, err := strconv.ParseUint(p, 16, 32)
+		if err != nil {
+			return id
+		}
+		vs[i] = v
+	}
+	return fmt.Sprintf("%04x:%02x:%02x", vs[0], vs[1], vs[2])
+}
@@ -0,0 +1,125 @@
+package xid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	nvidia_ecc_id "github.com/leptonai/gpud/components/accelerator/nvidia/ecc/id"
+	nvidia_hw_slowdown_id "github.com/leptonai/gpud/components/accelerator/nvidia/hw-slowdown/id"
+	nvidia_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/sxid"
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+func TestNormalizePCIBusID(t *testing.T) {
+	assert.Equal(t, "0000:05:00", normalizePCIBusID("PCI:0000:05:00"))
+	assert.Equal(t, "0000:05:00", normalizePCIBusID("00000000:05:00.0"))
+	assert.Equal(t, "0000:b1:00", normalizePCIBusID("0000:B1:00"))
+	assert.Equal(t, "0000:b1:00", normalizePCIBusID("b1:00"))
+	assert.Equal(t, "invalid", normalizePCIBusID("invalid"))
+}
+
+func TestCorrelate(t *testing.T) {
+	baseTime := time.Unix(1700000000, 0).UTC()
+	at := func(d time.Duration) metav1.Time { return metav1.Time{Time: baseTime.Add(d)} }
+	xidEvent := func(d time.Duration, xid string, device string, extra map[string]string) components.Event {
+		ev := components.Event{
+			Time: at(d),
+			Name: EventNameErrorXid,
+			ExtraInfo: map[string]string{
+				EventKeyErrorXidData: xid,
+				EventKeyDeviceUUID:   device,
+			},
+		}
+		for k, v := range extra {
+			ev.ExtraInfo[k] = v
+		}
+		return ev
+	}
+
+	xidEvents := []components.Event{
+		// the following Xid on the same GPU
+		xidEvent(2*time.Minute, "63", "PCI:0000:05:00", nil),
+		xidEvent(0, "48", "PCI:0000:05:00", map[string]string{EventKeyPID: "1234", EventKeyProcessName: "python"}),
+		// another GPU
+		xidEvent(time.Minute, "79", "PCI:0000:b1:00", nil),
+		// the same GPU, but long after
+		xidEvent(time.Hour, "48", "PCI:0000:05:00", nil),
+		{Time: at(0), Name: "SetHealthy"},
+	}
+	related := v1.LeptonEvents{
+		{
+			Component: nvidia_sxid.Name,
+			Events: []components.Event{
+				{Time: at(30 * time.Second), Name: "error_sxid", ExtraInfo: map[string]string{"device_uuid": "PCI:0000:c5:00"}},
+			},
+		},
+		{
+			Component: nvidia_ecc_id.Name,
+			Events: []components.Event{
+				{Time: at(-time.Minute), Name: "aggregate_total_uncorrected_increase", ExtraInfo: map[string]string{components.StateKeyGPUUUID: "GPU-a"}},
+				{Time: at(time.Minute), Name: "aggregate_total_uncorrected_increase", ExtraInfo: map[string]string{components.StateKeyGPUUUID: "GPU-b"}},
+				// out of the window
+				{Time: at(30 * time.Minute), Name: "aggregate_total_uncorrected_increase", ExtraInfo: map[string]string{components.StateKeyGPUUUID: "GPU-a"}},
+			},
+		},
+		{
+			Component: nvidia_hw_slowdown_id.Name,
+			Events: []components.Event{
+				{Time: at(5 * time.Minute), Name: "hw_slowdown", Type: common.EventTypeWarning, ExtraInfo: map[string]string{components.StateKeyGPUUUID: "gpu-a"}},
+			},
+		},
+	}
+	gpus := map[string]string{
+		"00000000:05:00.0": "GPU-a",
+		"00000000:B1:00.0": "GPU-b",
+	}
+
+	incidents := Correlate(xidEvents, related, gpus, DefaultIncidentWindow)
+	assert.Len(t, incidents, 3)
+
+	inc := incidents[0]
+	assert.Equal(t, "xid-48-0000:05:00-1700000000", inc.ID)
+	assert.Equal(t, []int{48, 63}, inc.Xids)
+	assert.Equal(t, "0000:05:00", inc.PCIBusID)
+	assert.Equal(t, "GPU-a", inc.GPUUUID)
+	assert.Equal(t, 1234, inc.PID)
+	assert.Equal(t, "python", inc.ProcessName)
+	assert.Equal(t, baseTime.Add(-time.Minute), inc.StartTime)
+	assert.Equal(t, baseTime.Add(5*time.Minute), inc.EndTime)
+	assert.NotNil(t, inc.SuggestedActions)
+
+	var timeline []string
+	for _, e := range inc.Timeline {
+		timeline = append(timeline, e.Component+"/"+e.Event.Name)
+	}
+	assert.Equal(t, []string{
+		nvidia_ecc_id.Name + "/aggregate_total_uncorrected_increase",
+		Name + "/" + EventNameErrorXid,
+		nvidia_sxid.Name + "/error_sxid",
+		Name + "/" + EventNameErrorXid,
+		nvidia_hw_slowdown_id.Name + "/hw_slowdown",
+	}, timeline)
+
+	inc = incidents[1]
+	assert.Equal(t, []int{79}, inc.Xids)
+	assert.Equal(t, "GPU-b", inc.GPUUUID)
+	assert.Equal(t, common.EventTypeFatal, inc.Type)
+	assert.Len(t, inc.Timeline, 3)
+
+	inc = incidents[2]
+	assert.Equal(t, []int{48}, inc.Xids)
+	assert.Len(t, inc.Timeline, 1)
+	assert.Equal(t, inc.StartTime, inc.EndTime)
+
+	// not resolved to the GPU, so the per-GPU events of all the GPUs are related
+	incidents = Correlate(xidEvents[2:3], related, nil, DefaultIncidentWindow)
+	assert.Len(t, incidents, 1)
+	assert.Len(t, incidents[0].Timeline, 5)
+
+	assert.Empty(t, Correlate(nil, related, gpus, DefaultIncidentWindow))
+}
@@ -0,0 +1,47 @@
+// Package all imports all the in-tree components,
+// so that their factories are registered to the component registry.
+// Out-of-tree components register themselves the same way,
+// by importing the component package for its side effects.
+package all
+
+import (
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/gpm"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/hw-slowdown"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/info"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/nccl"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/peermem"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/power"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/processes"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/sxid"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
+	_ "github.com/leptonai/gpud/components/accelerator/nvidia/xid"
+	_ "github.com/leptonai/gpud/components/containerd/pod"
+	_ "github.com/leptonai/gpud/components/cpu"
+	_ "github.com/leptonai/gpud/components/custom-check"
+	_ "github.com/leptonai/gpud/components/disk"
+	_ "github.com/leptonai/gpud/components/docker/container"
+	_ "github.com/leptonai/gpud/components/fd"
+	_ "github.com/leptonai/gpud/components/file"
+	_ "github.com/leptonai/gpud/components/fuse"
+	_ "github.com/leptonai/gpud/components/info"
+	_ "github.com/leptonai/gpud/components/kernel-module"
+	_ "github.com/leptonai/gpud/components/kubelet/pod"
+	_ "github.com/leptonai/gpud/components/library"
+	_ "github.com/leptonai/gpud/components/memory"
+	_ "github.com/leptonai/gpud/components/network/latency"
+	_ "github.com/leptonai/gpud/components/os"
+	_ "github.com/leptonai/gpud/components/pci"
+	_ "github.com/leptonai/gpud/components/systemd"
+	_ "github.com/leptonai/gpud/components/tailscale"
+)
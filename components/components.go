@@ -3,8 +3,8 @@ package components
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -67,7 +67,7 @@ type OutputProvider interface {
 
 // Defines an optional component interface that supports Prometheus metrics.
 type PromRegisterer interface {
-	RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error
+	RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error
 }
 
 type State struct {
@@ -88,6 +88,28 @@ const (
 	StateDegraded     = "Degraded"
 )
 
+// The extra info keys of the per-GPU states, identifying the device
+// (e.g., for the device plugin to isolate only the bad GPU).
+const (
+	StateKeyGPUUUID        = "gpu_uuid"
+	StateKeyGPUPCIBusID    = "gpu_pci_bus_id"
+	StateKeyGPUMinorNumber = "gpu_minor_number"
+)
+
+// StateMatchesGPU returns true if the state is the per-GPU state of the device,
+// identified by its UUID, PCI bus ID, or minor number.
+func StateMatchesGPU(s State, gpu string) bool {
+	if gpu == "" || s.ExtraInfo == nil {
+		return false
+	}
+	for _, k := range []string{StateKeyGPUUUID, StateKeyGPUPCIBusID, StateKeyGPUMinorNumber} {
+		if v, ok := s.ExtraInfo[k]; ok && strings.EqualFold(v, gpu) {
+			return true
+		}
+	}
+	return false
+}
+
 type Event struct {
 	Time             metav1.Time              `json:"time"`
 	Name             string                   `json:"name,omitempty"`
@@ -95,6 +117,14 @@ type Event struct {
 	Message          string                   `json:"message,omitempty"`    // detailed message of the event
 	ExtraInfo        map[string]string        `json:"extra_info,omitempty"` // any extra information the component may want to expose
 	SuggestedActions *common.SuggestedActions `json:"suggested_actions,omitempty"`
+
+	// Count is the number of the occurrences aggregated into this event
+	// by the bucket aggregate window (zero if not aggregated, the single occurrence).
+	Count int `json:"count,omitempty"`
+	// FirstSeen is the time of the first aggregated occurrence (nil if not aggregated).
+	FirstSeen *metav1.Time `json:"first_seen,omitempty"`
+	// LastSeen is the time of the last aggregated occurrence, same as "Time" (nil if not aggregated).
+	LastSeen *metav1.Time `json:"last_seen,omitempty"`
 }
 
 type Metric struct {
@@ -154,8 +184,34 @@ func getComponent(set map[string]Component, name string) (Component, error) {
 	return v, nil
 }
 
+// DeregisterComponent removes the component from the set
+// and returns the removed component (e.g., to be closed by the caller).
+func DeregisterComponent(name string) (Component, error) {
+	defaultSetMu.Lock()
+	defer defaultSetMu.Unlock()
+
+	return deregisterComponent(defaultSet, name)
+}
+
+func deregisterComponent(set map[string]Component, name string) (Component, error) {
+	v, err := getComponent(set, name)
+	if err != nil {
+		return nil, err
+	}
+	delete(set, name)
+	return v, nil
+}
+
+// GetAllComponents returns a copy of the registered components,
+// since the components can be registered or deregistered at runtime
+// (e.g., configuration reload).
 func GetAllComponents() map[string]Component {
 	defaultSetMu.RLock()
 	defer defaultSetMu.RUnlock()
-	return defaultSet
+
+	all := make(map[string]Component, len(defaultSet))
+	for k, v := range defaultSet {
+		all[k] = v
+	}
+	return all
 }
@@ -15,3 +15,44 @@ func TestGetComponentErrors(t *testing.T) {
 		t.Errorf("expected ErrNotFound, got %v", err)
 	}
 }
+
+func TestDeregisterComponent(t *testing.T) {
+	if _, err := deregisterComponent(nil, "nvidia"); !errors.Is(err, errdefs.ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+
+	set := map[string]Component{"nvidia": nil}
+	if _, err := deregisterComponent(set, "nvidia"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := set["nvidia"]; ok {
+		t.Error("expected component to be removed")
+	}
+	if _, err := deregisterComponent(set, "nvidia"); !errors.Is(err, errdefs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStateMatchesGPU(t *testing.T) {
+	s := State{
+		Name: "temperature_GPU-a",
+		ExtraInfo: map[string]string{
+			StateKeyGPUUUID:        "GPU-a",
+			StateKeyGPUPCIBusID:    "00000000:0a:00.0",
+			StateKeyGPUMinorNumber: "3",
+		},
+	}
+	for _, gpu := range []string{"GPU-a", "gpu-a", "00000000:0A:00.0", "3"} {
+		if !StateMatchesGPU(s, gpu) {
+			t.Errorf("expected %q to match", gpu)
+		}
+	}
+	for _, gpu := range []string{"", "GPU-b", "4"} {
+		if StateMatchesGPU(s, gpu) {
+			t.Errorf("expected %q not to match", gpu)
+		}
+	}
+	if StateMatchesGPU(State{Name: "temperature"}, "GPU-a") {
+		t.Error("expected the roll-up state not to match")
+	}
+}
@@ -244,6 +244,7 @@ func convertToPodSandbox(resp *runtimeapi.PodSandboxStatusResponse) PodSandbox {
 		ID:        status.Id,
 		Name:      status.Metadata.Name,
 		Namespace: status.Metadata.Namespace,
+		UID:       status.Metadata.Uid,
 		State:     status.State.String(),
 		Info:      resp.GetInfo(),
 	}
@@ -277,6 +278,7 @@ type PodSandbox struct {
 	ID         string                      `json:"id,omitempty"`
 	Namespace  string                      `json:"namespace,omitempty"`
 	Name       string                      `json:"name,omitempty"`
+	UID        string                      `json:"uid,omitempty"`
 	State      string                      `json:"state,omitempty"`
 	Info       map[string]string           `json:"info,omitempty"`
 	Containers []PodSandboxContainerStatus `json:"containers,omitempty"`
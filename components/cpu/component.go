@@ -3,7 +3,6 @@ package cpu
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -14,8 +13,10 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	"github.com/leptonai/gpud/components/cpu/metrics"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/dmesg"
 	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/kmsg"
 	"github.com/leptonai/gpud/pkg/log"
 )
@@ -43,8 +44,18 @@ type component struct {
 	lastData *Data
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: Name,
+		New: func(ctx context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, instance.EventStore)
+		},
+		DefaultEnabled: registry.AlwaysEnabled,
+	})
+}
+
 func New(ctx context.Context, eventStore eventstore.Store) (components.Component, error) {
-	eventBucket, err := eventStore.Bucket(Name)
+	eventBucket, err := eventStore.Bucket(Name, eventstore.WithAggregateWindow(eventstore.DefaultAggregateWindow))
 	if err != nil {
 		return nil, err
 	}
@@ -113,6 +124,10 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 	return c.eventBucket.Get(ctx, since)
 }
 
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventBucket.Query(ctx, q)
+}
+
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
@@ -157,9 +172,9 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return metrics.Register(reg, dbRW, dbRO, tableName)
+	return metrics.Register(reg, store)
 }
 
 // CheckOnce checks the current pods
@@ -9,6 +9,7 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -449,6 +450,10 @@ func (m *mockEventBucket) Get(ctx context.Context, since time.Time) ([]component
 	return m.events, nil
 }
 
+func (m *mockEventBucket) Query(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return eventstore.FilterEvents(m.events, q)
+}
+
 func (m *mockEventBucket) Latest(ctx context.Context) (*components.Event, error) {
 	return nil, nil
 }
@@ -3,7 +3,6 @@ package metrics
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -59,9 +58,9 @@ var (
 	)
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	loadAverage5minAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_load_average_5min")
-	usedPercentAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_used_percent")
+func InitAveragers(store components_metrics_state.Store) {
+	loadAverage5minAverager = components_metrics.NewAverager(store, SubSystem+"_load_average_5min")
+	usedPercentAverager = components_metrics.NewAverager(store, SubSystem+"_used_percent")
 }
 
 func ReadLoadAverage5mins(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -120,8 +119,8 @@ func SetUsedPercent(ctx context.Context, pct float64, currentTime time.Time) err
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
@@ -0,0 +1,175 @@
+// Package customcheck provides the components that run the user-defined check scripts
+// (e.g., site-specific mount flags, BIOS settings), one component per check
+// (e.g., "custom-check-mount-flags") that reports the check result as its state.
+package customcheck
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	custom_check_id "github.com/leptonai/gpud/components/custom-check/id"
+	"github.com/leptonai/gpud/components/registry"
+	"github.com/leptonai/gpud/pkg/log"
+)
+
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: custom_check_id.Name,
+		ParseConfig: func(cfg any, _ *registry.GPUdInstance) (any, error) {
+			if cfg == nil {
+				return Config(nil), nil
+			}
+			return ParseConfig(cfg)
+		},
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			checks := cfg.(Config)
+			// nothing to check without the checks specified
+			if len(checks) == 0 {
+				return nil, nil
+			}
+			if len(checks) > 1 {
+				return nil, fmt.Errorf("expected one check per component, got %d", len(checks))
+			}
+			return New(ctx, checks[0]), nil
+		},
+		Instances: func(cfg any) (map[string]any, error) {
+			if cfg == nil {
+				return nil, nil
+			}
+			checks, err := ParseConfig(cfg)
+			if err != nil {
+				return nil, err
+			}
+			if err := checks.Validate(); err != nil {
+				return nil, err
+			}
+			cfgs := make(map[string]any, len(checks))
+			for _, check := range checks {
+				cfgs[ComponentName(check.Name)] = Config{check}
+			}
+			return cfgs, nil
+		},
+	})
+}
+
+// ComponentName returns the name of the component that runs the check.
+func ComponentName(check string) string {
+	return custom_check_id.Name + "-" + check
+}
+
+// New creates the component that runs the check
+// periodically until the component is closed.
+func New(ctx context.Context, check Check) components.Component {
+	cfg := Config{check}
+	cfg.SetDefaults()
+
+	cctx, ccancel := context.WithCancel(ctx)
+	return &component{
+		check:     cfg[0],
+		ctx:       cctx,
+		cancel:    ccancel,
+		runScript: runBashScript,
+	}
+}
+
+var _ components.Component = &component{}
+
+type component struct {
+	check  Check
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	startOnce sync.Once
+
+	resultMu sync.RWMutex
+	// latest result, nil if the check has not run yet
+	result *Result
+
+	// for testing
+	runScript func(ctx context.Context, script string) ([]byte, int, error)
+}
+
+func (c *component) Name() string { return ComponentName(c.check.Name) }
+
+func (c *component) Start() error {
+	c.startOnce.Do(func() {
+		go c.poll()
+	})
+	return nil
+}
+
+func (c *component) poll() {
+	ticker := time.NewTicker(c.check.Interval.Duration)
+	defer ticker.Stop()
+
+	for {
+		c.setResult(c.run())
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *component) run() Result {
+	cctx, cancel := context.WithTimeout(c.ctx, c.check.Timeout.Duration)
+	defer cancel()
+
+	start := time.Now()
+	stdout, exitCode, err := c.runScript(cctx, c.check.Script)
+	r := newResult(c.check.Name, stdout, exitCode, err)
+	r.Time = start.UTC()
+	r.Duration = time.Since(start)
+
+	log.Logger.Debugw("custom check finished", "check", c.check.Name, "health", r.Health, "exitCode", r.ExitCode, "duration", r.Duration)
+	return r
+}
+
+func (c *component) setResult(r Result) {
+	c.resultMu.Lock()
+	defer c.resultMu.Unlock()
+	c.result = &r
+}
+
+func (c *component) States(ctx context.Context) ([]components.State, error) {
+	c.resultMu.RLock()
+	defer c.resultMu.RUnlock()
+
+	if c.result == nil {
+		return []components.State{{
+			Name:    c.check.Name,
+			Healthy: true,
+			Health:  components.StateInitializing,
+			Reason:  "check not run yet",
+		}}, nil
+	}
+	return []components.State{c.result.State()}, nil
+}
+
+func (c *component) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+
+func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	log.Logger.Debugw("querying metrics", "since", since)
+
+	c.resultMu.RLock()
+	defer c.resultMu.RUnlock()
+
+	if c.result == nil || c.result.Time.Before(since) {
+		return nil, nil
+	}
+	return c.result.Metrics(), nil
+}
+
+func (c *component) Close() error {
+	log.Logger.Debugw("closing component")
+
+	c.cancel()
+	return nil
+}
@@ -0,0 +1,167 @@
+package customcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/components"
+	custom_check_id "github.com/leptonai/gpud/components/custom-check/id"
+	"github.com/leptonai/gpud/components/registry"
+)
+
+func TestRunBashScript(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantHealth string
+		wantReason string
+		wantExtra  map[string]string
+		wantMetric map[string]float64
+		wantErr    bool
+	}{
+		{
+			name:       "healthy without output",
+			script:     "exit 0",
+			wantHealth: components.StateHealthy,
+			wantReason: "check exited with code 0",
+		},
+		{
+			name:       "degraded with json output",
+			script:     `echo '{"reason":"noatime not set","extra_info":{"mount":"/data"},"metrics":{"mounts":3}}'; echo ignored >&2; exit 1`,
+			wantHealth: components.StateDegraded,
+			wantReason: "noatime not set",
+			wantExtra:  map[string]string{"mount": "/data"},
+			wantMetric: map[string]float64{"mounts": 3},
+		},
+		{
+			name:       "unhealthy with plain text output",
+			script:     "echo 'SR-IOV disabled in BIOS'; exit 2",
+			wantHealth: components.StateUnhealthy,
+			wantReason: "SR-IOV disabled in BIOS",
+		},
+		{
+			name:       "unknown exit code",
+			script:     "exit 7",
+			wantHealth: components.StateUnhealthy,
+			wantReason: "check exited with code 7",
+		},
+		{
+			name:       "timeout",
+			script:     "sleep 10",
+			wantHealth: components.StateUnhealthy,
+			wantReason: "check exited with code -1",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(context.Background(), Check{Name: "check", Script: tt.script, Timeout: metav1.Duration{Duration: 500 * time.Millisecond}}).(*component)
+			defer c.Close()
+
+			r := c.run()
+			require.Equal(t, tt.wantHealth, r.Health)
+			require.Equal(t, tt.wantErr, r.Error != "")
+
+			s := r.State()
+			require.Equal(t, "check", s.Name)
+			require.Equal(t, tt.wantHealth == components.StateHealthy, s.Healthy)
+			require.Equal(t, tt.wantReason, s.Reason)
+			for k, v := range tt.wantExtra {
+				require.Equal(t, v, s.ExtraInfo[k])
+			}
+			require.Contains(t, s.ExtraInfo, StateKeyExitCode)
+
+			ms := r.Metrics()
+			require.Len(t, ms, len(tt.wantMetric))
+			for _, m := range ms {
+				require.Equal(t, "check", m.MetricSecondaryName)
+				require.Equal(t, tt.wantMetric[m.MetricName], m.Value)
+			}
+		})
+	}
+}
+
+func TestComponentStates(t *testing.T) {
+	c := New(context.Background(), Check{Name: "a", Script: "a"}).(*component)
+	defer c.Close()
+	require.Equal(t, "custom-check-a", c.Name())
+
+	ran := make(chan string, 1)
+	c.runScript = func(ctx context.Context, script string) ([]byte, int, error) {
+		defer func() { ran <- script }()
+		return []byte(`{"metrics":{"value":1.5}}`), 0, nil
+	}
+
+	states, err := c.States(context.Background())
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	require.Equal(t, components.StateInitializing, states[0].Health)
+
+	require.NoError(t, c.Start())
+	<-ran
+	require.Eventually(t, func() bool {
+		c.resultMu.RLock()
+		defer c.resultMu.RUnlock()
+		return c.result != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	states, err = c.States(context.Background())
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	require.Equal(t, "a", states[0].Name)
+	require.Equal(t, components.StateHealthy, states[0].Health)
+
+	ms, err := c.Metrics(context.Background(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+	require.Equal(t, "value", ms[0].MetricName)
+	require.Equal(t, 1.5, ms[0].Value)
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]any{
+		map[string]any{"name": "mount-flags", "script": "findmnt -no OPTIONS /data | grep -q noatime", "interval": "5m"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, 5*time.Minute, cfg[0].Interval.Duration)
+	require.Equal(t, DefaultTimeout, cfg[0].Timeout.Duration)
+
+	require.Error(t, Config{{Script: "true"}}.Validate())
+	require.Error(t, Config{{Name: "a"}}.Validate())
+	require.Error(t, Config{{Name: "a", Script: "true"}, {Name: "a", Script: "true"}}.Validate())
+	require.Error(t, Config{{Name: "a/b", Script: "true"}}.Validate())
+}
+
+func TestFactory(t *testing.T) {
+	cfgs, err := registry.Expand(custom_check_id.Name, nil)
+	require.NoError(t, err)
+	require.Empty(t, cfgs, "nothing to run without checks")
+
+	_, err = registry.Expand(custom_check_id.Name, []any{map[string]any{"name": "a"}})
+	require.Error(t, err)
+
+	cfgs, err = registry.Expand(custom_check_id.Name, []any{
+		map[string]any{"name": "a", "script": "true"},
+		map[string]any{"name": "b", "script": "false"},
+	})
+	require.NoError(t, err)
+	require.Len(t, cfgs, 2)
+	require.Contains(t, cfgs, "custom-check-a")
+	require.Contains(t, cfgs, "custom-check-b")
+
+	for name, cfg := range cfgs {
+		c, err := registry.Create(context.Background(), name, cfg, &registry.GPUdInstance{})
+		require.NoError(t, err)
+		require.Equal(t, name, c.Name())
+		require.NoError(t, c.Close())
+	}
+
+	c, err := registry.Create(context.Background(), custom_check_id.Name, nil, &registry.GPUdInstance{})
+	require.NoError(t, err)
+	require.Nil(t, c, "nothing to run without checks")
+}
@@ -0,0 +1,92 @@
+package customcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultInterval = time.Minute
+	DefaultTimeout  = 30 * time.Second
+)
+
+// Config is the list of the user-defined checks, e.g.,
+//
+//	[{"name": "mount-flags", "script": "/opt/checks/mount-flags.sh", "interval": "5m"}]
+type Config []Check
+
+// Check defines a user-defined check script.
+//
+// The script exit code maps to the health state:
+// 0 is "Healthy", 1 is "Degraded", and 2 (or any other code) is "Unhealthy".
+// The script may print a JSON object to stdout with the optional fields
+// "reason" (string), "extra_info" (string map), and "metrics" (number map).
+type Check struct {
+	// Name is the unique check name, used as the state name
+	// and in the component name (e.g., "custom-check-mount-flags").
+	Name string `json:"name"`
+	// Script is the bash script (or the path to the script) to run.
+	Script string `json:"script"`
+	// Interval is the interval between the check runs.
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// Timeout is the timeout of each check run.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ParseConfig parses the raw configuration value
+// and sets the default values for the unset fields.
+func ParseConfig(b any) (Config, error) {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	cfg.SetDefaults()
+	return cfg, nil
+}
+
+// SetDefaults sets the default values for the unset fields.
+func (cfg Config) SetDefaults() {
+	for i := range cfg {
+		if cfg[i].Interval.Duration == 0 {
+			cfg[i].Interval = metav1.Duration{Duration: DefaultInterval}
+		}
+		if cfg[i].Timeout.Duration == 0 {
+			cfg[i].Timeout = metav1.Duration{Duration: DefaultTimeout}
+		}
+	}
+}
+
+// checkNameRegex matches the check names valid in the component name.
+var checkNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+func (cfg Config) Validate() error {
+	names := make(map[string]struct{}, len(cfg))
+	for i, c := range cfg {
+		if c.Name == "" {
+			return fmt.Errorf("check[%d]: name is required", i)
+		}
+		if !checkNameRegex.MatchString(c.Name) {
+			return fmt.Errorf("check[%d]: invalid name %q (expected alphanumerics, '-', '_', or '.')", i, c.Name)
+		}
+		if _, ok := names[c.Name]; ok {
+			return fmt.Errorf("check[%d]: duplicate name %q", i, c.Name)
+		}
+		names[c.Name] = struct{}{}
+
+		if c.Script == "" {
+			return fmt.Errorf("check %q: script is required", c.Name)
+		}
+		if c.Interval.Duration < 0 || c.Timeout.Duration < 0 {
+			return fmt.Errorf("check %q: interval and timeout must be non-negative", c.Name)
+		}
+	}
+	return nil
+}
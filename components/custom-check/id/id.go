@@ -0,0 +1,4 @@
+// Package id defines the component ID for the custom-check component.
+package id
+
+const Name = "custom-check"
@@ -0,0 +1,165 @@
+package customcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+)
+
+const (
+	ExitCodeHealthy   = 0
+	ExitCodeDegraded  = 1
+	ExitCodeUnhealthy = 2
+)
+
+const (
+	StateKeyExitCode   = "exit_code"
+	StateKeyDurationMs = "duration_ms"
+)
+
+// maxOutputSize is the maximum size of the script output to parse.
+const maxOutputSize = 64 * 1024
+
+// Output is the optional JSON object that the check script prints to stdout.
+type Output struct {
+	Reason    string             `json:"reason,omitempty"`
+	ExtraInfo map[string]string  `json:"extra_info,omitempty"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Result is the result of a check run.
+type Result struct {
+	Name     string
+	Time     time.Time
+	Duration time.Duration
+
+	ExitCode int
+	Health   string
+	Output   Output
+	// Error is set if the script failed to run (e.g., timed out),
+	// or its output is not valid JSON.
+	Error string
+}
+
+// HealthFromExitCode maps the script exit code to the health state.
+func HealthFromExitCode(code int) string {
+	switch code {
+	case ExitCodeHealthy:
+		return components.StateHealthy
+	case ExitCodeDegraded:
+		return components.StateDegraded
+	default:
+		return components.StateUnhealthy
+	}
+}
+
+// newResult creates the result from the script stdout and exit code.
+// The non-nil error means the script did not exit on its own (e.g., timeout).
+func newResult(name string, stdout []byte, exitCode int, err error) Result {
+	r := Result{
+		Name:     name,
+		ExitCode: exitCode,
+		Health:   HealthFromExitCode(exitCode),
+	}
+	if err != nil {
+		r.Health = components.StateUnhealthy
+		r.Error = err.Error()
+	}
+
+	out := strings.TrimSpace(string(stdout))
+	if out == "" {
+		return r
+	}
+	if perr := json.Unmarshal([]byte(out), &r.Output); perr != nil {
+		// plain text output is used as the reason as is
+		r.Output = Output{Reason: truncate(out, 512)}
+	}
+	return r
+}
+
+// State converts the result into the component state.
+func (r Result) State() components.State {
+	reason := r.Output.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("check exited with code %d", r.ExitCode)
+	}
+
+	extraInfo := make(map[string]string, len(r.Output.ExtraInfo)+2)
+	for k, v := range r.Output.ExtraInfo {
+		extraInfo[k] = v
+	}
+	extraInfo[StateKeyExitCode] = strconv.Itoa(r.ExitCode)
+	extraInfo[StateKeyDurationMs] = strconv.FormatInt(r.Duration.Milliseconds(), 10)
+
+	return components.State{
+		Name:      r.Name,
+		Healthy:   r.Health == components.StateHealthy,
+		Health:    r.Health,
+		Reason:    reason,
+		Error:     r.Error,
+		ExtraInfo: extraInfo,
+	}
+}
+
+// Metrics converts the reported metrics into the component metrics,
+// with the check name as the secondary name.
+func (r Result) Metrics() []components.Metric {
+	names := make([]string, 0, len(r.Output.Metrics))
+	for name := range r.Output.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ms := make([]components.Metric, 0, len(names))
+	for _, name := range names {
+		ms = append(ms, components.Metric{
+			Metric: components_metrics_state.Metric{
+				UnixSeconds:         r.Time.Unix(),
+				MetricName:          name,
+				MetricSecondaryName: r.Name,
+				Value:               r.Output.Metrics[name],
+			},
+		})
+	}
+	return ms
+}
+
+// runBashScript runs the script and returns its stdout and exit code.
+// Returns a non-nil error only if the script did not run to its exit.
+func runBashScript(ctx context.Context, script string) ([]byte, int, error) {
+	cmd := exec.CommandContext(ctx, "bash", "-c", script)
+	// not to block on the pipes held by the orphaned child processes after the timeout
+	cmd.WaitDelay = time.Second
+	stdout, err := cmd.Output()
+	if len(stdout) > maxOutputSize {
+		stdout = stdout[:maxOutputSize]
+	}
+	if ctx.Err() != nil {
+		return stdout, -1, fmt.Errorf("check did not finish: %w", ctx.Err())
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout, exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return stdout, -1, err
+	}
+	return stdout, 0, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -3,19 +3,39 @@ package disk
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	disk_id "github.com/leptonai/gpud/components/disk/id"
 	"github.com/leptonai/gpud/components/disk/metrics"
+	"github.com/leptonai/gpud/components/registry"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	"github.com/leptonai/gpud/pkg/query"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: disk_id.Name,
+		ParseConfig: func(cfg any, instance *registry.GPUdInstance) (any, error) {
+			if cfg == nil {
+				return &Config{Query: instance.DefaultQueryConfig()}, nil
+			}
+			return ParseConfig(cfg, instance.DBRW, instance.DBRO)
+		},
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*Config)), nil
+		},
+		DefaultEnabled: registry.AlwaysEnabled,
+		DefaultConfig: func() any {
+			return DefaultConfig()
+		},
+	})
+}
+
 func New(ctx context.Context, cfg Config) components.Component {
 	cfg.Query.SetDefaultsIfNotSet()
 	setDefaultPoller(cfg)
@@ -145,7 +165,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return metrics.Register(reg, dbRW, dbRO, tableName)
+	return metrics.Register(reg, store)
 }
@@ -3,7 +3,6 @@ package metrics
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -99,10 +98,10 @@ var (
 	)
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	totalBytesAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_total_bytes")
-	usedBytesAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_used_bytes")
-	usedBytesPercentAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_used_bytes_percent")
+func InitAveragers(store components_metrics_state.Store) {
+	totalBytesAverager = components_metrics.NewAverager(store, SubSystem+"_total_bytes")
+	usedBytesAverager = components_metrics.NewAverager(store, SubSystem+"_used_bytes")
+	usedBytesPercentAverager = components_metrics.NewAverager(store, SubSystem+"_used_bytes_percent")
 }
 
 func ReadTotalBytes(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -198,8 +197,8 @@ func SetUsedInodesPercent(mountPoint string, pct float64) {
 	usedInodesPercent.WithLabelValues(mountPoint).Set(pct)
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/pods"
 	"github.com/leptonai/gpud/pkg/systemd"
 )
 
@@ -35,6 +37,16 @@ type component struct {
 	lastData *Data
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: Name,
+		New: func(ctx context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, instance.DockerIgnoreConnectionErrors), nil
+		},
+		DefaultEnabled: registry.AlwaysEnabled,
+	})
+}
+
 func New(ctx context.Context, ignoreConnectionErrors bool) components.Component {
 	cctx, cancel := context.WithCancel(ctx)
 	c := &component{
@@ -52,6 +64,8 @@ func New(ctx context.Context, ignoreConnectionErrors bool) components.Component
 func (c *component) Name() string { return Name }
 
 func (c *component) Start() error {
+	pods.RegisterLister(Name, c)
+
 	go func() {
 		ticker := time.NewTicker(time.Minute)
 		defer ticker.Stop()
@@ -89,11 +103,35 @@ func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.
 func (c *component) Close() error {
 	log.Logger.Debugw("closing component")
 
+	pods.UnregisterLister(Name)
 	c.cancel()
 
 	return nil
 }
 
+var _ pods.Lister = &component{}
+
+// ListPods lists the last checked containers, with the pod names
+// of the containers created by the kubelet.
+func (c *component) ListPods() []pods.Pod {
+	c.lastMu.RLock()
+	defer c.lastMu.RUnlock()
+	if c.lastData == nil {
+		return nil
+	}
+
+	ps := make([]pods.Pod, 0, len(c.lastData.Containers))
+	for _, container := range c.lastData.Containers {
+		ps = append(ps, pods.Pod{
+			Namespace:   container.PodNamespace,
+			Name:        container.PodName,
+			Container:   strings.TrimPrefix(container.Name, "/"),
+			ContainerID: container.ID,
+		})
+	}
+	return ps
+}
+
 // CheckOnce checks the current pods
 // run this periodically
 func (c *component) CheckOnce() {
@@ -3,15 +3,44 @@ package file
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/leptonai/gpud/components"
 	file_id "github.com/leptonai/gpud/components/file/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/log"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: file_id.Name,
+		ParseConfig: func(cfg any, _ *registry.GPUdInstance) (any, error) {
+			if cfg == nil {
+				return nil, nil
+			}
+			raw, err := json.Marshal(cfg)
+			if err != nil {
+				return nil, err
+			}
+			var files []string
+			if err := json.Unmarshal(raw, &files); err != nil {
+				return nil, err
+			}
+			return files, nil
+		},
+		New: func(_ context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			// nothing to check without the files specified
+			if cfg == nil {
+				return nil, nil
+			}
+			return New(cfg.([]string)), nil
+		},
+	})
+}
+
 func New(filesToCheck []string) components.Component {
 	return &component{filesToCheck: filesToCheck}
 }
@@ -3,7 +3,6 @@ package fuse
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
@@ -12,11 +11,33 @@ import (
 	"github.com/leptonai/gpud/components"
 	fuse_id "github.com/leptonai/gpud/components/fuse/id"
 	"github.com/leptonai/gpud/components/fuse/metrics"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	"github.com/leptonai/gpud/pkg/log"
 	"github.com/leptonai/gpud/pkg/query"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: fuse_id.Name,
+		ParseConfig: func(cfg any, instance *registry.GPUdInstance) (any, error) {
+			if cfg == nil {
+				return &Config{
+					Query:                                instance.DefaultQueryConfig(),
+					CongestedPercentAgainstThreshold:     DefaultCongestedPercentAgainstThreshold,
+					MaxBackgroundPercentAgainstThreshold: DefaultMaxBackgroundPercentAgainstThreshold,
+				}, nil
+			}
+			return ParseConfig(cfg, instance.DBRW, instance.DBRO)
+		},
+		New: func(ctx context.Context, cfg any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*Config), instance.EventStore)
+		},
+		DefaultEnabled: registry.AlwaysEnabled,
+	})
+}
+
 func New(ctx context.Context, cfg Config, eventStore eventstore.Store) (components.Component, error) {
 	eventBucket, err := eventStore.Bucket(fuse_id.Name)
 	if err != nil {
@@ -92,6 +113,10 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 	return c.eventBucket.Get(ctx, since)
 }
 
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventBucket.Query(ctx, q)
+}
+
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
@@ -129,7 +154,7 @@ func (c *component) Close() error {
 
 var _ components.PromRegisterer = (*component)(nil)
 
-func (c *component) RegisterCollectors(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
+func (c *component) RegisterCollectors(reg *prometheus.Registry, store components_metrics_state.Store) error {
 	c.gatherer = reg
-	return metrics.Register(reg, dbRW, dbRO, tableName)
+	return metrics.Register(reg, store)
 }
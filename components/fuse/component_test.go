@@ -10,6 +10,7 @@ import (
 	"github.com/leptonai/gpud/components"
 	"github.com/leptonai/gpud/pkg/common"
 	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	query_config "github.com/leptonai/gpud/pkg/query/config"
 	"github.com/leptonai/gpud/pkg/sqlite"
 
@@ -98,7 +99,7 @@ func TestComponent(t *testing.T) {
 
 	// Test prometheus registration
 	reg := prometheus.NewRegistry()
-	if err := comp.RegisterCollectors(reg, dbRW, dbRO, "test_table"); err != nil {
+	if err := comp.RegisterCollectors(reg, components_metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table")); err != nil {
 		t.Fatalf("Failed to register collectors: %v", err)
 	}
 
@@ -3,7 +3,6 @@ package metrics
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -47,9 +46,9 @@ var (
 	connsMaxBackgroundPctAverager = components_metrics.NewNoOpAverager()
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	connsCongestedPctAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_connections_congested_percent_against_threshold")
-	connsMaxBackgroundPctAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_connections_max_background_percent_against_threshold")
+func InitAveragers(store components_metrics_state.Store) {
+	connsCongestedPctAverager = components_metrics.NewAverager(store, SubSystem+"_connections_congested_percent_against_threshold")
+	connsMaxBackgroundPctAverager = components_metrics.NewAverager(store, SubSystem+"_connections_max_background_percent_against_threshold")
 }
 
 func ReadConnectionsCongestedPercents(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -94,8 +93,8 @@ func SetConnectionsMaxBackgroundPercent(ctx context.Context, deviceName string,
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
@@ -15,6 +15,7 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	info_id "github.com/leptonai/gpud/components/info/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/file"
 	gpud_manager "github.com/leptonai/gpud/pkg/gpud-manager"
 	"github.com/leptonai/gpud/pkg/log"
@@ -26,6 +27,16 @@ import (
 	"github.com/dustin/go-humanize"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: info_id.Name,
+		New: func(_ context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(instance.Annotations, instance.DBRO, instance.PromRegistry), nil
+		},
+		DefaultEnabled: registry.AlwaysEnabled,
+	})
+}
+
 func New(annotations map[string]string, dbRO *sql.DB, gatherer prometheus.Gatherer) components.Component {
 	return &component{
 		annotations: annotations,
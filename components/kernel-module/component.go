@@ -11,9 +11,34 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	kernel_module_id "github.com/leptonai/gpud/components/kernel-module/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/log"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: kernel_module_id.Name,
+		ParseConfig: func(cfg any, _ *registry.GPUdInstance) (any, error) {
+			modules := []string{}
+			if cfg == nil {
+				return modules, nil
+			}
+			raw, err := json.Marshal(cfg)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(raw, &modules); err != nil {
+				return nil, err
+			}
+			return modules, nil
+		},
+		New: func(_ context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(cfg.([]string)), nil
+		},
+		DefaultEnabled: registry.AlwaysEnabled,
+	})
+}
+
 func New(modulesToCheck []string) components.Component {
 	return &component{modulesToCheck: modulesToCheck}
 }
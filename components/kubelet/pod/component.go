@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/pods"
 	"github.com/leptonai/gpud/pkg/systemd"
 )
 
@@ -35,6 +37,16 @@ type component struct {
 	lastData *Data
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: Name,
+		New: func(ctx context.Context, _ any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, DefaultKubeletReadOnlyPort, instance.KubeletIgnoreConnectionErrors), nil
+		},
+		DefaultEnabled: registry.AlwaysEnabled,
+	})
+}
+
 func New(ctx context.Context, kubeletReadOnlyPort int, ignoreConnectionErrors bool) components.Component {
 	cctx, cancel := context.WithCancel(ctx)
 	c := &component{
@@ -53,6 +65,8 @@ func New(ctx context.Context, kubeletReadOnlyPort int, ignoreConnectionErrors bo
 func (c *component) Name() string { return Name }
 
 func (c *component) Start() error {
+	pods.RegisterLister(Name, c)
+
 	go func() {
 		ticker := time.NewTicker(time.Minute)
 		defer ticker.Stop()
@@ -90,11 +104,39 @@ func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.
 func (c *component) Close() error {
 	log.Logger.Debugw("closing component")
 
+	pods.UnregisterLister(Name)
 	c.cancel()
 
 	return nil
 }
 
+var _ pods.Lister = &component{}
+
+// ListPods lists the containers (including the init containers) of the last checked pods.
+func (c *component) ListPods() []pods.Pod {
+	c.lastMu.RLock()
+	defer c.lastMu.RUnlock()
+	if c.lastData == nil {
+		return nil
+	}
+
+	var ps []pods.Pod
+	for _, pod := range c.lastData.Pods {
+		for _, statuses := range [][]ContainerStatus{pod.InitContainerStatuses, pod.ContainerStatuses} {
+			for _, st := range statuses {
+				ps = append(ps, pods.Pod{
+					Namespace:   pod.Namespace,
+					Name:        pod.Name,
+					UID:         pod.ID,
+					Container:   st.Name,
+					ContainerID: pods.NormalizeContainerID(st.ContainerID),
+				})
+			}
+		}
+	}
+	return ps
+}
+
 // CheckOnce checks the current pods
 // run this periodically
 func (c *component) CheckOnce() {
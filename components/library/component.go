@@ -3,15 +3,19 @@ package library
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/accelerator/nvidia"
 	library_id "github.com/leptonai/gpud/components/library/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/file"
 	"github.com/leptonai/gpud/pkg/log"
+	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
 )
 
 type Config struct {
@@ -19,6 +23,42 @@ type Config struct {
 	SearchDirs []string
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: library_id.Name,
+		ParseConfig: func(cfg any, _ *registry.GPUdInstance) (any, error) {
+			if cfg == nil {
+				return nil, nil
+			}
+			raw, err := json.Marshal(cfg)
+			if err != nil {
+				return nil, err
+			}
+			parsed := new(Config)
+			if err := json.Unmarshal(raw, parsed); err != nil {
+				return nil, err
+			}
+			return parsed, nil
+		},
+		New: func(_ context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			// nothing to check without the libraries specified
+			if cfg == nil {
+				return nil, nil
+			}
+			return New(*cfg.(*Config)), nil
+		},
+
+		// enabled by default to check the NVIDIA libraries
+		DefaultEnabled: nvidia.DefaultEnabled,
+		DefaultConfig: func() any {
+			return Config{
+				Libraries:  nvidia_query.DefaultNVIDIALibraries,
+				SearchDirs: nvidia_query.DefaultNVIDIALibrariesSearchDirs,
+			}
+		},
+	})
+}
+
 func New(cfg Config) components.Component {
 	searchDirs := make(map[string]any)
 	for _, dir := range cfg.SearchDirs {
@@ -3,7 +3,6 @@ package metrics
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -112,10 +111,10 @@ var (
 	)
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	totalBytesAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_total_bytes")
-	usedBytesAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_used_bytes")
-	usedPercentAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_used_percent")
+func InitAveragers(store components_metrics_state.Store) {
+	totalBytesAverager = components_metrics.NewAverager(store, SubSystem+"_total_bytes")
+	usedBytesAverager = components_metrics.NewAverager(store, SubSystem+"_used_bytes")
+	usedPercentAverager = components_metrics.NewAverager(store, SubSystem+"_used_percent")
 }
 
 func ReadTotalBytes(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -214,8 +213,8 @@ func SetFreeBytes(bytes float64) {
 	freeBytes.Set(bytes)
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
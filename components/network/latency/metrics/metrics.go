@@ -3,7 +3,6 @@ package metrics
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -36,8 +35,8 @@ var (
 	edgeInMillisecondsAverager = components_metrics.NewNoOpAverager()
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	edgeInMillisecondsAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_edge_in_milliseconds")
+func InitAveragers(store components_metrics_state.Store) {
+	edgeInMillisecondsAverager = components_metrics.NewAverager(store, SubSystem+"_edge_in_milliseconds")
 }
 
 func ReadEdgeInMilliseconds(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -62,8 +61,8 @@ func SetEdgeInMilliseconds(ctx context.Context, providerRegion string, latencyIn
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
@@ -8,6 +8,7 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	os_id "github.com/leptonai/gpud/components/os/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/eventstore"
 	"github.com/leptonai/gpud/pkg/log"
 	"github.com/leptonai/gpud/pkg/query"
@@ -17,6 +18,22 @@ const (
 	DefaultRetentionPeriod = eventstore.DefaultRetention
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: os_id.Name,
+		ParseConfig: func(cfg any, instance *registry.GPUdInstance) (any, error) {
+			if cfg == nil {
+				return &Config{Query: instance.DefaultQueryConfig()}, nil
+			}
+			return ParseConfig(cfg, instance.DBRW, instance.DBRO)
+		},
+		New: func(ctx context.Context, cfg any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*Config), instance.EventStore)
+		},
+		DefaultEnabled: registry.AlwaysEnabled,
+	})
+}
+
 func New(ctx context.Context, cfg Config, eventStore eventstore.Store) (components.Component, error) {
 	eventBucket, err := eventStore.Bucket(os_id.Name)
 	if err != nil {
@@ -99,6 +116,10 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 	return c.eventsBucket.Get(ctx, since)
 }
 
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventsBucket.Query(ctx, q)
+}
+
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	log.Logger.Debugw("querying metrics", "since", since)
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/leptonai/gpud/components"
 	"github.com/leptonai/gpud/components/pci/id"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/eventstore"
 	"github.com/leptonai/gpud/pkg/log"
 	"github.com/leptonai/gpud/pkg/query"
@@ -22,6 +23,22 @@ type component struct {
 	eventBucket eventstore.Bucket
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: id.Name,
+		ParseConfig: func(cfg any, instance *registry.GPUdInstance) (any, error) {
+			if cfg == nil {
+				return &Config{Query: instance.DefaultQueryConfig()}, nil
+			}
+			return ParseConfig(cfg, instance.DBRW, instance.DBRO)
+		},
+		New: func(ctx context.Context, cfg any, instance *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*Config), instance.EventStore)
+		},
+		DefaultEnabled: registry.LinuxOnly,
+	})
+}
+
 func New(ctx context.Context, cfg Config, eventStore eventstore.Store) (components.Component, error) {
 	eventBucket, err := eventStore.Bucket(id.Name)
 	if err != nil {
@@ -55,6 +72,10 @@ func (c *component) Events(ctx context.Context, since time.Time) ([]components.E
 	return c.eventBucket.Get(ctx, since)
 }
 
+func (c *component) QueryEvents(ctx context.Context, q eventstore.Query) (eventstore.Page, error) {
+	return c.eventBucket.Query(ctx, q)
+}
+
 func (c *component) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
 	return nil, nil
 }
@@ -0,0 +1,268 @@
+// Package registry provides the component factory registry.
+// Each component package registers its factory (constructor, config parser,
+// and default-enable predicate), so that the server can build the components
+// from the configuration without hard-coding each constructor.
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/leptonai/gpud/components"
+	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/pkg/errdefs"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	query_config "github.com/leptonai/gpud/pkg/query/config"
+)
+
+// GPUdInstance is the set of shared GPUd resources
+// that the component factories can use to create the components.
+type GPUdInstance struct {
+	// DB instance for read-write.
+	DBRW *sql.DB
+	// DB instance for read-only.
+	DBRO *sql.DB
+
+	EventStore eventstore.Store
+	// MetricsStore is the storage backend of the component metrics
+	// (e.g., the sqlite table, or in memory for the diskless nodes).
+	MetricsStore components_metrics_state.Store
+	PromRegistry *prometheus.Registry
+
+	// Basic server annotations (e.g., machine id, host name, etc.).
+	Annotations map[string]string
+
+	NvidiaToolOverwrites nvidia_common.ToolOverwrites
+
+	DockerIgnoreConnectionErrors  bool
+	KubeletIgnoreConnectionErrors bool
+}
+
+// DefaultQueryConfig returns the default query configuration
+// that persists the states to the GPUd state database.
+func (in *GPUdInstance) DefaultQueryConfig() query_config.Config {
+	return query_config.Config{
+		State: &query_config.State{
+			DBRW: in.DBRW,
+			DBRO: in.DBRO,
+		},
+	}
+}
+
+// Factory defines how to create a component from its configuration.
+type Factory struct {
+	// Name is the globally unique component name,
+	// which is also the key in the "components" configuration.
+	Name string
+
+	// ParseConfig parses the raw component configuration value
+	// into the configuration object passed to "New".
+	// The raw value is nil if the component is enabled without any configuration,
+	// in which case the default configuration should be returned.
+	// If the returned object implements "Validate() error", it is validated before "New".
+	// Optional; if not set, the raw value is passed to "New" as is.
+	ParseConfig func(cfg any, instance *GPUdInstance) (any, error)
+
+	// New creates the component with the parsed configuration.
+	// Returns a nil component if there is nothing to run
+	// for the given configuration (e.g., no file to check).
+	New func(ctx context.Context, cfg any, instance *GPUdInstance) (components.Component, error)
+
+	// DefaultEnabled returns true if the component should be enabled
+	// in the default configuration (e.g., linux only, NVIDIA GPUs installed).
+	// Optional; if not set, the component must be enabled explicitly.
+	DefaultEnabled func(ctx context.Context) (bool, error)
+
+	// DefaultConfig returns the configuration value for the default configuration.
+	// Optional; if not set, the component is enabled with the nil value.
+	DefaultConfig func() any
+
+	// Instances splits the raw configuration value into the configurations
+	// of the multiple components created by the factory (e.g., one per check),
+	// keyed by the component name prefixed with the factory name and "-".
+	// Each configuration is then created separately as the raw value of the component name.
+	// Optional; if not set, one component is created with the factory name.
+	Instances func(cfg any) (map[string]any, error)
+}
+
+// AlwaysEnabled is the default-enable predicate
+// for the components that are enabled in all platforms.
+func AlwaysEnabled(context.Context) (bool, error) {
+	return true, nil
+}
+
+// LinuxOnly is the default-enable predicate
+// for the components that are only supported in linux.
+func LinuxOnly(context.Context) (bool, error) {
+	return runtime.GOOS == "linux", nil
+}
+
+var (
+	defaultFactoriesMu sync.RWMutex
+	defaultFactories   = make(map[string]Factory)
+)
+
+// Register registers the component factory.
+// Returns an error if the factory is invalid or the name is already registered.
+func Register(f Factory) error {
+	defaultFactoriesMu.Lock()
+	defer defaultFactoriesMu.Unlock()
+
+	return register(defaultFactories, f)
+}
+
+// MustRegister registers the component factory and panics on error.
+// Meant to be called in the component package "init".
+func MustRegister(f Factory) {
+	if err := Register(f); err != nil {
+		panic(err)
+	}
+}
+
+func register(set map[string]Factory, f Factory) error {
+	if f.Name == "" {
+		return fmt.Errorf("component name is required: %w", errdefs.ErrInvalidArgument)
+	}
+	if f.New == nil {
+		return fmt.Errorf("component %s constructor is required: %w", f.Name, errdefs.ErrInvalidArgument)
+	}
+	if _, ok := set[f.Name]; ok {
+		return fmt.Errorf("component factory %s already registered: %w", f.Name, errdefs.ErrAlreadyExists)
+	}
+	set[f.Name] = f
+	return nil
+}
+
+// Get returns the component factory registered with the name,
+// or the factory of the instance name (see "Factory.Instances").
+func Get(name string) (Factory, error) {
+	defaultFactoriesMu.RLock()
+	defer defaultFactoriesMu.RUnlock()
+
+	return get(defaultFactories, name)
+}
+
+func get(set map[string]Factory, name string) (Factory, error) {
+	if f, ok := set[name]; ok {
+		return f, nil
+	}
+	for _, f := range set {
+		if f.Instances != nil && strings.HasPrefix(name, f.Name+"-") {
+			return f, nil
+		}
+	}
+	return Factory{}, fmt.Errorf("unknown component %s: %w", name, errdefs.ErrNotFound)
+}
+
+// Expand returns the configurations of the components to create
+// from the raw configuration value, keyed by the component name.
+// Returns the raw value with the name as is, unless the factory
+// creates multiple components (see "Factory.Instances").
+// The unknown component is returned as is, to fail on "Create".
+func Expand(name string, cfg any) (map[string]any, error) {
+	f, err := Get(name)
+	if err != nil || f.Name != name {
+		return map[string]any{name: cfg}, nil
+	}
+	return f.expand(cfg)
+}
+
+func (f Factory) expand(cfg any) (map[string]any, error) {
+	if f.Instances == nil {
+		return map[string]any{f.Name: cfg}, nil
+	}
+	cfgs, err := f.Instances(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse component %s config: %w", f.Name, err)
+	}
+	for name := range cfgs {
+		if !strings.HasPrefix(name, f.Name+"-") {
+			return nil, fmt.Errorf("component %s instance name %q must be prefixed with %q: %w", f.Name, name, f.Name+"-", errdefs.ErrInvalidArgument)
+		}
+	}
+	return cfgs, nil
+}
+
+// All returns all the registered component factories sorted by name.
+func All() []Factory {
+	defaultFactoriesMu.RLock()
+	defer defaultFactoriesMu.RUnlock()
+
+	return sortedFactories(defaultFactories)
+}
+
+func sortedFactories(set map[string]Factory) []Factory {
+	fs := make([]Factory, 0, len(set))
+	for _, f := range set {
+		fs = append(fs, f)
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		return fs[i].Name < fs[j].Name
+	})
+	return fs
+}
+
+// Create parses, validates the raw configuration value,
+// and creates the component registered with the name.
+// Returns a nil component if the factory has nothing to run for the configuration.
+func Create(ctx context.Context, name string, cfg any, instance *GPUdInstance) (components.Component, error) {
+	f, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.create(ctx, cfg, instance)
+}
+
+func (f Factory) create(ctx context.Context, cfg any, instance *GPUdInstance) (components.Component, error) {
+	if f.ParseConfig != nil {
+		parsed, err := f.ParseConfig(cfg, instance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse component %s config: %w", f.Name, err)
+		}
+		cfg = parsed
+	}
+	if v, ok := cfg.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to validate component %s config: %w", f.Name, err)
+		}
+	}
+
+	c, err := f.New(ctx, cfg, instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create component %s: %w", f.Name, err)
+	}
+	return c, nil
+}
+
+// DefaultComponents returns the component configurations
+// of all the registered components that are enabled by default.
+func DefaultComponents(ctx context.Context) (map[string]any, error) {
+	cfgs := make(map[string]any)
+	for _, f := range All() {
+		if f.DefaultEnabled == nil {
+			continue
+		}
+		enabled, err := f.DefaultEnabled(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if component %s is enabled by default: %w", f.Name, err)
+		}
+		if !enabled {
+			continue
+		}
+
+		var cfg any
+		if f.DefaultConfig != nil {
+			cfg = f.DefaultConfig()
+		}
+		cfgs[f.Name] = cfg
+	}
+	return cfgs, nil
+}
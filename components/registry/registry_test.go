@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/errdefs"
+)
+
+type testConfig struct {
+	value string
+}
+
+func (cfg *testConfig) Validate() error {
+	if cfg.value == "" {
+		return errors.New("value is required")
+	}
+	return nil
+}
+
+type testComponent struct {
+	name string
+}
+
+func (c *testComponent) Name() string { return c.name }
+func (c *testComponent) Start() error { return nil }
+func (c *testComponent) Close() error { return nil }
+func (c *testComponent) States(ctx context.Context) ([]components.State, error) {
+	return nil, nil
+}
+func (c *testComponent) Events(ctx context.Context, since time.Time) ([]components.Event, error) {
+	return nil, nil
+}
+func (c *testComponent) Metrics(ctx context.Context, since time.Time) ([]components.Metric, error) {
+	return nil, nil
+}
+
+func newTestFactory(name string) Factory {
+	return Factory{
+		Name: name,
+		ParseConfig: func(cfg any, _ *GPUdInstance) (any, error) {
+			if cfg == nil {
+				return &testConfig{value: "default"}, nil
+			}
+			s, ok := cfg.(string)
+			if !ok {
+				return nil, errors.New("unexpected config type")
+			}
+			return &testConfig{value: s}, nil
+		},
+		New: func(_ context.Context, cfg any, _ *GPUdInstance) (components.Component, error) {
+			return &testComponent{name: name + "-" + cfg.(*testConfig).value}, nil
+		},
+	}
+}
+
+func TestRegister(t *testing.T) {
+	set := make(map[string]Factory)
+
+	if err := register(set, Factory{}); !errors.Is(err, errdefs.ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for empty name, got %v", err)
+	}
+	if err := register(set, Factory{Name: "test"}); !errors.Is(err, errdefs.ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for nil constructor, got %v", err)
+	}
+	if err := register(set, newTestFactory("b")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := register(set, newTestFactory("a")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := register(set, newTestFactory("a")); !errors.Is(err, errdefs.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists, got %v", err)
+	}
+
+	fs := sortedFactories(set)
+	if len(fs) != 2 || fs[0].Name != "a" || fs[1].Name != "b" {
+		t.Errorf("unexpected sorted factories: %+v", fs)
+	}
+}
+
+func TestFactoryCreate(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFactory("test")
+
+	c, err := f.create(ctx, nil, &GPUdInstance{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Name() != "test-default" {
+		t.Errorf("expected default config, got %q", c.Name())
+	}
+
+	c, err = f.create(ctx, "custom", &GPUdInstance{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Name() != "test-custom" {
+		t.Errorf("expected custom config, got %q", c.Name())
+	}
+
+	if _, err = f.create(ctx, 1, &GPUdInstance{}); err == nil {
+		t.Error("expected parse error")
+	}
+	if _, err = f.create(ctx, "", &GPUdInstance{}); err == nil {
+		t.Error("expected validate error")
+	}
+}
+
+func TestFactoryInstances(t *testing.T) {
+	set := make(map[string]Factory)
+	f := newTestFactory("multi")
+	f.Instances = func(cfg any) (map[string]any, error) {
+		values, ok := cfg.([]string)
+		if !ok {
+			return nil, errors.New("unexpected config type")
+		}
+		cfgs := make(map[string]any, len(values))
+		for _, v := range values {
+			cfgs["multi-"+v] = v
+		}
+		return cfgs, nil
+	}
+	if err := register(set, f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := register(set, newTestFactory("single")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfgs, err := f.expand([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfgs) != 2 || cfgs["multi-a"] != "a" || cfgs["multi-b"] != "b" {
+		t.Errorf("unexpected instances: %+v", cfgs)
+	}
+	if _, err := f.expand(1); err == nil {
+		t.Error("expected parse error")
+	}
+
+	cfgs, err = set["single"].expand("x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfgs) != 1 || cfgs["single"] != "x" {
+		t.Errorf("unexpected single instance: %+v", cfgs)
+	}
+
+	bad := newTestFactory("bad")
+	bad.Instances = func(cfg any) (map[string]any, error) {
+		return map[string]any{"other-a": "a"}, nil
+	}
+	if _, err := bad.expand(nil); !errors.Is(err, errdefs.ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for unprefixed instance, got %v", err)
+	}
+
+	got, err := get(set, "multi-a")
+	if err != nil || got.Name != "multi" {
+		t.Errorf("expected multi factory for the instance, got %q (%v)", got.Name, err)
+	}
+	if _, err := get(set, "single-a"); !errors.Is(err, errdefs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for single factory instance, got %v", err)
+	}
+}
+
+func TestCreateUnknown(t *testing.T) {
+	if _, err := Create(context.Background(), "unknown-component-for-test", nil, &GPUdInstance{}); !errors.Is(err, errdefs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -4,14 +4,46 @@ package systemd
 import (
 	"context"
 	"fmt"
+	"runtime"
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/registry"
 	systemd_id "github.com/leptonai/gpud/components/systemd/id"
 	"github.com/leptonai/gpud/pkg/log"
 	"github.com/leptonai/gpud/pkg/query"
+	"github.com/leptonai/gpud/pkg/systemd"
 )
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: systemd_id.Name,
+		ParseConfig: func(cfg any, instance *registry.GPUdInstance) (any, error) {
+			if cfg == nil {
+				return &Config{Query: instance.DefaultQueryConfig()}, nil
+			}
+			return ParseConfig(cfg, instance.DBRW, instance.DBRO)
+		},
+		New: func(ctx context.Context, cfg any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx, *cfg.(*Config))
+		},
+		DefaultEnabled: func(context.Context) (bool, error) {
+			if runtime.GOOS != "linux" {
+				log.Logger.Debugw("auto-detect systemd not supported -- skipping", "os", runtime.GOOS)
+				return false, nil
+			}
+			if !systemd.SystemdExists() || !systemd.SystemctlExists() {
+				return false, nil
+			}
+			log.Logger.Debugw("auto-detected systemd -- configuring systemd component")
+			return true, nil
+		},
+		DefaultConfig: func() any {
+			return DefaultConfig()
+		},
+	})
+}
+
 func New(ctx context.Context, cfg Config) (components.Component, error) {
 	if err := ConnectDbus(); err != nil {
 		log.Logger.Warnw("failed to connect to dbus", "error", err)
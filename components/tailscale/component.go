@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/components/registry"
 	"github.com/leptonai/gpud/pkg/log"
 	"github.com/leptonai/gpud/pkg/systemd"
 )
@@ -29,6 +30,16 @@ type component struct {
 	lastData *Data
 }
 
+func init() {
+	registry.MustRegister(registry.Factory{
+		Name: Name,
+		New: func(ctx context.Context, _ any, _ *registry.GPUdInstance) (components.Component, error) {
+			return New(ctx), nil
+		},
+		DefaultEnabled: registry.LinuxOnly,
+	})
+}
+
 func New(ctx context.Context) components.Component {
 	cctx, cancel := context.WithCancel(ctx)
 	c := &component{
@@ -38,3 +38,21 @@ func EventTypeFromString(s string) EventType {
 		return EventTypeUnknown
 	}
 }
+
+// Severity returns the severity level of the event type,
+// where the higher value is more severe (e.g., "Fatal" > "Critical").
+// Returns 0 for the unknown event type.
+func (t EventType) Severity() int {
+	switch t {
+	case EventTypeInfo:
+		return 1
+	case EventTypeWarning:
+		return 2
+	case EventTypeCritical:
+		return 3
+	case EventTypeFatal:
+		return 4
+	default:
+		return 0
+	}
+}
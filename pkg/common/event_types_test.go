@@ -50,3 +50,21 @@ func TestEventTypeFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestEventTypeSeverity(t *testing.T) {
+	ordered := []EventType{
+		EventTypeUnknown,
+		EventTypeInfo,
+		EventTypeWarning,
+		EventTypeCritical,
+		EventTypeFatal,
+	}
+	for i := 1; i < len(ordered); i++ {
+		if ordered[i].Severity() <= ordered[i-1].Severity() {
+			t.Errorf("expected %q to be more severe than %q", ordered[i], ordered[i-1])
+		}
+	}
+	if EventType("NonExistent").Severity() != EventTypeUnknown.Severity() {
+		t.Error("expected non-existent event type to have the unknown severity")
+	}
+}
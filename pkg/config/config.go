@@ -9,6 +9,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
+	"github.com/leptonai/gpud/pkg/health"
+	"github.com/leptonai/gpud/pkg/k8s"
+	"github.com/leptonai/gpud/pkg/notifier"
+	"github.com/leptonai/gpud/pkg/nvidia-query/catalog"
+	"github.com/leptonai/gpud/pkg/otlp"
+	"github.com/leptonai/gpud/pkg/remediation"
+	"github.com/leptonai/gpud/pkg/remotewrite"
+	"github.com/leptonai/gpud/pkg/storage"
 )
 
 // Config provides gpud configuration data for the server
@@ -66,6 +74,40 @@ type Config struct {
 
 	// A list of nvidia tool command paths to overwrite the default paths.
 	NvidiaToolOverwrites nvidia_common.ToolOverwrites `json:"nvidia_tool_overwrites"`
+
+	// Configures the alert notifier.
+	// If nil, no alert is pushed other than the control plane session.
+	Notifier *notifier.Config `json:"notifier,omitempty"`
+
+	// Configures the OpenTelemetry OTLP/HTTP exporter for the metrics and the events.
+	// If nil, the metrics are only served at the Prometheus "/metrics" endpoint.
+	OTLP *otlp.Config `json:"otlp,omitempty"`
+
+	// Configures the Prometheus remote-write push of the metrics,
+	// labeled with the annotations and the machine ID.
+	// If nil, the metrics are only served at the Prometheus "/metrics" endpoint.
+	RemoteWrite *remotewrite.Config `json:"remote_write,omitempty"`
+
+	// Configures the Kubernetes node condition and event publisher.
+	// If nil, nothing is written back to the cluster.
+	Kubernetes *k8s.Config `json:"kubernetes,omitempty"`
+
+	// Configures the node health verdict aggregation.
+	// If nil, all components are equally weighted.
+	Health *health.Config `json:"health,omitempty"`
+
+	// Configures the local remediation engine that acts on the suggested repair actions.
+	// If nil, the suggested actions are only reported.
+	Remediation *remediation.Config `json:"remediation,omitempty"`
+
+	// Configures the storage backend of the events and the metrics.
+	// If nil, they are stored in the sqlite state file.
+	Storage *storage.Config `json:"storage,omitempty"`
+
+	// XIDCatalogFile is the YAML or JSON overlay file of the XID and SXID catalogs,
+	// to change the severity and the suggested actions of the codes, or to add the new codes.
+	// If empty, the catalogs compiled into GPUd are used as is.
+	XIDCatalogFile string `json:"xid_catalog_file,omitempty"`
 }
 
 // Configures the local web configuration.
@@ -89,6 +131,10 @@ type ToolOverwriteOptions struct {
 
 var ErrInvalidAutoUpdateExitCode = errors.New("auto_update_exit_code is only valid when auto_update is enabled")
 
+// ErrRemediationRebootsWithMemoryStorage is returned if the remediation may reboot the host
+// with the memory storage, which loses the reboot ledger on every boot, so max_reboots never applies.
+var ErrRemediationRebootsWithMemoryStorage = errors.New("remediation reboots require the persistent storage, set disable_reboots with the memory storage")
+
 func (config *Config) Validate() error {
 	if config.Address == "" {
 		return errors.New("address is required")
@@ -105,5 +151,48 @@ func (config *Config) Validate() error {
 	if !config.EnableAutoUpdate && config.AutoUpdateExitCode != -1 {
 		return ErrInvalidAutoUpdateExitCode
 	}
+	if config.Notifier != nil {
+		if err := config.Notifier.Validate(); err != nil {
+			return fmt.Errorf("invalid notifier config: %w", err)
+		}
+	}
+	if config.OTLP != nil {
+		if err := config.OTLP.Validate(); err != nil {
+			return fmt.Errorf("invalid otlp config: %w", err)
+		}
+	}
+	if config.RemoteWrite != nil {
+		if err := config.RemoteWrite.Validate(); err != nil {
+			return fmt.Errorf("invalid remote write config: %w", err)
+		}
+	}
+	if config.Kubernetes != nil {
+		if err := config.Kubernetes.Validate(); err != nil {
+			return fmt.Errorf("invalid kubernetes config: %w", err)
+		}
+	}
+	if config.Health != nil {
+		if err := config.Health.Validate(); err != nil {
+			return fmt.Errorf("invalid health config: %w", err)
+		}
+	}
+	if config.Remediation != nil {
+		if err := config.Remediation.Validate(); err != nil {
+			return fmt.Errorf("invalid remediation config: %w", err)
+		}
+	}
+	if config.Storage != nil {
+		if err := config.Storage.Validate(); err != nil {
+			return fmt.Errorf("invalid storage config: %w", err)
+		}
+	}
+	if config.Storage.IsMemory() && config.Remediation != nil && !config.Remediation.DisableReboots {
+		return ErrRemediationRebootsWithMemoryStorage
+	}
+	if config.XIDCatalogFile != "" {
+		if _, err := catalog.LoadFile(config.XIDCatalogFile); err != nil {
+			return fmt.Errorf("invalid xid catalog file: %w", err)
+		}
+	}
 	return nil
 }
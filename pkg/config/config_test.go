@@ -5,6 +5,10 @@ import (
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/pkg/eventstore"
+	"github.com/leptonai/gpud/pkg/remediation"
+	"github.com/leptonai/gpud/pkg/storage"
 )
 
 func TestConfigValidate_AutoUpdateExitCode(t *testing.T) {
@@ -56,3 +60,56 @@ func TestConfigValidate_AutoUpdateExitCode(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigValidate_MemoryStorage(t *testing.T) {
+	tests := []struct {
+		name        string
+		storage     *storage.Config
+		remediation *remediation.Config
+		wantErr     bool
+	}{
+		{
+			name:        "Valid: Remediation reboots with sqlite storage",
+			storage:     &storage.Config{Backend: storage.BackendSQLite},
+			remediation: &remediation.Config{},
+			wantErr:     false,
+		},
+		{
+			name:        "Valid: Remediation without reboots with memory storage",
+			storage:     &storage.Config{Backend: storage.BackendMemory},
+			remediation: &remediation.Config{DisableReboots: true},
+			wantErr:     false,
+		},
+		{
+			name:        "Invalid: Remediation reboots with memory storage",
+			storage:     &storage.Config{Backend: storage.BackendMemory},
+			remediation: &remediation.Config{},
+			wantErr:     true,
+		},
+		{
+			name: "Invalid: Bucket configs with memory storage",
+			storage: &storage.Config{
+				Backend: storage.BackendMemory,
+				Buckets: map[string]eventstore.BucketConfig{"memory": {Retention: metav1.Duration{Duration: time.Hour}}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				RetentionPeriod:    metav1.Duration{Duration: time.Hour},
+				Address:            "localhost:8080",
+				AutoUpdateExitCode: -1,
+				Storage:            tt.storage,
+				Remediation:        tt.remediation,
+			}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"sigs.k8s.io/yaml"
+
+	"github.com/leptonai/gpud/pkg/log"
+)
+
+// LoadConfig reads the YAML configuration file and overwrites
+// the configuration fields that are set in the file.
+// If the file sets the "components", they replace the existing components
+// (i.e., the components not in the file are disabled).
+func LoadConfig(file string, cfg *Config) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", file, err)
+	}
+
+	prevComponents := cfg.Components
+	cfg.Components = nil
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		cfg.Components = prevComponents
+		return fmt.Errorf("failed to parse config file %s: %w", file, err)
+	}
+	if cfg.Components == nil {
+		cfg.Components = prevComponents
+	}
+	return nil
+}
+
+// WatchConfigFile watches the configuration file and calls "onChange"
+// whenever the file is written or (re-)created.
+// The parent directory is watched, since editors and config management tools
+// often replace the file rather than writing to it.
+// The watcher is closed when the context is canceled.
+func WatchConfigFile(ctx context.Context, file string, onChange func()) error {
+	file = filepath.Clean(file)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("failed to watch config file %s: %w", file, err)
+	}
+
+	go func() {
+		defer func() {
+			if err := watcher.Close(); err != nil {
+				log.Logger.Warnw("failed to close config file watcher", "error", err)
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != file {
+					continue
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+				log.Logger.Infow("config file changed", "file", file, "op", event.Op.String())
+				onChange()
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Logger.Warnw("config file watcher error", "file", file, "error", err)
+			}
+		}
+	}()
+	return nil
+}
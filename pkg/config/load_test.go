@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cfg := &Config{
+		Address:    "localhost:15132",
+		Components: map[string]any{"cpu": nil},
+	}
+	if err := LoadConfig(filepath.Join("testdata", "test.0.yaml"), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Address != "127.0.0.1:123" {
+		t.Errorf("unexpected address %q", cfg.Address)
+	}
+	if cfg.Annotations["a"] != "b" || cfg.Annotations["c"] != "d" {
+		t.Errorf("unexpected annotations %v", cfg.Annotations)
+	}
+	if len(cfg.Components) != 2 {
+		t.Fatalf("expected 2 components, got %v", cfg.Components)
+	}
+	if _, ok := cfg.Components["cpu"]; ok {
+		t.Error("expected components to be replaced by the config file")
+	}
+	if _, ok := cfg.Components["systemd"]; !ok {
+		t.Error("expected systemd component from the config file")
+	}
+}
+
+func TestLoadConfigKeepComponents(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "gpud.yaml")
+	if err := os.WriteFile(f, []byte("address: 127.0.0.1:123\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Components: map[string]any{"cpu": nil}}
+	if err := LoadConfig(f, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.Components["cpu"]; !ok {
+		t.Errorf("expected components to be kept, got %v", cfg.Components)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "gpud.yaml")
+	if err := os.WriteFile(f, []byte("components: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Components: map[string]any{"cpu": nil}}
+	if err := LoadConfig(f, cfg); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if _, ok := cfg.Components["cpu"]; !ok {
+		t.Errorf("expected components to be kept on error, got %v", cfg.Components)
+	}
+
+	if err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), cfg); err == nil {
+		t.Fatal("expected read error")
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f := filepath.Join(t.TempDir(), "gpud.yaml")
+	changed := make(chan struct{}, 10)
+	if err := WatchConfigFile(ctx, f, func() { changed <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	// other files in the same directory are ignored
+	if err := os.WriteFile(filepath.Join(filepath.Dir(f), "other.yaml"), []byte("a: b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f, []byte("address: 127.0.0.1:123\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for config file change")
+	}
+}
@@ -0,0 +1,219 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultAggregateWindow is the default window to aggregate
+// the repeats of the same event, for the components
+// whose events repeat in bursts (e.g., the dmesg log lines).
+const DefaultAggregateWindow = 5 * time.Minute
+
+// WithAggregateWindow aggregates the repeats of the same event
+// last seen within the window into one event of the bucket,
+// unless the bucket config sets its own window.
+// Only applies to the "Bucket" calls.
+func WithAggregateWindow(window time.Duration) OpOption {
+	return func(op *Op) {
+		op.aggregateWindow = window
+	}
+}
+
+// occurrences returns the number of the occurrences the event represents.
+func occurrences(ev components.Event) int {
+	if ev.Count > 1 {
+		return ev.Count
+	}
+	return 1
+}
+
+// setOccurrences sets the occurrence fields of the event read from the table,
+// only if it aggregates the repeats, so the single events read the same as before.
+func setOccurrences(ev *components.Event, count int, firstSeen sql.NullInt64) {
+	if count <= 1 {
+		return
+	}
+	first := ev.Time
+	if firstSeen.Valid {
+		first = metav1.Time{Time: time.Unix(firstSeen.Int64, 0)}
+	}
+	last := ev.Time
+	ev.Count = count
+	ev.FirstSeen = &first
+	ev.LastSeen = &last
+}
+
+// aggregateEvent adds the occurrence to the same event last seen within the window,
+// moving its timestamp to the latest occurrence.
+// Returns false if no such event, for the caller to insert it.
+func aggregateEvent(ctx context.Context, db execer, tableName string, ev components.Event, window time.Duration) (bool, error) {
+	extraInfoJSON, suggestedActionsJSON, err := marshalEvent(ev)
+	if err != nil {
+		return false, err
+	}
+
+	first := ev.Time.Unix()
+	if ev.FirstSeen != nil {
+		first = ev.FirstSeen.Unix()
+	}
+
+	// the SET expressions read the values before the update
+	updateStatement := fmt.Sprintf(`UPDATE %s SET %s = %s + ?, %s = MAX(%s, ?), %s = MIN(IFNULL(%s, %s), ?)
+WHERE rowid = (
+	SELECT rowid FROM %s
+	WHERE %s = ? AND %s = ? AND IFNULL(%s, '') = ? AND IFNULL(%s, '') = ? AND IFNULL(%s, '') = ? AND %s >= ?
+	ORDER BY %s DESC, rowid DESC
+	LIMIT 1
+)`,
+		tableName,
+		columnCount, columnCount,
+		columnTimestamp, columnTimestamp,
+		columnFirstSeen, columnFirstSeen, columnTimestamp,
+		tableName,
+		columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions, columnTimestamp,
+		columnTimestamp,
+	)
+
+	start := time.Now()
+	rs, err := db.ExecContext(ctx, updateStatement,
+		occurrences(ev),
+		ev.Time.Unix(),
+		first,
+		ev.Name,
+		ev.Type,
+		ev.Message,
+		extraInfoJSON,
+		suggestedActionsJSON,
+		ev.Time.Add(-window).Unix(),
+	)
+	sqlite.RecordInsertUpdate(time.Since(start).Seconds())
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// AddOccurrenceColumns adds the occurrence columns to the event tables
+// of the schema version (e.g., "v0_4_0") created before the aggregation,
+// where the existing events are the single occurrences.
+// To be called by the state migration (see "pkg/gpud-state"),
+// which runs before the tables are opened. Returns the altered tables.
+func AddOccurrenceColumns(ctx context.Context, tx *sql.Tx, version string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		if versionedTableNameRegex.MatchString(name) && strings.HasSuffix(name, "_events_"+version) {
+			tables = append(tables, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	_ = rows.Close()
+
+	var altered []string
+	for _, tableName := range tables {
+		columns, err := tableColumns(ctx, tx, tableName)
+		if err != nil {
+			return altered, err
+		}
+		if columns[columnCount] && columns[columnFirstSeen] {
+			continue
+		}
+		if !columns[columnCount] {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s INTEGER NOT NULL DEFAULT 1`, tableName, columnCount)); err != nil {
+				return altered, err
+			}
+		}
+		if !columns[columnFirstSeen] {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s INTEGER`, tableName, columnFirstSeen)); err != nil {
+				return altered, err
+			}
+		}
+		altered = append(altered, tableName)
+	}
+	return altered, nil
+}
+
+// tableColumns returns the column names of the table.
+func tableColumns(ctx context.Context, db queryer, tableName string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT name FROM pragma_table_info('%s')`, tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// sameEvent returns true if the two events are the repeats of the same event.
+func sameEvent(a, b components.Event) bool {
+	if a.Name != b.Name || a.Type != b.Type || a.Message != b.Message {
+		return false
+	}
+	aExtraInfo, aActions, err := marshalEvent(a)
+	if err != nil {
+		return false
+	}
+	bExtraInfo, bActions, err := marshalEvent(b)
+	if err != nil {
+		return false
+	}
+	return aExtraInfo == bExtraInfo && aActions == bActions
+}
+
+// mergeOccurrence returns the aggregated event of the occurrence "ev"
+// added to the event "agg", timestamped at the latest occurrence.
+func mergeOccurrence(agg components.Event, ev components.Event) components.Event {
+	first := agg.Time
+	if agg.FirstSeen != nil {
+		first = *agg.FirstSeen
+	}
+	evFirst := ev.Time
+	if ev.FirstSeen != nil {
+		evFirst = *ev.FirstSeen
+	}
+	if evFirst.Before(&first) {
+		first = evFirst
+	}
+	if ev.Time.After(agg.Time.Time) {
+		agg.Time = ev.Time
+	}
+	last := agg.Time
+
+	agg.Count = occurrences(agg) + occurrences(ev)
+	agg.FirstSeen = &first
+	agg.LastSeen = &last
+	return agg
+}
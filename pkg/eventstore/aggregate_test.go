@@ -0,0 +1,195 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testAggregate(t *testing.T, ctx context.Context, bucket Bucket) {
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	ev := func(offset time.Duration, message string) components.Event {
+		return components.Event{
+			Time:      metav1.Time{Time: base.Add(offset)},
+			Name:      "edac",
+			Type:      common.EventTypeWarning,
+			Message:   message,
+			ExtraInfo: map[string]string{"log_line": message},
+		}
+	}
+
+	assert.NoError(t, bucket.Insert(ctx, ev(0, "a")))
+	assert.NoError(t, bucket.Insert(ctx, ev(time.Minute, "a")))
+	assert.NoError(t, bucket.Insert(ctx, ev(2*time.Minute, "b")))
+	assert.NoError(t, bucket.Insert(ctx, ev(3*time.Minute, "a")))
+	// last seen over the window ago
+	assert.NoError(t, bucket.Insert(ctx, ev(20*time.Minute, "a")))
+
+	evs, err := bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, 3)
+
+	// latest occurrence first
+	assert.Equal(t, "a", evs[0].Message)
+	assert.Equal(t, base.Add(20*time.Minute).Unix(), evs[0].Time.Unix())
+	assert.Zero(t, evs[0].Count)
+	assert.Nil(t, evs[0].FirstSeen)
+
+	assert.Equal(t, "a", evs[1].Message)
+	assert.Equal(t, 3, evs[1].Count)
+	assert.Equal(t, base.Add(3*time.Minute).Unix(), evs[1].Time.Unix())
+	if assert.NotNil(t, evs[1].FirstSeen) && assert.NotNil(t, evs[1].LastSeen) {
+		assert.Equal(t, base.Unix(), evs[1].FirstSeen.Unix())
+		assert.Equal(t, base.Add(3*time.Minute).Unix(), evs[1].LastSeen.Unix())
+	}
+
+	assert.Equal(t, "b", evs[2].Message)
+	assert.Zero(t, evs[2].Count)
+
+	latest, err := bucket.Latest(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, evs[0], *latest)
+
+	page, err := bucket.Query(ctx, Query{Names: []string{"edac"}, MessageContains: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, evs[:2], page.Events)
+}
+
+func TestAggregate(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, err := New(dbRW, dbRO, 0)
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("memory", WithAggregateWindow(10*time.Minute))
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	testAggregate(t, ctx, bucket)
+}
+
+func TestAggregateMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, err := NewMemory(10, 0)
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("memory", WithAggregateWindow(10*time.Minute))
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	testAggregate(t, ctx, bucket)
+}
+
+func TestAggregateBucketConfig(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, err := New(dbRW, dbRO, 0, WithBucketConfigs(map[string]BucketConfig{
+		"fd": {AggregateWindow: metav1.Duration{Duration: time.Hour}},
+	}))
+	assert.NoError(t, err)
+
+	// the config overrides the window of the component
+	bucket, err := store.Bucket("fd", WithAggregateWindow(time.Minute))
+	assert.NoError(t, err)
+	defer bucket.Close()
+	assert.Equal(t, time.Hour, bucket.(*table).cfg.AggregateWindow.Duration)
+
+	base := time.Now().UTC().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, bucket.Insert(ctx, components.Event{
+			Time: metav1.Time{Time: base.Add(time.Duration(i) * 10 * time.Minute)},
+			Name: "file_max",
+			Type: common.EventTypeWarning,
+		}))
+	}
+	evs, err := bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	if assert.Len(t, evs, 1) {
+		assert.Equal(t, 3, evs[0].Count)
+	}
+
+	// no aggregation by default
+	other, err := store.Bucket("cpu")
+	assert.NoError(t, err)
+	defer other.Close()
+	assert.Zero(t, other.(*table).cfg.AggregateWindow.Duration)
+}
+
+func TestAddOccurrenceColumns(t *testing.T) {
+	t.Parallel()
+
+	dbRW, _, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the table created before the aggregation
+	tableName := defaultTableName("test_old")
+	_, err := dbRW.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT,
+	%s TEXT,
+	%s TEXT
+);`, tableName, columnTimestamp, columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions))
+	assert.NoError(t, err)
+	now := time.Now().UTC().Unix()
+	_, err = dbRW.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s) VALUES (?, 'old', 'Warning')`, tableName, columnTimestamp, columnName, columnType), now)
+	assert.NoError(t, err)
+
+	// read before the columns are added
+	evs, err := ReadTable(ctx, dbRW, tableName)
+	assert.NoError(t, err)
+	assert.Len(t, evs, 1)
+
+	tx, err := dbRW.BeginTx(ctx, nil)
+	assert.NoError(t, err)
+	altered, err := AddOccurrenceColumns(ctx, tx, schemaVersion)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, []string{tableName}, altered)
+
+	bucket, err := newTable(dbRW, dbRW, "test_old", 0, 0)
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	evs, err = bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	if assert.Len(t, evs, 1) {
+		assert.Equal(t, "old", evs[0].Name)
+		assert.Zero(t, evs[0].Count)
+	}
+
+	// idempotent
+	tx, err = dbRW.BeginTx(ctx, nil)
+	assert.NoError(t, err)
+	altered, err = AddOccurrenceColumns(ctx, tx, schemaVersion)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Empty(t, altered)
+	assert.NoError(t, createTable(ctx, dbRW, tableName))
+}
@@ -19,6 +19,9 @@ import (
 )
 
 const (
+	// schemaVersion is the suffix of the event table names.
+	// On the bump, register a state migration that calls "RenameTables"
+	// with the new version, to keep the events of the existing tables.
 	schemaVersion = "v0_4_0"
 )
 
@@ -48,6 +51,13 @@ const (
 	// columnSuggestedActions represents event suggested actions
 	// e.g., "reboot"
 	columnSuggestedActions = "suggested_actions"
+
+	// columnCount represents the number of the occurrences aggregated into the event,
+	// whose timestamp is the last occurrence.
+	columnCount = "count"
+
+	// columnFirstSeen represents the first occurrence of the aggregated event in unix seconds.
+	columnFirstSeen = "first_seen"
 )
 
 var (
@@ -55,10 +65,21 @@ var (
 	_ Bucket = &table{}
 )
 
+// execer is either the database or the transaction of the group commit.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
 type database struct {
 	dbRW      *sql.DB
 	dbRO      *sql.DB
 	retention time.Duration
+	buckets   map[string]BucketConfig
+	writer    *Writer
 }
 
 type table struct {
@@ -67,30 +88,67 @@ type table struct {
 	retention     time.Duration
 	checkInterval time.Duration
 
+	// size quota and aggregate window of the table
+	// (its retention is resolved into "retention")
+	cfg BucketConfig
+
 	table string
 	dbRW  *sql.DB
 	dbRO  *sql.DB
+
+	// nil to insert the events synchronously
+	writer *Writer
 }
 
-func New(dbRW *sql.DB, dbRO *sql.DB, retention time.Duration) (Store, error) {
+// New creates the events store whose buckets keep the events for the retention
+// (no purge if zero), unless configured per bucket.
+func New(dbRW *sql.DB, dbRO *sql.DB, retention time.Duration, opts ...OpOption) (Store, error) {
+	op := &Op{}
+	op.applyOpts(opts)
 	return &database{
 		dbRW:      dbRW,
 		dbRO:      dbRO,
 		retention: retention,
+		buckets:   op.buckets,
+		writer:    op.writer,
 	}, nil
 }
 
-func (d *database) Bucket(name string) (Bucket, error) {
+func (d *database) Bucket(name string, opts ...OpOption) (Bucket, error) {
+	op := &Op{}
+	op.applyOpts(opts)
+
+	cfg := d.buckets[defaultTableName(name)]
+	if cfg.AggregateWindow.Duration == 0 {
+		cfg.AggregateWindow.Duration = op.aggregateWindow
+	}
+	retention := d.retention
+	if cfg.Retention.Duration > 0 {
+		retention = cfg.Retention.Duration
+	}
+
 	// actual check interval should be lower than the retention period
 	// in case of GPUd restarts
-	checkInterval := d.retention / 5
+	checkInterval := retention / 5
 	if checkInterval < time.Second {
 		checkInterval = time.Second
 	}
-	return newTable(d.dbRW, d.dbRO, name, d.retention, checkInterval)
+	if cfg.hasQuota() && (retention == 0 || checkInterval > DefaultQuotaCheckInterval) {
+		checkInterval = DefaultQuotaCheckInterval
+	}
+	t, err := newTableWithQuota(d.dbRW, d.dbRO, name, retention, checkInterval, cfg)
+	if err != nil {
+		return nil, err
+	}
+	t.writer = d.writer
+	return t, nil
 }
 
 func newTable(dbRW *sql.DB, dbRO *sql.DB, name string, retention time.Duration, checkInterval time.Duration) (*table, error) {
+	return newTableWithQuota(dbRW, dbRO, name, retention, checkInterval, BucketConfig{})
+}
+
+func newTableWithQuota(dbRW *sql.DB, dbRO *sql.DB, name string, retention time.Duration, checkInterval time.Duration, cfg BucketConfig) (*table, error) {
 	tableName := defaultTableName(name)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	err := createTable(ctx, dbRW, tableName)
@@ -108,8 +166,9 @@ func newTable(dbRW *sql.DB, dbRO *sql.DB, name string, retention time.Duration,
 		dbRO:          dbRO,
 		retention:     retention,
 		checkInterval: checkInterval,
+		cfg:           cfg,
 	}
-	if retention > time.Second {
+	if retention > time.Second || cfg.hasQuota() {
 		go t.runPurge()
 	}
 	return t, nil
@@ -132,7 +191,7 @@ func (t *table) Name() string {
 }
 
 func (t *table) runPurge() {
-	log.Logger.Infow("start purging", "table", t.table, "retention", t.retention, "checkInterval", t.checkInterval)
+	log.Logger.Infow("start purging", "table", t.table, "retention", t.retention, "maxRows", t.cfg.MaxRows, "maxBytes", t.cfg.MaxBytes, "checkInterval", t.checkInterval)
 	for {
 		select {
 		case <-t.rootCtx.Done():
@@ -140,12 +199,19 @@ func (t *table) runPurge() {
 		case <-time.After(t.checkInterval):
 		}
 
-		now := time.Now().UTC()
-		purged, err := t.Purge(t.rootCtx, now.Add(-t.retention).Unix())
-		if err != nil {
-			log.Logger.Errorw("failed to purge data", "table", t.table, "retention", t.retention, "error", err)
-		} else {
-			log.Logger.Infow("purged data", "table", t.table, "retention", t.retention, "purged", purged)
+		if t.retention > time.Second {
+			now := time.Now().UTC()
+			purged, err := t.Purge(t.rootCtx, now.Add(-t.retention).Unix())
+			if err != nil {
+				log.Logger.Errorw("failed to purge data", "table", t.table, "retention", t.retention, "error", err)
+			} else {
+				log.Logger.Infow("purged data", "table", t.table, "retention", t.retention, "purged", purged)
+				evictedTotal.WithLabelValues(t.table, evictReasonRetention).Add(float64(purged))
+			}
+		}
+
+		if err := t.enforceQuota(t.rootCtx); err != nil {
+			log.Logger.Errorw("failed to enforce quota", "table", t.table, "maxRows", t.cfg.MaxRows, "maxBytes", t.cfg.MaxBytes, "error", err)
 		}
 	}
 }
@@ -157,23 +223,94 @@ func (t *table) Close() {
 	}
 }
 
+// Insert inserts the event, or aggregates it into the same event
+// last seen within the aggregate window if configured.
+// With the writer, the event is queued and committed asynchronously.
 func (t *table) Insert(ctx context.Context, ev components.Event) error {
+	if t.writer != nil {
+		return t.writer.enqueue(ctx, t.table, t.cfg.AggregateWindow.Duration, ev)
+	}
+	if t.cfg.AggregateWindow.Duration > 0 {
+		aggregated, err := aggregateEvent(ctx, t.dbRW, t.table, ev, t.cfg.AggregateWindow.Duration)
+		if err != nil || aggregated {
+			return err
+		}
+	}
 	return insertEvent(ctx, t.dbRW, t.table, ev)
 }
 
+// InsertSync inserts the event committed before returning, even with the writer.
+func (t *table) InsertSync(ctx context.Context, ev components.Event) error {
+	return writeEvents(ctx, t.dbRW, []*pendingEvent{{Table: t.table, AggregateWindow: t.cfg.AggregateWindow.Duration, Event: ev}}, false)
+}
+
 // Find returns nil if the event is not found.
 func (t *table) Find(ctx context.Context, ev components.Event) (*components.Event, error) {
+	if t.writer != nil {
+		found, err := findInEvents(t.writer.pendingEvents(t.table), ev)
+		if err != nil || found != nil {
+			return found, err
+		}
+	}
 	return findEvent(ctx, t.dbRO, t.table, ev)
 }
 
 // Get queries the event in the descending order of timestamp (latest event first).
 func (t *table) Get(ctx context.Context, since time.Time) ([]components.Event, error) {
-	return getEvents(ctx, t.dbRO, t.table, since)
+	if t.writer == nil {
+		return getEvents(ctx, t.dbRO, t.table, since)
+	}
+
+	// read before the table, not to miss the events committed in between
+	var pending []components.Event
+	for _, ev := range t.writer.pendingEvents(t.table) {
+		if ev.Time.Unix() > since.UTC().Unix() {
+			pending = append(pending, ev)
+		}
+	}
+	evs, err := getEvents(ctx, t.dbRO, t.table, since)
+	if err != nil {
+		return nil, err
+	}
+	return mergePending(evs, pending), nil
+}
+
+// Query queries the events matching the query
+// in the descending order of timestamp (latest event first).
+func (t *table) Query(ctx context.Context, q Query) (Page, error) {
+	if t.writer == nil {
+		return queryEvents(ctx, t.dbRO, t.table, q)
+	}
+
+	// read before the table, not to miss the events committed in between
+	pending, err := queryPending(t.writer.pendingEvents(t.table), q)
+	if err != nil {
+		return Page{}, err
+	}
+	evs, err := queryRows(ctx, t.dbRO, t.table, q)
+	if err != nil {
+		return Page{}, err
+	}
+	return paginate(mergePendingRows(evs, pending), q.Limit), nil
 }
 
 // Latest queries the latest event, returns nil if no event found.
 func (t *table) Latest(ctx context.Context) (*components.Event, error) {
-	return lastEvent(ctx, t.dbRO, t.table)
+	if t.writer == nil {
+		return lastEvent(ctx, t.dbRO, t.table)
+	}
+
+	pending := t.writer.pendingEvents(t.table)
+	latest, err := lastEvent(ctx, t.dbRO, t.table)
+	if err != nil {
+		return nil, err
+	}
+	for _, ev := range pending {
+		if latest == nil || ev.Time.Unix() >= latest.Time.Unix() {
+			latest = &ev
+		}
+	}
+	return latest, nil
 }
 
 func (t *table) Purge(ctx context.Context, beforeTimestamp int64) (int, error) {
@@ -193,7 +330,9 @@ func createTable(ctx context.Context, db *sql.DB, tableName string) error {
 	%s TEXT NOT NULL,
 	%s TEXT,
 	%s TEXT,
-	%s TEXT
+	%s TEXT,
+	%s INTEGER NOT NULL DEFAULT 1,
+	%s INTEGER
 );`, tableName,
 		columnTimestamp,
 		columnName,
@@ -201,6 +340,8 @@ func createTable(ctx context.Context, db *sql.DB, tableName string) error {
 		columnMessage,
 		columnExtraInfo,
 		columnSuggestedActions,
+		columnCount,
+		columnFirstSeen,
 	))
 	if err != nil {
 		_ = tx.Rollback()
@@ -231,24 +372,20 @@ func createTable(ctx context.Context, db *sql.DB, tableName string) error {
 	return tx.Commit()
 }
 
-func insertEvent(ctx context.Context, db *sql.DB, tableName string, ev components.Event) error {
+func insertEvent(ctx context.Context, db execer, tableName string, ev components.Event) error {
 	start := time.Now()
-	var extraInfoJSON, suggestedActionsJSON []byte
-	var err error
-	if ev.ExtraInfo != nil {
-		extraInfoJSON, err = json.Marshal(ev.ExtraInfo)
-		if err != nil {
-			return fmt.Errorf("failed to marshal extra info: %w", err)
-		}
-	}
-	if ev.SuggestedActions != nil {
-		suggestedActionsJSON, err = json.Marshal(ev.SuggestedActions)
-	}
+	extraInfoJSON, suggestedActionsJSON, err := marshalEvent(ev)
 	if err != nil {
-		return fmt.Errorf("failed to marshal suggested actions: %w", err)
+		return err
+	}
+
+	// the aggregated events (e.g., imported from the bundle) keep their occurrences
+	firstSeen := ev.Time.Unix()
+	if ev.FirstSeen != nil {
+		firstSeen = ev.FirstSeen.Unix()
 	}
 
-	_, err = db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))",
+	_, err = db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, ?)",
 		tableName,
 		columnTimestamp,
 		columnName,
@@ -256,28 +393,54 @@ func insertEvent(ctx context.Context, db *sql.DB, tableName string, ev component
 		columnMessage,
 		columnExtraInfo,
 		columnSuggestedActions,
+		columnCount,
+		columnFirstSeen,
 	),
 		ev.Time.Unix(),
 		ev.Name,
 		ev.Type,
 		ev.Message,
-		string(extraInfoJSON),
-		string(suggestedActionsJSON),
+		extraInfoJSON,
+		suggestedActionsJSON,
+		occurrences(ev),
+		firstSeen,
 	)
 	sqlite.RecordInsertUpdate(time.Since(start).Seconds())
 
 	return err
 }
 
-func findEvent(ctx context.Context, db *sql.DB, tableName string, ev components.Event) (*components.Event, error) {
+// marshalEvent returns the extra info and the suggested actions
+// in JSON, empty if not set.
+func marshalEvent(ev components.Event) (string, string, error) {
+	var extraInfoJSON, suggestedActionsJSON []byte
+	var err error
+	if ev.ExtraInfo != nil {
+		extraInfoJSON, err = json.Marshal(ev.ExtraInfo)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to marshal extra info: %w", err)
+		}
+	}
+	if ev.SuggestedActions != nil {
+		suggestedActionsJSON, err = json.Marshal(ev.SuggestedActions)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to marshal suggested actions: %w", err)
+		}
+	}
+	return string(extraInfoJSON), string(suggestedActionsJSON), nil
+}
+
+func findEvent(ctx context.Context, db queryer, tableName string, ev components.Event) (*components.Event, error) {
 	selectStatement := fmt.Sprintf(`
-SELECT %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ? AND %s = ? AND %s = ?`,
+SELECT %s, %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ? AND %s = ? AND %s = ?`,
 		columnTimestamp,
 		columnName,
 		columnType,
 		columnMessage,
 		columnExtraInfo,
 		columnSuggestedActions,
+		columnCount,
+		columnFirstSeen,
 		tableName,
 		columnTimestamp,
 		columnName,
@@ -328,11 +491,11 @@ SELECT %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ? AND %s = ? AND %s = ?`,
 
 // Returns the event in the descending order of timestamp (latest event first).
 func getEvents(ctx context.Context, db *sql.DB, tableName string, since time.Time) ([]components.Event, error) {
-	query := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s
+	query := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s, %s, %s
 FROM %s
 WHERE %s > ?
 ORDER BY %s DESC`,
-		columnTimestamp, columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions,
+		columnTimestamp, columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions, columnCount, columnFirstSeen,
 		tableName,
 		columnTimestamp,
 		columnTimestamp,
@@ -366,8 +529,8 @@ ORDER BY %s DESC`,
 }
 
 func lastEvent(ctx context.Context, db *sql.DB, tableName string) (*components.Event, error) {
-	query := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s FROM %s ORDER BY %s DESC LIMIT 1`,
-		columnTimestamp, columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions, tableName, columnTimestamp)
+	query := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s, %s, %s FROM %s ORDER BY %s DESC LIMIT 1`,
+		columnTimestamp, columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions, columnCount, columnFirstSeen, tableName, columnTimestamp)
 
 	start := time.Now()
 	row := db.QueryRowContext(ctx, query)
@@ -390,6 +553,8 @@ func scanRow(row *sql.Row) (components.Event, error) {
 	var msg sql.NullString
 	var extraInfo sql.NullString
 	var suggestedActions sql.NullString
+	var count int
+	var firstSeen sql.NullInt64
 	err := row.Scan(
 		&timestamp,
 		&event.Name,
@@ -397,12 +562,15 @@ func scanRow(row *sql.Row) (components.Event, error) {
 		&msg,
 		&extraInfo,
 		&suggestedActions,
+		&count,
+		&firstSeen,
 	)
 	if err != nil {
 		return event, err
 	}
 
 	event.Time = metav1.Time{Time: time.Unix(timestamp, 0)}
+	setOccurrences(&event, count, firstSeen)
 	if msg.Valid {
 		event.Message = msg.String
 	}
@@ -423,25 +591,32 @@ func scanRow(row *sql.Row) (components.Event, error) {
 	return event, nil
 }
 
-func scanRows(rows *sql.Rows) (components.Event, error) {
+// scanRows scans the event columns, after the optional
+// leading columns (e.g., "rowid") scanned into "leading".
+func scanRows(rows *sql.Rows, leading ...any) (components.Event, error) {
 	var event components.Event
 	var timestamp int64
 	var msg sql.NullString
 	var extraInfo sql.NullString
 	var suggestedActions sql.NullString
-	err := rows.Scan(
+	var count int
+	var firstSeen sql.NullInt64
+	err := rows.Scan(append(leading,
 		&timestamp,
 		&event.Name,
 		&event.Type,
 		&msg,
 		&extraInfo,
 		&suggestedActions,
-	)
+		&count,
+		&firstSeen,
+	)...)
 	if err != nil {
 		return event, err
 	}
 
 	event.Time = metav1.Time{Time: time.Unix(timestamp, 0)}
+	setOccurrences(&event, count, firstSeen)
 	if msg.Valid {
 		event.Message = msg.String
 	}
@@ -0,0 +1,131 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+// matches the table names created by "defaultTableName"
+var tableNameRegex = regexp.MustCompile(`^components_[a-z0-9_]*_events_` + schemaVersion + `$`)
+
+// IsTableName returns true if the name is an event table of the current schema version.
+func IsTableName(name string) bool {
+	return tableNameRegex.MatchString(name)
+}
+
+// ListTables returns the names of the event tables in the database,
+// of the current schema version.
+func ListTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name`)
+	sqlite.RecordSelect(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if IsTableName(name) {
+			tables = append(tables, name)
+		}
+	}
+	return tables, rows.Err()
+}
+
+// ReadTable reads all the events of the table
+// in the ascending order of timestamp (oldest event first).
+func ReadTable(ctx context.Context, db *sql.DB, tableName string) ([]components.Event, error) {
+	var events []components.Event
+	if err := WalkTable(ctx, db, tableName, func(ev components.Event) error {
+		events = append(events, ev)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// WalkTable calls "fn" for each event of the table in the ascending order
+// of timestamp (oldest event first), without loading the whole table into memory.
+// Stops at the first error returned by "fn".
+func WalkTable(ctx context.Context, db *sql.DB, tableName string, fn func(components.Event) error) error {
+	if !IsTableName(tableName) {
+		return fmt.Errorf("invalid events table name %q", tableName)
+	}
+
+	// the tables not opened since the aggregation only have the single occurrences
+	columns, err := tableColumns(ctx, db, tableName)
+	if err != nil {
+		return err
+	}
+	count, firstSeen := columnCount, columnFirstSeen
+	if !columns[columnCount] || !columns[columnFirstSeen] {
+		count, firstSeen = "1", "NULL"
+	}
+
+	query := fmt.Sprintf(`SELECT %s, %s, %s, %s, %s, %s, %s, %s
+FROM %s
+ORDER BY %s ASC, rowid ASC`,
+		columnTimestamp, columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions, count, firstSeen,
+		tableName,
+		columnTimestamp,
+	)
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, query)
+	sqlite.RecordSelect(time.Since(start).Seconds())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanRows(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// WriteTable creates the table if not exists, and inserts the events
+// in the given order, skipping the ones that already exist.
+// Returns the number of the inserted events.
+func WriteTable(ctx context.Context, dbRW *sql.DB, dbRO *sql.DB, tableName string, evs []components.Event) (int, error) {
+	if !IsTableName(tableName) {
+		return 0, fmt.Errorf("invalid events table name %q", tableName)
+	}
+	if err := createTable(ctx, dbRW, tableName); err != nil {
+		return 0, err
+	}
+
+	inserted := 0
+	for _, ev := range evs {
+		found, err := findEvent(ctx, dbRO, tableName, ev)
+		if err != nil {
+			return inserted, err
+		}
+		if found != nil {
+			continue
+		}
+		if err := insertEvent(ctx, dbRW, tableName, ev); err != nil {
+			return inserted, err
+		}
+		inserted++
+	}
+	return inserted, nil
+}
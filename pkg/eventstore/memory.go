@@ -0,0 +1,228 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+)
+
+// DefaultMaxEventsPerBucket is the default number of events
+// kept in memory per bucket.
+const DefaultMaxEventsPerBucket = 1000
+
+var (
+	_ Store  = &memoryStore{}
+	_ Bucket = &memoryBucket{}
+)
+
+type memoryStore struct {
+	maxEvents int
+	retention time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*memoryEvents
+}
+
+// NewMemory returns the store that keeps the events in memory,
+// for the nodes without the persistent disk.
+// Each bucket keeps up to "maxEventsPerBucket" latest events,
+// evicting the oldest ones, and the events older than the retention
+// are purged (no purge if zero).
+func NewMemory(maxEventsPerBucket int, retention time.Duration) (Store, error) {
+	if maxEventsPerBucket <= 0 {
+		maxEventsPerBucket = DefaultMaxEventsPerBucket
+	}
+	return &memoryStore{
+		maxEvents: maxEventsPerBucket,
+		retention: retention,
+		buckets:   make(map[string]*memoryEvents),
+	}, nil
+}
+
+// Bucket returns the bucket of the name,
+// sharing the events with the other buckets of the same name
+// same as the sqlite tables.
+func (s *memoryStore) Bucket(name string, opts ...OpOption) (Bucket, error) {
+	op := &Op{}
+	op.applyOpts(opts)
+
+	tableName := defaultTableName(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evs, ok := s.buckets[tableName]
+	if !ok {
+		evs = &memoryEvents{}
+		s.buckets[tableName] = evs
+	}
+	return &memoryBucket{
+		name:      tableName,
+		maxEvents: s.maxEvents,
+		retention: s.retention,
+		window:    op.aggregateWindow,
+		events:    evs,
+	}, nil
+}
+
+type memoryEvents struct {
+	mu sync.RWMutex
+	// in the ascending order of timestamp
+	events []components.Event
+}
+
+type memoryBucket struct {
+	name      string
+	maxEvents int
+	retention time.Duration
+	// aggregate window (no aggregation if zero)
+	window time.Duration
+
+	events *memoryEvents
+}
+
+func (b *memoryBucket) Name() string {
+	return b.name
+}
+
+func (b *memoryBucket) Insert(ctx context.Context, ev components.Event) error {
+	b.events.mu.Lock()
+	defer b.events.mu.Unlock()
+
+	// purged on the writes, instead of the periodic purge of the tables
+	if b.retention > 0 {
+		b.purgeLocked(time.Now().UTC().Add(-b.retention).Unix())
+	}
+
+	evs := b.events.events
+	if b.window > 0 {
+		since := ev.Time.Add(-b.window).Unix()
+		for j := len(evs) - 1; j >= 0 && evs[j].Time.Unix() >= since; j-- {
+			if sameEvent(evs[j], ev) {
+				// re-inserted at the latest occurrence
+				ev = mergeOccurrence(evs[j], ev)
+				evs = append(evs[:j], evs[j+1:]...)
+				break
+			}
+		}
+	}
+
+	i := sort.Search(len(evs), func(i int) bool { return evs[i].Time.Unix() > ev.Time.Unix() })
+	evs = append(evs, components.Event{})
+	copy(evs[i+1:], evs[i:])
+	evs[i] = ev
+
+	if len(evs) > b.maxEvents {
+		// copied not to pin the evicted events in the backing array
+		evs = append([]components.Event(nil), evs[len(evs)-b.maxEvents:]...)
+	}
+	b.events.events = evs
+	return nil
+}
+
+// InsertSync is the same as Insert, since the events are not persisted.
+func (b *memoryBucket) InsertSync(ctx context.Context, ev components.Event) error {
+	return b.Insert(ctx, ev)
+}
+
+// Find returns nil if the event is not found.
+func (b *memoryBucket) Find(ctx context.Context, ev components.Event) (*components.Event, error) {
+	b.events.mu.RLock()
+	defer b.events.mu.RUnlock()
+
+	return findInEvents(b.events.events, ev)
+}
+
+// findInEvents finds the event with the same fields as the "findEvent" query,
+// returns nil if not found.
+func findInEvents(evs []components.Event, ev components.Event) (*components.Event, error) {
+	var suggestedActions []byte
+	if ev.SuggestedActions != nil {
+		var err error
+		suggestedActions, err = json.Marshal(ev.SuggestedActions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, cur := range evs {
+		if cur.Time.Unix() != ev.Time.Unix() || cur.Name != ev.Name || cur.Type != ev.Type {
+			continue
+		}
+		if ev.Message != "" && cur.Message != ev.Message {
+			continue
+		}
+		if suggestedActions != nil {
+			actions, err := json.Marshal(cur.SuggestedActions)
+			if err != nil || string(actions) != string(suggestedActions) {
+				continue
+			}
+		}
+		if compareEvent(cur, ev) {
+			return &cur, nil
+		}
+	}
+	return nil, nil
+}
+
+// Get queries the event in the descending order of timestamp (latest event first).
+func (b *memoryBucket) Get(ctx context.Context, since time.Time) ([]components.Event, error) {
+	b.events.mu.RLock()
+	defer b.events.mu.RUnlock()
+
+	var evs []components.Event
+	for i := len(b.events.events) - 1; i >= 0; i-- {
+		ev := b.events.events[i]
+		if ev.Time.Unix() <= since.Unix() {
+			break
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
+
+// Query queries the events matching the query
+// in the descending order of timestamp (latest event first).
+func (b *memoryBucket) Query(ctx context.Context, q Query) (Page, error) {
+	evs, err := b.Get(ctx, time.Time{})
+	if err != nil {
+		return Page{}, err
+	}
+	return FilterEvents(evs, q)
+}
+
+// Latest queries the latest event, returns nil if no event found.
+func (b *memoryBucket) Latest(ctx context.Context) (*components.Event, error) {
+	b.events.mu.RLock()
+	defer b.events.mu.RUnlock()
+
+	if len(b.events.events) == 0 {
+		return nil, nil
+	}
+	ev := b.events.events[len(b.events.events)-1]
+	return &ev, nil
+}
+
+func (b *memoryBucket) Purge(ctx context.Context, beforeTimestamp int64) (int, error) {
+	b.events.mu.Lock()
+	defer b.events.mu.Unlock()
+	return b.purgeLocked(beforeTimestamp), nil
+}
+
+// purgeLocked deletes the events before the timestamp.
+// The caller must hold the write lock.
+func (b *memoryBucket) purgeLocked(beforeTimestamp int64) int {
+	evs := b.events.events
+	i := sort.Search(len(evs), func(i int) bool { return evs[i].Time.Unix() >= beforeTimestamp })
+	if i > 0 {
+		b.events.events = append([]components.Event(nil), evs[i:]...)
+	}
+	return i
+}
+
+// Close is a no-op, the events are kept for the other buckets of the same name.
+func (b *memoryBucket) Close() {}
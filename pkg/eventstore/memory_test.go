@@ -0,0 +1,110 @@
+package eventstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMemoryInsertsReads(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, err := NewMemory(5, 0)
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("test")
+	assert.NoError(t, err)
+	defer bucket.Close()
+	assert.Equal(t, defaultTableName("test"), bucket.Name())
+
+	latest, err := bucket.Latest(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, latest)
+
+	base := time.Unix(1700000000, 0)
+	evs := testQueryEvents(base)
+	for _, ev := range evs {
+		assert.NoError(t, bucket.Insert(ctx, ev))
+	}
+
+	// only the latest 5 events are kept
+	got, err := bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9", "8", "7", "6", "5"}, seqs(got))
+
+	got, err = bucket.Get(ctx, base.Add(3*time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9", "8"}, seqs(got))
+
+	latest, err = bucket.Latest(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "9", latest.ExtraInfo["seq"])
+
+	// out-of-order insert
+	assert.NoError(t, bucket.Insert(ctx, components.Event{
+		Time:      metav1.Time{Time: base.Add(3 * time.Second)},
+		Name:      "xid",
+		Type:      common.EventTypeWarning,
+		ExtraInfo: map[string]string{"seq": "late"},
+	}))
+	got, err = bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9", "8", "late", "7", "6"}, seqs(got))
+
+	// same events from the bucket of the same name
+	other, err := store.Bucket("test")
+	assert.NoError(t, err)
+	page, err := other.Query(ctx, Query{Types: []common.EventType{common.EventTypeCritical}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"8"}, seqs(page.Events))
+
+	found, err := bucket.Find(ctx, evs[7])
+	assert.NoError(t, err)
+	assert.NotNil(t, found)
+	assert.Equal(t, evs[7].Message, found.Message)
+
+	notFound := evs[7]
+	notFound.Message = "other"
+	found, err = bucket.Find(ctx, notFound)
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	purged, err := bucket.Purge(ctx, base.Add(4*time.Second).Unix())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	got, err = bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9", "8"}, seqs(got))
+}
+
+func TestMemoryRetention(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, err := NewMemory(0, time.Hour)
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("test")
+	assert.NoError(t, err)
+
+	now := time.Now().UTC()
+	assert.NoError(t, bucket.Insert(ctx, components.Event{Time: metav1.Time{Time: now.Add(-2 * time.Hour)}, Name: "old"}))
+	assert.NoError(t, bucket.Insert(ctx, components.Event{Time: metav1.Time{Time: now}, Name: "new"}))
+
+	// the old event is purged on the next insert
+	assert.NoError(t, bucket.Insert(ctx, components.Event{Time: metav1.Time{Time: now}, Name: "newer"}))
+	got, err := bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "newer", got[0].Name)
+	assert.Equal(t, "new", got[1].Name)
+}
@@ -0,0 +1,81 @@
+package eventstore
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	evictedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gpud",
+			Subsystem: "eventstore",
+			Name:      "evicted_total",
+			Help:      "total number of events evicted by the retention or the quota",
+		},
+		[]string{"table", "reason"},
+	)
+
+	writeQueueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gpud",
+			Subsystem: "eventstore",
+			Name:      "write_queue_length",
+			Help:      "current number of events queued to write",
+		},
+	)
+	writeBatchSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "gpud",
+			Subsystem: "eventstore",
+			Name:      "write_batch_size",
+			Help:      "number of events committed per group commit",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 7),
+		},
+	)
+	writeErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gpud",
+			Subsystem: "eventstore",
+			Name:      "write_errors_total",
+			Help:      "total number of failed group commits, retried on the next flush",
+		},
+	)
+	spilledTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gpud",
+			Subsystem: "eventstore",
+			Name:      "spilled_total",
+			Help:      "total number of events spilled to the disk on the full write queue",
+		},
+	)
+	spillPending = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gpud",
+			Subsystem: "eventstore",
+			Name:      "spill_pending",
+			Help:      "current number of spilled events not replayed into the database yet",
+		},
+	)
+)
+
+func Register(reg *prometheus.Registry) error {
+	if err := reg.Register(evictedTotal); err != nil {
+		return err
+	}
+	if err := reg.Register(writeQueueLength); err != nil {
+		return err
+	}
+	if err := reg.Register(writeBatchSize); err != nil {
+		return err
+	}
+	if err := reg.Register(writeErrorsTotal); err != nil {
+		return err
+	}
+	if err := reg.Register(spilledTotal); err != nil {
+		return err
+	}
+	if err := reg.Register(spillPending); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leptonai/gpud/pkg/log"
+)
+
+// matches the event tables of any schema version
+// (e.g., "components_memory_events_v0_4_0")
+var versionedTableNameRegex = regexp.MustCompile(`^(components_[a-z0-9_]*_events)_v([0-9]+)_([0-9]+)_([0-9]+)$`)
+
+// RenameTables renames the event tables of the schema versions older than "to"
+// (e.g., "v0_5_0") to the version "to", so that the events are kept
+// on the schema version bump. If a component has the tables of multiple
+// older versions, the newest one is renamed and the rest are left as is.
+// The component tables that already exist in the version "to" are kept.
+//
+// To be called by the state migration registered for each bump
+// (see "pkg/gpud-state"), which runs before the tables of the new version
+// are created. Returns the renamed tables in the new names.
+func RenameTables(ctx context.Context, tx *sql.Tx, to string) ([]string, error) {
+	toVersion, ok := parseSchemaVersion(to)
+	if !ok {
+		return nil, fmt.Errorf("invalid events schema version %q", to)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		return nil, err
+	}
+	type versionedTable struct {
+		name    string
+		prefix  string
+		version [3]int
+	}
+	var older []versionedTable
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		existing[name] = true
+
+		m := versionedTableNameRegex.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		v, _ := parseSchemaVersion("v" + strings.Join(m[2:], "_"))
+		if compareSchemaVersions(v, toVersion) < 0 {
+			older = append(older, versionedTable{name: name, prefix: m[1], version: v})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	_ = rows.Close()
+
+	// the newest version first
+	sort.Slice(older, func(i, j int) bool {
+		return compareSchemaVersions(older[i].version, older[j].version) > 0
+	})
+
+	var renamed []string
+	for _, t := range older {
+		newName := t.prefix + "_" + to
+		if existing[newName] {
+			log.Logger.Warnw("events table of the schema version already exists -- keeping the older table as is", "table", t.name, "existing", newName)
+			continue
+		}
+
+		// the indexes are named after the table, and re-created with the new names on open
+		if err := dropIndexes(ctx, tx, t.name); err != nil {
+			return renamed, err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s RENAME TO %s;`, t.name, newName)); err != nil {
+			return renamed, err
+		}
+		existing[newName] = true
+		renamed = append(renamed, newName)
+	}
+	return renamed, nil
+}
+
+func dropIndexes(ctx context.Context, tx *sql.Tx, tableName string) error {
+	// the automatic indexes (e.g., primary key) have no sql, and cannot be dropped
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL`, tableName)
+	if err != nil {
+		return err
+	}
+	var indexes []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		indexes = append(indexes, name)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	_ = rows.Close()
+
+	for _, name := range indexes {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP INDEX IF EXISTS %s;`, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSchemaVersion parses the version (e.g., "v0_4_0").
+func parseSchemaVersion(s string) ([3]int, bool) {
+	var v [3]int
+	parts := strings.Split(strings.TrimPrefix(s, "v"), "_")
+	if !strings.HasPrefix(s, "v") || len(parts) != 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+func compareSchemaVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameTables(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	// the tables of the older schema without the occurrence columns
+	createOld := func(tableName string, message string) {
+		_, err := dbRW.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE %s (timestamp INTEGER NOT NULL, name TEXT NOT NULL, type TEXT NOT NULL, message TEXT, extra_info TEXT, suggested_actions TEXT);`, tableName))
+		assert.NoError(t, err)
+		_, err = dbRW.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX idx_%s_timestamp ON %s(timestamp);`, tableName, tableName))
+		assert.NoError(t, err)
+		_, err = dbRW.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s VALUES (?, 'oom', 'Warning', ?, '{}', '');`, tableName), time.Now().Unix(), message)
+		assert.NoError(t, err)
+	}
+	createOld("components_memory_events_v0_2_0", "v0.2")
+	createOld("components_memory_events_v0_3_0", "v0.3")
+	createOld("components_xid_events_v0_3_0", "v0.3")
+	assert.NoError(t, createTable(ctx, dbRW, defaultTableName("xid")))
+
+	tx, err := dbRW.BeginTx(ctx, nil)
+	assert.NoError(t, err)
+	renamed, err := RenameTables(ctx, tx, schemaVersion)
+	assert.NoError(t, err)
+	_, err = AddOccurrenceColumns(ctx, tx, schemaVersion)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	// the newest older version only, and never over the existing table
+	assert.Equal(t, []string{defaultTableName("memory")}, renamed)
+
+	tables := make(map[string]bool)
+	rows, err := dbRO.QueryContext(ctx, `SELECT name FROM sqlite_master`)
+	assert.NoError(t, err)
+	for rows.Next() {
+		var name string
+		assert.NoError(t, rows.Scan(&name))
+		tables[name] = true
+	}
+	assert.NoError(t, rows.Close())
+	assert.True(t, tables["components_memory_events_v0_2_0"])
+	assert.False(t, tables["components_memory_events_v0_3_0"])
+	assert.False(t, tables["idx_components_memory_events_v0_3_0_timestamp"])
+	assert.True(t, tables["components_xid_events_v0_3_0"])
+
+	// the events of the renamed table are served by the bucket
+	store, err := New(dbRW, dbRO, 0)
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("memory")
+	assert.NoError(t, err)
+	defer bucket.Close()
+	evs, err := bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, 1)
+	assert.Equal(t, "v0.3", evs[0].Message)
+
+	tx, err = dbRW.BeginTx(ctx, nil)
+	assert.NoError(t, err)
+	_, err = RenameTables(ctx, tx, "v0_4")
+	assert.Error(t, err)
+	assert.NoError(t, tx.Rollback())
+}
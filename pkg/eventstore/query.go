@@ -0,0 +1,301 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+// ErrInvalidCursor is returned when the query cursor is malformed.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Query defines the event filters and the pagination.
+// The zero value matches all the events in the bucket.
+type Query struct {
+	// Since is the exclusive lower bound of the event time (zero for no bound).
+	Since time.Time
+	// Until is the inclusive upper bound of the event time (zero for no bound).
+	Until time.Time
+
+	// Types matches any of the event types (empty for all).
+	Types []common.EventType
+	// Names matches any of the event names (empty for all).
+	Names []string
+	// ExtraInfo matches the events with all the extra info key-value pairs
+	// (e.g., {"device_uuid": "GPU-..."}).
+	ExtraInfo map[string]string
+	// MessageContains matches the events whose message contains the substring
+	// (case-insensitive for ASCII).
+	MessageContains string
+
+	// Limit is the maximum number of events to return (0 for no limit).
+	Limit int
+	// Cursor is the "NextCursor" of the previous page, to resume from.
+	Cursor string
+}
+
+// Page is the query result in the descending order of timestamp (latest event first).
+type Page struct {
+	Events []components.Event
+	// NextCursor is set if there are more events to read,
+	// to be passed as the "Cursor" of the next query.
+	NextCursor string
+}
+
+// cursor points to the last returned event, ordered by
+// the timestamp and then by the row id, both in descending order.
+type cursor struct {
+	timestamp int64
+	id        int64
+}
+
+func (c cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.timestamp, c.id)))
+}
+
+func parseCursor(s string) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return cursor{}, ErrInvalidCursor
+	}
+	var c cursor
+	if c.timestamp, err = strconv.ParseInt(ts, 10, 64); err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	if c.id, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Match returns true if the event matches the filters,
+// without the pagination applied.
+func (q Query) Match(ev components.Event) bool {
+	ts := ev.Time.Unix()
+	if !q.Since.IsZero() && ts <= q.Since.Unix() {
+		return false
+	}
+	if !q.Until.IsZero() && ts > q.Until.Unix() {
+		return false
+	}
+	if len(q.Types) > 0 && !contains(q.Types, ev.Type) {
+		return false
+	}
+	if len(q.Names) > 0 && !contains(q.Names, ev.Name) {
+		return false
+	}
+	for k, v := range q.ExtraInfo {
+		if got, ok := ev.ExtraInfo[k]; !ok || got != v {
+			return false
+		}
+	}
+	if q.MessageContains != "" && !strings.Contains(strings.ToLower(ev.Message), strings.ToLower(q.MessageContains)) {
+		return false
+	}
+	return true
+}
+
+func contains[T comparable](vs []T, v T) bool {
+	for _, x := range vs {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterEvents applies the query to the events in memory, for the
+// components whose events are not queried from the bucket.
+// The events with the same timestamp keep their relative order.
+func FilterEvents(evs []components.Event, q Query) (Page, error) {
+	var after *cursor
+	if q.Cursor != "" {
+		c, err := parseCursor(q.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		after = &c
+	}
+
+	matched := make([]components.Event, 0, len(evs))
+	for _, ev := range evs {
+		if q.Match(ev) {
+			matched = append(matched, ev)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Time.Unix() > matched[j].Time.Unix()
+	})
+
+	// the ids descend within the same timestamp, same as the row ids
+	ids := make([]int64, len(matched))
+	for i := 0; i < len(matched); {
+		j := i
+		for j < len(matched) && matched[j].Time.Unix() == matched[i].Time.Unix() {
+			j++
+		}
+		for k := i; k < j; k++ {
+			ids[k] = int64(j - k)
+		}
+		i = j
+	}
+
+	page := Page{}
+	for i, ev := range matched {
+		c := cursor{timestamp: ev.Time.Unix(), id: ids[i]}
+		if after != nil && !c.before(*after) {
+			continue
+		}
+		if q.Limit > 0 && len(page.Events) == q.Limit {
+			last := page.Events[len(page.Events)-1]
+			page.NextCursor = cursor{timestamp: last.Time.Unix(), id: ids[i-1]}.String()
+			break
+		}
+		page.Events = append(page.Events, ev)
+	}
+	return page, nil
+}
+
+// before returns true if the cursor comes after the other in the descending order.
+func (c cursor) before(o cursor) bool {
+	return c.timestamp < o.timestamp || (c.timestamp == o.timestamp && c.id < o.id)
+}
+
+// queriedEvent is the event with its position in the query results.
+type queriedEvent struct {
+	event  components.Event
+	cursor cursor
+}
+
+// paginate returns the page of the events sorted in the descending order
+// of the cursors, with the next cursor if there are more than the limit.
+func paginate(evs []queriedEvent, limit int) Page {
+	page := Page{}
+	for i, qe := range evs {
+		if limit > 0 && i == limit {
+			page.NextCursor = evs[i-1].cursor.String()
+			break
+		}
+		page.Events = append(page.Events, qe.event)
+	}
+	return page
+}
+
+// queryEvents returns the events matching the query
+// in the descending order of timestamp (latest event first).
+func queryEvents(ctx context.Context, db *sql.DB, tableName string, q Query) (Page, error) {
+	evs, err := queryRows(ctx, db, tableName, q)
+	if err != nil {
+		return Page{}, err
+	}
+	return paginate(evs, q.Limit), nil
+}
+
+// queryRows returns up to one more event than the limit, to know if there is the next page.
+func queryRows(ctx context.Context, db *sql.DB, tableName string, q Query) ([]queriedEvent, error) {
+	var where []string
+	var params []any
+
+	if !q.Since.IsZero() {
+		where = append(where, columnTimestamp+" > ?")
+		params = append(params, q.Since.UTC().Unix())
+	}
+	if !q.Until.IsZero() {
+		where = append(where, columnTimestamp+" <= ?")
+		params = append(params, q.Until.UTC().Unix())
+	}
+	if len(q.Types) > 0 {
+		where = append(where, fmt.Sprintf("%s IN (%s)", columnType, placeholders(len(q.Types))))
+		for _, t := range q.Types {
+			params = append(params, string(t))
+		}
+	}
+	if len(q.Names) > 0 {
+		where = append(where, fmt.Sprintf("%s IN (%s)", columnName, placeholders(len(q.Names))))
+		for _, n := range q.Names {
+			params = append(params, n)
+		}
+	}
+	keys := make([]string, 0, len(q.ExtraInfo))
+	for k := range q.ExtraInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		where = append(where, fmt.Sprintf("json_extract(%s, ?) = ?", columnExtraInfo))
+		params = append(params, jsonPath(k), q.ExtraInfo[k])
+	}
+	if q.MessageContains != "" {
+		where = append(where, fmt.Sprintf(`%s LIKE ? ESCAPE '\'`, columnMessage))
+		params = append(params, "%"+escapeLike(q.MessageContains)+"%")
+	}
+	if q.Cursor != "" {
+		c, err := parseCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, fmt.Sprintf("(%s < ? OR (%s = ? AND rowid < ?))", columnTimestamp, columnTimestamp))
+		params = append(params, c.timestamp, c.timestamp, c.id)
+	}
+
+	query := fmt.Sprintf(`SELECT rowid, %s, %s, %s, %s, %s, %s, %s, %s
+FROM %s`,
+		columnTimestamp, columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions, columnCount, columnFirstSeen,
+		tableName,
+	)
+	if len(where) > 0 {
+		query += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf("\nORDER BY %s DESC, rowid DESC", columnTimestamp)
+	if q.Limit > 0 {
+		query += "\nLIMIT ?"
+		params = append(params, q.Limit+1)
+	}
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, query, params...)
+	sqlite.RecordSelect(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var evs []queriedEvent
+	for rows.Next() {
+		var id int64
+		event, err := scanRows(rows, &id)
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, queriedEvent{event: event, cursor: cursor{timestamp: event.Time.Unix(), id: id}})
+	}
+	return evs, rows.Err()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// jsonPath returns the JSON path of the object key, quoted
+// to allow the special characters (e.g., ".") in the key.
+func jsonPath(key string) string {
+	return `$."` + strings.ReplaceAll(key, `"`, `\"`) + `"`
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,159 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testQueryEvents(base time.Time) []components.Event {
+	var evs []components.Event
+	for i := 0; i < 10; i++ {
+		ev := components.Event{
+			// two events per second
+			Time:      metav1.Time{Time: base.Add(time.Duration(i/2) * time.Second)},
+			Name:      "xid",
+			Type:      common.EventTypeWarning,
+			Message:   fmt.Sprintf("XID %d detected", 40+i),
+			ExtraInfo: map[string]string{"device_uuid": fmt.Sprintf("GPU-%d", i%3), "seq": fmt.Sprint(i)},
+		}
+		if i%4 == 0 {
+			ev.Name = "sxid"
+			ev.Type = common.EventTypeCritical
+		}
+		evs = append(evs, ev)
+	}
+	return evs
+}
+
+// latestFirst returns the events in the order returned by "Get".
+func latestFirst(evs []components.Event) []components.Event {
+	ret := make([]components.Event, 0, len(evs))
+	for i := len(evs) - 1; i >= 0; i-- {
+		ret = append(ret, evs[i])
+	}
+	return ret
+}
+
+func seqs(evs []components.Event) []string {
+	s := make([]string, 0, len(evs))
+	for _, ev := range evs {
+		s = append(s, ev.ExtraInfo["seq"])
+	}
+	return s
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bucket, err := newTable(dbRW, dbRO, "test", 0, 0)
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	base := time.Unix(1700000000, 0)
+	evs := testQueryEvents(base)
+	for _, ev := range evs {
+		assert.NoError(t, bucket.Insert(ctx, ev))
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{name: "all", query: Query{}, want: []string{"9", "8", "7", "6", "5", "4", "3", "2", "1", "0"}},
+		{name: "since exclusive", query: Query{Since: base.Add(3 * time.Second)}, want: []string{"9", "8"}},
+		{name: "until inclusive", query: Query{Until: base.Add(time.Second)}, want: []string{"3", "2", "1", "0"}},
+		{name: "type", query: Query{Types: []common.EventType{common.EventTypeCritical}}, want: []string{"8", "4", "0"}},
+		{name: "names", query: Query{Names: []string{"sxid", "unknown"}}, want: []string{"8", "4", "0"}},
+		{name: "extra info", query: Query{ExtraInfo: map[string]string{"device_uuid": "GPU-1"}}, want: []string{"7", "4", "1"}},
+		{name: "extra info all pairs", query: Query{ExtraInfo: map[string]string{"device_uuid": "GPU-1", "seq": "4"}}, want: []string{"4"}},
+		{name: "message", query: Query{MessageContains: "xid 4"}, want: []string{"9", "8", "7", "6", "5", "4", "3", "2", "1", "0"}},
+		{name: "message substring", query: Query{MessageContains: "48"}, want: []string{"8"}},
+		{name: "message like wildcard escaped", query: Query{MessageContains: "4_"}, want: []string{}},
+		{name: "combined", query: Query{Types: []common.EventType{common.EventTypeWarning}, ExtraInfo: map[string]string{"device_uuid": "GPU-2"}}, want: []string{"5", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := bucket.Query(ctx, tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, seqs(page.Events))
+			assert.Empty(t, page.NextCursor)
+
+			// same results in memory
+			page, err = FilterEvents(latestFirst(evs), tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, seqs(page.Events))
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestQueryPagination(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bucket, err := newTable(dbRW, dbRO, "test", 0, 0)
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	evs := testQueryEvents(time.Unix(1700000000, 0))
+	for _, ev := range evs {
+		assert.NoError(t, bucket.Insert(ctx, ev))
+	}
+
+	queries := map[string]func(Query) (Page, error){
+		"sqlite": func(q Query) (Page, error) { return bucket.Query(ctx, q) },
+		"memory": func(q Query) (Page, error) { return FilterEvents(latestFirst(evs), q) },
+	}
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			// the page boundaries split the events with the same timestamp
+			var pages [][]string
+			q := Query{Limit: 3}
+			for {
+				page, err := query(q)
+				assert.NoError(t, err)
+				pages = append(pages, seqs(page.Events))
+				if page.NextCursor == "" {
+					break
+				}
+				q.Cursor = page.NextCursor
+			}
+			assert.Equal(t, [][]string{{"9", "8", "7"}, {"6", "5", "4"}, {"3", "2", "1"}, {"0"}}, pages)
+
+			// filters apply across the pages
+			page, err := query(Query{Types: []common.EventType{common.EventTypeCritical}, Limit: 2})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"8", "4"}, seqs(page.Events))
+			assert.NotEmpty(t, page.NextCursor)
+
+			page, err = query(Query{Types: []common.EventType{common.EventTypeCritical}, Limit: 2, Cursor: page.NextCursor})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"0"}, seqs(page.Events))
+			assert.Empty(t, page.NextCursor)
+
+			_, err = query(Query{Cursor: "not-a-cursor"})
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultQuotaCheckInterval is the interval to enforce the bucket quotas.
+const DefaultQuotaCheckInterval = time.Minute
+
+// BucketConfig configures the retention and the size quota of a bucket.
+type BucketConfig struct {
+	// Retention is the period to keep the events for.
+	// If zero, the retention of the store is used.
+	Retention metav1.Duration `json:"retention,omitempty"`
+
+	// MaxRows is the maximum number of the events to keep,
+	// evicting the oldest ones. If zero, no limit.
+	MaxRows int `json:"max_rows,omitempty"`
+
+	// MaxBytes is the maximum size of the events to keep in bytes,
+	// evicting the oldest ones. If zero, no limit.
+	// The size is the sum of the column values of the events,
+	// excluding the sqlite page and index overhead.
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+
+	// AggregateWindow aggregates the repeats of the same event
+	// (same name, type, message, extra info, and suggested actions)
+	// last seen within the window into one event with the occurrence count.
+	// If zero, the window requested by the component is used (no aggregation by default).
+	AggregateWindow metav1.Duration `json:"aggregate_window,omitempty"`
+}
+
+func (cfg BucketConfig) Validate() error {
+	if cfg.Retention.Duration < 0 {
+		return errors.New("retention must be non-negative")
+	}
+	if cfg.Retention.Duration > 0 && cfg.Retention.Duration < time.Minute {
+		return fmt.Errorf("retention must be at least 1 minute, got %s", cfg.Retention.Duration)
+	}
+	if cfg.MaxRows < 0 {
+		return errors.New("max_rows must be non-negative")
+	}
+	if cfg.MaxBytes < 0 {
+		return errors.New("max_bytes must be non-negative")
+	}
+	if cfg.AggregateWindow.Duration < 0 {
+		return errors.New("aggregate_window must be non-negative")
+	}
+	return nil
+}
+
+func (cfg BucketConfig) hasQuota() bool {
+	return cfg.MaxRows > 0 || cfg.MaxBytes > 0
+}
+
+type Op struct {
+	buckets         map[string]BucketConfig
+	aggregateWindow time.Duration
+	writer          *Writer
+}
+
+type OpOption func(*Op)
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+// WithBucketConfigs sets the retention and the quota per bucket name
+// (e.g., "accelerator-nvidia-error-xid").
+func WithBucketConfigs(buckets map[string]BucketConfig) OpOption {
+	return func(op *Op) {
+		if op.buckets == nil {
+			op.buckets = make(map[string]BucketConfig, len(buckets))
+		}
+		for name, cfg := range buckets {
+			// same table for the names that only differ in the case, spaces, or hyphens
+			op.buckets[defaultTableName(name)] = cfg
+		}
+	}
+}
+
+const (
+	evictReasonRetention = "retention"
+	evictReasonMaxRows   = "max_rows"
+	evictReasonMaxBytes  = "max_bytes"
+)
+
+// enforceQuota evicts the oldest events over the row and the byte quota.
+func (t *table) enforceQuota(ctx context.Context) error {
+	if t.cfg.MaxRows > 0 {
+		evicted, err := evictOverMaxRows(ctx, t.dbRW, t.table, t.cfg.MaxRows)
+		if err != nil {
+			return err
+		}
+		if evicted > 0 {
+			evictedTotal.WithLabelValues(t.table, evictReasonMaxRows).Add(float64(evicted))
+		}
+	}
+	if t.cfg.MaxBytes > 0 {
+		evicted, err := evictOverMaxBytes(ctx, t.dbRW, t.table, t.cfg.MaxBytes)
+		if err != nil {
+			return err
+		}
+		if evicted > 0 {
+			evictedTotal.WithLabelValues(t.table, evictReasonMaxBytes).Add(float64(evicted))
+		}
+	}
+	return nil
+}
+
+// evictOverMaxRows deletes the oldest events beyond the latest "maxRows" events.
+func evictOverMaxRows(ctx context.Context, db *sql.DB, tableName string, maxRows int) (int, error) {
+	deleteStatement := fmt.Sprintf(`DELETE FROM %s WHERE rowid IN (
+	SELECT rowid FROM %s ORDER BY %s DESC, rowid DESC LIMIT -1 OFFSET ?
+)`, tableName, tableName, columnTimestamp)
+	return execDelete(ctx, db, deleteStatement, maxRows)
+}
+
+// evictOverMaxBytes deletes the oldest events beyond the latest events
+// whose total size is within "maxBytes".
+func evictOverMaxBytes(ctx context.Context, db *sql.DB, tableName string, maxBytes int64) (int, error) {
+	deleteStatement := fmt.Sprintf(`DELETE FROM %s WHERE rowid IN (
+	SELECT rowid FROM (
+		SELECT rowid, SUM(8 + LENGTH(%s) + LENGTH(%s) + IFNULL(LENGTH(%s), 0) + IFNULL(LENGTH(%s), 0) + IFNULL(LENGTH(%s), 0))
+			OVER (ORDER BY %s DESC, rowid DESC) AS cumulative_bytes
+		FROM %s
+	) WHERE cumulative_bytes > ?
+)`, tableName,
+		columnName, columnType, columnMessage, columnExtraInfo, columnSuggestedActions,
+		columnTimestamp,
+		tableName,
+	)
+	return execDelete(ctx, db, deleteStatement, maxBytes)
+}
+
+func execDelete(ctx context.Context, db *sql.DB, deleteStatement string, args ...any) (int, error) {
+	start := time.Now()
+	rs, err := db.ExecContext(ctx, deleteStatement, args...)
+	if err != nil {
+		return 0, err
+	}
+	sqlite.RecordDelete(time.Since(start).Seconds())
+
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,144 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func insertQuotaEvents(t *testing.T, ctx context.Context, bucket Bucket, n int, message string) {
+	base := time.Now().UTC().Add(-time.Hour)
+	for i := 0; i < n; i++ {
+		assert.NoError(t, bucket.Insert(ctx, components.Event{
+			Time:      metav1.Time{Time: base.Add(time.Duration(i) * time.Second)},
+			Name:      "test",
+			Type:      common.EventTypeWarning,
+			Message:   message,
+			ExtraInfo: map[string]string{"seq": fmt.Sprint(i)},
+		}))
+	}
+}
+
+func TestEvictOverMaxRows(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bucket, err := newTable(dbRW, dbRO, "test_max_rows", 0, 0)
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	insertQuotaEvents(t, ctx, bucket, 10, "")
+
+	evicted, err := evictOverMaxRows(ctx, dbRW, bucket.Name(), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, evicted)
+
+	evs, err := bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9", "8", "7", "6"}, seqs(evs))
+
+	// within the quota
+	evicted, err = evictOverMaxRows(ctx, dbRW, bucket.Name(), 4)
+	assert.NoError(t, err)
+	assert.Zero(t, evicted)
+}
+
+func TestEvictOverMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bucket, err := newTable(dbRW, dbRO, "test_max_bytes", 0, 0)
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	// each event is over 1 KB
+	insertQuotaEvents(t, ctx, bucket, 10, strings.Repeat("x", 1000))
+
+	evicted, err := evictOverMaxBytes(ctx, dbRW, bucket.Name(), 3500)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, evicted)
+
+	evs, err := bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9", "8", "7"}, seqs(evs))
+}
+
+func TestBucketConfigs(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, err := New(dbRW, dbRO, time.Hour, WithBucketConfigs(map[string]BucketConfig{
+		"accelerator-nvidia-error-xid": {Retention: metav1.Duration{Duration: 90 * 24 * time.Hour}},
+		"memory":                       {MaxRows: 3},
+	}))
+	assert.NoError(t, err)
+
+	xid, err := store.Bucket("accelerator-nvidia-error-xid")
+	assert.NoError(t, err)
+	defer xid.Close()
+	assert.Equal(t, 90*24*time.Hour, xid.(*table).retention)
+	assert.Equal(t, 18*24*time.Hour, xid.(*table).checkInterval)
+
+	mem, err := store.Bucket("memory")
+	assert.NoError(t, err)
+	defer mem.Close()
+	assert.Equal(t, time.Hour, mem.(*table).retention)
+	assert.Equal(t, DefaultQuotaCheckInterval, mem.(*table).checkInterval)
+
+	other, err := store.Bucket("fd")
+	assert.NoError(t, err)
+	defer other.Close()
+	assert.Equal(t, time.Hour, other.(*table).retention)
+	assert.Equal(t, 12*time.Minute, other.(*table).checkInterval)
+
+	insertQuotaEvents(t, ctx, mem, 5, "")
+	before := readEvictedTotal(t, mem.Name(), evictReasonMaxRows)
+	assert.NoError(t, mem.(*table).enforceQuota(ctx))
+	assert.Equal(t, 2.0, readEvictedTotal(t, mem.Name(), evictReasonMaxRows)-before)
+
+	evs, err := mem.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, 3)
+}
+
+func readEvictedTotal(t *testing.T, tableName string, reason string) float64 {
+	var m dto.Metric
+	assert.NoError(t, evictedTotal.WithLabelValues(tableName, reason).Write(&m))
+	return m.GetCounter().GetValue()
+}
+
+func TestBucketConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, BucketConfig{}.Validate())
+	assert.NoError(t, BucketConfig{Retention: metav1.Duration{Duration: time.Hour}, MaxRows: 10, MaxBytes: 1 << 20}.Validate())
+	assert.Error(t, BucketConfig{Retention: metav1.Duration{Duration: time.Second}}.Validate())
+	assert.Error(t, BucketConfig{Retention: metav1.Duration{Duration: -time.Hour}}.Validate())
+	assert.Error(t, BucketConfig{MaxRows: -1}.Validate())
+	assert.Error(t, BucketConfig{MaxBytes: -1}.Validate())
+}
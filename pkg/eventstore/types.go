@@ -12,7 +12,9 @@ const (
 )
 
 type Store interface {
-	Bucket(name string) (Bucket, error)
+	// Bucket returns the bucket of the name
+	// (e.g., with "WithAggregateWindow").
+	Bucket(name string, opts ...OpOption) (Bucket, error)
 }
 
 type Bucket interface {
@@ -22,8 +24,25 @@ type Bucket interface {
 	Find(ctx context.Context, ev components.Event) (*components.Event, error)
 	// Get queries the event in the descending order of timestamp (latest event first).
 	Get(ctx context.Context, since time.Time) ([]components.Event, error)
+	// Query queries the events matching the filters, one page at a time,
+	// in the descending order of timestamp (latest event first).
+	Query(ctx context.Context, q Query) (Page, error)
 	// Latest queries the latest event, returns nil if no event found.
 	Latest(ctx context.Context) (*components.Event, error)
 	Purge(ctx context.Context, beforeTimestamp int64) (int, error)
 	Close()
 }
+
+// Syncer is the optional bucket interface that inserts the event
+// committed before returning, bypassing the async writer
+// (e.g., to record the decision that must survive the reboot).
+type Syncer interface {
+	InsertSync(ctx context.Context, ev components.Event) error
+}
+
+// Querier is the optional component interface
+// that queries its events from the bucket with the filters and pagination.
+// The events of the components not implementing it are filtered in memory.
+type Querier interface {
+	QueryEvents(ctx context.Context, q Query) (Page, error)
+}
@@ -0,0 +1,611 @@
+package eventstore
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultWriteQueueSize     = 10000
+	DefaultWriteBatchSize     = 500
+	DefaultWriteFlushInterval = time.Second
+	DefaultWriteMaxRetries    = 5
+)
+
+// WriterConfig configures the batched async writes of the events.
+type WriterConfig struct {
+	// Disabled set true to insert the events synchronously.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// QueueSize is the number of the events queued in memory to write.
+	QueueSize int `json:"queue_size,omitempty"`
+
+	// BatchSize is the maximum number of the events per group commit.
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// FlushInterval is the interval to commit the queued events
+	// before the batch is full, and to retry the failed commits.
+	FlushInterval metav1.Duration `json:"flush_interval,omitempty"`
+
+	// MaxRetries is the number of the times to retry the batch failed to commit,
+	// before moving it to the spill file (or dropping it without the spill file),
+	// so that the batch that never commits does not stall the writes.
+	MaxRetries int `json:"max_retries,omitempty"`
+
+	// SpillFile is the file to append the events to while the queue is full
+	// (e.g., the state file is locked by the compaction),
+	// replayed into the database once the writes catch up, or on restart.
+	// If empty, the inserts fail while the queue is full.
+	SpillFile string `json:"spill_file,omitempty"`
+}
+
+// SetDefaults sets the default values for the unset fields.
+func (cfg *WriterConfig) SetDefaults() {
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = DefaultWriteQueueSize
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = DefaultWriteBatchSize
+	}
+	if cfg.FlushInterval.Duration == 0 {
+		cfg.FlushInterval = metav1.Duration{Duration: DefaultWriteFlushInterval}
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultWriteMaxRetries
+	}
+}
+
+func (cfg WriterConfig) Validate() error {
+	if cfg.QueueSize < 0 {
+		return errors.New("queue_size must be non-negative")
+	}
+	if cfg.BatchSize < 0 {
+		return errors.New("batch_size must be non-negative")
+	}
+	if cfg.FlushInterval.Duration < 0 {
+		return errors.New("flush_interval must be non-negative")
+	}
+	if cfg.MaxRetries < 0 {
+		return errors.New("max_retries must be non-negative")
+	}
+	return nil
+}
+
+// WithWriter writes the events of the buckets through the async writer,
+// instead of inserting them synchronously.
+// Only applies to the "New" calls.
+func WithWriter(w *Writer) OpOption {
+	return func(op *Op) {
+		op.writer = w
+	}
+}
+
+// pendingEvent is the event queued or spilled to write.
+type pendingEvent struct {
+	Table           string           `json:"table"`
+	AggregateWindow time.Duration    `json:"aggregate_window,omitempty"`
+	Event           components.Event `json:"event"`
+}
+
+// Writer is the batched async event writer shared by all buckets,
+// so that the slow or locked state file does not stall the watch loops.
+// The queued events are committed in groups, and spilled to the disk
+// when the queue overflows, so that no event is lost while the writes are stalled.
+// The bucket reads include the queued events not committed yet.
+type Writer struct {
+	cfg  WriterConfig
+	dbRW *sql.DB
+
+	queue  chan *pendingEvent
+	flushC chan chan error
+
+	mu sync.Mutex
+	// queued or being committed, by table
+	pending map[string][]*pendingEvent
+
+	spillMu sync.Mutex
+	// number of the events in the spill file
+	spilled int
+
+	// number of the consecutive failed commits of the batch,
+	// only accessed by the "run" goroutine
+	retries int
+
+	closed atomic.Bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWriter creates the writer and replays the events spilled before the restart.
+// The configuration defaults are set for the unset fields.
+func NewWriter(dbRW *sql.DB, cfg WriterConfig) (*Writer, error) {
+	w, err := newWriter(dbRW, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err = w.replaySpill(ctx)
+	cancel()
+	if err != nil {
+		// retried on the next flush
+		log.Logger.Warnw("failed to replay spilled events", "file", w.cfg.SpillFile, "error", err)
+	}
+
+	w.start()
+	return w, nil
+}
+
+func newWriter(dbRW *sql.DB, cfg WriterConfig) (*Writer, error) {
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		cfg:     cfg,
+		dbRW:    dbRW,
+		queue:   make(chan *pendingEvent, cfg.QueueSize),
+		flushC:  make(chan chan error),
+		pending: make(map[string][]*pendingEvent),
+		done:    make(chan struct{}),
+	}
+	if cfg.SpillFile != "" {
+		n, err := countLines(cfg.SpillFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spill file: %w", err)
+		}
+		w.spilled = n
+		spillPending.Set(float64(n))
+	}
+	return w, nil
+}
+
+func (w *Writer) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	go w.run(ctx)
+}
+
+// Close commits the queued events and stops the writer.
+// The later inserts are written synchronously.
+func (w *Writer) Close() {
+	// under the lock, so that no event is queued after the last flush of the queue
+	w.mu.Lock()
+	closed := w.closed.Swap(true)
+	w.mu.Unlock()
+	if closed {
+		return
+	}
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+// Flush commits the queued events, and returns the error if the commit fails.
+func (w *Writer) Flush(ctx context.Context) error {
+	if w.closed.Load() {
+		return nil
+	}
+	errc := make(chan error, 1)
+	select {
+	case w.flushC <- errc:
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Writer) enqueue(ctx context.Context, tableName string, aggregateWindow time.Duration, ev components.Event) error {
+	pe := &pendingEvent{Table: tableName, AggregateWindow: aggregateWindow, Event: ev}
+
+	// the closed check and the send are atomic with the close,
+	// not to queue the event after the queue is flushed on close
+	w.mu.Lock()
+	if w.closed.Load() {
+		w.mu.Unlock()
+		return writeEvents(ctx, w.dbRW, []*pendingEvent{pe}, false)
+	}
+	w.pending[tableName] = append(w.pending[tableName], pe)
+	select {
+	case w.queue <- pe:
+		writeQueueLength.Set(float64(len(w.queue)))
+		w.mu.Unlock()
+		return nil
+	default:
+	}
+	w.mu.Unlock()
+
+	w.removePending([]*pendingEvent{pe})
+	if err := w.spill(pe); err != nil {
+		return fmt.Errorf("event write queue is full: %w", err)
+	}
+	return nil
+}
+
+// pendingEvents returns the events of the table not committed yet.
+func (w *Writer) pendingEvents(tableName string) []components.Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	evs := make([]components.Event, 0, len(w.pending[tableName]))
+	for _, pe := range w.pending[tableName] {
+		evs = append(evs, pe.Event)
+	}
+	return evs
+}
+
+func (w *Writer) removePending(pes []*pendingEvent) {
+	committed := make(map[*pendingEvent]struct{}, len(pes))
+	for _, pe := range pes {
+		committed[pe] = struct{}{}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for tableName, cur := range w.pending {
+		kept := cur[:0]
+		for _, pe := range cur {
+			if _, ok := committed[pe]; !ok {
+				kept = append(kept, pe)
+			}
+		}
+		if len(kept) == 0 {
+			delete(w.pending, tableName)
+		} else {
+			w.pending[tableName] = kept
+		}
+	}
+}
+
+func (w *Writer) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval.Duration)
+	defer ticker.Stop()
+
+	var batch []*pendingEvent
+	for {
+		// stops reading the queue while the full batch fails to commit,
+		// so the overflow is spilled to the disk
+		queue := w.queue
+		if len(batch) >= w.cfg.BatchSize {
+			queue = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			// best-effort with a fresh context since the parent one is done
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := w.flush(flushCtx, batch); err != nil {
+				log.Logger.Warnw("failed to commit queued events on close", "error", err)
+			}
+			cancel()
+			return
+
+		case pe := <-queue:
+			batch = append(batch, pe)
+			if len(batch) >= w.cfg.BatchSize {
+				batch = w.commit(ctx, batch)
+			}
+
+		case <-ticker.C:
+			batch = w.commit(ctx, batch)
+			if len(batch) == 0 && len(w.queue) < w.cfg.QueueSize/2 {
+				if err := w.replaySpill(ctx); err != nil {
+					log.Logger.Warnw("failed to replay spilled events", "file", w.cfg.SpillFile, "error", err)
+				}
+			}
+
+		case errc := <-w.flushC:
+			err := w.flush(ctx, batch)
+			batch = nil
+			errc <- err
+		}
+		writeQueueLength.Set(float64(len(w.queue)))
+	}
+}
+
+// commit commits the batch in a group, and returns the events to retry.
+// The batch failed to commit more than the max retries is moved to the spill file.
+func (w *Writer) commit(ctx context.Context, batch []*pendingEvent) []*pendingEvent {
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := writeEvents(ctx, w.dbRW, batch, false); err != nil {
+		writeErrorsTotal.Inc()
+		w.retries++
+		if w.retries <= w.cfg.MaxRetries {
+			log.Logger.Warnw("failed to commit events, retrying", "events", len(batch), "retries", w.retries, "error", err)
+			return batch
+		}
+
+		log.Logger.Warnw("failed to commit events, spilling", "events", len(batch), "retries", w.retries, "error", err)
+		w.retries = 0
+		if err := w.spillBatch(batch); err != nil {
+			log.Logger.Errorw("failed to spill events", "error", err)
+		}
+		return nil
+	}
+	w.retries = 0
+	writeBatchSize.Observe(float64(len(batch)))
+	w.removePending(batch)
+	return nil
+}
+
+// spillBatch moves the events failed to commit to the spill file, not to be lost.
+// The events are dropped if they cannot be spilled.
+func (w *Writer) spillBatch(pes []*pendingEvent) error {
+	w.removePending(pes)
+	for i, pe := range pes {
+		if err := w.spill(pe); err != nil {
+			return fmt.Errorf("dropped %d events: %w", len(pes)-i, err)
+		}
+	}
+	return nil
+}
+
+// flush commits the batch and all the queued events.
+// The events failed to commit are spilled, not to be lost.
+func (w *Writer) flush(ctx context.Context, batch []*pendingEvent) error {
+	for {
+		select {
+		case pe := <-w.queue:
+			batch = append(batch, pe)
+			continue
+		default:
+		}
+		break
+	}
+
+	var errs []error
+	for len(batch) > 0 {
+		n := min(len(batch), w.cfg.BatchSize)
+		if remaining := w.commit(ctx, batch[:n]); len(remaining) > 0 {
+			errs = append(errs, errors.New("failed to commit queued events"))
+			w.retries = 0
+			if err := w.spillBatch(remaining); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		batch = batch[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// spill appends the event to the spill file, synced to survive the crash.
+func (w *Writer) spill(pe *pendingEvent) error {
+	if w.cfg.SpillFile == "" {
+		return errors.New("no spill file")
+	}
+	b, err := json.Marshal(pe)
+	if err != nil {
+		return err
+	}
+
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+
+	f, err := os.OpenFile(w.cfg.SpillFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	w.spilled++
+	spilledTotal.Inc()
+	spillPending.Set(float64(w.spilled))
+	return nil
+}
+
+// replaySpill writes the spilled events into the database, and removes the spill file.
+// The events already written are skipped, in case the last replay
+// failed (or crashed) before removing the spill file.
+func (w *Writer) replaySpill(ctx context.Context) error {
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+
+	if w.cfg.SpillFile == "" || w.spilled == 0 {
+		return nil
+	}
+
+	pes, err := readSpillFile(w.cfg.SpillFile)
+	if err != nil {
+		return err
+	}
+
+	tables := make(map[string]struct{})
+	for _, pe := range pes {
+		tables[pe.Table] = struct{}{}
+	}
+	for tableName := range tables {
+		// the spilled tables may not be opened yet after the restart
+		if err := createTable(ctx, w.dbRW, tableName); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < len(pes); i += w.cfg.BatchSize {
+		batch := pes[i:min(i+w.cfg.BatchSize, len(pes))]
+		if err := writeEvents(ctx, w.dbRW, batch, true); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(w.cfg.SpillFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	log.Logger.Infow("replayed spilled events", "file", w.cfg.SpillFile, "events", len(pes))
+	w.spilled = 0
+	spillPending.Set(0)
+	return nil
+}
+
+func readSpillFile(file string) ([]*pendingEvent, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var pes []*pendingEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var pe pendingEvent
+		if err := json.Unmarshal(scanner.Bytes(), &pe); err != nil {
+			// the last line partially written on the crash
+			log.Logger.Warnw("skipping malformed spilled event", "file", file, "error", err)
+			continue
+		}
+		if !IsTableName(pe.Table) {
+			log.Logger.Warnw("skipping spilled event of invalid table", "file", file, "table", pe.Table)
+			continue
+		}
+		pes = append(pes, &pe)
+	}
+	return pes, scanner.Err()
+}
+
+func countLines(file string) (int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// writeEvents writes the events in one transaction,
+// optionally skipping the events already written.
+func writeEvents(ctx context.Context, dbRW *sql.DB, pes []*pendingEvent, skipExisting bool) error {
+	tx, err := dbRW.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, pe := range pes {
+		if err := writeEvent(ctx, tx, pe, skipExisting); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func writeEvent(ctx context.Context, tx *sql.Tx, pe *pendingEvent, skipExisting bool) error {
+	if skipExisting {
+		found, err := findEvent(ctx, tx, pe.Table, pe.Event)
+		if err != nil {
+			return err
+		}
+		if found != nil {
+			return nil
+		}
+	}
+	if pe.AggregateWindow > 0 {
+		aggregated, err := aggregateEvent(ctx, tx, pe.Table, pe.Event, pe.AggregateWindow)
+		if err != nil || aggregated {
+			return err
+		}
+	}
+	return insertEvent(ctx, tx, pe.Table, pe.Event)
+}
+
+// mergePending merges the events not committed yet into the events
+// read from the table, in the descending order of timestamp (latest event first).
+func mergePending(evs []components.Event, pending []components.Event) []components.Event {
+	if len(pending) == 0 {
+		return evs
+	}
+	merged := make([]components.Event, 0, len(evs)+len(pending))
+	merged = append(merged, evs...)
+	merged = append(merged, pending...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Time.Unix() > merged[j].Time.Unix()
+	})
+	return merged
+}
+
+// queryPending returns the events not committed yet matching the query.
+// The pending events are given the ids after all the row ids in the queue order,
+// as they will be on commit, so they page in the same order as the committed ones.
+func queryPending(pending []components.Event, q Query) ([]queriedEvent, error) {
+	var after *cursor
+	if q.Cursor != "" {
+		c, err := parseCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &c
+	}
+
+	var evs []queriedEvent
+	for i, ev := range pending {
+		if !q.Match(ev) {
+			continue
+		}
+		c := cursor{timestamp: ev.Time.Unix(), id: math.MaxInt64 - int64(len(pending)-1-i)}
+		if after != nil && !c.before(*after) {
+			continue
+		}
+		evs = append(evs, queriedEvent{event: ev, cursor: c})
+	}
+	return evs, nil
+}
+
+// mergePendingRows merges the events not committed yet into the events
+// queried from the table, in the descending order of the cursors.
+func mergePendingRows(evs []queriedEvent, pending []queriedEvent) []queriedEvent {
+	if len(pending) == 0 {
+		return evs
+	}
+	merged := make([]queriedEvent, 0, len(evs)+len(pending))
+	merged = append(merged, evs...)
+	merged = append(merged, pending...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[j].cursor.before(merged[i].cursor)
+	})
+	return merged
+}
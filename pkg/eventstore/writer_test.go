@@ -0,0 +1,363 @@
+package eventstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWriterReadsPending(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// not started, so the events stay queued until flushed
+	w, err := newWriter(dbRW, WriterConfig{})
+	assert.NoError(t, err)
+
+	store, err := New(dbRW, dbRO, 0, WithWriter(w))
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("test")
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	baseTime := time.Unix(1700000000, 0)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, bucket.Insert(ctx, components.Event{
+			Time:      metav1.Time{Time: baseTime.Add(time.Duration(i) * time.Second)},
+			Name:      "test",
+			Type:      common.EventTypeWarning,
+			Message:   "queued",
+			ExtraInfo: map[string]string{"id": string(rune('a' + i))},
+		}))
+	}
+
+	committed, err := getEvents(ctx, dbRO, bucket.Name(), time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, committed)
+
+	evs, err := bucket.Get(ctx, baseTime)
+	assert.NoError(t, err)
+	assert.Len(t, evs, 2)
+	assert.Equal(t, "c", evs[0].ExtraInfo["id"])
+
+	found, err := bucket.Find(ctx, components.Event{
+		Time:      metav1.Time{Time: baseTime},
+		Name:      "test",
+		Type:      common.EventTypeWarning,
+		ExtraInfo: map[string]string{"id": "a"},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, found)
+
+	latest, err := bucket.Latest(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "c", latest.ExtraInfo["id"])
+
+	// paged without flushing the queue
+	page, err := bucket.Query(ctx, Query{Limit: 2})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Events, 2) {
+		assert.Equal(t, "c", page.Events[0].ExtraInfo["id"])
+		assert.Equal(t, "b", page.Events[1].ExtraInfo["id"])
+	}
+	assert.NotEmpty(t, page.NextCursor)
+	page, err = bucket.Query(ctx, Query{Limit: 2, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Events, 1) {
+		assert.Equal(t, "a", page.Events[0].ExtraInfo["id"])
+	}
+	assert.Empty(t, page.NextCursor)
+	assert.Len(t, w.pendingEvents(bucket.Name()), 3)
+
+	w.start()
+	defer w.Close()
+	assert.NoError(t, w.Flush(ctx))
+	assert.Empty(t, w.pendingEvents(bucket.Name()))
+
+	committed, err = getEvents(ctx, dbRO, bucket.Name(), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, committed, 3)
+
+	evs, err = bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, 3)
+}
+
+func TestWriterInsertSync(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// not started, so the queued events are never committed
+	w, err := newWriter(dbRW, WriterConfig{})
+	assert.NoError(t, err)
+
+	store, err := New(dbRW, dbRO, 0, WithWriter(w))
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("test")
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	syncer, ok := bucket.(Syncer)
+	assert.True(t, ok)
+	assert.NoError(t, syncer.InsertSync(ctx, components.Event{
+		Time:    metav1.Time{Time: time.Unix(1700000000, 0)},
+		Name:    "test",
+		Type:    common.EventTypeWarning,
+		Message: "committed",
+	}))
+
+	committed, err := getEvents(ctx, dbRO, bucket.Name(), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, committed, 1)
+	assert.Empty(t, w.pendingEvents(bucket.Name()))
+}
+
+func TestWriterAggregates(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	w, err := NewWriter(dbRW, WriterConfig{BatchSize: 2})
+	assert.NoError(t, err)
+	defer w.Close()
+
+	store, err := New(dbRW, dbRO, 0, WithWriter(w))
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("test", WithAggregateWindow(time.Minute))
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	baseTime := time.Unix(1700000000, 0)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, bucket.Insert(ctx, components.Event{
+			Time:    metav1.Time{Time: baseTime.Add(time.Duration(i) * time.Second)},
+			Name:    "test",
+			Type:    common.EventTypeWarning,
+			Message: "repeated",
+		}))
+	}
+	assert.NoError(t, w.Flush(ctx))
+
+	evs, err := bucket.Get(ctx, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, 1)
+	assert.Equal(t, 5, evs[0].Count)
+	assert.Equal(t, baseTime.Unix(), evs[0].FirstSeen.Unix())
+	assert.Equal(t, baseTime.Add(4*time.Second).Unix(), evs[0].Time.Unix())
+}
+
+func TestWriterSpill(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	spillFile := filepath.Join(t.TempDir(), "events-spill")
+
+	// not started, so the second event overflows the queue
+	w, err := newWriter(dbRW, WriterConfig{QueueSize: 1, SpillFile: spillFile})
+	assert.NoError(t, err)
+
+	store, err := New(dbRW, dbRO, 0, WithWriter(w))
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("test")
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	baseTime := time.Unix(1700000000, 0)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, bucket.Insert(ctx, components.Event{
+			Time:    metav1.Time{Time: baseTime.Add(time.Duration(i) * time.Second)},
+			Name:    "test",
+			Type:    common.EventTypeCritical,
+			Message: "spilled",
+		}))
+	}
+	assert.Len(t, w.pendingEvents(bucket.Name()), 1)
+	assert.Equal(t, 2, w.spilled)
+
+	// partially written on the crash
+	f, err := os.OpenFile(spillFile, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"table":"`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	// the spilled events are replayed on restart, once
+	for i := 0; i < 2; i++ {
+		restarted, err := NewWriter(dbRW, WriterConfig{SpillFile: spillFile})
+		assert.NoError(t, err)
+		restarted.Close()
+
+		_, err = os.Stat(spillFile)
+		assert.True(t, os.IsNotExist(err))
+
+		evs, err := getEvents(ctx, dbRO, bucket.Name(), time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, evs, 2)
+	}
+
+	// no spill file to fall back to
+	w, err = newWriter(dbRW, WriterConfig{QueueSize: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, w.enqueue(ctx, bucket.Name(), 0, components.Event{Time: metav1.Time{Time: baseTime}, Name: "test"}))
+	assert.Error(t, w.enqueue(ctx, bucket.Name(), 0, components.Event{Time: metav1.Time{Time: baseTime}, Name: "test"}))
+}
+
+func TestWriterSpillsFailingBatch(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// not started, to commit the batch step by step
+	spillFile := filepath.Join(t.TempDir(), "spill")
+	w, err := newWriter(dbRW, WriterConfig{SpillFile: spillFile, MaxRetries: 1})
+	assert.NoError(t, err)
+
+	// the table is not created yet, so the commit fails
+	tableName := defaultTableName("test")
+	assert.NoError(t, w.enqueue(ctx, tableName, 0, components.Event{Time: metav1.Time{Time: time.Unix(1700000000, 0)}, Name: "test"}))
+	batch := []*pendingEvent{<-w.queue}
+
+	batch = w.commit(ctx, batch)
+	assert.Len(t, batch, 1)
+	assert.Len(t, w.pendingEvents(tableName), 1)
+
+	// moved to the spill file after the retries
+	batch = w.commit(ctx, batch)
+	assert.Empty(t, batch)
+	assert.Empty(t, w.pendingEvents(tableName))
+	assert.Equal(t, 1, w.spilled)
+
+	// the replay creates the table
+	assert.NoError(t, w.replaySpill(ctx))
+	evs, err := getEvents(ctx, dbRO, tableName, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, 1)
+}
+
+func TestWriterCloseCommits(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	w, err := NewWriter(dbRW, WriterConfig{FlushInterval: metav1.Duration{Duration: time.Hour}})
+	assert.NoError(t, err)
+
+	store, err := New(dbRW, dbRO, 0, WithWriter(w))
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("test")
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	ev := components.Event{Time: metav1.Time{Time: time.Unix(1700000000, 0)}, Name: "test", Type: common.EventTypeInfo}
+	assert.NoError(t, bucket.Insert(ctx, ev))
+	w.Close()
+
+	evs, err := getEvents(ctx, dbRO, bucket.Name(), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, 1)
+
+	// written synchronously after close
+	ev.Time = metav1.Time{Time: ev.Time.Add(time.Second)}
+	assert.NoError(t, bucket.Insert(ctx, ev))
+	evs, err = getEvents(ctx, dbRO, bucket.Name(), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, 2)
+}
+
+func TestWriterInsertWhileClosing(t *testing.T) {
+	t.Parallel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	w, err := NewWriter(dbRW, WriterConfig{FlushInterval: metav1.Duration{Duration: time.Hour}})
+	assert.NoError(t, err)
+
+	store, err := New(dbRW, dbRO, 0, WithWriter(w))
+	assert.NoError(t, err)
+	bucket, err := store.Bucket("test")
+	assert.NoError(t, err)
+	defer bucket.Close()
+
+	// queued before the close, or written synchronously after,
+	// but never left in the queue after the last flush
+	const inserts = 100
+	var wg sync.WaitGroup
+	for i := 0; i < inserts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, bucket.Insert(ctx, components.Event{
+				Time: metav1.Time{Time: time.Unix(1700000000+int64(i), 0)},
+				Name: "test",
+				Type: common.EventTypeInfo,
+			}))
+		}(i)
+		if i == inserts/2 {
+			go w.Close()
+		}
+	}
+	wg.Wait()
+	w.Close()
+
+	evs, err := getEvents(ctx, dbRO, bucket.Name(), time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, evs, inserts)
+}
+
+func TestWriterConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	cfg := WriterConfig{}
+	cfg.SetDefaults()
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, DefaultWriteQueueSize, cfg.QueueSize)
+	assert.Equal(t, DefaultWriteBatchSize, cfg.BatchSize)
+	assert.Equal(t, DefaultWriteFlushInterval, cfg.FlushInterval.Duration)
+	assert.Equal(t, DefaultWriteMaxRetries, cfg.MaxRetries)
+
+	assert.Error(t, WriterConfig{QueueSize: -1}.Validate())
+	assert.Error(t, WriterConfig{BatchSize: -1}.Validate())
+	assert.Error(t, WriterConfig{FlushInterval: metav1.Duration{Duration: -time.Second}}.Validate())
+	assert.Error(t, WriterConfig{MaxRetries: -1}.Validate())
+}
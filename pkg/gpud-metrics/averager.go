@@ -2,7 +2,6 @@ package gpudmetrics
 
 import (
 	"context"
-	"database/sql"
 	"sync"
 	"time"
 
@@ -72,21 +71,18 @@ func (n *noOpAverager) Read(ctx context.Context, opts ...OpOption) (state.Metric
 var _ Averager = (*continuousAverager)(nil)
 
 type continuousAverager struct {
-	dbRW *sql.DB
-	dbRO *sql.DB
+	store state.Store
 
-	tableName  string
 	metricName string
 
 	secondaryNameToValueMu sync.RWMutex
 	secondaryNameToValue   map[string]float64
 }
 
-func NewAverager(dbRW *sql.DB, dbRO *sql.DB, tableName string, metricName string) Averager {
+// NewAverager returns the averager persisting the metrics in the store.
+func NewAverager(store state.Store, metricName string) Averager {
 	return &continuousAverager{
-		dbRW:                 dbRW,
-		dbRO:                 dbRO,
-		tableName:            tableName,
+		store:                store,
 		metricName:           metricName,
 		secondaryNameToValue: make(map[string]float64, 1),
 	}
@@ -103,7 +99,7 @@ func (c *continuousAverager) Last(ctx context.Context, opts ...OpOption) (float6
 	}
 
 	if len(c.secondaryNameToValue) == 0 {
-		m, err := state.ReadLastMetric(ctx, c.dbRO, c.tableName, c.metricName, op.metricSecondaryName)
+		m, err := c.store.ReadLast(ctx, c.metricName, op.metricSecondaryName)
 		if err != nil {
 			return 0.0, false, err
 		}
@@ -140,7 +136,7 @@ func (c *continuousAverager) Observe(ctx context.Context, value float64, opts ..
 	c.secondaryNameToValue[op.metricSecondaryName] = value
 	c.secondaryNameToValueMu.Unlock()
 
-	return state.InsertMetric(ctx, c.dbRW, c.tableName, m)
+	return c.store.Insert(ctx, m)
 }
 
 // Avg returns the average value from the "since" time.
@@ -150,7 +146,7 @@ func (c *continuousAverager) Avg(ctx context.Context, opts ...OpOption) (float64
 	if err := op.applyOpts(opts); err != nil {
 		return 0.0, err
 	}
-	return state.AvgSince(ctx, c.dbRO, c.tableName, c.metricName, op.metricSecondaryName, op.since)
+	return c.store.AvgSince(ctx, c.metricName, op.metricSecondaryName, op.since)
 }
 
 // EMA returns the EMA value from the "since" time.
@@ -160,7 +156,7 @@ func (c *continuousAverager) EMA(ctx context.Context, opts ...OpOption) (float64
 	if err := op.applyOpts(opts); err != nil {
 		return 0.0, err
 	}
-	return state.EMASince(ctx, c.dbRO, c.tableName, c.metricName, op.metricSecondaryName, op.emaPeriod, op.since)
+	return c.store.EMASince(ctx, c.metricName, op.metricSecondaryName, op.emaPeriod, op.since)
 }
 
 func (c *continuousAverager) Read(ctx context.Context, opts ...OpOption) (state.Metrics, error) {
@@ -168,7 +164,7 @@ func (c *continuousAverager) Read(ctx context.Context, opts ...OpOption) (state.
 	if err := op.applyOpts(opts); err != nil {
 		return nil, err
 	}
-	return state.ReadMetricsSince(ctx, c.dbRO, c.tableName, c.metricName, op.metricSecondaryName, op.since)
+	return c.store.ReadSince(ctx, c.metricName, op.metricSecondaryName, op.since)
 }
 
 type Op struct {
@@ -22,7 +22,7 @@ func TestNewAverager(t *testing.T) {
 		t.Fatalf("failed to create table: %v", err)
 	}
 
-	a := NewAverager(dbRW, dbRO, "test_table", "test_name")
+	a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "test_name")
 	if a == nil {
 		t.Fatal("NewAverager returned nil")
 	}
@@ -41,7 +41,7 @@ func TestAveragerObserve(t *testing.T) {
 		t.Fatalf("failed to create table: %v", err)
 	}
 
-	a := NewAverager(dbRW, dbRO, "test_table", "test_name")
+	a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "test_name")
 	now := time.Now()
 
 	numPoints := 500
@@ -167,7 +167,7 @@ func TestAveragerAll(t *testing.T) {
 		t.Fatalf("failed to create table: %v", err)
 	}
 
-	a := NewAverager(dbRW, dbRO, "test_table", "test_name")
+	a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "test_name")
 	now := time.Now()
 
 	values := []float64{1.0, 2.0, 3.0, 4.0, 5.0}
@@ -219,7 +219,7 @@ func TestEmptyAverager(t *testing.T) {
 		t.Fatalf("failed to create table: %v", err)
 	}
 
-	a := NewAverager(dbRW, dbRO, "test_table", "test_name")
+	a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "test_name")
 
 	result, err := a.Avg(ctx)
 	if err != nil {
@@ -258,7 +258,7 @@ func TestContinuousAveragerRead(t *testing.T) {
 		{
 			name: "empty averager",
 			setup: func() *continuousAverager {
-				return NewAverager(dbRW, dbRO, "test_table", "empty averager").(*continuousAverager)
+				return NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "empty averager").(*continuousAverager)
 			},
 			since:    time.Time{},
 			expected: 0.0,
@@ -266,7 +266,7 @@ func TestContinuousAveragerRead(t *testing.T) {
 		{
 			name: "all values",
 			setup: func() *continuousAverager {
-				a := NewAverager(dbRW, dbRO, "test_table", "all values").(*continuousAverager)
+				a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "all values").(*continuousAverager)
 				if err := a.Observe(ctx, 1.0, WithCurrentTime(createTime(1))); err != nil {
 					t.Fatalf("Observe(1.0) returned error: %v", err)
 				}
@@ -284,7 +284,7 @@ func TestContinuousAveragerRead(t *testing.T) {
 		{
 			name: "since middle",
 			setup: func() *continuousAverager {
-				a := NewAverager(dbRW, dbRO, "test_table", "since middle").(*continuousAverager)
+				a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "since middle").(*continuousAverager)
 				if err := a.Observe(ctx, 1.0, WithCurrentTime(createTime(1))); err != nil {
 					t.Fatalf("Observe(1.0) returned error: %v", err)
 				}
@@ -305,7 +305,7 @@ func TestContinuousAveragerRead(t *testing.T) {
 		{
 			name: "since before all values",
 			setup: func() *continuousAverager {
-				a := NewAverager(dbRW, dbRO, "test_table", "since before all values").(*continuousAverager)
+				a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "since before all values").(*continuousAverager)
 				if err := a.Observe(ctx, 1.0, WithCurrentTime(createTime(2))); err != nil {
 					t.Fatalf("Observe(1.0) returned error: %v", err)
 				}
@@ -320,7 +320,7 @@ func TestContinuousAveragerRead(t *testing.T) {
 		{
 			name: "since after all values",
 			setup: func() *continuousAverager {
-				a := NewAverager(dbRW, dbRO, "test_table", "since after all values").(*continuousAverager)
+				a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "since after all values").(*continuousAverager)
 				if err := a.Observe(ctx, 1.0, WithCurrentTime(createTime(1))); err != nil {
 					t.Fatalf("Observe(1.0) returned error: %v", err)
 				}
@@ -335,7 +335,7 @@ func TestContinuousAveragerRead(t *testing.T) {
 		{
 			name: "wrapped buffer",
 			setup: func() *continuousAverager {
-				a := NewAverager(dbRW, dbRO, "test_table", "wrapped buffer").(*continuousAverager)
+				a := NewAverager(metrics_state.NewSQLiteStore(dbRW, dbRO, "test_table"), "wrapped buffer").(*continuousAverager)
 				if err := a.Observe(ctx, 1.0, WithCurrentTime(createTime(1))); err != nil {
 					t.Fatalf("Observe(1.0) returned error: %v", err)
 				}
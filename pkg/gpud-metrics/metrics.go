@@ -56,6 +56,15 @@ func SetRegistered(componentName string) {
 	componentsRegistered.With(prometheus.Labels{"component": componentName}).Set(1.0)
 }
 
+// SetUnregistered removes the component from the component metrics
+// (e.g., the component is disabled by the configuration reload).
+func SetUnregistered(componentName string) {
+	labels := prometheus.Labels{"component": componentName}
+	componentsRegistered.Delete(labels)
+	componentsHealthy.Delete(labels)
+	componentsUnhealthy.Delete(labels)
+}
+
 func SetHealthy(componentName string) {
 	componentsHealthy.With(prometheus.Labels{"component": componentName}).Set(1.0)
 	componentsUnhealthy.With(prometheus.Labels{"component": componentName}).Set(0.0)
@@ -117,9 +126,14 @@ func ReadUnhealthyTotal(gatherer prometheus.Gatherer) (int64, error) {
 	return total, nil
 }
 
-func NewWatchableComponent(c components.Component) components.WatchableComponent {
+// StatesObserver is called with the component states
+// whenever the states are successfully read through the watchable component.
+type StatesObserver func(componentName string, states []components.State)
+
+func NewWatchableComponent(c components.Component, observers ...StatesObserver) components.WatchableComponent {
 	return &WatchableComponentStruct{
 		Component: c,
+		observers: observers,
 	}
 }
 
@@ -129,6 +143,7 @@ func (w *WatchableComponentStruct) Unwrap() interface{} {
 
 type WatchableComponentStruct struct {
 	components.Component
+	observers []StatesObserver
 }
 
 func (w *WatchableComponentStruct) States(ctx context.Context) ([]components.State, error) {
@@ -150,5 +165,8 @@ func (w *WatchableComponentStruct) States(ctx context.Context) ([]components.Sta
 	} else {
 		SetUnhealthy(w.Component.Name())
 	}
+	for _, observe := range w.observers {
+		observe(w.Component.Name(), states)
+	}
 	return states, nil
 }
@@ -149,6 +149,17 @@ func TestSetAndReadFunctions(t *testing.T) {
 	unhealthyTotal, err = ReadUnhealthyTotal(registry)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), unhealthyTotal, "Should have 2 unhealthy components")
+
+	// Test component deregistration
+	SetUnregistered("test_set_3")
+
+	total, err = ReadRegisteredTotal(registry)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total, "Should have 2 registered components")
+
+	unhealthyTotal, err = ReadUnhealthyTotal(registry)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), unhealthyTotal, "Should have 1 unhealthy component")
 }
 
 func TestReadFunctionsWithErrorGatherer(t *testing.T) {
@@ -303,6 +314,31 @@ func TestWatchableComponentUnwrap(t *testing.T) {
 	assert.Equal(t, comp, unwrapped)
 }
 
+func TestWatchableComponentObservers(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	err := Register(registry)
+	require.NoError(t, err)
+
+	comp := NewSimpleComponent("test_observers", true)
+
+	var observed []string
+	watchableComp := NewWatchableComponent(comp, func(componentName string, states []components.State) {
+		observed = append(observed, componentName)
+		assert.Len(t, states, 1)
+	})
+
+	ctx := context.Background()
+	_, err = watchableComp.States(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test_observers"}, observed)
+
+	// observers are not called on error
+	comp.SetError(fmt.Errorf("component error"))
+	_, err = watchableComp.States(ctx)
+	assert.Error(t, err)
+	assert.Len(t, observed, 1)
+}
+
 // TestRegisterNilRegistry tests that Register panics with a nil registry
 // This behavior is controlled by the prometheus library, not our code
 func TestRegisterNilRegistry(t *testing.T) {
@@ -0,0 +1,284 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+// AggregateFunc is the function to aggregate the metrics in each step.
+type AggregateFunc string
+
+const (
+	AggregateAvg AggregateFunc = "avg"
+	AggregateMin AggregateFunc = "min"
+	AggregateMax AggregateFunc = "max"
+	AggregateP50 AggregateFunc = "p50"
+	AggregateP95 AggregateFunc = "p95"
+	AggregateP99 AggregateFunc = "p99"
+	// AggregateRate is the per-second increase between the first
+	// and the last metrics of each step (e.g., of the counters).
+	AggregateRate AggregateFunc = "rate"
+	// AggregateDelta is the difference between the first
+	// and the last metrics of each step.
+	AggregateDelta AggregateFunc = "delta"
+)
+
+var aggregateFuncs = map[AggregateFunc]struct{}{
+	AggregateAvg:   {},
+	AggregateMin:   {},
+	AggregateMax:   {},
+	AggregateP50:   {},
+	AggregateP95:   {},
+	AggregateP99:   {},
+	AggregateRate:  {},
+	AggregateDelta: {},
+}
+
+// MaxAggregateSteps is the maximum number of the steps per series,
+// not to build the unbounded response with the tiny step over the long range.
+const MaxAggregateSteps = 11000
+
+// AggregateQuery is the query to aggregate the metrics into the steps.
+type AggregateQuery struct {
+	// MetricName is the metric name to aggregate.
+	MetricName string
+	// MetricSecondaryName selects the series (e.g., GPU UUID, mount point),
+	// or all the series if empty.
+	MetricSecondaryName string
+
+	// Start is the inclusive start of the time range.
+	Start time.Time
+	// End is the exclusive end of the time range.
+	End time.Time
+	// Step is the size of each aggregated bucket, starting at "Start".
+	Step time.Duration
+
+	Func AggregateFunc
+}
+
+func (q AggregateQuery) Validate() error {
+	if q.MetricName == "" {
+		return errors.New("metric name is required")
+	}
+	if _, ok := aggregateFuncs[q.Func]; !ok {
+		return fmt.Errorf("unknown aggregate function %q", q.Func)
+	}
+	if !q.End.After(q.Start) {
+		return errors.New("end must be after start")
+	}
+	if q.Step < time.Second {
+		return errors.New("step must be at least 1s")
+	}
+	if steps := q.End.Sub(q.Start) / q.Step; steps > MaxAggregateSteps {
+		return fmt.Errorf("too many steps %d (max %d), increase the step", steps, MaxAggregateSteps)
+	}
+	return nil
+}
+
+// Series is the aggregated metrics of a secondary name
+// in the ascending order of time, each timestamped at the step start.
+// The steps without the metrics are omitted.
+type Series struct {
+	MetricName          string  `json:"metric_name"`
+	MetricSecondaryName string  `json:"metric_secondary_name,omitempty"`
+	Metrics             Metrics `json:"metrics"`
+}
+
+// AggregateTable aggregates the metrics of the table in the time range of the query.
+// If the rollups are enabled for the table and the raw metrics do not cover
+// the range, the finest tier that does is aggregated in SQL, which only
+// supports avg, min, and max (see "ErrUnsupportedRollupAggregate").
+func AggregateTable(ctx context.Context, db *sql.DB, tableName string, q AggregateQuery) ([]Series, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	if tier, ok := SelectTier(tableName, q.Start, time.Now()); ok {
+		return aggregateTier(ctx, db, tableName, tier, q)
+	}
+	ms, err := readMetricsRange(ctx, db, tableName, q.MetricName, q.MetricSecondaryName, q.Start, q.End)
+	if err != nil {
+		return nil, err
+	}
+	return AggregateMetrics(ms, q)
+}
+
+// readMetricsRange reads the raw metrics from "start" (inclusive)
+// to "end" (exclusive) in the ascending order of time.
+// If the secondary name is empty, reads the metrics of all the secondary names.
+func readMetricsRange(ctx context.Context, db *sql.DB, tableName string, name string, secondaryName string, start time.Time, end time.Time) (Metrics, error) {
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s
+FROM %s
+WHERE %s = ? AND %s >= ? AND %s < ?`,
+		ColumnUnixSeconds, ColumnMetricSecondaryName, ColumnMetricValue,
+		tableName,
+		ColumnMetricName, ColumnUnixSeconds, ColumnUnixSeconds,
+	)
+	params := []any{name, start.Unix(), end.Unix()}
+	if secondaryName != "" {
+		query += fmt.Sprintf(" AND %s = ?", ColumnMetricSecondaryName)
+		params = append(params, secondaryName)
+	}
+	query += fmt.Sprintf("\nORDER BY %s ASC;", ColumnUnixSeconds)
+
+	queryStart := time.Now()
+	defer func() {
+		sqlite.RecordSelect(time.Since(queryStart).Seconds())
+	}()
+
+	queryRows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer queryRows.Close()
+
+	rows := make(Metrics, 0)
+	for queryRows.Next() {
+		m := Metric{MetricName: name}
+		var secondary sql.NullString
+		if err := queryRows.Scan(&m.UnixSeconds, &secondary, &m.Value); err != nil {
+			return nil, err
+		}
+		m.MetricSecondaryName = secondary.String
+		rows = append(rows, m)
+	}
+	if err := queryRows.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// AggregateMetrics aggregates the metrics (e.g., from "Store.ReadSince")
+// into the steps of the query, grouped by the secondary name.
+// The series are sorted by the secondary name.
+func AggregateMetrics(ms Metrics, q AggregateQuery) ([]Series, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	start, end := q.Start.Unix(), q.End.Unix()
+	step := int64(q.Step / time.Second)
+
+	// values of each step, by secondary name
+	grouped := make(map[string]map[int64][]Metric)
+	for _, m := range ms {
+		if m.MetricName != q.MetricName || m.UnixSeconds < start || m.UnixSeconds >= end {
+			continue
+		}
+		if q.MetricSecondaryName != "" && m.MetricSecondaryName != q.MetricSecondaryName {
+			continue
+		}
+		steps, ok := grouped[m.MetricSecondaryName]
+		if !ok {
+			steps = make(map[int64][]Metric)
+			grouped[m.MetricSecondaryName] = steps
+		}
+		stepStart := start + (m.UnixSeconds-start)/step*step
+		steps[stepStart] = append(steps[stepStart], m)
+	}
+
+	series := make([]Series, 0, len(grouped))
+	for secondaryName, steps := range grouped {
+		stepStarts := make([]int64, 0, len(steps))
+		for ts := range steps {
+			stepStarts = append(stepStarts, ts)
+		}
+		sort.Slice(stepStarts, func(i, j int) bool { return stepStarts[i] < stepStarts[j] })
+
+		s := Series{
+			MetricName:          q.MetricName,
+			MetricSecondaryName: secondaryName,
+			Metrics:             make(Metrics, 0, len(stepStarts)),
+		}
+		for _, ts := range stepStarts {
+			v, ok := aggregate(steps[ts], q.Func)
+			if !ok {
+				continue
+			}
+			s.Metrics = append(s.Metrics, Metric{
+				UnixSeconds:         ts,
+				MetricName:          q.MetricName,
+				MetricSecondaryName: secondaryName,
+				Value:               v,
+			})
+		}
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].MetricSecondaryName < series[j].MetricSecondaryName })
+	return series, nil
+}
+
+// aggregate returns false if the step does not have enough metrics
+// (e.g., the rate of a single metric).
+func aggregate(ms []Metric, fn AggregateFunc) (float64, bool) {
+	if len(ms) == 0 {
+		return 0, false
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].UnixSeconds < ms[j].UnixSeconds })
+
+	switch fn {
+	case AggregateAvg:
+		sum := 0.0
+		for _, m := range ms {
+			sum += m.Value
+		}
+		return sum / float64(len(ms)), true
+
+	case AggregateMin:
+		v := ms[0].Value
+		for _, m := range ms[1:] {
+			v = math.Min(v, m.Value)
+		}
+		return v, true
+
+	case AggregateMax:
+		v := ms[0].Value
+		for _, m := range ms[1:] {
+			v = math.Max(v, m.Value)
+		}
+		return v, true
+
+	case AggregateP50:
+		return percentile(ms, 0.50), true
+	case AggregateP95:
+		return percentile(ms, 0.95), true
+	case AggregateP99:
+		return percentile(ms, 0.99), true
+
+	case AggregateDelta:
+		if len(ms) < 2 {
+			return 0, false
+		}
+		return ms[len(ms)-1].Value - ms[0].Value, true
+
+	case AggregateRate:
+		first, last := ms[0], ms[len(ms)-1]
+		if last.UnixSeconds == first.UnixSeconds {
+			return 0, false
+		}
+		return (last.Value - first.Value) / float64(last.UnixSeconds-first.UnixSeconds), true
+	}
+	return 0, false
+}
+
+// percentile returns the nearest-rank percentile of the values.
+func percentile(ms []Metric, p float64) float64 {
+	vs := make([]float64, len(ms))
+	for i, m := range ms {
+		vs[i] = m.Value
+	}
+	sort.Float64s(vs)
+
+	rank := int(math.Ceil(p*float64(len(vs)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return vs[rank]
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestAggregateMetrics(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1700000000, 0)
+	var ms Metrics
+	for i := 0; i < 20; i++ {
+		ms = append(ms,
+			Metric{UnixSeconds: start.Unix() + int64(i)*30, MetricName: "power", MetricSecondaryName: "GPU-1", Value: float64(i + 1)},
+			Metric{UnixSeconds: start.Unix() + int64(i)*30, MetricName: "power", MetricSecondaryName: "GPU-0", Value: float64(100 - i)},
+			Metric{UnixSeconds: start.Unix() + int64(i)*30, MetricName: "other", Value: 1},
+		)
+	}
+
+	// 20 metrics per series every 30s, in 2 steps of 5 minutes
+	tests := []struct {
+		fn   AggregateFunc
+		want []float64
+	}{
+		{fn: AggregateAvg, want: []float64{5.5, 15.5}},
+		{fn: AggregateMin, want: []float64{1, 11}},
+		{fn: AggregateMax, want: []float64{10, 20}},
+		{fn: AggregateP50, want: []float64{5, 15}},
+		{fn: AggregateP95, want: []float64{10, 20}},
+		{fn: AggregateDelta, want: []float64{9, 9}},
+		{fn: AggregateRate, want: []float64{9.0 / 270, 9.0 / 270}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.fn), func(t *testing.T) {
+			series, err := AggregateMetrics(ms, AggregateQuery{
+				MetricName: "power",
+				Start:      start,
+				End:        start.Add(10 * time.Minute),
+				Step:       5 * time.Minute,
+				Func:       tt.fn,
+			})
+			if err != nil {
+				t.Fatalf("failed to aggregate: %v", err)
+			}
+			if len(series) != 2 || series[0].MetricSecondaryName != "GPU-0" || series[1].MetricSecondaryName != "GPU-1" {
+				t.Fatalf("unexpected series %+v", series)
+			}
+
+			var got []float64
+			for i, m := range series[1].Metrics {
+				if want := start.Add(time.Duration(i) * 5 * time.Minute).Unix(); m.UnixSeconds != want {
+					t.Fatalf("step %d: expected timestamp %d, got %d", i, want, m.UnixSeconds)
+				}
+				got = append(got, m.Value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// the secondary name is filtered, and the steps without metrics are omitted
+	series, err := AggregateMetrics(ms, AggregateQuery{
+		MetricName:          "power",
+		MetricSecondaryName: "GPU-0",
+		Start:               start.Add(-time.Hour),
+		End:                 start.Add(time.Minute),
+		Step:                time.Minute,
+		Func:                AggregateMax,
+	})
+	if err != nil {
+		t.Fatalf("failed to aggregate: %v", err)
+	}
+	if len(series) != 1 || len(series[0].Metrics) != 1 || series[0].Metrics[0].Value != 100 {
+		t.Fatalf("unexpected series %+v", series)
+	}
+}
+
+func TestAggregateQueryValidate(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1700000000, 0)
+	valid := AggregateQuery{MetricName: "power", Start: start, End: start.Add(time.Hour), Step: time.Minute, Func: AggregateP99}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, mutate := range map[string]func(q *AggregateQuery){
+		"no name":        func(q *AggregateQuery) { q.MetricName = "" },
+		"unknown func":   func(q *AggregateQuery) { q.Func = "p42" },
+		"empty range":    func(q *AggregateQuery) { q.End = q.Start },
+		"sub-second":     func(q *AggregateQuery) { q.Step = time.Millisecond },
+		"too many steps": func(q *AggregateQuery) { q.End = q.Start.Add(30 * 24 * time.Hour); q.Step = time.Second },
+	} {
+		q := valid
+		mutate(&q)
+		if err := q.Validate(); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAggregateMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := NewMemoryStore(0)
+
+	now := time.Now().Truncate(time.Minute)
+	for i := 0; i < 4; i++ {
+		if err := s.Insert(ctx, Metric{UnixSeconds: now.Add(time.Duration(i) * 15 * time.Second).Unix(), MetricName: "power", MetricSecondaryName: "GPU-0", Value: float64(i * 10)}); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+
+	q := AggregateQuery{MetricName: "power", Start: now, End: now.Add(time.Minute), Step: time.Minute, Func: AggregateRate}
+	series, err := s.Aggregate(ctx, q)
+	if err != nil {
+		t.Fatalf("failed to aggregate: %v", err)
+	}
+	if len(series) != 1 || len(series[0].Metrics) != 1 || series[0].Metrics[0].Value != 30.0/45 {
+		t.Fatalf("unexpected series %+v", series)
+	}
+}
+
+func TestAggregateTable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tableName := "test_metrics_aggregate"
+	if err := CreateTableMetrics(ctx, db, tableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	now := time.Now().Truncate(time.Minute)
+	for i := 0; i < 4; i++ {
+		if err := InsertMetric(ctx, db, tableName, Metric{UnixSeconds: now.Add(time.Duration(i) * 30 * time.Second).Unix(), MetricName: "power", MetricSecondaryName: "GPU-0", Value: float64(i * 10)}); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+
+	// the metrics at and after the end are excluded
+	series, err := NewSQLiteStore(db, db, tableName).Aggregate(ctx, AggregateQuery{MetricName: "power", Start: now, End: now.Add(time.Minute), Step: time.Minute, Func: AggregateMax})
+	if err != nil {
+		t.Fatalf("failed to aggregate: %v", err)
+	}
+	if len(series) != 1 || len(series[0].Metrics) != 1 || series[0].Metrics[0].Value != 10 {
+		t.Fatalf("unexpected series %+v", series)
+	}
+}
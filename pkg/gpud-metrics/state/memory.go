@@ -0,0 +1,204 @@
+package state
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxMetricsPerSeries keeps about 3 days of the per-minute metrics.
+const DefaultMaxMetricsPerSeries = 4320
+
+var _ Store = &memoryStore{}
+
+type seriesKey struct {
+	name          string
+	secondaryName string
+}
+
+type memoryStore struct {
+	maxPerSeries int
+
+	mu sync.RWMutex
+	// metrics of each series in the ascending order of time
+	series map[seriesKey]Metrics
+}
+
+// NewMemoryStore returns the in-memory store that keeps
+// up to "maxPerSeries" latest metrics per name and secondary name,
+// evicting the oldest ones.
+func NewMemoryStore(maxPerSeries int) Store {
+	if maxPerSeries <= 0 {
+		maxPerSeries = DefaultMaxMetricsPerSeries
+	}
+	return &memoryStore{
+		maxPerSeries: maxPerSeries,
+		series:       make(map[seriesKey]Metrics),
+	}
+}
+
+func (s *memoryStore) Insert(ctx context.Context, m Metric) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := seriesKey{name: m.MetricName, secondaryName: m.MetricSecondaryName}
+	ms := s.series[key]
+
+	i := sort.Search(len(ms), func(i int) bool { return ms[i].UnixSeconds >= m.UnixSeconds })
+	switch {
+	case i < len(ms) && ms[i].UnixSeconds == m.UnixSeconds:
+		ms[i] = m
+	case i == len(ms):
+		ms = append(ms, m)
+	default:
+		ms = append(ms, Metric{})
+		copy(ms[i+1:], ms[i:])
+		ms[i] = m
+	}
+
+	if len(ms) > s.maxPerSeries {
+		// copied not to pin the evicted metrics in the backing array
+		ms = append(Metrics(nil), ms[len(ms)-s.maxPerSeries:]...)
+	}
+	s.series[key] = ms
+	return nil
+}
+
+// matching returns the series of the name, and the secondary name if not empty.
+// The caller must hold the read lock.
+func (s *memoryStore) matching(name string, secondaryName string) []Metrics {
+	if secondaryName != "" {
+		return []Metrics{s.series[seriesKey{name: name, secondaryName: secondaryName}]}
+	}
+	var all []Metrics
+	for key, ms := range s.series {
+		if key.name == name {
+			all = append(all, ms)
+		}
+	}
+	return all
+}
+
+func (s *memoryStore) ReadLast(ctx context.Context, name string, secondaryName string) (*Metric, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var last *Metric
+	for _, ms := range s.matching(name, secondaryName) {
+		if len(ms) == 0 {
+			continue
+		}
+		m := ms[len(ms)-1]
+		if last == nil || m.UnixSeconds > last.UnixSeconds {
+			last = &m
+		}
+	}
+	return last, nil
+}
+
+func (s *memoryStore) ReadSince(ctx context.Context, name string, secondaryName string, since time.Time) (Metrics, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make(Metrics, 0)
+	for _, ms := range s.matching(name, secondaryName) {
+		rows = append(rows, metricsSince(ms, since)...)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].UnixSeconds < rows[j].UnixSeconds })
+	return rows, nil
+}
+
+// AvgSince only averages the series of the exact secondary name,
+// same as the sqlite store.
+func (s *memoryStore) AvgSince(ctx context.Context, name string, secondaryName string, since time.Time) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ms := metricsSince(s.series[seriesKey{name: name, secondaryName: secondaryName}], since)
+	if len(ms) == 0 {
+		return 0.0, nil
+	}
+	sum := 0.0
+	for _, m := range ms {
+		sum += m.Value
+	}
+	return sum / float64(len(ms)), nil
+}
+
+// Aggregate aggregates the raw metrics, since the in-memory metrics are not rolled up.
+func (s *memoryStore) Aggregate(ctx context.Context, q AggregateQuery) ([]Series, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	ms, err := s.ReadSince(ctx, q.MetricName, q.MetricSecondaryName, q.Start)
+	if err != nil {
+		return nil, err
+	}
+	return AggregateMetrics(ms, q)
+}
+
+// EMASince computes the same value as the sqlite store, which
+// smooths the last value with the previous one.
+func (s *memoryStore) EMASince(ctx context.Context, name string, secondaryName string, period time.Duration, since time.Time) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ms := metricsSince(s.series[seriesKey{name: name, secondaryName: secondaryName}], since)
+	switch len(ms) {
+	case 0:
+		return 0.0, nil
+	case 1:
+		return ms[0].Value, nil
+	}
+	alpha := 2.0 / (period.Minutes() + 1)
+	return alpha*ms[len(ms)-1].Value + (1-alpha)*ms[len(ms)-2].Value, nil
+}
+
+// Walk calls the function without holding the lock,
+// on the copy of the metrics at the time of the call.
+func (s *memoryStore) Walk(ctx context.Context, fn func(Metric) error) error {
+	s.mu.RLock()
+	rows := make(Metrics, 0)
+	for _, ms := range s.series {
+		rows = append(rows, ms...)
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].UnixSeconds < rows[j].UnixSeconds })
+	for _, m := range rows {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Purge(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for key, ms := range s.series {
+		i := sort.Search(len(ms), func(i int) bool { return ms[i].UnixSeconds >= before.Unix() })
+		if i == 0 {
+			continue
+		}
+		purged += i
+		if i == len(ms) {
+			delete(s.series, key)
+			continue
+		}
+		s.series[key] = append(Metrics(nil), ms[i:]...)
+	}
+	return purged, nil
+}
+
+// metricsSince returns the metrics at or after the time, all if the time is zero.
+func metricsSince(ms Metrics, since time.Time) Metrics {
+	if since.IsZero() {
+		return ms
+	}
+	i := sort.Search(len(ms), func(i int) bool { return ms[i].UnixSeconds >= since.Unix() })
+	return ms[i:]
+}
@@ -0,0 +1,168 @@
+package state
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+// TestMemoryStore checks the in-memory store returns the same as the sqlite store.
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tableName := "test_metrics_memory"
+	if err := CreateTableMetrics(ctx, db, tableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	stores := map[string]Store{
+		"sqlite": NewSQLiteStore(db, db, tableName),
+		"memory": NewMemoryStore(100),
+	}
+
+	now := time.Now().Truncate(time.Second)
+	for name, s := range stores {
+		if m, err := s.ReadLast(ctx, "temperature", ""); m != nil || err != nil {
+			t.Fatalf("%s: expected no metric, got %v, %v", name, m, err)
+		}
+		for i := 0; i < 10; i++ {
+			for _, gpu := range []string{"gpu0", "gpu1"} {
+				v := float64(i)
+				if gpu == "gpu1" {
+					v += 100
+				}
+				m := Metric{UnixSeconds: now.Add(time.Duration(i-9) * time.Minute).Unix(), MetricName: "temperature", MetricSecondaryName: gpu, Value: v}
+				if err := s.Insert(ctx, m); err != nil {
+					t.Fatalf("%s: failed to insert: %v", name, err)
+				}
+			}
+		}
+		// replaces the same timestamp
+		if err := s.Insert(ctx, Metric{UnixSeconds: now.Unix(), MetricName: "temperature", MetricSecondaryName: "gpu0", Value: 19}); err != nil {
+			t.Fatalf("%s: failed to insert: %v", name, err)
+		}
+	}
+
+	since := now.Add(-5 * time.Minute)
+	for _, secondary := range []string{"", "gpu0", "gpu1"} {
+		expectedLast, err := stores["sqlite"].ReadLast(ctx, "temperature", secondary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last, err := stores["memory"].ReadLast(ctx, "temperature", secondary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last.UnixSeconds != expectedLast.UnixSeconds {
+			t.Errorf("%q: expected last %+v, got %+v", secondary, expectedLast, last)
+		}
+
+		expected, err := stores["sqlite"].ReadSince(ctx, "temperature", secondary, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := stores["memory"].ReadSince(ctx, "temperature", secondary, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("%q: expected %d metrics, got %d", secondary, len(expected), len(got))
+		}
+		sum, expectedSum := 0.0, 0.0
+		for i := range got {
+			if got[i].UnixSeconds != expected[i].UnixSeconds {
+				t.Fatalf("%q: expected %+v, got %+v", secondary, expected[i], got[i])
+			}
+			sum += got[i].Value
+			expectedSum += expected[i].Value
+		}
+		if sum != expectedSum {
+			t.Errorf("%q: expected sum %f, got %f", secondary, expectedSum, sum)
+		}
+
+		expectedAvg, err := stores["sqlite"].AvgSince(ctx, "temperature", secondary, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		avg, err := stores["memory"].AvgSince(ctx, "temperature", secondary, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(avg-expectedAvg) > 1e-9 {
+			t.Errorf("%q: expected average %f, got %f", secondary, expectedAvg, avg)
+		}
+
+		expectedEMA, err := stores["sqlite"].EMASince(ctx, "temperature", secondary, 5*time.Minute, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ema, err := stores["memory"].EMASince(ctx, "temperature", secondary, 5*time.Minute, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(ema-expectedEMA) > 1e-9 {
+			t.Errorf("%q: expected EMA %f, got %f", secondary, expectedEMA, ema)
+		}
+	}
+
+	walked := make(map[string]int, len(stores))
+	for name, s := range stores {
+		var last int64
+		if err := s.Walk(ctx, func(m Metric) error {
+			if m.UnixSeconds < last {
+				t.Fatalf("%s: expected ascending order of time, got %d after %d", name, m.UnixSeconds, last)
+			}
+			last = m.UnixSeconds
+			walked[name]++
+			return nil
+		}); err != nil {
+			t.Fatalf("%s: failed to walk: %v", name, err)
+		}
+	}
+	if walked["memory"] != walked["sqlite"] || walked["memory"] != 20 {
+		t.Errorf("expected 20 metrics walked, got %v", walked)
+	}
+
+	before := now.Add(-7 * time.Minute)
+	for name, s := range stores {
+		purged, err := s.Purge(ctx, before)
+		if err != nil {
+			t.Fatalf("%s: failed to purge: %v", name, err)
+		}
+		if purged != 4 {
+			t.Errorf("%s: expected 4 purged, got %d", name, purged)
+		}
+	}
+}
+
+func TestMemoryStoreCap(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := NewMemoryStore(3)
+
+	// inserted in the reverse order of time
+	for i := 9; i >= 0; i-- {
+		if err := s.Insert(ctx, Metric{UnixSeconds: int64(i), MetricName: "m", Value: float64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ms, err := s.ReadSince(ctx, "m", "", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 3 || ms[0].Value != 7 || ms[2].Value != 9 {
+		t.Fatalf("expected the latest 3 metrics, got %+v", ms)
+	}
+}
@@ -0,0 +1,493 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+// Tier is the rollup tier that aggregates the metrics
+// into the fixed-size time buckets.
+type Tier struct {
+	// Name is the tier name, used as the rollup table name suffix (e.g., "1m").
+	Name string
+	// Resolution is the bucket size.
+	Resolution time.Duration
+	// Retention is how long the buckets are kept.
+	Retention time.Duration
+}
+
+// DefaultRollupTiers keeps the per-minute rollups for a week,
+// and the per-hour rollups for 30 days.
+var DefaultRollupTiers = []Tier{
+	{Name: "1m", Resolution: time.Minute, Retention: 7 * 24 * time.Hour},
+	{Name: "1h", Resolution: time.Hour, Retention: 30 * 24 * time.Hour},
+}
+
+// RollupConfig configures the rollup tiers of a metrics table.
+type RollupConfig struct {
+	// RawRetention is how long the raw metrics are kept in the table.
+	RawRetention time.Duration
+	// Tiers are the rollup tiers in the ascending order of resolution.
+	// Each tier is computed from the previous one (or from the raw metrics for the first).
+	Tiers []Tier
+}
+
+func (cfg RollupConfig) Validate() error {
+	var prev time.Duration
+	for i, tier := range cfg.Tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("tier[%d]: name is required", i)
+		}
+		if tier.Resolution < time.Second || tier.Resolution%time.Second != 0 {
+			return fmt.Errorf("tier %q: resolution must be whole seconds", tier.Name)
+		}
+		if prev > 0 && tier.Resolution%prev != 0 {
+			return fmt.Errorf("tier %q: resolution must be a multiple of the previous tier", tier.Name)
+		}
+		if tier.Retention < tier.Resolution {
+			return fmt.Errorf("tier %q: retention must be at least the resolution", tier.Name)
+		}
+		prev = tier.Resolution
+	}
+	return nil
+}
+
+const (
+	ColumnRollupMin   = "min_value"
+	ColumnRollupMax   = "max_value"
+	ColumnRollupSum   = "sum_value"
+	ColumnRollupCount = "count"
+)
+
+// RollupTableName returns the rollup table name of the tier.
+func RollupTableName(tableName string, tier Tier) string {
+	return tableName + "_" + tier.Name
+}
+
+// Rollup is the aggregated metric in the time bucket starting at "UnixSeconds".
+type Rollup struct {
+	UnixSeconds         int64   `json:"unix_seconds"`
+	MetricName          string  `json:"metric_name"`
+	MetricSecondaryName string  `json:"metric_secondary_name,omitempty"`
+	Min                 float64 `json:"min"`
+	Max                 float64 `json:"max"`
+	Sum                 float64 `json:"sum"`
+	Avg                 float64 `json:"avg"`
+	Count               int64   `json:"count"`
+}
+
+var (
+	rollupsMu sync.RWMutex
+	// rollup configuration, keyed by the raw metrics table name
+	rollups = make(map[string]RollupConfig)
+)
+
+// EnableRollups creates the rollup tables of the metrics table, and registers
+// the tiers for "ReadMetricsSince" and "AvgSince" to read from, so that
+// the longer time ranges are served from the coarser tiers.
+// The rollups are computed by "RunRollups".
+func EnableRollups(ctx context.Context, db *sql.DB, tableName string, cfg RollupConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	for _, tier := range cfg.Tiers {
+		if err := CreateTableRollup(ctx, db, tableName, tier); err != nil {
+			return err
+		}
+	}
+
+	rollupsMu.Lock()
+	rollups[tableName] = cfg
+	rollupsMu.Unlock()
+	return nil
+}
+
+func getRollupConfig(tableName string) (RollupConfig, bool) {
+	rollupsMu.RLock()
+	defer rollupsMu.RUnlock()
+	cfg, ok := rollups[tableName]
+	return cfg, ok
+}
+
+// CreateTableRollup creates the rollup table of the tier if not exists.
+func CreateTableRollup(ctx context.Context, db *sql.DB, tableName string, tier Tier) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER NOT NULL,
+	%s TEXT NOT NULL,
+	%s TEXT NOT NULL,
+	%s REAL NOT NULL,
+	%s REAL NOT NULL,
+	%s REAL NOT NULL,
+	%s INTEGER NOT NULL,
+	PRIMARY KEY (%s, %s, %s)
+) WITHOUT ROWID;`,
+		RollupTableName(tableName, tier),
+		ColumnUnixSeconds, ColumnMetricName, ColumnMetricSecondaryName, ColumnRollupMin, ColumnRollupMax, ColumnRollupSum, ColumnRollupCount, // columns
+		ColumnUnixSeconds, ColumnMetricName, ColumnMetricSecondaryName, // primary keys
+	))
+	return err
+}
+
+// SelectTier returns the finest rollup tier that still covers the time range
+// from "since" to "now", or false if the raw metrics cover the range
+// (or no rollup is enabled for the table).
+// The zero "since" reads the raw metrics, not to drop the recent samples
+// that are not yet rolled up.
+func SelectTier(tableName string, since time.Time, now time.Time) (Tier, bool) {
+	cfg, ok := getRollupConfig(tableName)
+	if !ok || len(cfg.Tiers) == 0 || since.IsZero() {
+		return Tier{}, false
+	}
+	if !since.Before(now.Add(-cfg.RawRetention)) {
+		return Tier{}, false
+	}
+	for _, tier := range cfg.Tiers {
+		if !since.Before(now.Add(-tier.Retention)) {
+			return tier, true
+		}
+	}
+	return cfg.Tiers[len(cfg.Tiers)-1], true
+}
+
+// ComputeRollups aggregates the complete time buckets of each tier until "now",
+// resuming from the latest bucket of each tier.
+func ComputeRollups(ctx context.Context, db *sql.DB, tableName string, now time.Time) error {
+	cfg, ok := getRollupConfig(tableName)
+	if !ok {
+		return fmt.Errorf("rollups not enabled for %s", tableName)
+	}
+
+	srcTable, fromRaw := tableName, true
+	for _, tier := range cfg.Tiers {
+		dstTable := RollupTableName(tableName, tier)
+		res := int64(tier.Resolution / time.Second)
+
+		// the latest bucket is re-computed in case it was written while incomplete
+		var from sql.NullInt64
+		if err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT MAX(%s) FROM %s;`, ColumnUnixSeconds, dstTable)).Scan(&from); err != nil {
+			return err
+		}
+		to := now.Unix() / res * res
+
+		var aggregate string
+		if fromRaw {
+			aggregate = fmt.Sprintf("MIN(%s), MAX(%s), SUM(%s), COUNT(*)",
+				ColumnMetricValue, ColumnMetricValue, ColumnMetricValue)
+		} else {
+			aggregate = fmt.Sprintf("MIN(%s), MAX(%s), SUM(%s), SUM(%s)",
+				ColumnRollupMin, ColumnRollupMax, ColumnRollupSum, ColumnRollupCount)
+		}
+		query := fmt.Sprintf(`
+INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s, %s, %s)
+SELECT (%s / ?) * ?, %s, %s, %s
+FROM %s
+WHERE %s >= ? AND %s < ?
+GROUP BY 1, %s, %s;`,
+			dstTable,
+			ColumnUnixSeconds, ColumnMetricName, ColumnMetricSecondaryName, ColumnRollupMin, ColumnRollupMax, ColumnRollupSum, ColumnRollupCount,
+			ColumnUnixSeconds, ColumnMetricName, ColumnMetricSecondaryName, aggregate,
+			srcTable,
+			ColumnUnixSeconds, ColumnUnixSeconds,
+			ColumnMetricName, ColumnMetricSecondaryName,
+		)
+
+		start := time.Now()
+		_, err := db.ExecContext(ctx, query, res, res, from.Int64, to)
+		sqlite.RecordInsertUpdate(time.Since(start).Seconds())
+		if err != nil {
+			return fmt.Errorf("failed to compute %s rollups: %w", tier.Name, err)
+		}
+
+		srcTable, fromRaw = dstTable, false
+	}
+	return nil
+}
+
+// PurgeRollups deletes the buckets older than the retention of each tier.
+func PurgeRollups(ctx context.Context, db *sql.DB, tableName string, now time.Time) (int, error) {
+	cfg, ok := getRollupConfig(tableName)
+	if !ok {
+		return 0, nil
+	}
+	total := 0
+	for _, tier := range cfg.Tiers {
+		purged, err := PurgeMetrics(ctx, db, RollupTableName(tableName, tier), now.Add(-tier.Retention))
+		if err != nil {
+			return total, err
+		}
+		total += purged
+	}
+	return total, nil
+}
+
+// RunRollups computes and purges the rollups every interval
+// until the context is canceled.
+func RunRollups(ctx context.Context, db *sql.DB, tableName string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now().UTC()
+		if err := ComputeRollups(ctx, db, tableName, now); err != nil {
+			log.Logger.Warnw("failed to compute metrics rollups", "table", tableName, "error", err)
+			continue
+		}
+		purged, err := PurgeRollups(ctx, db, tableName, now)
+		if err != nil {
+			log.Logger.Warnw("failed to purge metrics rollups", "table", tableName, "error", err)
+			continue
+		}
+		log.Logger.Debugw("computed metrics rollups", "table", tableName, "purged", purged)
+	}
+}
+
+// ReadRollupsSince reads the rollups of the tier in the ascending order of time.
+// The bucket containing "since" is included.
+// If the secondary name is empty, the rollups of all the secondary names are returned.
+func ReadRollupsSince(ctx context.Context, db *sql.DB, tableName string, tier Tier, name string, secondaryName string, since time.Time) ([]Rollup, error) {
+	res := int64(tier.Resolution / time.Second)
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s, %s, %s, %s
+FROM %s
+WHERE %s >= ? AND %s = ?`,
+		ColumnUnixSeconds, ColumnMetricSecondaryName, ColumnRollupMin, ColumnRollupMax, ColumnRollupSum, ColumnRollupCount,
+		RollupTableName(tableName, tier),
+		ColumnUnixSeconds, ColumnMetricName,
+	)
+	params := []any{since.Unix() / res * res, name}
+	if secondaryName != "" {
+		query += fmt.Sprintf(" AND %s = ?", ColumnMetricSecondaryName)
+		params = append(params, secondaryName)
+	}
+	query += fmt.Sprintf("\nORDER BY %s ASC;", ColumnUnixSeconds)
+
+	start := time.Now()
+	defer func() {
+		sqlite.RecordSelect(time.Since(start).Seconds())
+	}()
+
+	queryRows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer queryRows.Close()
+
+	rows := make([]Rollup, 0)
+	for queryRows.Next() {
+		r := Rollup{MetricName: name}
+		if err := queryRows.Scan(&r.UnixSeconds, &r.MetricSecondaryName, &r.Min, &r.Max, &r.Sum, &r.Count); err != nil {
+			return nil, err
+		}
+		if r.Count > 0 {
+			r.Avg = r.Sum / float64(r.Count)
+		}
+		rows = append(rows, r)
+	}
+	if err := queryRows.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// WalkRollups calls "fn" for each rollup of the tier in the ascending order of time,
+// without loading the whole table into memory.
+// No-op if the rollup table does not exist (e.g., the rollups were never enabled).
+func WalkRollups(ctx context.Context, db *sql.DB, tableName string, tier Tier, fn func(Rollup) error) error {
+	rollupTable := RollupTableName(tableName, tier)
+
+	var found int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;`, rollupTable).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s, %s, %s, %s, %s
+FROM %s
+ORDER BY %s ASC;`,
+		ColumnUnixSeconds, ColumnMetricName, ColumnMetricSecondaryName, ColumnRollupMin, ColumnRollupMax, ColumnRollupSum, ColumnRollupCount,
+		rollupTable,
+		ColumnUnixSeconds,
+	)
+
+	start := time.Now()
+	defer func() {
+		sqlite.RecordSelect(time.Since(start).Seconds())
+	}()
+
+	queryRows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer queryRows.Close()
+
+	for queryRows.Next() {
+		var r Rollup
+		if err := queryRows.Scan(&r.UnixSeconds, &r.MetricName, &r.MetricSecondaryName, &r.Min, &r.Max, &r.Sum, &r.Count); err != nil {
+			return err
+		}
+		if r.Count > 0 {
+			r.Avg = r.Sum / float64(r.Count)
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return queryRows.Err()
+}
+
+// InsertRollup inserts or replaces the rollup of the tier.
+// The rollup table must be created first (see "CreateTableRollup").
+func InsertRollup(ctx context.Context, db *sql.DB, tableName string, tier Tier, r Rollup) error {
+	query := fmt.Sprintf(`
+INSERT OR REPLACE INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?);`,
+		RollupTableName(tableName, tier),
+		ColumnUnixSeconds, ColumnMetricName, ColumnMetricSecondaryName, ColumnRollupMin, ColumnRollupMax, ColumnRollupSum, ColumnRollupCount,
+	)
+
+	start := time.Now()
+	_, err := db.ExecContext(ctx, query, r.UnixSeconds, r.MetricName, r.MetricSecondaryName, r.Min, r.Max, r.Sum, r.Count)
+	sqlite.RecordInsertUpdate(time.Since(start).Seconds())
+	return err
+}
+
+// ErrUnsupportedRollupAggregate is returned when aggregating the time range
+// served from the rollups with the function that needs the individual values
+// (e.g., the percentiles), which the rollups do not keep.
+var ErrUnsupportedRollupAggregate = errors.New("only avg, min, and max are supported for the time range older than the raw metrics retention")
+
+// aggregateTier aggregates the rollups of the tier in SQL into the steps of the query,
+// from the min, max, sum, and count of each bucket.
+// The bucket containing the start is included in the first step.
+func aggregateTier(ctx context.Context, db *sql.DB, tableName string, tier Tier, q AggregateQuery) ([]Series, error) {
+	var value string
+	switch q.Func {
+	case AggregateAvg:
+		value = fmt.Sprintf("SUM(%s) / SUM(%s)", ColumnRollupSum, ColumnRollupCount)
+	case AggregateMin:
+		value = fmt.Sprintf("MIN(%s)", ColumnRollupMin)
+	case AggregateMax:
+		value = fmt.Sprintf("MAX(%s)", ColumnRollupMax)
+	default:
+		return nil, fmt.Errorf("%w (got %q from the %s rollups)", ErrUnsupportedRollupAggregate, q.Func, tier.Name)
+	}
+
+	res := int64(tier.Resolution / time.Second)
+	start, end := q.Start.Unix(), q.End.Unix()
+	step := int64(q.Step / time.Second)
+
+	query := fmt.Sprintf(`
+SELECT ? + ((MAX(%s, ?) - ?) / ?) * ? AS step_start, %s, %s
+FROM %s
+WHERE %s = ? AND %s >= ? AND %s < ?`,
+		ColumnUnixSeconds, ColumnMetricSecondaryName, value,
+		RollupTableName(tableName, tier),
+		ColumnMetricName, ColumnUnixSeconds, ColumnUnixSeconds,
+	)
+	params := []any{start, start, start, step, step, q.MetricName, start / res * res, end}
+	if q.MetricSecondaryName != "" {
+		query += fmt.Sprintf(" AND %s = ?", ColumnMetricSecondaryName)
+		params = append(params, q.MetricSecondaryName)
+	}
+	query += fmt.Sprintf("\nGROUP BY step_start, %s\nORDER BY %s ASC, step_start ASC;", ColumnMetricSecondaryName, ColumnMetricSecondaryName)
+
+	queryStart := time.Now()
+	defer func() {
+		sqlite.RecordSelect(time.Since(queryStart).Seconds())
+	}()
+
+	queryRows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer queryRows.Close()
+
+	series := make([]Series, 0)
+	for queryRows.Next() {
+		m := Metric{MetricName: q.MetricName}
+		var v sql.NullFloat64
+		if err := queryRows.Scan(&m.UnixSeconds, &m.MetricSecondaryName, &v); err != nil {
+			return nil, err
+		}
+		if !v.Valid {
+			continue
+		}
+		m.Value = v.Float64
+
+		if len(series) == 0 || series[len(series)-1].MetricSecondaryName != m.MetricSecondaryName {
+			series = append(series, Series{
+				MetricName:          q.MetricName,
+				MetricSecondaryName: m.MetricSecondaryName,
+				Metrics:             make(Metrics, 0),
+			})
+		}
+		s := &series[len(series)-1]
+		s.Metrics = append(s.Metrics, m)
+	}
+	if err := queryRows.Err(); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// readTierMetricsSince reads the bucket averages of the tier as the metrics.
+func readTierMetricsSince(ctx context.Context, db *sql.DB, tableName string, tier Tier, name string, secondaryName string, since time.Time) (Metrics, error) {
+	rollups, err := ReadRollupsSince(ctx, db, tableName, tier, name, secondaryName, since)
+	if err != nil {
+		return nil, err
+	}
+	rows := make(Metrics, 0, len(rollups))
+	for _, r := range rollups {
+		rows = append(rows, Metric{
+			UnixSeconds:         r.UnixSeconds,
+			MetricName:          r.MetricName,
+			MetricSecondaryName: r.MetricSecondaryName,
+			Value:               r.Avg,
+		})
+	}
+	return rows, nil
+}
+
+// avgTierSince computes the average weighted by the bucket counts.
+func avgTierSince(ctx context.Context, db *sql.DB, tableName string, tier Tier, name string, secondaryName string, since time.Time) (float64, error) {
+	res := int64(tier.Resolution / time.Second)
+	query := fmt.Sprintf(`
+SELECT SUM(%s) / SUM(%s)
+FROM %s
+WHERE %s = ? AND %s = ? AND %s >= ?;`,
+		ColumnRollupSum, ColumnRollupCount,
+		RollupTableName(tableName, tier),
+		ColumnMetricName, ColumnMetricSecondaryName, ColumnUnixSeconds,
+	)
+
+	start := time.Now()
+	var avg sql.NullFloat64
+	err := db.QueryRowContext(ctx, query, name, secondaryName, since.Unix()/res*res).Scan(&avg)
+	sqlite.RecordSelect(time.Since(start).Seconds())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0.0, nil
+		}
+		return 0.0, err
+	}
+	if !avg.Valid {
+		return 0.0, nil
+	}
+	return avg.Float64, nil
+}
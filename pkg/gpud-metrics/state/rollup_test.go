@@ -0,0 +1,207 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+func TestRollups(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := sqlite.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tableName := "test_metrics_rollups"
+	if err := CreateTableMetrics(ctx, db, tableName); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	cfg := RollupConfig{
+		RawRetention: time.Hour,
+		Tiers: []Tier{
+			{Name: "1m", Resolution: time.Minute, Retention: 24 * time.Hour},
+			{Name: "1h", Resolution: time.Hour, Retention: 30 * 24 * time.Hour},
+		},
+	}
+	if err := EnableRollups(ctx, db, tableName, cfg); err != nil {
+		t.Fatalf("failed to enable rollups: %v", err)
+	}
+
+	// one data point every 10 seconds for 2 hours, for two GPUs
+	now := time.Now().UTC()
+	end := now.Truncate(time.Hour)
+	start := end.Add(-2 * time.Hour)
+	for ts := start; ts.Before(end); ts = ts.Add(10 * time.Second) {
+		minute := float64(ts.Sub(start) / time.Minute)
+		for _, gpu := range []string{"gpu0", "gpu1"} {
+			v := minute
+			if gpu == "gpu1" {
+				v += 100
+			}
+			if err := InsertMetric(ctx, db, tableName, Metric{UnixSeconds: ts.Unix(), MetricName: "temperature", MetricSecondaryName: gpu, Value: v}); err != nil {
+				t.Fatalf("failed to insert metric: %v", err)
+			}
+		}
+	}
+
+	if err := ComputeRollups(ctx, db, tableName, now); err != nil {
+		t.Fatalf("failed to compute rollups: %v", err)
+	}
+	// idempotent
+	if err := ComputeRollups(ctx, db, tableName, now); err != nil {
+		t.Fatalf("failed to compute rollups: %v", err)
+	}
+
+	minutes, err := ReadRollupsSince(ctx, db, tableName, cfg.Tiers[0], "temperature", "gpu0", start)
+	if err != nil {
+		t.Fatalf("failed to read rollups: %v", err)
+	}
+	if len(minutes) != 120 {
+		t.Fatalf("expected 120 minute rollups, got %d", len(minutes))
+	}
+	for i, r := range minutes {
+		if r.UnixSeconds != start.Add(time.Duration(i)*time.Minute).Unix() {
+			t.Fatalf("unexpected bucket %d time %d", i, r.UnixSeconds)
+		}
+		if r.Count != 6 || r.Min != float64(i) || r.Max != float64(i) || r.Avg != float64(i) {
+			t.Fatalf("unexpected bucket %d: %+v", i, r)
+		}
+	}
+
+	hours, err := ReadRollupsSince(ctx, db, tableName, cfg.Tiers[1], "temperature", "", start)
+	if err != nil {
+		t.Fatalf("failed to read rollups: %v", err)
+	}
+	if len(hours) != 4 {
+		t.Fatalf("expected 2 hour rollups for 2 GPUs, got %d", len(hours))
+	}
+	for _, r := range hours {
+		if r.Count != 360 || r.Max-r.Min != 59 {
+			t.Fatalf("unexpected hour rollup: %+v", r)
+		}
+	}
+	if hours[0].MetricSecondaryName == "gpu0" && hours[0].Avg != 29.5 {
+		t.Fatalf("unexpected hour average: %+v", hours[0])
+	}
+
+	// the raw metrics cover the last hour
+	if _, ok := SelectTier(tableName, now.Add(-30*time.Minute), now); ok {
+		t.Fatal("expected raw metrics for the last 30 minutes")
+	}
+	if tier, ok := SelectTier(tableName, now.Add(-3*time.Hour), now); !ok || tier.Name != "1m" {
+		t.Fatalf("expected 1m tier for the last 3 hours, got %+v", tier)
+	}
+	if tier, ok := SelectTier(tableName, now.Add(-7*24*time.Hour), now); !ok || tier.Name != "1h" {
+		t.Fatalf("expected 1h tier for the last week, got %+v", tier)
+	}
+	if tier, ok := SelectTier(tableName, time.Time{}, now); ok {
+		t.Fatalf("expected raw metrics for the zero since, got %+v", tier)
+	}
+	if _, ok := SelectTier("unknown", now.Add(-7*24*time.Hour), now); ok {
+		t.Fatal("expected raw metrics without rollups")
+	}
+
+	// served from the 1m tier
+	since := now.Add(-3 * time.Hour)
+	ms, err := ReadMetricsSince(ctx, db, tableName, "temperature", "gpu1", since)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	if len(ms) != 120 || ms[0].Value != 100 || ms[0].UnixSeconds != start.Unix() {
+		t.Fatalf("unexpected tier metrics: %d %+v", len(ms), ms[0])
+	}
+	avg, err := AvgSince(ctx, db, tableName, "temperature", "gpu0", since)
+	if err != nil {
+		t.Fatalf("failed to compute average: %v", err)
+	}
+	if math.Abs(avg-59.5) > 1e-9 {
+		t.Fatalf("expected average 59.5, got %f", avg)
+	}
+
+	// the zero since reads the raw metrics, including the samples not yet rolled up
+	recent := now.Add(-time.Second)
+	if err := InsertMetric(ctx, db, tableName, Metric{UnixSeconds: recent.Unix(), MetricName: "temperature", MetricSecondaryName: "gpu2", Value: 7}); err != nil {
+		t.Fatalf("failed to insert metric: %v", err)
+	}
+	ms, err = ReadMetricsSince(ctx, db, tableName, "temperature", "gpu2", time.Time{})
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	if len(ms) != 1 || ms[0].Value != 7 || ms[0].UnixSeconds != recent.Unix() {
+		t.Fatalf("unexpected raw metrics for the zero since: %+v", ms)
+	}
+	avg, err = AvgSince(ctx, db, tableName, "temperature", "gpu2", time.Time{})
+	if err != nil {
+		t.Fatalf("failed to compute average: %v", err)
+	}
+	if avg != 7 {
+		t.Fatalf("expected average 7 for the zero since, got %f", avg)
+	}
+
+	// aggregated from the 1m tier, bounded by the end
+	store := NewSQLiteStore(db, db, tableName)
+	series, err := store.Aggregate(ctx, AggregateQuery{MetricName: "temperature", Start: start, End: start.Add(time.Hour), Step: time.Hour, Func: AggregateAvg})
+	if err != nil {
+		t.Fatalf("failed to aggregate: %v", err)
+	}
+	if len(series) != 2 || series[0].MetricSecondaryName != "gpu0" || len(series[0].Metrics) != 1 {
+		t.Fatalf("unexpected series: %+v", series)
+	}
+	if m := series[0].Metrics[0]; m.UnixSeconds != start.Unix() || math.Abs(m.Value-29.5) > 1e-9 {
+		t.Fatalf("unexpected average: %+v", m)
+	}
+	if _, err := store.Aggregate(ctx, AggregateQuery{MetricName: "temperature", Start: start, End: end, Step: time.Hour, Func: AggregateP95}); !errors.Is(err, ErrUnsupportedRollupAggregate) {
+		t.Fatalf("expected unsupported aggregate, got %v", err)
+	}
+
+	// the min and max of the buckets, not of the bucket averages
+	if err := InsertRollup(ctx, db, tableName, cfg.Tiers[0], Rollup{UnixSeconds: start.Unix(), MetricName: "power", Min: 1, Max: 9, Sum: 10, Count: 2}); err != nil {
+		t.Fatalf("failed to insert rollup: %v", err)
+	}
+	for fn, expected := range map[AggregateFunc]float64{AggregateMin: 1, AggregateMax: 9, AggregateAvg: 5} {
+		series, err := store.Aggregate(ctx, AggregateQuery{MetricName: "power", Start: start, End: end, Step: time.Hour, Func: fn})
+		if err != nil {
+			t.Fatalf("failed to aggregate: %v", err)
+		}
+		if len(series) != 1 || len(series[0].Metrics) != 1 || series[0].Metrics[0].Value != expected {
+			t.Fatalf("unexpected %s series: %+v", fn, series)
+		}
+	}
+
+	purged, err := PurgeRollups(ctx, db, tableName, end.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("failed to purge rollups: %v", err)
+	}
+	if purged != 241 {
+		t.Fatalf("expected 241 minute rollups purged, got %d", purged)
+	}
+}
+
+func TestRollupConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := (RollupConfig{Tiers: DefaultRollupTiers}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	invalid := []RollupConfig{
+		{Tiers: []Tier{{Resolution: time.Minute, Retention: time.Hour}}},
+		{Tiers: []Tier{{Name: "1ms", Resolution: time.Millisecond, Retention: time.Hour}}},
+		{Tiers: []Tier{{Name: "1m", Resolution: time.Minute, Retention: time.Hour}, {Name: "90s", Resolution: 90 * time.Second, Retention: time.Hour}}},
+		{Tiers: []Tier{{Name: "1h", Resolution: time.Hour, Retention: time.Minute}}},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
@@ -44,6 +44,15 @@ CREATE TABLE IF NOT EXISTS %s (
 		ColumnUnixSeconds, ColumnMetricName, ColumnMetricSecondaryName, ColumnMetricValue, // columns
 		ColumnUnixSeconds, ColumnMetricName, ColumnMetricSecondaryName, // primary keys
 	))
+	if err != nil {
+		return err
+	}
+
+	// the primary key leads with the time, while the reads filter by the name first
+	// (added to the existing tables by the state migration)
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_%s_%s ON %s(%s, %s);`,
+		tableName, ColumnMetricName, ColumnUnixSeconds,
+		tableName, ColumnMetricName, ColumnUnixSeconds))
 	return err
 }
 
@@ -141,7 +150,12 @@ LIMIT 1;
 }
 
 // Returns nil if no record is found ("database/sql.ErrNoRows").
+// If the rollups are enabled for the table and the raw metrics do not cover
+// the time range, returns the bucket averages of the finest tier that does.
 func ReadMetricsSince(ctx context.Context, db *sql.DB, tableName string, name string, secondaryName string, since time.Time) (Metrics, error) {
+	if tier, ok := SelectTier(tableName, since, time.Now()); ok {
+		return readTierMetricsSince(ctx, db, tableName, tier, name, secondaryName, since)
+	}
 	if secondaryName == "" {
 		return readSinceWithAllSecondaryNames(ctx, db, tableName, name, since)
 	}
@@ -176,12 +190,11 @@ ORDER BY %s ASC;`,
 
 	rows := make(Metrics, 0)
 	for queryRows.Next() {
-		var unixSeconds int64
 		metric := Metric{
 			MetricName:          name,
 			MetricSecondaryName: secondaryName,
 		}
-		if err := queryRows.Scan(&unixSeconds, &metric.Value); err != nil {
+		if err := queryRows.Scan(&metric.UnixSeconds, &metric.Value); err != nil {
 			return nil, err
 		}
 		rows = append(rows, metric)
@@ -240,7 +253,13 @@ ORDER BY %s ASC;`,
 // Computes the average of the last metrics.
 // If the since is zero, all metrics are used.
 // Returns zero if no record is found ("database/sql.ErrNoRows").
+// Computed from the rollups if the raw metrics do not cover the time range
+// (see "ReadMetricsSince").
 func AvgSince(ctx context.Context, db *sql.DB, tableName string, name string, secondaryName string, since time.Time) (float64, error) {
+	if tier, ok := SelectTier(tableName, since, time.Now()); ok {
+		return avgTierSince(ctx, db, tableName, tier, name, secondaryName, since)
+	}
+
 	query := fmt.Sprintf(`
 SELECT AVG(%s)
 FROM %s
@@ -349,6 +368,60 @@ func EMASince(ctx context.Context, db *sql.DB, tableName string, name string, se
 	return ema.Float64, nil
 }
 
+// ReadAllMetrics reads all the metrics of the table
+// in the ascending order of time.
+func ReadAllMetrics(ctx context.Context, db *sql.DB, tableName string) (Metrics, error) {
+	rows := make(Metrics, 0)
+	if err := WalkMetrics(ctx, db, tableName, func(m Metric) error {
+		rows = append(rows, m)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// WalkMetrics calls the function for each metric of the table
+// in the ascending order of time, without reading all into memory.
+// Stops at the first error returned by the function.
+func WalkMetrics(ctx context.Context, db *sql.DB, tableName string, fn func(Metric) error) error {
+	query := fmt.Sprintf(`
+SELECT %s, %s, %s, %s
+FROM %s
+ORDER BY %s ASC;`,
+		ColumnUnixSeconds,
+		ColumnMetricName,
+		ColumnMetricSecondaryName,
+		ColumnMetricValue,
+		tableName,
+		ColumnUnixSeconds,
+	)
+
+	start := time.Now()
+	defer func() {
+		sqlite.RecordSelect(time.Since(start).Seconds())
+	}()
+
+	queryRows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer queryRows.Close()
+
+	for queryRows.Next() {
+		var metric Metric
+		var secondaryName sql.NullString
+		if err := queryRows.Scan(&metric.UnixSeconds, &metric.MetricName, &secondaryName, &metric.Value); err != nil {
+			return err
+		}
+		metric.MetricSecondaryName = secondaryName.String
+		if err := fn(metric); err != nil {
+			return err
+		}
+	}
+	return queryRows.Err()
+}
+
 func PurgeMetrics(ctx context.Context, db *sql.DB, tableName string, before time.Time) (int, error) {
 	query := fmt.Sprintf(`
 DELETE FROM %s WHERE %s < ?;`, tableName, ColumnUnixSeconds)
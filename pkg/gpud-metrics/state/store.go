@@ -0,0 +1,77 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Store is the storage backend of a metrics table.
+type Store interface {
+	// Insert inserts the metric, replacing the one
+	// with the same timestamp, name, and secondary name.
+	Insert(ctx context.Context, m Metric) error
+	// ReadLast reads the last metric, returns nil if not found.
+	// If the secondary name is empty, reads the last of all the secondary names.
+	ReadLast(ctx context.Context, name string, secondaryName string) (*Metric, error)
+	// ReadSince reads the metrics since the time in the ascending order of time.
+	// If the secondary name is empty, reads the metrics of all the secondary names.
+	ReadSince(ctx context.Context, name string, secondaryName string, since time.Time) (Metrics, error)
+	// AvgSince computes the average since the time (zero for all).
+	AvgSince(ctx context.Context, name string, secondaryName string, since time.Time) (float64, error)
+	// Aggregate aggregates the metrics in the time range of the query into the steps.
+	Aggregate(ctx context.Context, q AggregateQuery) ([]Series, error)
+	// EMASince computes the exponential moving average since the time.
+	EMASince(ctx context.Context, name string, secondaryName string, period time.Duration, since time.Time) (float64, error)
+	// Walk calls the function for each metric of all the names
+	// in the ascending order of time (e.g., to export the metrics).
+	// Stops at the first error returned by the function.
+	Walk(ctx context.Context, fn func(Metric) error) error
+	// Purge deletes the metrics before the time.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+var _ Store = &sqliteStore{}
+
+type sqliteStore struct {
+	dbRW      *sql.DB
+	dbRO      *sql.DB
+	tableName string
+}
+
+// NewSQLiteStore returns the store backed by the sqlite metrics table.
+func NewSQLiteStore(dbRW *sql.DB, dbRO *sql.DB, tableName string) Store {
+	return &sqliteStore{dbRW: dbRW, dbRO: dbRO, tableName: tableName}
+}
+
+func (s *sqliteStore) Insert(ctx context.Context, m Metric) error {
+	return InsertMetric(ctx, s.dbRW, s.tableName, m)
+}
+
+func (s *sqliteStore) ReadLast(ctx context.Context, name string, secondaryName string) (*Metric, error) {
+	return ReadLastMetric(ctx, s.dbRO, s.tableName, name, secondaryName)
+}
+
+func (s *sqliteStore) ReadSince(ctx context.Context, name string, secondaryName string, since time.Time) (Metrics, error) {
+	return ReadMetricsSince(ctx, s.dbRO, s.tableName, name, secondaryName, since)
+}
+
+func (s *sqliteStore) AvgSince(ctx context.Context, name string, secondaryName string, since time.Time) (float64, error) {
+	return AvgSince(ctx, s.dbRO, s.tableName, name, secondaryName, since)
+}
+
+func (s *sqliteStore) Aggregate(ctx context.Context, q AggregateQuery) ([]Series, error) {
+	return AggregateTable(ctx, s.dbRO, s.tableName, q)
+}
+
+func (s *sqliteStore) EMASince(ctx context.Context, name string, secondaryName string, period time.Duration, since time.Time) (float64, error) {
+	return EMASince(ctx, s.dbRO, s.tableName, name, secondaryName, period, since)
+}
+
+func (s *sqliteStore) Walk(ctx context.Context, fn func(Metric) error) error {
+	return WalkMetrics(ctx, s.dbRO, s.tableName, fn)
+}
+
+func (s *sqliteStore) Purge(ctx context.Context, before time.Time) (int, error) {
+	return PurgeMetrics(ctx, s.dbRW, s.tableName, before)
+}
@@ -0,0 +1,429 @@
+package gpudstate
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/version"
+)
+
+// BundleVersion is the version of the bundle format,
+// to be bumped on the incompatible changes.
+const BundleVersion = "v1"
+
+const (
+	bundleManifestFile = "manifest.json"
+	bundleEventsDir    = "events"
+	bundleMetricsFile  = "metrics.jsonl"
+	bundleRollupsDir   = "rollups"
+
+	// the number of the events to write at once on import
+	bundleImportBatchSize = 1000
+)
+
+// BundleManifest describes the state bundle.
+// The bundle is a gzipped tar archive of the manifest, followed by
+// one JSON-lines file per event table ("events/<table>.jsonl"),
+// the metrics ("metrics.jsonl"), and one JSON-lines file per
+// metrics rollup tier ("rollups/<tier>.jsonl").
+type BundleManifest struct {
+	Version     string    `json:"version"`
+	GPUdVersion string    `json:"gpud_version"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// MachineID and Components are from the machine metadata.
+	// The login token is never exported.
+	MachineID  string `json:"machine_id,omitempty"`
+	Components string `json:"components,omitempty"`
+
+	// EventTables is the number of the events per event table.
+	EventTables map[string]int `json:"event_tables,omitempty"`
+	// Metrics is the number of the metrics data points.
+	Metrics int `json:"metrics"`
+	// Rollups is the number of the metrics rollups per tier.
+	Rollups map[string]int `json:"rollups,omitempty"`
+}
+
+// ExportBundle writes the events of all the event tables, the metrics in the store,
+// the metrics rollups and the machine metadata in the state database as a bundle.
+//
+// Each table is streamed into a temporary file first, since the tar header
+// requires the file size, and the manifest with the counts goes first.
+// Thus, the tables are never held in memory.
+func ExportBundle(ctx context.Context, dbRO *sql.DB, metricsStore metrics_state.Store, w io.Writer) (BundleManifest, error) {
+	manifest := BundleManifest{
+		Version:     BundleVersion,
+		GPUdVersion: version.Version,
+		CreatedAt:   time.Now().UTC(),
+		EventTables: make(map[string]int),
+		Rollups:     make(map[string]int),
+	}
+
+	machineID, comps, err := readMachineMetadata(ctx, dbRO)
+	if err != nil {
+		return BundleManifest{}, fmt.Errorf("failed to read machine metadata: %w", err)
+	}
+	manifest.MachineID = machineID
+	manifest.Components = comps
+
+	spool, err := newBundleSpool()
+	if err != nil {
+		return BundleManifest{}, err
+	}
+	defer spool.remove()
+
+	tables, err := eventstore.ListTables(ctx, dbRO)
+	if err != nil {
+		return BundleManifest{}, fmt.Errorf("failed to list event tables: %w", err)
+	}
+	for _, table := range tables {
+		if err := spool.write(path.Join(bundleEventsDir, table+".jsonl"), func(enc *json.Encoder) error {
+			return eventstore.WalkTable(ctx, dbRO, table, func(ev components.Event) error {
+				manifest.EventTables[table]++
+				return enc.Encode(ev)
+			})
+		}); err != nil {
+			return BundleManifest{}, fmt.Errorf("failed to read events from %q: %w", table, err)
+		}
+	}
+
+	if err := spool.write(bundleMetricsFile, func(enc *json.Encoder) error {
+		return metricsStore.Walk(ctx, func(m metrics_state.Metric) error {
+			manifest.Metrics++
+			return enc.Encode(m)
+		})
+	}); err != nil {
+		return BundleManifest{}, fmt.Errorf("failed to read metrics: %w", err)
+	}
+
+	for _, tier := range metrics_state.DefaultRollupTiers {
+		if err := spool.write(path.Join(bundleRollupsDir, tier.Name+".jsonl"), func(enc *json.Encoder) error {
+			return metrics_state.WalkRollups(ctx, dbRO, metrics_state.DefaultTableName, tier, func(r metrics_state.Rollup) error {
+				manifest.Rollups[tier.Name]++
+				return enc.Encode(r)
+			})
+		}); err != nil {
+			return BundleManifest{}, fmt.Errorf("failed to read %s metrics rollups: %w", tier.Name, err)
+		}
+	}
+
+	manifestB, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return BundleManifest{}, err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	// manifest first, to check the version before reading the rest
+	if err := writeTarFile(tw, bundleManifestFile, manifestB, manifest.CreatedAt); err != nil {
+		return BundleManifest{}, err
+	}
+	if err := spool.copyTo(tw, manifest.CreatedAt); err != nil {
+		return BundleManifest{}, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return BundleManifest{}, err
+	}
+	if err := gw.Close(); err != nil {
+		return BundleManifest{}, err
+	}
+	return manifest, nil
+}
+
+// BundleImport is the result of importing a bundle.
+type BundleImport struct {
+	Manifest BundleManifest
+	// EventsInserted is the number of the events inserted,
+	// excluding the ones that already exist.
+	EventsInserted int
+	// MetricsInserted is the number of the metrics data points inserted or replaced.
+	MetricsInserted int
+	// RollupsInserted is the number of the metrics rollups inserted or replaced.
+	RollupsInserted int
+}
+
+// ImportBundle loads the bundle into the state database,
+// and the metrics into the store.
+// The metrics rollups are loaded into the state database.
+// The events that already exist are skipped, so importing
+// the same bundle twice is a no-op.
+// The machine metadata is only imported if the database has no machine ID,
+// so the state of a live node is never overwritten.
+func ImportBundle(ctx context.Context, dbRW *sql.DB, dbRO *sql.DB, metricsStore metrics_state.Store, r io.Reader) (BundleImport, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return BundleImport{}, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return BundleImport{}, fmt.Errorf("failed to read bundle manifest: %w", err)
+	}
+	if hdr.Name != bundleManifestFile {
+		return BundleImport{}, fmt.Errorf("expected %q first in the bundle, got %q", bundleManifestFile, hdr.Name)
+	}
+	var ret BundleImport
+	if err := json.NewDecoder(tr).Decode(&ret.Manifest); err != nil {
+		return BundleImport{}, fmt.Errorf("failed to decode bundle manifest: %w", err)
+	}
+	if ret.Manifest.Version != BundleVersion {
+		return BundleImport{}, fmt.Errorf("unsupported bundle version %q (expected %q)", ret.Manifest.Version, BundleVersion)
+	}
+
+	if err := CreateTableMachineMetadata(ctx, dbRW); err != nil {
+		return BundleImport{}, err
+	}
+	if err := importMachineMetadata(ctx, dbRW, dbRO, ret.Manifest); err != nil {
+		return BundleImport{}, fmt.Errorf("failed to import machine metadata: %w", err)
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ret, fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		switch {
+		case hdr.Name == bundleMetricsFile:
+			dec := json.NewDecoder(tr)
+			for dec.More() {
+				var m metrics_state.Metric
+				if err := dec.Decode(&m); err != nil {
+					return ret, fmt.Errorf("failed to decode metric: %w", err)
+				}
+				if err := metricsStore.Insert(ctx, m); err != nil {
+					return ret, err
+				}
+				ret.MetricsInserted++
+			}
+
+		case path.Dir(hdr.Name) == bundleEventsDir && strings.HasSuffix(hdr.Name, ".jsonl"):
+			table := strings.TrimSuffix(path.Base(hdr.Name), ".jsonl")
+			inserted, err := importEvents(ctx, dbRW, dbRO, table, json.NewDecoder(tr))
+			ret.EventsInserted += inserted
+			if err != nil {
+				return ret, err
+			}
+
+		case path.Dir(hdr.Name) == bundleRollupsDir && strings.HasSuffix(hdr.Name, ".jsonl"):
+			tierName := strings.TrimSuffix(path.Base(hdr.Name), ".jsonl")
+			tier, ok := findRollupTier(tierName)
+			if !ok {
+				log.Logger.Warnw("skipping unknown metrics rollup tier in the bundle", "file", hdr.Name)
+				continue
+			}
+			if err := metrics_state.CreateTableRollup(ctx, dbRW, metrics_state.DefaultTableName, tier); err != nil {
+				return ret, err
+			}
+			dec := json.NewDecoder(tr)
+			for dec.More() {
+				var r metrics_state.Rollup
+				if err := dec.Decode(&r); err != nil {
+					return ret, fmt.Errorf("failed to decode %s metrics rollup: %w", tier.Name, err)
+				}
+				if err := metrics_state.InsertRollup(ctx, dbRW, metrics_state.DefaultTableName, tier, r); err != nil {
+					return ret, err
+				}
+				ret.RollupsInserted++
+			}
+
+		default:
+			log.Logger.Warnw("skipping unknown file in the bundle", "file", hdr.Name)
+		}
+	}
+	return ret, nil
+}
+
+// importEvents writes the events in batches, so that the table is never held in memory.
+func importEvents(ctx context.Context, dbRW *sql.DB, dbRO *sql.DB, table string, dec *json.Decoder) (int, error) {
+	total := 0
+	evs := make([]components.Event, 0, bundleImportBatchSize)
+	flush := func() error {
+		inserted, err := eventstore.WriteTable(ctx, dbRW, dbRO, table, evs)
+		total += inserted
+		evs = evs[:0]
+		if err != nil {
+			return fmt.Errorf("failed to write events to %q: %w", table, err)
+		}
+		return nil
+	}
+
+	for dec.More() {
+		var ev components.Event
+		if err := dec.Decode(&ev); err != nil {
+			return total, fmt.Errorf("failed to decode event in %q: %w", table, err)
+		}
+		evs = append(evs, ev)
+		if len(evs) < bundleImportBatchSize {
+			continue
+		}
+		if err := flush(); err != nil {
+			return total, err
+		}
+	}
+	if err := flush(); err != nil {
+		return total, err
+	}
+	return total, nil
+}
+
+func findRollupTier(name string) (metrics_state.Tier, bool) {
+	for _, tier := range metrics_state.DefaultRollupTiers {
+		if tier.Name == name {
+			return tier, true
+		}
+	}
+	return metrics_state.Tier{}, false
+}
+
+// readMachineMetadata returns empty strings if the machine ID is not created yet.
+func readMachineMetadata(ctx context.Context, dbRO *sql.DB) (string, string, error) {
+	query := fmt.Sprintf(`SELECT %s, COALESCE(%s, '') FROM %s LIMIT 1;`, ColumnMachineID, ColumnComponents, TableNameMachineMetadata)
+
+	var machineID, components string
+	err := dbRO.QueryRowContext(ctx, query).Scan(&machineID, &components)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	return machineID, components, err
+}
+
+func importMachineMetadata(ctx context.Context, dbRW *sql.DB, dbRO *sql.DB, manifest BundleManifest) error {
+	if manifest.MachineID == "" {
+		return nil
+	}
+
+	machineID, _, err := readMachineMetadata(ctx, dbRO)
+	if err != nil {
+		return err
+	}
+	if machineID != "" {
+		if machineID != manifest.MachineID {
+			log.Logger.Warnw("keeping the existing machine id", "machineID", machineID, "bundleMachineID", manifest.MachineID)
+		}
+		return nil
+	}
+
+	if _, err := CreateMachineIDIfNotExist(ctx, dbRW, dbRO, manifest.MachineID); err != nil {
+		return err
+	}
+	if manifest.Components == "" {
+		return nil
+	}
+	return UpdateComponents(ctx, dbRW, manifest.MachineID, manifest.Components)
+}
+
+// bundleSpool holds the bundle files in a temporary directory
+// until the manifest is written.
+type bundleSpool struct {
+	dir string
+	// the bundle file names and the spooled file paths, in the order written
+	names []string
+	paths []string
+}
+
+func newBundleSpool() (*bundleSpool, error) {
+	dir, err := os.MkdirTemp("", "gpud-bundle-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle spool directory: %w", err)
+	}
+	return &bundleSpool{dir: dir}, nil
+}
+
+// write spools the JSON lines encoded by "fn" as the bundle file "name".
+func (s *bundleSpool) write(name string, fn func(enc *json.Encoder) error) error {
+	f, err := os.CreateTemp(s.dir, "*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	if err := fn(json.NewEncoder(bw)); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	s.names = append(s.names, name)
+	s.paths = append(s.paths, f.Name())
+	return nil
+}
+
+// copyTo writes the spooled files to the tar archive in the order spooled.
+func (s *bundleSpool) copyTo(tw *tar.Writer, modTime time.Time) error {
+	for i, name := range s.names {
+		if err := copyTarFile(tw, name, s.paths[i], modTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *bundleSpool) remove() {
+	if err := os.RemoveAll(s.dir); err != nil {
+		log.Logger.Warnw("failed to remove bundle spool directory", "dir", s.dir, "error", err)
+	}
+}
+
+func copyTarFile(tw *tar.Writer, name string, file string, modTime time.Time) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func writeTarFile(tw *tar.Writer, name string, b []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(b)),
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(b)
+	return err
+}
@@ -0,0 +1,196 @@
+package gpudstate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBundleExportImport(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// the source node
+	srcRW, srcRO, srcCleanup := sqlite.OpenTestDB(t)
+	defer srcCleanup()
+
+	require.NoError(t, CreateTableMachineMetadata(ctx, srcRW))
+	machineID, err := CreateMachineIDIfNotExist(ctx, srcRW, srcRO, "test-machine")
+	require.NoError(t, err)
+	require.NoError(t, UpdateLoginInfo(ctx, srcRW, machineID, "secret-token"))
+	require.NoError(t, UpdateComponents(ctx, srcRW, machineID, `{"accelerator-nvidia-error-xid":{}}`))
+
+	store, err := eventstore.New(srcRW, srcRO, 0)
+	require.NoError(t, err)
+	xidBucket, err := store.Bucket("accelerator-nvidia-error-xid")
+	require.NoError(t, err)
+	defer xidBucket.Close()
+	memoryBucket, err := store.Bucket("memory")
+	require.NoError(t, err)
+	defer memoryBucket.Close()
+
+	base := time.Unix(1700000000, 0).UTC()
+	xidEvents := []components.Event{
+		{Time: metav1.Time{Time: base}, Name: "xid", Type: common.EventTypeCritical, Message: "XID 79", ExtraInfo: map[string]string{"xid": "79"}},
+		{Time: metav1.Time{Time: base}, Name: "xid", Type: common.EventTypeWarning, Message: "XID 13", ExtraInfo: map[string]string{"xid": "13"}},
+		{Time: metav1.Time{Time: base.Add(time.Minute)}, Name: "xid", Type: common.EventTypeFatal, Message: "XID 48", SuggestedActions: &common.SuggestedActions{RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem}}},
+	}
+	for _, ev := range xidEvents {
+		require.NoError(t, xidBucket.Insert(ctx, ev))
+	}
+	require.NoError(t, memoryBucket.Insert(ctx, components.Event{Time: metav1.Time{Time: base}, Name: "oom", Type: common.EventTypeWarning}))
+
+	require.NoError(t, metrics_state.CreateTableMetrics(ctx, srcRW, metrics_state.DefaultTableName))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, metrics_state.InsertMetric(ctx, srcRW, metrics_state.DefaultTableName, metrics_state.Metric{
+			UnixSeconds: base.Add(time.Duration(i) * time.Minute).Unix(), MetricName: "temperature", MetricSecondaryName: "GPU-0", Value: float64(40 + i),
+		}))
+	}
+
+	tier := metrics_state.DefaultRollupTiers[1]
+	require.NoError(t, metrics_state.CreateTableRollup(ctx, srcRW, metrics_state.DefaultTableName, tier))
+	rollup := metrics_state.Rollup{
+		UnixSeconds: base.Truncate(time.Hour).Unix(), MetricName: "temperature", MetricSecondaryName: "GPU-0", Min: 40, Max: 44, Sum: 210, Count: 5,
+	}
+	require.NoError(t, metrics_state.InsertRollup(ctx, srcRW, metrics_state.DefaultTableName, tier, rollup))
+
+	buf := bytes.NewBuffer(nil)
+	manifest, err := ExportBundle(ctx, srcRO, metrics_state.NewSQLiteStore(srcRW, srcRO, metrics_state.DefaultTableName), buf)
+	require.NoError(t, err)
+	assert.Equal(t, BundleVersion, manifest.Version)
+	assert.Equal(t, "test-machine", manifest.MachineID)
+	assert.Equal(t, 5, manifest.Metrics)
+	assert.Equal(t, map[string]int{xidBucket.Name(): 3, memoryBucket.Name(): 1}, manifest.EventTables)
+	// the tier without the rollup table is empty
+	assert.Equal(t, map[string]int{tier.Name: 1}, manifest.Rollups)
+	assert.NotContains(t, buf.String(), "secret-token")
+
+	// the local node of the support engineer
+	dstRW, dstRO, dstCleanup := sqlite.OpenTestDB(t)
+	defer dstCleanup()
+
+	require.NoError(t, metrics_state.CreateTableMetrics(ctx, dstRW, metrics_state.DefaultTableName))
+	dstMetrics := metrics_state.NewSQLiteStore(dstRW, dstRO, metrics_state.DefaultTableName)
+
+	bundle := buf.Bytes()
+	imported, err := ImportBundle(ctx, dstRW, dstRO, dstMetrics, bytes.NewReader(bundle))
+	require.NoError(t, err)
+	assert.Equal(t, manifest.MachineID, imported.Manifest.MachineID)
+	assert.Equal(t, 4, imported.EventsInserted)
+	assert.Equal(t, 5, imported.MetricsInserted)
+	assert.Equal(t, 1, imported.RollupsInserted)
+
+	dstMachineID, err := GetMachineID(ctx, dstRO)
+	require.NoError(t, err)
+	assert.Equal(t, "test-machine", dstMachineID)
+	comps, err := GetComponents(ctx, dstRO, dstMachineID)
+	require.NoError(t, err)
+	assert.Equal(t, `{"accelerator-nvidia-error-xid":{}}`, comps)
+
+	// served from the same bucket
+	dstStore, err := eventstore.New(dstRW, dstRO, 0)
+	require.NoError(t, err)
+	dstBucket, err := dstStore.Bucket("accelerator-nvidia-error-xid")
+	require.NoError(t, err)
+	defer dstBucket.Close()
+	page, err := dstBucket.Query(ctx, eventstore.Query{})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 3)
+	assert.Equal(t, "XID 48", page.Events[0].Message)
+	assert.Equal(t, xidEvents[2].SuggestedActions, page.Events[0].SuggestedActions)
+	// the insertion order of the same timestamp is kept
+	assert.Equal(t, "XID 13", page.Events[1].Message)
+	assert.Equal(t, "XID 79", page.Events[2].Message)
+
+	ms, err := metrics_state.ReadAllMetrics(ctx, dstRO, metrics_state.DefaultTableName)
+	require.NoError(t, err)
+	require.Len(t, ms, 5)
+	assert.Equal(t, "GPU-0", ms[0].MetricSecondaryName)
+	assert.Equal(t, 44.0, ms[4].Value)
+
+	rollups, err := metrics_state.ReadRollupsSince(ctx, dstRO, metrics_state.DefaultTableName, tier, "temperature", "GPU-0", base)
+	require.NoError(t, err)
+	require.Len(t, rollups, 1)
+	rollup.Avg = 42
+	assert.Equal(t, rollup, rollups[0])
+
+	// no duplicate on re-import
+	imported, err = ImportBundle(ctx, dstRW, dstRO, dstMetrics, bytes.NewReader(bundle))
+	require.NoError(t, err)
+	assert.Equal(t, 0, imported.EventsInserted)
+	page, err = dstBucket.Query(ctx, eventstore.Query{})
+	require.NoError(t, err)
+	assert.Len(t, page.Events, 3)
+
+	// the metrics are imported into the store of any backend
+	memRW, memRO, memCleanup := sqlite.OpenTestDB(t)
+	defer memCleanup()
+	memMetrics := metrics_state.NewMemoryStore(0)
+	imported, err = ImportBundle(ctx, memRW, memRO, memMetrics, bytes.NewReader(bundle))
+	require.NoError(t, err)
+	assert.Equal(t, 5, imported.MetricsInserted)
+	last, err := memMetrics.ReadLast(ctx, "temperature", "GPU-0")
+	require.NoError(t, err)
+	require.NotNil(t, last)
+	assert.Equal(t, 44.0, last.Value)
+}
+
+func TestImportBundleInvalid(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	_, err := ImportBundle(ctx, dbRW, dbRO, metrics_state.NewMemoryStore(0), bytes.NewReader([]byte("not a bundle")))
+	assert.Error(t, err)
+
+	newBundle := func(files map[string]string, order ...string) []byte {
+		buf := bytes.NewBuffer(nil)
+		gw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gw)
+		for _, name := range order {
+			require.NoError(t, writeTarFile(tw, name, []byte(files[name]), time.Now()))
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+		return buf.Bytes()
+	}
+
+	// unknown version
+	_, err = ImportBundle(ctx, dbRW, dbRO, metrics_state.NewMemoryStore(0), bytes.NewReader(newBundle(map[string]string{
+		bundleManifestFile: `{"version":"v0"}`,
+	}, bundleManifestFile)))
+	assert.ErrorContains(t, err, "unsupported bundle version")
+
+	// manifest not first
+	_, err = ImportBundle(ctx, dbRW, dbRO, metrics_state.NewMemoryStore(0), bytes.NewReader(newBundle(map[string]string{
+		bundleMetricsFile:  "",
+		bundleManifestFile: `{"version":"v1"}`,
+	}, bundleMetricsFile, bundleManifestFile)))
+	assert.Error(t, err)
+
+	// table name not to be interpolated into the statements
+	_, err = ImportBundle(ctx, dbRW, dbRO, metrics_state.NewMemoryStore(0), bytes.NewReader(newBundle(map[string]string{
+		bundleManifestFile:             `{"version":"v1"}`,
+		"events/x; DROP TABLE y.jsonl": `{"name":"x"}`,
+	}, bundleManifestFile, "events/x; DROP TABLE y.jsonl")))
+	assert.ErrorContains(t, err, "invalid events table name")
+}
@@ -0,0 +1,220 @@
+package gpudstate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/eventstore"
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+const (
+	TableNameSchemaMigrations = "schema_migrations"
+
+	ColumnMigrationVersion     = "version"
+	ColumnMigrationDescription = "description"
+	ColumnMigrationAppliedAt   = "applied_at"
+)
+
+// Migration is a schema change of the state database.
+type Migration struct {
+	// Version is the order of the migration, unique and increasing.
+	Version int
+	// Description is shown in the dry-run.
+	Description string
+	// Up applies the migration within the transaction.
+	// Must be idempotent (e.g., "IF NOT EXISTS"), since the state file
+	// may already have the change made by the gpud without the migrations.
+	Up func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations is the registry of the schema migrations, in the order of the versions.
+// The migrations run before the tables are created by their packages
+// (and before anything else touches the schema), so that the state file is
+// backed up unchanged, and the tables of a new version are not created
+// before the existing ones are migrated. Thus, each migration must skip the
+// tables that do not exist yet (e.g., a new state file), since the packages
+// create them with the current schema.
+// Append the new migration with the next version when changing the schema
+// (e.g., renaming the event tables on the eventstore schema version bump),
+// and never modify the applied ones.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "index the metrics by name and time",
+		Up: func(ctx context.Context, tx *sql.Tx) error {
+			exists, err := tableExists(ctx, tx, metrics_state.DefaultTableName)
+			if err != nil || !exists {
+				return err
+			}
+			// the primary key leads with the time, while the reads filter by the name first
+			_, err = tx.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_%s_%s ON %s(%s, %s);`,
+				metrics_state.DefaultTableName, metrics_state.ColumnMetricName, metrics_state.ColumnUnixSeconds,
+				metrics_state.DefaultTableName, metrics_state.ColumnMetricName, metrics_state.ColumnUnixSeconds))
+			return err
+		},
+	},
+	{
+		Version:     2,
+		Description: "rename the event tables of the older schema versions to v0_4_0",
+		Up: func(ctx context.Context, tx *sql.Tx) error {
+			renamed, err := eventstore.RenameTables(ctx, tx, "v0_4_0")
+			if len(renamed) > 0 {
+				log.Logger.Infow("renamed event tables", "tables", renamed)
+			}
+			return err
+		},
+	},
+	{
+		Version:     3,
+		Description: "add the occurrence count and first seen columns to the v0_4_0 event tables",
+		Up: func(ctx context.Context, tx *sql.Tx) error {
+			altered, err := eventstore.AddOccurrenceColumns(ctx, tx, "v0_4_0")
+			if len(altered) > 0 {
+				log.Logger.Infow("added occurrence columns to event tables", "tables", altered)
+			}
+			return err
+		},
+	},
+}
+
+func tableExists(ctx context.Context, tx *sql.Tx, tableName string) (bool, error) {
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists > 0, nil
+}
+
+func CreateTableSchemaMigrations(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY,
+	%s TEXT,
+	%s INTEGER
+);`, TableNameSchemaMigrations, ColumnMigrationVersion, ColumnMigrationDescription, ColumnMigrationAppliedAt))
+	return err
+}
+
+// PendingMigrations returns the migrations not applied yet, in the order to apply.
+// Returns all the migrations if the migrations table does not exist yet.
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	return pendingMigrations(ctx, db, migrations)
+}
+
+// Migrate applies the pending migrations in order, each in its own transaction,
+// and returns the applied ones.
+func Migrate(ctx context.Context, dbRW *sql.DB) ([]Migration, error) {
+	return migrate(ctx, dbRW, migrations)
+}
+
+func pendingMigrations(ctx context.Context, db *sql.DB, ms []Migration) ([]Migration, error) {
+	if err := validateMigrations(ms); err != nil {
+		return nil, err
+	}
+
+	var exists int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, TableNameSchemaMigrations).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return ms, nil
+	}
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s FROM %s`, ColumnMigrationVersion, TableNameSchemaMigrations))
+	sqlite.RecordSelect(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range ms {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+func migrate(ctx context.Context, dbRW *sql.DB, ms []Migration) ([]Migration, error) {
+	if err := CreateTableSchemaMigrations(ctx, dbRW); err != nil {
+		return nil, fmt.Errorf("failed to create schema migrations table: %w", err)
+	}
+	pending, err := pendingMigrations(ctx, dbRW, ms)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		if err := applyMigration(ctx, dbRW, m); err != nil {
+			return applied, fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		log.Logger.Infow("applied schema migration", "version", m.Version, "description", m.Description)
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+func applyMigration(ctx context.Context, dbRW *sql.DB, m Migration) error {
+	tx, err := dbRW.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := m.Up(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	start := time.Now()
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?);`,
+		TableNameSchemaMigrations, ColumnMigrationVersion, ColumnMigrationDescription, ColumnMigrationAppliedAt),
+		m.Version, m.Description, time.Now().UTC().Unix())
+	sqlite.RecordInsertUpdate(time.Since(start).Seconds())
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func validateMigrations(ms []Migration) error {
+	for i, m := range ms {
+		if m.Up == nil {
+			return fmt.Errorf("migration %d has no up function", m.Version)
+		}
+		if i > 0 && m.Version <= ms[i-1].Version {
+			return fmt.Errorf("migration %d is not after %d", m.Version, ms[i-1].Version)
+		}
+	}
+	return nil
+}
+
+// BackupStateFile copies the state database next to the state file
+// (e.g., "gpud.state.bak-20250101T000000Z"), and returns the backup file path.
+func BackupStateFile(ctx context.Context, dbRW *sql.DB, stateFile string) (string, error) {
+	backup := fmt.Sprintf("%s.bak-%s", stateFile, time.Now().UTC().Format("20060102T150405Z"))
+	// consistent copy while the database is in use, unlike copying the file with the WAL
+	if _, err := dbRW.ExecContext(ctx, `VACUUM INTO ?`, backup); err != nil {
+		return "", fmt.Errorf("failed to back up the state file: %w", err)
+	}
+	return backup, nil
+}
@@ -0,0 +1,171 @@
+package gpudstate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	"github.com/leptonai/gpud/pkg/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	var calls []int
+	ms := []Migration{
+		{Version: 1, Description: "create t", Up: func(ctx context.Context, tx *sql.Tx) error {
+			calls = append(calls, 1)
+			_, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS t (a INTEGER)`)
+			return err
+		}},
+		{Version: 2, Description: "add t.b", Up: func(ctx context.Context, tx *sql.Tx) error {
+			calls = append(calls, 2)
+			_, err := tx.ExecContext(ctx, `ALTER TABLE t ADD COLUMN b TEXT`)
+			return err
+		}},
+	}
+
+	// all pending without the migrations table
+	pending, err := pendingMigrations(ctx, dbRW, ms)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	applied, err := migrate(ctx, dbRW, ms[:1])
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+
+	pending, err = pendingMigrations(ctx, dbRO, ms)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 2, pending[0].Version)
+
+	applied, err = migrate(ctx, dbRW, ms)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, 2, applied[0].Version)
+	_, err = dbRW.ExecContext(ctx, `INSERT INTO t (a, b) VALUES (1, 'x')`)
+	require.NoError(t, err)
+
+	// no-op once applied
+	applied, err = migrate(ctx, dbRW, ms)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Equal(t, []int{1, 2}, calls)
+}
+
+func TestMigrateFailureRollsBack(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	ms := []Migration{
+		{Version: 1, Description: "create t and fail", Up: func(ctx context.Context, tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `CREATE TABLE t (a INTEGER)`); err != nil {
+				return err
+			}
+			return errors.New("test failure")
+		}},
+	}
+	applied, err := migrate(ctx, dbRW, ms)
+	assert.ErrorContains(t, err, "test failure")
+	assert.Empty(t, applied)
+
+	var n int
+	require.NoError(t, dbRO.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE name = 't'`).Scan(&n))
+	assert.Zero(t, n)
+
+	pending, err := pendingMigrations(ctx, dbRO, ms)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func TestValidateMigrations(t *testing.T) {
+	t.Parallel()
+
+	up := func(ctx context.Context, tx *sql.Tx) error { return nil }
+	assert.NoError(t, validateMigrations(migrations))
+	assert.Error(t, validateMigrations([]Migration{{Version: 1}}))
+	assert.Error(t, validateMigrations([]Migration{{Version: 2, Up: up}, {Version: 1, Up: up}}))
+	assert.Error(t, validateMigrations([]Migration{{Version: 1, Up: up}, {Version: 1, Up: up}}))
+}
+
+func TestMigrateDefault(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	stateFile := filepath.Join(t.TempDir(), "gpud.state")
+	dbRW, err := sqlite.Open(stateFile)
+	require.NoError(t, err)
+	defer dbRW.Close()
+
+	// the metrics table before the index, and the events table of the older schema version
+	_, err = dbRW.ExecContext(ctx, `CREATE TABLE components_metrics (unix_seconds INTEGER NOT NULL, metric_name TEXT NOT NULL, metric_secondary_name TEXT, value REAL NOT NULL, PRIMARY KEY (unix_seconds, metric_name, metric_secondary_name)) WITHOUT ROWID;`)
+	require.NoError(t, err)
+	_, err = dbRW.ExecContext(ctx, `CREATE TABLE components_memory_events_v0_3_0 (timestamp INTEGER NOT NULL, name TEXT NOT NULL, type TEXT NOT NULL, message TEXT, extra_info TEXT, suggested_actions TEXT);`)
+	require.NoError(t, err)
+
+	pending, err := PendingMigrations(ctx, dbRW)
+	require.NoError(t, err)
+	assert.Len(t, pending, len(migrations))
+
+	backup, err := BackupStateFile(ctx, dbRW, stateFile)
+	require.NoError(t, err)
+	_, err = os.Stat(backup)
+	require.NoError(t, err)
+
+	applied, err := Migrate(ctx, dbRW)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+
+	pending, err = PendingMigrations(ctx, dbRW)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	var indexes, tables int
+	require.NoError(t, dbRW.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?`,
+		"idx_"+metrics_state.DefaultTableName+"_"+metrics_state.ColumnMetricName+"_"+metrics_state.ColumnUnixSeconds).Scan(&indexes))
+	assert.Equal(t, 1, indexes)
+	require.NoError(t, dbRW.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'components_memory_events_v0_4_0'`).Scan(&tables))
+	assert.Equal(t, 1, tables)
+	var columns int
+	require.NoError(t, dbRW.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info('components_memory_events_v0_4_0') WHERE name IN ('count', 'first_seen')`).Scan(&columns))
+	assert.Equal(t, 2, columns)
+
+	// the backup is the state before the migrations
+	backupDB, err := sqlite.Open(backup)
+	require.NoError(t, err)
+	defer backupDB.Close()
+	pending, err = PendingMigrations(ctx, backupDB)
+	require.NoError(t, err)
+	assert.Len(t, pending, len(migrations))
+
+	// no-op on the new state file, before the packages create the tables
+	newRW, err := sqlite.Open(filepath.Join(t.TempDir(), "gpud.state"))
+	require.NoError(t, err)
+	defer newRW.Close()
+	applied, err = Migrate(ctx, newRW)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+	require.NoError(t, newRW.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name != ?`, TableNameSchemaMigrations).Scan(&tables))
+	assert.Equal(t, 0, tables)
+}
@@ -171,17 +171,15 @@ LIMIT 1;
 
 func UpdateComponents(ctx context.Context, db *sql.DB, machineID string, components string) error {
 	query := fmt.Sprintf(`
-UPDATE %s SET %s = '%s' WHERE %s = '%s';
+UPDATE %s SET %s = ? WHERE %s = ?;
 `,
 		TableNameMachineMetadata,
 		ColumnComponents,
-		components,
 		ColumnMachineID,
-		machineID,
 	)
 
 	start := time.Now()
-	_, err := db.ExecContext(ctx, query)
+	_, err := db.ExecContext(ctx, query, components, machineID)
 	sqlite.RecordInsertUpdate(time.Since(start).Seconds())
 
 	return err
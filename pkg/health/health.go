@@ -0,0 +1,263 @@
+// Package health aggregates the component states into the node-level health verdict.
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/watch"
+)
+
+const (
+	DefaultWeight             = 1.0
+	DefaultDegradedThreshold  = 0.5
+	DefaultUnhealthyThreshold = 1.0
+
+	scoreDegraded  = 0.5
+	scoreUnhealthy = 1.0
+)
+
+// DefaultRepairActionHealth is the minimum node verdict implied by
+// each suggested repair action, regardless of the component weights.
+var DefaultRepairActionHealth = map[common.RepairActionType]string{
+	common.RepairActionTypeRebootSystem:       components.StateUnhealthy,
+	common.RepairActionTypeHardwareInspection: components.StateUnhealthy,
+	common.RepairActionTypeCheckUserAppAndGPU: components.StateDegraded,
+}
+
+// Config configures the node health aggregation.
+//
+// Each component contributes its weight times the health score
+// (0 for healthy, 0.5 for degraded, 1 for unhealthy) to the node score,
+// and the node is degraded or unhealthy once the score reaches the thresholds.
+// The suggested repair actions of the contributing components may
+// escalate the verdict further (e.g., "REBOOT_SYSTEM" is always unhealthy).
+type Config struct {
+	// Weights are the per-component weights, defaults to 1.
+	// Set 0 to ignore the component.
+	Weights map[string]float64 `json:"weights,omitempty"`
+
+	// DegradedThreshold is the minimum score for the degraded verdict.
+	DegradedThreshold float64 `json:"degraded_threshold,omitempty"`
+	// UnhealthyThreshold is the minimum score for the unhealthy verdict.
+	UnhealthyThreshold float64 `json:"unhealthy_threshold,omitempty"`
+
+	// RepairActionHealth overrides the minimum verdict of the repair actions
+	// (e.g., "Healthy" to not escalate on the action).
+	RepairActionHealth map[common.RepairActionType]string `json:"repair_action_health,omitempty"`
+}
+
+// SetDefaults sets the default values for the unset fields.
+func (cfg *Config) SetDefaults() {
+	if cfg.DegradedThreshold == 0 {
+		cfg.DegradedThreshold = DefaultDegradedThreshold
+	}
+	if cfg.UnhealthyThreshold == 0 {
+		cfg.UnhealthyThreshold = DefaultUnhealthyThreshold
+	}
+}
+
+func (cfg *Config) Validate() error {
+	for name, w := range cfg.Weights {
+		if w < 0 {
+			return fmt.Errorf("weight of %q must be non-negative", name)
+		}
+	}
+	if cfg.DegradedThreshold < 0 || cfg.UnhealthyThreshold < 0 {
+		return errors.New("thresholds must be non-negative")
+	}
+	if cfg.DegradedThreshold > 0 && cfg.UnhealthyThreshold > 0 && cfg.DegradedThreshold > cfg.UnhealthyThreshold {
+		return errors.New("degraded_threshold must not exceed unhealthy_threshold")
+	}
+	for action, h := range cfg.RepairActionHealth {
+		if healthRank(h) < 0 {
+			return fmt.Errorf("invalid health %q for repair action %q", h, action)
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) weight(component string) float64 {
+	if w, ok := cfg.Weights[component]; ok {
+		return w
+	}
+	return DefaultWeight
+}
+
+func (cfg *Config) repairActionHealth(action common.RepairActionType) string {
+	if h, ok := cfg.RepairActionHealth[action]; ok {
+		return h
+	}
+	return DefaultRepairActionHealth[action]
+}
+
+// healthRank orders the verdicts, returns -1 for the unknown health.
+// The other component health values (e.g., "Initializing") are not
+// the verdicts, and are treated as healthy by the aggregation.
+func healthRank(h string) int {
+	switch h {
+	case components.StateHealthy, "":
+		return 0
+	case components.StateDegraded:
+		return 1
+	case components.StateUnhealthy:
+		return 2
+	default:
+		return -1
+	}
+}
+
+func worse(a, b string) string {
+	if healthRank(b) > healthRank(a) {
+		return b
+	}
+	return a
+}
+
+// Evaluate computes the node health verdict from the component states.
+func Evaluate(cfg Config, states v1.LeptonStates) v1.NodeHealth {
+	cfg.SetDefaults()
+
+	var (
+		score       float64
+		actionWorst = components.StateHealthy
+		contrib     []v1.ComponentHealth
+		merged      *common.SuggestedActions
+		unhealthy   []string
+		degraded    []string
+	)
+	for _, cs := range states {
+		w := cfg.weight(cs.Component)
+		if w == 0 {
+			continue
+		}
+
+		ch := componentHealth(cs)
+		if ch.Health == components.StateHealthy {
+			continue
+		}
+		ch.Weight = w
+		contrib = append(contrib, ch)
+
+		switch ch.Health {
+		case components.StateUnhealthy:
+			score += w * scoreUnhealthy
+			unhealthy = append(unhealthy, cs.Component)
+		case components.StateDegraded:
+			score += w * scoreDegraded
+			degraded = append(degraded, cs.Component)
+		}
+
+		if ch.SuggestedActions != nil {
+			merged = mergeSuggestedActions(merged, ch.SuggestedActions)
+			for _, a := range ch.SuggestedActions.RepairActions {
+				actionWorst = worse(actionWorst, cfg.repairActionHealth(a))
+			}
+		}
+	}
+
+	verdict := components.StateHealthy
+	switch {
+	case score >= cfg.UnhealthyThreshold:
+		verdict = components.StateUnhealthy
+	case score >= cfg.DegradedThreshold:
+		verdict = components.StateDegraded
+	}
+	verdict = worse(verdict, actionWorst)
+
+	sort.Slice(contrib, func(i, j int) bool {
+		ri, rj := healthRank(contrib[i].Health), healthRank(contrib[j].Health)
+		if ri != rj {
+			return ri > rj
+		}
+		return contrib[i].Component < contrib[j].Component
+	})
+
+	return v1.NodeHealth{
+		Time:             time.Now().UTC(),
+		Health:           verdict,
+		Healthy:          verdict == components.StateHealthy,
+		Reason:           reason(len(states), unhealthy, degraded, merged),
+		Score:            score,
+		Components:       contrib,
+		SuggestedActions: merged,
+	}
+}
+
+// componentHealth returns the worst health of the component states,
+// with the reasons and the suggested actions of the states not healthy.
+func componentHealth(cs v1.LeptonComponentStates) v1.ComponentHealth {
+	ch := v1.ComponentHealth{
+		Component: cs.Component,
+		Health:    components.StateHealthy,
+	}
+
+	var reasons []string
+	for _, s := range cs.States {
+		h := watch.StateHealth(s)
+		if healthRank(h) <= 0 {
+			continue
+		}
+		ch.Health = worse(ch.Health, h)
+		if s.Reason != "" {
+			reasons = append(reasons, s.Reason)
+		}
+		if s.SuggestedActions != nil {
+			ch.SuggestedActions = mergeSuggestedActions(ch.SuggestedActions, s.SuggestedActions)
+		}
+	}
+	ch.Reason = strings.Join(reasons, "; ")
+	return ch
+}
+
+// mergeSuggestedActions returns the union of the suggested actions,
+// without the duplicate references, descriptions, and repair actions.
+func mergeSuggestedActions(dst *common.SuggestedActions, src *common.SuggestedActions) *common.SuggestedActions {
+	if dst == nil {
+		dst = &common.SuggestedActions{}
+	}
+	dst.References = appendUnique(dst.References, src.References...)
+	dst.Descriptions = appendUnique(dst.Descriptions, src.Descriptions...)
+	dst.RepairActions = appendUnique(dst.RepairActions, src.RepairActions...)
+	return dst
+}
+
+func appendUnique[T comparable](dst []T, vs ...T) []T {
+	for _, v := range vs {
+		found := false
+		for _, d := range dst {
+			if d == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+func reason(total int, unhealthy []string, degraded []string, actions *common.SuggestedActions) string {
+	if len(unhealthy) == 0 && len(degraded) == 0 {
+		return fmt.Sprintf("all %d component(s) healthy", total)
+	}
+
+	var parts []string
+	if len(unhealthy) > 0 {
+		parts = append(parts, fmt.Sprintf("%d unhealthy component(s): %s", len(unhealthy), strings.Join(unhealthy, ", ")))
+	}
+	if len(degraded) > 0 {
+		parts = append(parts, fmt.Sprintf("%d degraded component(s): %s", len(degraded), strings.Join(degraded, ", ")))
+	}
+	if actions != nil && len(actions.RepairActions) > 0 {
+		parts = append(parts, "suggested actions: "+actions.DescribeActions())
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,149 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+func TestEvaluate(t *testing.T) {
+	healthy := v1.LeptonComponentStates{Component: "cpu", States: []components.State{{Name: "cpu", Healthy: true}}}
+	initializing := v1.LeptonComponentStates{Component: "disk", States: []components.State{{Name: "disk", Health: components.StateInitializing}}}
+	degraded := v1.LeptonComponentStates{Component: "accelerator-nvidia-temperature", States: []components.State{
+		{Name: "temp", Health: components.StateDegraded, Reason: "temperature above slowdown threshold"},
+	}}
+	unhealthy := v1.LeptonComponentStates{Component: "accelerator-nvidia-ecc", States: []components.State{
+		{Name: "ecc", Healthy: false, Reason: "uncorrectable ecc errors"},
+	}}
+	reboot := v1.LeptonComponentStates{Component: "accelerator-nvidia-xid", States: []components.State{
+		{
+			Name:    "xid",
+			Health:  components.StateDegraded,
+			Reason:  "xid 79",
+			Healthy: false,
+			SuggestedActions: &common.SuggestedActions{
+				Descriptions:  []string{"GPU fell off the bus"},
+				RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+			},
+		},
+		{
+			Name:    "xid-2",
+			Health:  components.StateDegraded,
+			Healthy: false,
+			SuggestedActions: &common.SuggestedActions{
+				Descriptions:  []string{"GPU fell off the bus"},
+				RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem, common.RepairActionTypeHardwareInspection},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name         string
+		cfg          Config
+		states       v1.LeptonStates
+		wantHealth   string
+		wantContribs []string
+		wantActions  []common.RepairActionType
+	}{
+		{
+			name:       "all healthy",
+			states:     v1.LeptonStates{healthy, initializing},
+			wantHealth: components.StateHealthy,
+		},
+		{
+			name:         "degraded",
+			states:       v1.LeptonStates{healthy, degraded},
+			wantHealth:   components.StateDegraded,
+			wantContribs: []string{"accelerator-nvidia-temperature"},
+		},
+		{
+			name:         "unhealthy",
+			states:       v1.LeptonStates{healthy, degraded, unhealthy},
+			wantHealth:   components.StateUnhealthy,
+			wantContribs: []string{"accelerator-nvidia-ecc", "accelerator-nvidia-temperature"},
+		},
+		{
+			name:         "weighted down to degraded",
+			cfg:          Config{Weights: map[string]float64{"accelerator-nvidia-ecc": 0.5}},
+			states:       v1.LeptonStates{unhealthy},
+			wantHealth:   components.StateDegraded,
+			wantContribs: []string{"accelerator-nvidia-ecc"},
+		},
+		{
+			name:       "ignored component",
+			cfg:        Config{Weights: map[string]float64{"accelerator-nvidia-ecc": 0}},
+			states:     v1.LeptonStates{unhealthy},
+			wantHealth: components.StateHealthy,
+		},
+		{
+			name:         "degraded components add up",
+			states:       v1.LeptonStates{degraded, {Component: "memory", States: []components.State{{Name: "memory", Health: components.StateDegraded}}}},
+			wantHealth:   components.StateUnhealthy,
+			wantContribs: []string{"accelerator-nvidia-temperature", "memory"},
+		},
+		{
+			name:         "repair action escalates",
+			states:       v1.LeptonStates{reboot},
+			wantHealth:   components.StateUnhealthy,
+			wantContribs: []string{"accelerator-nvidia-xid"},
+			wantActions:  []common.RepairActionType{common.RepairActionTypeRebootSystem, common.RepairActionTypeHardwareInspection},
+		},
+		{
+			name: "repair action overridden",
+			cfg: Config{RepairActionHealth: map[common.RepairActionType]string{
+				common.RepairActionTypeRebootSystem:       components.StateHealthy,
+				common.RepairActionTypeHardwareInspection: components.StateHealthy,
+			}},
+			states:       v1.LeptonStates{reboot},
+			wantHealth:   components.StateDegraded,
+			wantContribs: []string{"accelerator-nvidia-xid"},
+			wantActions:  []common.RepairActionType{common.RepairActionTypeRebootSystem, common.RepairActionTypeHardwareInspection},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(tt.cfg, tt.states)
+			require.Equal(t, tt.wantHealth, got.Health, got.Reason)
+			require.Equal(t, tt.wantHealth == components.StateHealthy, got.Healthy)
+			require.NotEmpty(t, got.Reason)
+
+			var contribs []string
+			for _, c := range got.Components {
+				contribs = append(contribs, c.Component)
+			}
+			require.Equal(t, tt.wantContribs, contribs)
+
+			if tt.wantActions == nil {
+				require.Nil(t, got.SuggestedActions)
+				return
+			}
+			require.Equal(t, tt.wantActions, got.SuggestedActions.RepairActions)
+			require.Equal(t, []string{"GPU fell off the bus"}, got.SuggestedActions.Descriptions)
+		})
+	}
+}
+
+func TestEvaluateReason(t *testing.T) {
+	got := Evaluate(Config{}, v1.LeptonStates{
+		{Component: "cpu", States: []components.State{{Name: "cpu", Healthy: true}}},
+	})
+	require.Equal(t, "all 1 component(s) healthy", got.Reason)
+
+	got = Evaluate(Config{}, v1.LeptonStates{
+		{Component: "accelerator-nvidia-ecc", States: []components.State{{Name: "ecc", Healthy: false, Reason: "uncorrectable ecc errors"}}},
+	})
+	require.Equal(t, "1 unhealthy component(s): accelerator-nvidia-ecc", got.Reason)
+	require.Equal(t, "uncorrectable ecc errors", got.Components[0].Reason)
+	require.Equal(t, 1.0, got.Score)
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, (&Config{}).Validate())
+	require.Error(t, (&Config{Weights: map[string]float64{"cpu": -1}}).Validate())
+	require.Error(t, (&Config{DegradedThreshold: 2, UnhealthyThreshold: 1}).Validate())
+	require.Error(t, (&Config{RepairActionHealth: map[common.RepairActionType]string{common.RepairActionTypeRebootSystem: "Bad"}}).Validate())
+}
@@ -0,0 +1,80 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+const (
+	// EnvNodeName is the environment variable for the node name
+	// (e.g., set from "spec.nodeName" via the downward API).
+	EnvNodeName = "NODE_NAME"
+
+	DefaultConditionPrefix = "GPUd"
+	DefaultMinEventType    = common.EventTypeCritical
+	DefaultEventNamespace  = "default"
+	DefaultSyncInterval    = 30 * time.Second
+)
+
+// Config configures the Kubernetes node condition and event publisher.
+type Config struct {
+	// NodeName is the Kubernetes node name of this machine.
+	// Defaults to the "NODE_NAME" environment variable, or the hostname.
+	NodeName string `json:"node_name,omitempty"`
+
+	// Kubeconfig is the kubeconfig file path.
+	// If empty, the in-cluster configuration is used.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+
+	// ConditionPrefix is the prefix of the node condition types
+	// (e.g., "GPUd" for "GPUdXidHealthy").
+	ConditionPrefix string `json:"condition_prefix,omitempty"`
+
+	// MinEventType is the minimum component event type
+	// to create the Kubernetes event for.
+	MinEventType common.EventType `json:"min_event_type,omitempty"`
+
+	// EventNamespace is the namespace to create the Kubernetes events in.
+	EventNamespace string `json:"event_namespace,omitempty"`
+
+	// SyncInterval is the interval to update the node conditions.
+	SyncInterval metav1.Duration `json:"sync_interval,omitempty"`
+}
+
+// SetDefaults sets the default values for the unset fields.
+func (cfg *Config) SetDefaults() {
+	if cfg.NodeName == "" {
+		cfg.NodeName = os.Getenv(EnvNodeName)
+	}
+	if cfg.NodeName == "" {
+		cfg.NodeName, _ = os.Hostname()
+	}
+	if cfg.ConditionPrefix == "" {
+		cfg.ConditionPrefix = DefaultConditionPrefix
+	}
+	if cfg.MinEventType == "" {
+		cfg.MinEventType = DefaultMinEventType
+	}
+	if cfg.EventNamespace == "" {
+		cfg.EventNamespace = DefaultEventNamespace
+	}
+	if cfg.SyncInterval.Duration == 0 {
+		cfg.SyncInterval = metav1.Duration{Duration: DefaultSyncInterval}
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.MinEventType != "" && common.EventTypeFromString(string(cfg.MinEventType)) == common.EventTypeUnknown {
+		return fmt.Errorf("invalid min_event_type %q", cfg.MinEventType)
+	}
+	if cfg.SyncInterval.Duration < 0 {
+		return errors.New("sync_interval must be non-negative")
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+// Package k8s implements the publisher that writes the component health
+// back to the Kubernetes cluster, as the node conditions and the events,
+// so that the node remediation controllers can act on them.
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/watch"
+)
+
+const (
+	// eventSource is the reporting component of the Kubernetes events.
+	eventSource = "gpud"
+
+	// componentPrefixNVIDIA is trimmed from the condition types
+	// (e.g., "accelerator-nvidia-xid" to "GPUdXidHealthy").
+	componentPrefixNVIDIA = "accelerator-nvidia-"
+	conditionSuffix       = "Healthy"
+
+	// the component states not observed for this many sync intervals are
+	// considered removed, and their conditions are removed from the node
+	staleSyncIntervals = 3
+)
+
+// NewClient creates the Kubernetes client from the kubeconfig file,
+// or from the in-cluster configuration if the file is empty.
+func NewClient(kubeconfig string) (kubernetes.Interface, error) {
+	var (
+		restCfg *rest.Config
+		err     error
+	)
+	if kubeconfig == "" {
+		restCfg, err = rest.InClusterConfig()
+	} else {
+		restCfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
+	}
+	return kubernetes.NewForConfig(restCfg)
+}
+
+type observedStates struct {
+	states []components.State
+	time   time.Time
+}
+
+// Publisher maps the aggregated health of each component to a node condition,
+// and creates the Kubernetes events for the component events.
+type Publisher struct {
+	cfg Config
+	cli kubernetes.Interface
+
+	mu sync.Mutex
+	// latest observed states, keyed by the component name
+	states map[string]observedStates
+
+	// for testing
+	timeNow func() time.Time
+}
+
+// New creates the publisher with the Kubernetes client.
+// The configuration defaults are set for the unset fields.
+func New(cfg Config, cli kubernetes.Interface) (*Publisher, error) {
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.NodeName == "" {
+		return nil, errors.New("node name is required")
+	}
+	return &Publisher{
+		cfg:     cfg,
+		cli:     cli,
+		states:  make(map[string]observedStates),
+		timeNow: time.Now,
+	}, nil
+}
+
+// ObserveStates records the latest component states,
+// to be published as the node condition on the next sync.
+func (p *Publisher) ObserveStates(component string, states []components.State) {
+	p.mu.Lock()
+	p.states[component] = observedStates{states: states, time: p.timeNow()}
+	p.mu.Unlock()
+}
+
+// Run updates the node conditions every sync interval,
+// and creates the Kubernetes events for the component events
+// at or above the minimum event type, until the context is canceled.
+func (p *Publisher) Run(ctx context.Context, broker *watch.Broker) {
+	ch, unsubscribe := broker.Subscribe(watch.Filter{Kinds: []v1.WatchEventKind{v1.WatchEventKindEvent}})
+	defer unsubscribe()
+
+	ticker := time.NewTicker(p.cfg.SyncInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := p.SyncConditions(ctx); err != nil {
+				log.Logger.Warnw("failed to sync node conditions", "node", p.cfg.NodeName, "error", err)
+			}
+
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if ev.Event == nil || ev.Event.Type.Severity() < p.cfg.MinEventType.Severity() {
+				continue
+			}
+			if err := p.PublishEvent(ctx, ev.Component, *ev.Event); err != nil {
+				log.Logger.Warnw("failed to publish kubernetes event", "node", p.cfg.NodeName, "component", ev.Component, "error", err)
+			}
+		}
+	}
+}
+
+// SyncConditions updates the node conditions from the latest component states.
+// The conditions of the components no longer reporting are removed,
+// and the conditions not owned by the publisher are left untouched.
+func (p *Publisher) SyncConditions(ctx context.Context) error {
+	now := metav1.NewTime(p.timeNow())
+	desired := p.desiredConditions(now.Time)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := p.cli.CoreV1().Nodes().Get(ctx, p.cfg.NodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		node.Status.Conditions = mergeConditions(node.Status.Conditions, desired, p.cfg.ConditionPrefix, now)
+		_, err = p.cli.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (p *Publisher) desiredConditions(now time.Time) []corev1.NodeCondition {
+	staleAfter := staleSyncIntervals * p.cfg.SyncInterval.Duration
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conds := make([]corev1.NodeCondition, 0, len(p.states))
+	for component, observed := range p.states {
+		if now.Sub(observed.time) > staleAfter {
+			delete(p.states, component)
+			continue
+		}
+		status, reason, message := aggregateHealth(component, observed.states)
+		conds = append(conds, corev1.NodeCondition{
+			Type:    ConditionType(p.cfg.ConditionPrefix, component),
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+	}
+	sort.Slice(conds, func(i, j int) bool { return conds[i].Type < conds[j].Type })
+	return conds
+}
+
+// mergeConditions replaces the conditions owned by the publisher (by the prefix)
+// with the desired ones, preserving the last transition time if the status is unchanged.
+func mergeConditions(existing []corev1.NodeCondition, desired []corev1.NodeCondition, prefix string, now metav1.Time) []corev1.NodeCondition {
+	prev := make(map[corev1.NodeConditionType]corev1.NodeCondition)
+	merged := make([]corev1.NodeCondition, 0, len(existing)+len(desired))
+	for _, c := range existing {
+		if isOwnedCondition(prefix, c.Type) {
+			prev[c.Type] = c
+			continue
+		}
+		merged = append(merged, c)
+	}
+
+	for _, c := range desired {
+		c.LastHeartbeatTime = now
+		c.LastTransitionTime = now
+		if old, ok := prev[c.Type]; ok && old.Status == c.Status {
+			c.LastTransitionTime = old.LastTransitionTime
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+func isOwnedCondition(prefix string, t corev1.NodeConditionType) bool {
+	return strings.HasPrefix(string(t), prefix) && strings.HasSuffix(string(t), conditionSuffix)
+}
+
+// ConditionType returns the node condition type of the component
+// (e.g., "GPUdXidHealthy" for "accelerator-nvidia-xid" with the "GPUd" prefix).
+func ConditionType(prefix string, component string) corev1.NodeConditionType {
+	return corev1.NodeConditionType(prefix + camelCase(strings.TrimPrefix(component, componentPrefixNVIDIA)) + conditionSuffix)
+}
+
+// camelCase converts the dash or underscore separated name to the upper camel case.
+func camelCase(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+		sb.WriteString(strings.ToUpper(part[:1]))
+		sb.WriteString(part[1:])
+	}
+	return sb.String()
+}
+
+// aggregateHealth returns the worst health of the component states
+// as the node condition status, reason, and message.
+// The condition is "True" only if all the states are healthy.
+func aggregateHealth(component string, states []components.State) (corev1.ConditionStatus, string, string) {
+	health := components.StateHealthy
+	var reasons []string
+	for _, s := range states {
+		h := watch.StateHealth(s)
+		if h == components.StateHealthy {
+			continue
+		}
+		if h == components.StateUnhealthy || health == components.StateHealthy {
+			health = h
+		}
+		if s.Reason != "" {
+			reasons = append(reasons, s.Name+": "+s.Reason)
+		} else {
+			reasons = append(reasons, s.Name+": "+h)
+		}
+	}
+
+	switch health {
+	case components.StateHealthy:
+		return corev1.ConditionTrue, "Healthy", fmt.Sprintf("%s is healthy", component)
+	case components.StateDegraded:
+		return corev1.ConditionFalse, "Degraded", strings.Join(reasons, "; ")
+	default:
+		return corev1.ConditionFalse, "Unhealthy", strings.Join(reasons, "; ")
+	}
+}
+
+// PublishEvent creates the Kubernetes event on the node for the component event.
+func (p *Publisher) PublishEvent(ctx context.Context, component string, ev components.Event) error {
+	now := p.timeNow()
+	t := ev.Time
+	if t.IsZero() {
+		t = metav1.NewTime(now)
+	}
+
+	eventType := corev1.EventTypeNormal
+	if ev.Type.Severity() >= DefaultMinEventType.Severity() {
+		eventType = corev1.EventTypeWarning
+	}
+
+	kev := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// same naming as the kubelet event recorder
+			Name:      fmt.Sprintf("%s.%x", p.cfg.NodeName, now.UnixNano()),
+			Namespace: p.cfg.EventNamespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: "Node",
+			Name: p.cfg.NodeName,
+			// the kubelet uses the node name as the node UID for the events
+			UID: types.UID(p.cfg.NodeName),
+		},
+		Reason:              p.cfg.ConditionPrefix + camelCase(ev.Name),
+		Message:             fmt.Sprintf("[%s] %s: %s", ev.Type, component, ev.Message),
+		Type:                eventType,
+		Source:              corev1.EventSource{Component: eventSource, Host: p.cfg.NodeName},
+		FirstTimestamp:      t,
+		LastTimestamp:       t,
+		Count:               1,
+		ReportingController: eventSource,
+		ReportingInstance:   p.cfg.NodeName,
+	}
+	_, err := p.cli.CoreV1().Events(p.cfg.EventNamespace).Create(ctx, kev, metav1.CreateOptions{})
+	return err
+}
@@ -0,0 +1,167 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+func TestConditionType(t *testing.T) {
+	tests := []struct {
+		component string
+		want      corev1.NodeConditionType
+	}{
+		{component: "accelerator-nvidia-xid", want: "GPUdXidHealthy"},
+		{component: "accelerator-nvidia-hw-slowdown", want: "GPUdHwSlowdownHealthy"},
+		{component: "kernel-module", want: "GPUdKernelModuleHealthy"},
+		{component: "os", want: "GPUdOsHealthy"},
+	}
+	for _, tt := range tests {
+		if got := ConditionType(DefaultConditionPrefix, tt.component); got != tt.want {
+			t.Errorf("ConditionType(%q) = %q, want %q", tt.component, got, tt.want)
+		}
+	}
+}
+
+func TestAggregateHealth(t *testing.T) {
+	status, reason, _ := aggregateHealth("xid", []components.State{{Name: "a", Healthy: true}})
+	require.Equal(t, corev1.ConditionTrue, status)
+	require.Equal(t, "Healthy", reason)
+
+	status, reason, msg := aggregateHealth("xid", []components.State{
+		{Name: "a", Healthy: true},
+		{Name: "b", Health: components.StateDegraded, Reason: "slow"},
+	})
+	require.Equal(t, corev1.ConditionFalse, status)
+	require.Equal(t, "Degraded", reason)
+	require.Equal(t, "b: slow", msg)
+
+	status, reason, msg = aggregateHealth("xid", []components.State{
+		{Name: "b", Health: components.StateDegraded, Reason: "slow"},
+		{Name: "c", Healthy: false, Reason: "xid 79"},
+	})
+	require.Equal(t, corev1.ConditionFalse, status)
+	require.Equal(t, "Unhealthy", reason)
+	require.Equal(t, "b: slow; c: xid 79", msg)
+}
+
+func newTestPublisher(t *testing.T) (*Publisher, *fake.Clientset) {
+	cli := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			},
+		},
+	})
+	p, err := New(Config{NodeName: "node-1"}, cli)
+	require.NoError(t, err)
+	return p, cli
+}
+
+func getConditions(t *testing.T, cli *fake.Clientset) map[corev1.NodeConditionType]corev1.NodeCondition {
+	node, err := cli.CoreV1().Nodes().Get(context.Background(), "node-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	conds := make(map[corev1.NodeConditionType]corev1.NodeCondition)
+	for _, c := range node.Status.Conditions {
+		conds[c.Type] = c
+	}
+	return conds
+}
+
+func TestSyncConditions(t *testing.T) {
+	p, cli := newTestPublisher(t)
+	now := time.Now().Add(-time.Hour).Truncate(time.Second)
+	p.timeNow = func() time.Time { return now }
+
+	p.ObserveStates("accelerator-nvidia-xid", []components.State{{Name: "xid", Healthy: false, Reason: "xid 79 detected"}})
+	p.ObserveStates("os", []components.State{{Name: "os", Healthy: true}})
+	require.NoError(t, p.SyncConditions(context.Background()))
+
+	conds := getConditions(t, cli)
+	require.Len(t, conds, 3)
+	require.Equal(t, corev1.ConditionTrue, conds[corev1.NodeReady].Status, "not owned condition is kept")
+	require.Equal(t, corev1.ConditionFalse, conds["GPUdXidHealthy"].Status)
+	require.Equal(t, "Unhealthy", conds["GPUdXidHealthy"].Reason)
+	require.Equal(t, "xid: xid 79 detected", conds["GPUdXidHealthy"].Message)
+	require.Equal(t, corev1.ConditionTrue, conds["GPUdOsHealthy"].Status)
+	transition := conds["GPUdXidHealthy"].LastTransitionTime
+
+	// same status keeps the transition time, updates the heartbeat
+	now = now.Add(time.Minute)
+	p.ObserveStates("accelerator-nvidia-xid", []components.State{{Name: "xid", Healthy: false, Reason: "xid 79 detected"}})
+	p.ObserveStates("os", []components.State{{Name: "os", Healthy: true}})
+	require.NoError(t, p.SyncConditions(context.Background()))
+	conds = getConditions(t, cli)
+	require.Equal(t, transition.Unix(), conds["GPUdXidHealthy"].LastTransitionTime.Unix())
+	require.Equal(t, now.Unix(), conds["GPUdXidHealthy"].LastHeartbeatTime.Unix())
+
+	// recovery updates the transition time
+	now = now.Add(time.Minute)
+	p.ObserveStates("accelerator-nvidia-xid", []components.State{{Name: "xid", Healthy: true}})
+	require.NoError(t, p.SyncConditions(context.Background()))
+	conds = getConditions(t, cli)
+	require.Equal(t, corev1.ConditionTrue, conds["GPUdXidHealthy"].Status)
+	require.Equal(t, now.Unix(), conds["GPUdXidHealthy"].LastTransitionTime.Unix())
+
+	// the components no longer reporting are removed
+	now = now.Add(staleSyncIntervals*DefaultSyncInterval + time.Second)
+	p.ObserveStates("accelerator-nvidia-xid", []components.State{{Name: "xid", Healthy: true}})
+	require.NoError(t, p.SyncConditions(context.Background()))
+	conds = getConditions(t, cli)
+	require.Len(t, conds, 2)
+	_, ok := conds["GPUdOsHealthy"]
+	require.False(t, ok)
+}
+
+func TestSyncConditionsNodeNotFound(t *testing.T) {
+	p, err := New(Config{NodeName: "missing"}, fake.NewSimpleClientset())
+	require.NoError(t, err)
+	require.Error(t, p.SyncConditions(context.Background()))
+}
+
+func TestPublishEvent(t *testing.T) {
+	p, cli := newTestPublisher(t)
+
+	err := p.PublishEvent(context.Background(), "accelerator-nvidia-xid", components.Event{
+		Time:    metav1.Now(),
+		Name:    "xid",
+		Type:    common.EventTypeFatal,
+		Message: "Xid 79 detected",
+	})
+	require.NoError(t, err)
+
+	evs, err := cli.CoreV1().Events(DefaultEventNamespace).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, evs.Items, 1)
+
+	ev := evs.Items[0]
+	require.Equal(t, "Node", ev.InvolvedObject.Kind)
+	require.Equal(t, "node-1", ev.InvolvedObject.Name)
+	require.Equal(t, corev1.EventTypeWarning, ev.Type)
+	require.Equal(t, "GPUdXid", ev.Reason)
+	require.Equal(t, "[Fatal] accelerator-nvidia-xid: Xid 79 detected", ev.Message)
+	require.Equal(t, eventSource, ev.Source.Component)
+}
+
+func TestConfigSetDefaults(t *testing.T) {
+	t.Setenv(EnvNodeName, "node-from-env")
+
+	cfg := Config{}
+	cfg.SetDefaults()
+	require.Equal(t, "node-from-env", cfg.NodeName)
+	require.Equal(t, DefaultConditionPrefix, cfg.ConditionPrefix)
+	require.Equal(t, DefaultMinEventType, cfg.MinEventType)
+	require.Equal(t, DefaultEventNamespace, cfg.EventNamespace)
+	require.Equal(t, DefaultSyncInterval, cfg.SyncInterval.Duration)
+
+	require.Error(t, (&Config{MinEventType: "Bad"}).Validate())
+}
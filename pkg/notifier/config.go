@@ -0,0 +1,145 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+// SinkType is the notification sink type.
+type SinkType string
+
+const (
+	// SinkTypeWebhook posts the notification as JSON to the URL.
+	SinkTypeWebhook SinkType = "webhook"
+	// SinkTypeSlack posts the notification to the Slack incoming webhook URL.
+	SinkTypeSlack SinkType = "slack"
+	// SinkTypePagerDuty sends the notification to the PagerDuty Events API v2.
+	SinkTypePagerDuty SinkType = "pagerduty"
+)
+
+// DefaultPagerDutyURL is the PagerDuty Events API v2 endpoint.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+const (
+	DefaultMinEventType  = common.EventTypeCritical
+	DefaultDedupWindow   = 10 * time.Minute
+	DefaultRateLimit     = 30
+	DefaultMaxRetries    = 3
+	DefaultRetryInterval = time.Second
+)
+
+// Config configures the alert notifier.
+type Config struct {
+	// MinEventType is the minimum event type to notify
+	// (e.g., "Critical" notifies the "Critical" and "Fatal" events).
+	MinEventType common.EventType `json:"min_event_type,omitempty"`
+
+	// HealthTransitions set true to notify the component state health transitions
+	// (including the recoveries), regardless of the minimum event type.
+	HealthTransitions bool `json:"health_transitions,omitempty"`
+
+	// DedupWindow is the time window to suppress the duplicate notifications.
+	DedupWindow metav1.Duration `json:"dedup_window,omitempty"`
+	// DisableDedup set true to send all the duplicate notifications, regardless of DedupWindow.
+	DisableDedup bool `json:"disable_dedup,omitempty"`
+
+	// RateLimit is the maximum number of notifications per minute per sink.
+	RateLimit int `json:"rate_limit,omitempty"`
+	// DisableRateLimit set true to not limit the notifications, regardless of RateLimit.
+	DisableRateLimit bool `json:"disable_rate_limit,omitempty"`
+
+	// MaxRetries is the maximum number of retries on the failed delivery.
+	MaxRetries int `json:"max_retries,omitempty"`
+	// DisableRetries set true to not retry the failed delivery, regardless of MaxRetries.
+	DisableRetries bool `json:"disable_retries,omitempty"`
+	// RetryInterval is the initial interval between retries, doubled on each retry.
+	RetryInterval metav1.Duration `json:"retry_interval,omitempty"`
+
+	Sinks []SinkConfig `json:"sinks"`
+}
+
+// SinkConfig configures the notification sink.
+type SinkConfig struct {
+	Type SinkType `json:"type"`
+
+	// URL is the webhook URL.
+	// Optional for the PagerDuty sink (defaults to the Events API v2 endpoint).
+	URL string `json:"url,omitempty"`
+
+	// Headers are the extra HTTP request headers (e.g., authorization).
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// RoutingKey is the PagerDuty integration key.
+	RoutingKey string `json:"routing_key,omitempty"`
+}
+
+// SetDefaults sets the default values for the unset fields.
+// The zero dedup window, rate limit, and max retries are set to the defaults,
+// so each is turned off by its disable switch (e.g., "disable_dedup"),
+// which sets the value to zero.
+func (cfg *Config) SetDefaults() {
+	if cfg.MinEventType == "" {
+		cfg.MinEventType = DefaultMinEventType
+	}
+	if cfg.DisableDedup {
+		cfg.DedupWindow = metav1.Duration{}
+	} else if cfg.DedupWindow.Duration == 0 {
+		cfg.DedupWindow = metav1.Duration{Duration: DefaultDedupWindow}
+	}
+	if cfg.DisableRateLimit {
+		cfg.RateLimit = 0
+	} else if cfg.RateLimit == 0 {
+		cfg.RateLimit = DefaultRateLimit
+	}
+	if cfg.DisableRetries {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.RetryInterval.Duration == 0 {
+		cfg.RetryInterval = metav1.Duration{Duration: DefaultRetryInterval}
+	}
+	for i := range cfg.Sinks {
+		if cfg.Sinks[i].Type == SinkTypePagerDuty && cfg.Sinks[i].URL == "" {
+			cfg.Sinks[i].URL = DefaultPagerDutyURL
+		}
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.MinEventType != "" && common.EventTypeFromString(string(cfg.MinEventType)) == common.EventTypeUnknown {
+		return fmt.Errorf("invalid min_event_type %q", cfg.MinEventType)
+	}
+	if cfg.DedupWindow.Duration < 0 {
+		return errors.New("dedup_window must be non-negative")
+	}
+	if cfg.RateLimit < 0 {
+		return errors.New("rate_limit must be non-negative")
+	}
+	if cfg.MaxRetries < 0 {
+		return errors.New("max_retries must be non-negative")
+	}
+	if len(cfg.Sinks) == 0 {
+		return errors.New("at least one sink is required")
+	}
+	for i, sink := range cfg.Sinks {
+		switch sink.Type {
+		case SinkTypeWebhook, SinkTypeSlack:
+			if sink.URL == "" {
+				return fmt.Errorf("sinks[%d]: url is required for %s sink", i, sink.Type)
+			}
+		case SinkTypePagerDuty:
+			if sink.RoutingKey == "" {
+				return fmt.Errorf("sinks[%d]: routing_key is required for %s sink", i, sink.Type)
+			}
+		default:
+			return fmt.Errorf("sinks[%d]: unknown sink type %q", i, sink.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+func TestConfigSetDefaults(t *testing.T) {
+	cfg := Config{
+		Sinks: []SinkConfig{{Type: SinkTypePagerDuty, RoutingKey: "key"}},
+	}
+	cfg.SetDefaults()
+
+	if cfg.MinEventType != DefaultMinEventType {
+		t.Errorf("unexpected min event type %q", cfg.MinEventType)
+	}
+	if cfg.DedupWindow.Duration != DefaultDedupWindow {
+		t.Errorf("unexpected dedup window %v", cfg.DedupWindow)
+	}
+	if cfg.RateLimit != DefaultRateLimit || cfg.MaxRetries != DefaultMaxRetries || cfg.RetryInterval.Duration != DefaultRetryInterval {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+	if cfg.Sinks[0].URL != DefaultPagerDutyURL {
+		t.Errorf("unexpected pagerduty url %q", cfg.Sinks[0].URL)
+	}
+
+	cfg = Config{DisableRetries: true}
+	cfg.SetDefaults()
+	if cfg.MaxRetries != 0 {
+		t.Errorf("expected no retries, got %d", cfg.MaxRetries)
+	}
+
+	cfg = Config{DisableDedup: true, DedupWindow: metav1.Duration{Duration: time.Minute}, DisableRateLimit: true, RateLimit: 5}
+	cfg.SetDefaults()
+	if cfg.DedupWindow.Duration != 0 {
+		t.Errorf("expected no dedup window, got %v", cfg.DedupWindow)
+	}
+	if cfg.RateLimit != 0 {
+		t.Errorf("expected no rate limit, got %d", cfg.RateLimit)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			cfg: Config{
+				MinEventType: common.EventTypeWarning,
+				DedupWindow:  metav1.Duration{Duration: time.Minute},
+				Sinks: []SinkConfig{
+					{Type: SinkTypeWebhook, URL: "http://localhost"},
+					{Type: SinkTypeSlack, URL: "http://localhost"},
+					{Type: SinkTypePagerDuty, RoutingKey: "key"},
+				},
+			},
+		},
+		{
+			name:    "no sink",
+			cfg:     Config{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid min event type",
+			cfg:     Config{MinEventType: "Bad", Sinks: []SinkConfig{{Type: SinkTypeWebhook, URL: "http://localhost"}}},
+			wantErr: true,
+		},
+		{
+			name:    "negative rate limit",
+			cfg:     Config{RateLimit: -1, Sinks: []SinkConfig{{Type: SinkTypeWebhook, URL: "http://localhost"}}},
+			wantErr: true,
+		},
+		{
+			name:    "webhook without url",
+			cfg:     Config{Sinks: []SinkConfig{{Type: SinkTypeWebhook}}},
+			wantErr: true,
+		},
+		{
+			name:    "pagerduty without routing key",
+			cfg:     Config{Sinks: []SinkConfig{{Type: SinkTypePagerDuty}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown sink",
+			cfg:     Config{Sinks: []SinkConfig{{Type: "email", URL: "http://localhost"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+// Package notifier implements the alert notifier that pushes the component events
+// and the state health transitions to the webhook, Slack, and PagerDuty sinks.
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/watch"
+)
+
+// Notification is the alert delivered to the sinks.
+type Notification struct {
+	// Source is the host that generated the notification.
+	Source    string              `json:"source"`
+	Component string              `json:"component"`
+	Time      time.Time           `json:"time"`
+	Severity  common.EventType    `json:"severity"`
+	Summary   string              `json:"summary"`
+	Event     *components.Event   `json:"event,omitempty"`
+	State     *v1.StateTransition `json:"state,omitempty"`
+
+	// IncidentKey identifies the incident that the notification belongs to
+	// (e.g., the same component state), so that the recovery can resolve it.
+	IncidentKey string `json:"incident_key"`
+	// Resolved is true if the notification is the recovery of the incident.
+	Resolved bool `json:"resolved,omitempty"`
+}
+
+// dedupKey identifies the duplicate notifications.
+func (n Notification) dedupKey() string {
+	if n.State != nil {
+		return n.IncidentKey + "/" + n.State.Health
+	}
+	return n.IncidentKey + "/" + string(n.Severity) + "/" + n.Event.Message
+}
+
+// FromWatchEvent converts the watch event to the notification.
+// Returns false if the watch event should not be notified
+// based on the configuration.
+func FromWatchEvent(cfg Config, source string, ev v1.WatchEvent) (Notification, bool) {
+	switch {
+	case ev.Kind == v1.WatchEventKindEvent && ev.Event != nil:
+		if ev.Event.Type.Severity() < cfg.MinEventType.Severity() {
+			return Notification{}, false
+		}
+		return Notification{
+			Source:      source,
+			Component:   ev.Component,
+			Time:        ev.Time,
+			Severity:    ev.Event.Type,
+			Summary:     fmt.Sprintf("%s: %s event %q", ev.Component, ev.Event.Type, ev.Event.Name),
+			Event:       ev.Event,
+			IncidentKey: source + "/" + ev.Component + "/" + ev.Event.Name,
+		}, true
+
+	case ev.Kind == v1.WatchEventKindState && ev.State != nil:
+		if !cfg.HealthTransitions {
+			return Notification{}, false
+		}
+		return Notification{
+			Source:      source,
+			Component:   ev.Component,
+			Time:        ev.Time,
+			Severity:    healthSeverity(ev.State.Health),
+			Summary:     fmt.Sprintf("%s: %s is %s (was %s)", ev.Component, ev.State.Name, ev.State.Health, ev.State.PreviousHealth),
+			State:       ev.State,
+			IncidentKey: source + "/" + ev.Component + "/" + ev.State.Name,
+			Resolved:    ev.State.Health == components.StateHealthy,
+		}, true
+
+	default:
+		return Notification{}, false
+	}
+}
+
+func healthSeverity(health string) common.EventType {
+	switch health {
+	case components.StateUnhealthy:
+		return common.EventTypeCritical
+	case components.StateDegraded:
+		return common.EventTypeWarning
+	default:
+		return common.EventTypeInfo
+	}
+}
+
+// Notifier delivers the notifications to the configured sinks.
+type Notifier struct {
+	cfg    Config
+	source string
+	sinks  []*sinkWorker
+
+	dedupMu sync.Mutex
+	// last notified time, keyed by the dedup key
+	dedup map[string]time.Time
+
+	// for testing
+	timeNow func() time.Time
+}
+
+// New creates the notifier from the configuration.
+// The configuration defaults are set for the unset fields.
+func New(cfg Config) (*Notifier, error) {
+	// not to modify the caller's sinks when setting the defaults
+	cfg.Sinks = append([]SinkConfig(nil), cfg.Sinks...)
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	source, err := os.Hostname()
+	if err != nil {
+		source = "gpud"
+	}
+
+	cli := &http.Client{Timeout: 30 * time.Second}
+	n := &Notifier{
+		cfg:     cfg,
+		source:  source,
+		dedup:   make(map[string]time.Time),
+		timeNow: time.Now,
+	}
+	for _, sc := range cfg.Sinks {
+		sink, err := newSink(sc, cli)
+		if err != nil {
+			return nil, err
+		}
+		n.sinks = append(n.sinks, newSinkWorker(sink, cfg))
+	}
+	return n, nil
+}
+
+// Run subscribes the watch events and delivers the notifications,
+// until the context is canceled.
+func (n *Notifier) Run(ctx context.Context, broker *watch.Broker) {
+	for _, w := range n.sinks {
+		go w.run(ctx)
+	}
+
+	ch, unsubscribe := broker.Subscribe(watch.Filter{})
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			notification, ok := FromWatchEvent(n.cfg, n.source, ev)
+			if !ok {
+				continue
+			}
+			n.Notify(notification)
+		}
+	}
+}
+
+// Notify enqueues the notification to all the sinks,
+// unless the same notification was sent within the dedup window.
+func (n *Notifier) Notify(notification Notification) {
+	if n.isDuplicate(notification) {
+		log.Logger.Debugw("duplicate notification -- skipping", "component", notification.Component, "summary", notification.Summary)
+		return
+	}
+	for _, w := range n.sinks {
+		w.enqueue(notification)
+	}
+}
+
+func (n *Notifier) isDuplicate(notification Notification) bool {
+	if n.cfg.DedupWindow.Duration == 0 { // disabled
+		return false
+	}
+
+	now := n.timeNow()
+	key := notification.dedupKey()
+
+	n.dedupMu.Lock()
+	defer n.dedupMu.Unlock()
+
+	for k, t := range n.dedup {
+		if now.Sub(t) >= n.cfg.DedupWindow.Duration {
+			delete(n.dedup, k)
+		}
+	}
+	if _, ok := n.dedup[key]; ok {
+		return true
+	}
+
+	// only the last notified health of the state is deduplicated,
+	// so that the incident triggered again after the recovery
+	// (or recovered again) within the window is still notified
+	if notification.State != nil {
+		prefix := notification.IncidentKey + "/"
+		for k := range n.dedup {
+			if strings.HasPrefix(k, prefix) {
+				delete(n.dedup, k)
+			}
+		}
+	}
+	n.dedup[key] = now
+	return false
+}
+
+// defaultSinkQueueSize is the number of pending notifications per sink.
+const defaultSinkQueueSize = 128
+
+// sinkWorker delivers the notifications to a sink,
+// with the rate limit and retries.
+type sinkWorker struct {
+	sink          Sink
+	rateLimit     int
+	maxRetries    int
+	retryInterval time.Duration
+
+	queue chan Notification
+
+	// delivery times within the last minute, for the rate limit (zero to disable)
+	sent []time.Time
+
+	// for testing
+	timeNow func() time.Time
+}
+
+func newSinkWorker(sink Sink, cfg Config) *sinkWorker {
+	return &sinkWorker{
+		sink:          sink,
+		rateLimit:     cfg.RateLimit,
+		maxRetries:    cfg.MaxRetries,
+		retryInterval: cfg.RetryInterval.Duration,
+		queue:         make(chan Notification, defaultSinkQueueSize),
+		timeNow:       time.Now,
+	}
+}
+
+func (w *sinkWorker) enqueue(notification Notification) {
+	select {
+	case w.queue <- notification:
+	default:
+		log.Logger.Warnw("notification queue full -- dropping notification", "sink", w.sink.Name(), "component", notification.Component)
+	}
+}
+
+func (w *sinkWorker) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-w.queue:
+			if !w.allow() {
+				log.Logger.Warnw("notification rate limit exceeded -- dropping notification", "sink", w.sink.Name(), "component", notification.Component)
+				continue
+			}
+			if err := w.send(ctx, notification); err != nil {
+				log.Logger.Warnw("failed to send notification", "sink", w.sink.Name(), "component", notification.Component, "error", err)
+			}
+		}
+	}
+}
+
+// allow returns true if the sink has not reached the rate limit
+// in the last minute, and records the delivery.
+func (w *sinkWorker) allow() bool {
+	now := w.timeNow()
+
+	recent := w.sent[:0]
+	for _, t := range w.sent {
+		if now.Sub(t) < time.Minute {
+			recent = append(recent, t)
+		}
+	}
+	w.sent = recent
+
+	if w.rateLimit > 0 && len(w.sent) >= w.rateLimit {
+		return false
+	}
+	w.sent = append(w.sent, now)
+	return true
+}
+
+// send delivers the notification, retrying the retryable errors
+// with the exponential backoff.
+func (w *sinkWorker) send(ctx context.Context, notification Notification) error {
+	interval := w.retryInterval
+	for attempt := 0; ; attempt++ {
+		err := w.sink.Send(ctx, notification)
+		if err == nil {
+			return nil
+		}
+
+		var rerr *retryableError
+		if !errors.As(err, &rerr) || attempt >= w.maxRetries {
+			return err
+		}
+
+		log.Logger.Debugw("retrying notification", "sink", w.sink.Name(), "attempt", attempt+1, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
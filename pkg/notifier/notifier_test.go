@@ -0,0 +1,311 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/watch"
+)
+
+// recorder is the httptest stand-in for the sinks.
+type recorder struct {
+	mu       sync.Mutex
+	bodies   [][]byte
+	statuses []int // response status per request, 200 once exhausted
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	b, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	r.bodies = append(r.bodies, b)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status = r.statuses[0]
+		r.statuses = r.statuses[1:]
+	}
+	r.mu.Unlock()
+
+	w.WriteHeader(status)
+}
+
+func (r *recorder) requests() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]byte(nil), r.bodies...)
+}
+
+func criticalEvent(component string) v1.WatchEvent {
+	return v1.WatchEvent{
+		Kind:      v1.WatchEventKindEvent,
+		Component: component,
+		Time:      time.Now(),
+		Event: &components.Event{
+			Time:    metav1.Now(),
+			Name:    "xid",
+			Type:    common.EventTypeCritical,
+			Message: "Xid 79 detected",
+		},
+	}
+}
+
+func TestFromWatchEvent(t *testing.T) {
+	cfg := Config{MinEventType: common.EventTypeCritical}
+
+	n, ok := FromWatchEvent(cfg, "host", criticalEvent("xid"))
+	require.True(t, ok)
+	require.Equal(t, common.EventTypeCritical, n.Severity)
+	require.Equal(t, "host", n.Source)
+	require.Equal(t, "host/xid/xid", n.IncidentKey)
+
+	warning := criticalEvent("xid")
+	warning.Event.Type = common.EventTypeWarning
+	_, ok = FromWatchEvent(cfg, "host", warning)
+	require.False(t, ok, "below the min event type")
+
+	transition := v1.WatchEvent{
+		Kind:      v1.WatchEventKindState,
+		Component: "cpu",
+		State:     &v1.StateTransition{Name: "cpu", PreviousHealth: components.StateHealthy, Health: components.StateUnhealthy},
+	}
+	_, ok = FromWatchEvent(cfg, "host", transition)
+	require.False(t, ok, "health transitions disabled")
+
+	cfg.HealthTransitions = true
+	n, ok = FromWatchEvent(cfg, "host", transition)
+	require.True(t, ok)
+	require.Equal(t, common.EventTypeCritical, n.Severity)
+	require.False(t, n.Resolved)
+
+	transition.State = &v1.StateTransition{Name: "cpu", PreviousHealth: components.StateUnhealthy, Health: components.StateHealthy}
+	n, ok = FromWatchEvent(cfg, "host", transition)
+	require.True(t, ok)
+	require.True(t, n.Resolved)
+	require.Equal(t, "host/cpu/cpu", n.IncidentKey)
+}
+
+func newTestNotifier(t *testing.T, cfg Config) *Notifier {
+	n, err := New(cfg)
+	require.NoError(t, err)
+	n.source = "test-host"
+	return n
+}
+
+func waitForRequests(t *testing.T, r *recorder, count int) [][]byte {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		reqs := r.requests()
+		if len(reqs) >= count {
+			return reqs
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d requests, got %d", count, len(reqs))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNotifierSinks(t *testing.T) {
+	webhook, slack, pagerduty := &recorder{}, &recorder{}, &recorder{}
+	webhookSrv := httptest.NewServer(webhook)
+	defer webhookSrv.Close()
+	slackSrv := httptest.NewServer(slack)
+	defer slackSrv.Close()
+	pagerdutySrv := httptest.NewServer(pagerduty)
+	defer pagerdutySrv.Close()
+
+	n := newTestNotifier(t, Config{
+		HealthTransitions: true,
+		Sinks: []SinkConfig{
+			{Type: SinkTypeWebhook, URL: webhookSrv.URL},
+			{Type: SinkTypeSlack, URL: slackSrv.URL},
+			{Type: SinkTypePagerDuty, URL: pagerdutySrv.URL, RoutingKey: "routing-key"},
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := watch.NewBroker()
+	go n.Run(ctx, broker)
+	require.Eventually(t, func() bool {
+		// the notifier subscribed
+		_, unsubscribe := broker.Subscribe(watch.Filter{})
+		unsubscribe()
+		broker.Publish(criticalEvent("accelerator-nvidia-xid"))
+		return len(webhook.requests()) > 0
+	}, 10*time.Second, 50*time.Millisecond)
+
+	var got Notification
+	require.NoError(t, json.Unmarshal(waitForRequests(t, webhook, 1)[0], &got))
+	require.Equal(t, "accelerator-nvidia-xid", got.Component)
+	require.Equal(t, common.EventTypeCritical, got.Severity)
+	require.Equal(t, "test-host", got.Source)
+	require.NotNil(t, got.Event)
+
+	var slackMsg slackMessage
+	require.NoError(t, json.Unmarshal(waitForRequests(t, slack, 1)[0], &slackMsg))
+	require.Contains(t, slackMsg.Text, "*[Critical]*")
+	require.Contains(t, slackMsg.Text, "Xid 79 detected")
+
+	var pdEvent pagerDutyEvent
+	require.NoError(t, json.Unmarshal(waitForRequests(t, pagerduty, 1)[0], &pdEvent))
+	require.Equal(t, "routing-key", pdEvent.RoutingKey)
+	require.Equal(t, "trigger", pdEvent.EventAction)
+	require.Equal(t, "error", pdEvent.Payload.Severity)
+	require.Equal(t, "test-host", pdEvent.Payload.Source)
+
+	// recovery resolves the pagerduty incident
+	broker.ObserveStates("cpu", []components.State{{Name: "cpu", Healthy: false}})
+	broker.ObserveStates("cpu", []components.State{{Name: "cpu", Healthy: true}})
+	reqs := waitForRequests(t, pagerduty, 2)
+	pdEvent = pagerDutyEvent{}
+	require.NoError(t, json.Unmarshal(reqs[len(reqs)-1], &pdEvent))
+	require.Equal(t, "resolve", pdEvent.EventAction)
+	require.Equal(t, "test-host/cpu/cpu", pdEvent.DedupKey)
+	require.Nil(t, pdEvent.Payload)
+}
+
+func TestNotifierDedup(t *testing.T) {
+	n := newTestNotifier(t, Config{
+		DedupWindow: metav1.Duration{Duration: time.Minute},
+		Sinks:       []SinkConfig{{Type: SinkTypeWebhook, URL: "http://localhost"}},
+	})
+	now := time.Now()
+	n.timeNow = func() time.Time { return now }
+
+	notification, ok := FromWatchEvent(n.cfg, n.source, criticalEvent("xid"))
+	require.True(t, ok)
+
+	require.False(t, n.isDuplicate(notification))
+	require.True(t, n.isDuplicate(notification))
+
+	other := notification
+	other.Event = &components.Event{Name: "xid", Type: common.EventTypeCritical, Message: "Xid 48 detected"}
+	require.False(t, n.isDuplicate(other), "different message")
+
+	now = now.Add(time.Minute)
+	require.False(t, n.isDuplicate(notification), "dedup window elapsed")
+
+	n = newTestNotifier(t, Config{
+		DisableDedup: true,
+		Sinks:        []SinkConfig{{Type: SinkTypeWebhook, URL: "http://localhost"}},
+	})
+	require.False(t, n.isDuplicate(notification))
+	require.False(t, n.isDuplicate(notification), "dedup disabled")
+}
+
+func TestNotifierDedupStateTransitions(t *testing.T) {
+	n := newTestNotifier(t, Config{
+		DedupWindow:       metav1.Duration{Duration: time.Minute},
+		HealthTransitions: true,
+		Sinks:             []SinkConfig{{Type: SinkTypeWebhook, URL: "http://localhost"}},
+	})
+	now := time.Now()
+	n.timeNow = func() time.Time { return now }
+
+	unhealthy := Notification{IncidentKey: "host/cpu/cpu", State: &v1.StateTransition{Health: components.StateUnhealthy}}
+	healthy := Notification{IncidentKey: "host/cpu/cpu", State: &v1.StateTransition{Health: components.StateHealthy}, Resolved: true}
+
+	require.False(t, n.isDuplicate(unhealthy))
+	require.True(t, n.isDuplicate(unhealthy), "same health within the window")
+	require.False(t, n.isDuplicate(healthy))
+
+	// triggered again after the recovery within the window
+	now = now.Add(10 * time.Second)
+	require.False(t, n.isDuplicate(unhealthy))
+	require.False(t, n.isDuplicate(healthy))
+
+	other := Notification{IncidentKey: "host/memory/memory", State: &v1.StateTransition{Health: components.StateUnhealthy}}
+	require.False(t, n.isDuplicate(other), "different incident")
+	require.True(t, n.isDuplicate(healthy), "other incident does not clear the dedup")
+}
+
+func TestSinkWorkerRateLimit(t *testing.T) {
+	w := newSinkWorker(nil, Config{RateLimit: 2})
+	now := time.Now()
+	w.timeNow = func() time.Time { return now }
+
+	require.True(t, w.allow())
+	require.True(t, w.allow())
+	require.False(t, w.allow())
+
+	now = now.Add(time.Minute)
+	require.True(t, w.allow())
+
+	cfg := Config{DisableRateLimit: true}
+	cfg.SetDefaults()
+	w = newSinkWorker(nil, cfg)
+	for i := 0; i < 2*DefaultRateLimit; i++ {
+		require.True(t, w.allow())
+	}
+}
+
+func TestSinkWorkerRetry(t *testing.T) {
+	r := &recorder{statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	cfg := Config{MaxRetries: 3, RetryInterval: metav1.Duration{Duration: time.Millisecond}}
+	sink, err := newSink(SinkConfig{Type: SinkTypeWebhook, URL: srv.URL}, srv.Client())
+	require.NoError(t, err)
+	w := newSinkWorker(sink, cfg)
+
+	notification, _ := FromWatchEvent(Config{}, "host", criticalEvent("xid"))
+	require.NoError(t, w.send(context.Background(), notification))
+	require.Len(t, r.requests(), 3)
+
+	// 4xx is not retried
+	r = &recorder{statuses: []int{http.StatusBadRequest}}
+	srv4xx := httptest.NewServer(r)
+	defer srv4xx.Close()
+	sink, err = newSink(SinkConfig{Type: SinkTypeWebhook, URL: srv4xx.URL}, srv4xx.Client())
+	require.NoError(t, err)
+	w = newSinkWorker(sink, cfg)
+	err = w.send(context.Background(), notification)
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "400"))
+	require.Len(t, r.requests(), 1)
+
+	// gives up after the max retries
+	r = &recorder{statuses: []int{500, 500, 500, 500, 500}}
+	srv5xx := httptest.NewServer(r)
+	defer srv5xx.Close()
+	sink, err = newSink(SinkConfig{Type: SinkTypeWebhook, URL: srv5xx.URL}, srv5xx.Client())
+	require.NoError(t, err)
+	w = newSinkWorker(sink, cfg)
+	require.Error(t, w.send(context.Background(), notification))
+	require.Len(t, r.requests(), 4)
+
+	// no retry if disabled
+	r = &recorder{statuses: []int{500, 500}}
+	srvNoRetry := httptest.NewServer(r)
+	defer srvNoRetry.Close()
+	sink, err = newSink(SinkConfig{Type: SinkTypeWebhook, URL: srvNoRetry.URL}, srvNoRetry.Client())
+	require.NoError(t, err)
+	noRetryCfg := Config{DisableRetries: true, MaxRetries: 3}
+	noRetryCfg.SetDefaults()
+	w = newSinkWorker(sink, noRetryCfg)
+	require.Error(t, w.send(context.Background(), notification))
+	require.Len(t, r.requests(), 1)
+}
+
+func TestNewDoesNotModifySinks(t *testing.T) {
+	sinks := []SinkConfig{{Type: SinkTypePagerDuty, RoutingKey: "key"}}
+	_, err := New(Config{Sinks: sinks})
+	require.NoError(t, err)
+	require.Empty(t, sinks[0].URL)
+}
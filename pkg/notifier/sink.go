@@ -0,0 +1,164 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+// Sink delivers the notification.
+type Sink interface {
+	// Name returns the sink name for logging.
+	Name() string
+	// Send delivers the notification once (no retry).
+	// Returns a retryable error if the delivery may succeed on retry.
+	Send(ctx context.Context, n Notification) error
+}
+
+// retryableError marks the delivery errors worth retrying
+// (e.g., connection errors, 5xx, 429).
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+func newSink(cfg SinkConfig, cli *http.Client) (Sink, error) {
+	switch cfg.Type {
+	case SinkTypeWebhook:
+		return &httpSink{name: string(cfg.Type), cfg: cfg, cli: cli, encode: encodeWebhook}, nil
+	case SinkTypeSlack:
+		return &httpSink{name: string(cfg.Type), cfg: cfg, cli: cli, encode: encodeSlack}, nil
+	case SinkTypePagerDuty:
+		return &httpSink{name: string(cfg.Type), cfg: cfg, cli: cli, encode: encodePagerDuty}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+}
+
+type httpSink struct {
+	name   string
+	cfg    SinkConfig
+	cli    *http.Client
+	encode func(cfg SinkConfig, n Notification) ([]byte, error)
+}
+
+func (s *httpSink) Name() string { return s.name }
+
+func (s *httpSink) Send(ctx context.Context, n Notification) error {
+	b, err := s.encode(s.cfg, n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return &retryableError{err: fmt.Errorf("failed to send %s notification: %w", s.name, err)}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s notification rejected with status %d", s.name, resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return &retryableError{err: err}
+	}
+	return err
+}
+
+func encodeWebhook(_ SinkConfig, n Notification) ([]byte, error) {
+	return json.Marshal(n)
+}
+
+// slackMessage is the Slack incoming webhook payload.
+// ref. https://api.slack.com/messaging/webhooks
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func encodeSlack(_ SinkConfig, n Notification) ([]byte, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*[%s]* %s\n", n.Severity, n.Summary)
+	fmt.Fprintf(&sb, "source: `%s`, component: `%s`, time: %s", n.Source, n.Component, n.Time.UTC().Format("2006-01-02T15:04:05Z"))
+	if n.Event != nil && n.Event.Message != "" {
+		fmt.Fprintf(&sb, "\n> %s", n.Event.Message)
+	}
+	if n.State != nil && n.State.Reason != "" {
+		fmt.Fprintf(&sb, "\n> %s", n.State.Reason)
+	}
+	return json.Marshal(slackMessage{Text: sb.String()})
+}
+
+// pagerDutyEvent is the PagerDuty Events API v2 payload.
+// ref. https://developer.pagerduty.com/docs/events-api-v2/trigger-events/
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key,omitempty"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string `json:"summary"`
+	Source        string `json:"source"`
+	Severity      string `json:"severity"`
+	Timestamp     string `json:"timestamp,omitempty"`
+	Component     string `json:"component,omitempty"`
+	CustomDetails any    `json:"custom_details,omitempty"`
+}
+
+func encodePagerDuty(cfg SinkConfig, n Notification) ([]byte, error) {
+	ev := pagerDutyEvent{
+		RoutingKey:  cfg.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    n.IncidentKey,
+	}
+	if n.Resolved {
+		// resolves the incident triggered by the same component state
+		ev.EventAction = "resolve"
+		return json.Marshal(ev)
+	}
+
+	ev.Payload = &pagerDutyPayload{
+		Summary:       n.Summary,
+		Source:        n.Source,
+		Severity:      pagerDutySeverity(n.Severity),
+		Timestamp:     n.Time.UTC().Format("2006-01-02T15:04:05.000Z"),
+		Component:     n.Component,
+		CustomDetails: n,
+	}
+	return json.Marshal(ev)
+}
+
+// pagerDutySeverity maps the event type to the PagerDuty severity
+// ("critical", "error", "warning", "info").
+func pagerDutySeverity(t common.EventType) string {
+	switch t {
+	case common.EventTypeFatal:
+		return "critical"
+	case common.EventTypeCritical:
+		return "error"
+	case common.EventTypeWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,152 @@
+// Package catalog loads the user-supplied overlay of the NVIDIA XID and SXID catalogs,
+// to change the severity and the suggested actions of the codes compiled into GPUd,
+// or to add the codes that the newer drivers emit.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/nvidia-query/sxid"
+	"github.com/leptonai/gpud/pkg/nvidia-query/xid"
+)
+
+// Overlay is the XID and SXID catalog overlay, in YAML or JSON.
+//
+//	xids:
+//	- xid: 13
+//	  event_type: Critical
+//	  critical_error_marked_by_gpud: true
+//	  suggested_actions_by_gpud:
+//	    repair_actions:
+//	    - CHECK_USER_APP_AND_GPU
+//	- xid: 160
+//	  name: A new Xid
+//	  event_type: Warning
+type Overlay struct {
+	XIDs  []xid.Override  `json:"xids,omitempty"`
+	SXIDs []sxid.Override `json:"sxids,omitempty"`
+}
+
+// LoadFile reads and validates the overlay file.
+func LoadFile(file string) (*Overlay, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	o := &Overlay{}
+	if err := yaml.UnmarshalStrict(b, o); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog overlay %q: %w", file, err)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid catalog overlay %q: %w", file, err)
+	}
+	return o, nil
+}
+
+func (o *Overlay) Validate() error {
+	for _, ov := range o.XIDs {
+		if err := validate(ov.EventType, ov.SuggestedActionsByGPUd); err != nil {
+			return fmt.Errorf("xid %d: %w", ov.Xid, err)
+		}
+	}
+	for _, ov := range o.SXIDs {
+		if err := validate(ov.EventType, ov.SuggestedActionsByGPUd); err != nil {
+			return fmt.Errorf("sxid %d: %w", ov.SXid, err)
+		}
+	}
+
+	// dry-run of the catalog merges (e.g., missing fields of the new codes)
+	if err := xid.ValidateOverrides(o.XIDs); err != nil {
+		return err
+	}
+	return sxid.ValidateOverrides(o.SXIDs)
+}
+
+var knownRepairActions = map[common.RepairActionType]struct{}{
+	common.RepairActionTypeIgnoreNoActionRequired: {},
+	common.RepairActionTypeRebootSystem:           {},
+	common.RepairActionTypeHardwareInspection:     {},
+	common.RepairActionTypeCheckUserAppAndGPU:     {},
+}
+
+func validate(eventType common.EventType, actions *common.SuggestedActions) error {
+	if eventType != "" && common.EventTypeFromString(string(eventType)) == common.EventTypeUnknown {
+		return fmt.Errorf("unknown event type %q", eventType)
+	}
+	if actions == nil {
+		return nil
+	}
+	if len(actions.RepairActions) == 0 {
+		return errors.New("suggested actions without any repair action")
+	}
+	for _, a := range actions.RepairActions {
+		if _, ok := knownRepairActions[a]; !ok {
+			return fmt.Errorf("unknown repair action %q", a)
+		}
+	}
+	return nil
+}
+
+// Apply replaces the overrides of the XID and SXID catalogs.
+func (o *Overlay) Apply() error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if err := xid.SetOverrides(o.XIDs); err != nil {
+		return err
+	}
+	return sxid.SetOverrides(o.SXIDs)
+}
+
+var (
+	appliedMu   sync.RWMutex
+	appliedFile string
+)
+
+// ApplyFile loads and applies the overlay file,
+// or resets the catalogs to the ones compiled into GPUd if the file is empty.
+// The catalogs are left as is if the overlay is invalid.
+func ApplyFile(file string) error {
+	o := &Overlay{}
+	if file != "" {
+		var err error
+		o, err = LoadFile(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	appliedMu.Lock()
+	defer appliedMu.Unlock()
+	if err := o.Apply(); err != nil {
+		return err
+	}
+	appliedFile = file
+	return nil
+}
+
+// Catalog is the XID and SXID catalogs in effect, with the overlay applied.
+type Catalog struct {
+	// File is the overlay file applied, empty if none.
+	File string `json:"file,omitempty"`
+
+	XIDs  []xid.Detail  `json:"xids"`
+	SXIDs []sxid.Detail `json:"sxids"`
+}
+
+// Get returns the catalogs in effect.
+func Get() Catalog {
+	appliedMu.RLock()
+	defer appliedMu.RUnlock()
+	return Catalog{
+		File:  appliedFile,
+		XIDs:  xid.Details(),
+		SXIDs: sxid.Details(),
+	}
+}
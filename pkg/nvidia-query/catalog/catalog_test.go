@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/nvidia-query/sxid"
+	"github.com/leptonai/gpud/pkg/nvidia-query/xid"
+)
+
+func writeFile(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "catalog.yaml")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadFileAndApply(t *testing.T) {
+	defer func() {
+		if err := ApplyFile(""); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	file := writeFile(t, `
+xids:
+- xid: 13
+  event_type: Critical
+  critical_error_marked_by_gpud: true
+  suggested_actions_by_gpud:
+    repair_actions:
+    - CHECK_USER_APP_AND_GPU
+- xid: 9999
+  name: A new Xid
+  event_type: Warning
+sxids:
+- sxid: 99999
+  name: A new SXid
+  event_type: Fatal
+`)
+	if err := ApplyFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	d, ok := xid.GetDetail(13)
+	if !ok || d.EventType != common.EventTypeCritical || !d.CriticalErrorMarkedByGPUd {
+		t.Fatalf("unexpected Xid 13 %+v", d)
+	}
+	if _, ok := xid.GetDetail(9999); !ok {
+		t.Fatal("expected the new Xid")
+	}
+	if d, ok := sxid.GetDetail(99999); !ok || d.EventType != common.EventTypeFatal {
+		t.Fatalf("unexpected new SXid %+v", d)
+	}
+
+	c := Get()
+	if c.File != file || len(c.XIDs) == 0 || len(c.SXIDs) == 0 {
+		t.Fatalf("unexpected catalog %s (%d xids, %d sxids)", c.File, len(c.XIDs), len(c.SXIDs))
+	}
+
+	// kept as is on the invalid overlay
+	if err := ApplyFile(writeFile(t, "xids:\n- xid: 0\n")); err == nil {
+		t.Fatal("expected error")
+	}
+	if c := Get(); c.File != file {
+		t.Fatalf("expected the overlay %q kept, got %q", file, c.File)
+	}
+
+	if err := ApplyFile(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := xid.GetDetail(9999); ok {
+		t.Fatal("expected the new Xid removed")
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown field":         "xids:\n- xid: 13\n  severity: Critical\n",
+		"unknown event type":    "xids:\n- xid: 13\n  event_type: Bad\n",
+		"unknown repair action": "xids:\n- xid: 13\n  suggested_actions_by_gpud:\n    repair_actions:\n    - REPLACE_GPU\n",
+		"no repair action":      "sxids:\n- sxid: 11004\n  suggested_actions_by_gpud:\n    descriptions:\n    - x\n",
+		"new without name":      "xids:\n- xid: 9999\n  event_type: Warning\n",
+		"json":                  `{"xids": [{"xid": "13"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadFile(writeFile(t, content)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected error for the missing file")
+	}
+
+	// the valid JSON overlay
+	o, err := LoadFile(writeFile(t, `{"xids": [{"xid": 13, "event_type": "Warning"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.XIDs) != 1 {
+		t.Fatalf("expected 1 override, got %d", len(o.XIDs))
+	}
+}
@@ -0,0 +1,138 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	"github.com/leptonai/gpud/pkg/log"
+	metrics_ecc "github.com/leptonai/gpud/pkg/nvidia-query/metrics/ecc"
+	metrics_remapped_rows "github.com/leptonai/gpud/pkg/nvidia-query/metrics/remapped-rows"
+	"github.com/leptonai/gpud/pkg/nvidia-query/nvml"
+)
+
+const (
+	EventNameAggregateTotalCorrectedIncrease          = "aggregate_total_corrected_increase"
+	EventNameAggregateTotalUncorrectedIncrease        = "aggregate_total_uncorrected_increase"
+	EventNameRemappedDueToUncorrectableErrorsIncrease = "remapped_due_to_uncorrectable_errors_increase"
+)
+
+// counterIncrease records an event when the per-GPU error counter
+// (e.g., the aggregate ECC errors) increased since the previous poll.
+type counterIncrease struct {
+	eventName string
+	eventType common.EventType
+	// message is the format of the event message, with the increase and the GPU UUID
+	message string
+	// metricName is the name of the counter metric, to compare the first poll
+	// with the last value recorded before the restart
+	metricName string
+
+	mu sync.Mutex
+	// last is the last polled value, keyed by the GPU UUID
+	last map[string]float64
+}
+
+var (
+	aggregateTotalCorrectedIncrease = &counterIncrease{
+		eventName:  EventNameAggregateTotalCorrectedIncrease,
+		eventType:  common.EventTypeWarning,
+		message:    "%.0f new corrected ECC errors on %s",
+		metricName: metrics_ecc.MetricNameAggregateTotalCorrected,
+	}
+	aggregateTotalUncorrectedIncrease = &counterIncrease{
+		eventName:  EventNameAggregateTotalUncorrectedIncrease,
+		eventType:  common.EventTypeCritical,
+		message:    "%.0f new uncorrected ECC errors on %s",
+		metricName: metrics_ecc.MetricNameAggregateTotalUncorrected,
+	}
+	remappedDueToUncorrectableErrorsIncrease = &counterIncrease{
+		eventName:  EventNameRemappedDueToUncorrectableErrorsIncrease,
+		eventType:  common.EventTypeWarning,
+		message:    "%.0f new rows remapped due to uncorrectable errors on %s",
+		metricName: metrics_remapped_rows.MetricNameRemappedDueToUncorrectableErrors,
+	}
+)
+
+// observe returns the event if the counter of the GPU increased since the previous poll,
+// or nil if not increased (e.g., the first poll without any recorded metric,
+// or the counter reset by the driver reload).
+// To be called before the polled value is recorded as the metric.
+func (c *counterIncrease) observe(ctx context.Context, store components_metrics_state.Store, gpuUUID string, v float64, now time.Time) *components.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last == nil {
+		c.last = make(map[string]float64)
+	}
+	prev, ok := c.last[gpuUUID]
+	if !ok {
+		prev, ok = c.lastRecorded(ctx, store, gpuUUID)
+	}
+	c.last[gpuUUID] = v
+	if !ok || v <= prev {
+		return nil
+	}
+
+	return &components.Event{
+		Time:      metav1.Time{Time: now},
+		Name:      c.eventName,
+		Type:      c.eventType,
+		Message:   fmt.Sprintf(c.message, v-prev, gpuUUID),
+		ExtraInfo: map[string]string{components.StateKeyGPUUUID: gpuUUID},
+	}
+}
+
+// lastRecorded returns the last metric value of the GPU in the store,
+// or false if not recorded (or no store is set).
+func (c *counterIncrease) lastRecorded(ctx context.Context, store components_metrics_state.Store, gpuUUID string) (float64, bool) {
+	if store == nil {
+		return 0, false
+	}
+	last, err := store.ReadLast(ctx, c.metricName, gpuUUID)
+	if err != nil {
+		log.Logger.Warnw("failed to read the last counter value -- using the current value as the baseline", "event", c.eventName, "gpu_uuid", gpuUUID, "error", err)
+		return 0, false
+	}
+	if last == nil {
+		return 0, false
+	}
+	return last.Value, true
+}
+
+// insertCounterEvents inserts the events of the error counters that increased since the previous poll.
+// The events are not recorded if the bucket is not set (e.g., "gpud scan").
+func insertCounterEvents(ctx context.Context, op *Op, dev *nvml.DeviceInfo, now time.Time) {
+	for _, c := range []struct {
+		bucket eventstore.Bucket
+		inc    *counterIncrease
+		v      float64
+	}{
+		{op.eccEventsBucket, aggregateTotalCorrectedIncrease, float64(dev.ECCErrors.Aggregate.Total.Corrected)},
+		{op.eccEventsBucket, aggregateTotalUncorrectedIncrease, float64(dev.ECCErrors.Aggregate.Total.Uncorrected)},
+		{op.remappedRowsEventsBucket, remappedDueToUncorrectableErrorsIncrease, float64(dev.RemappedRows.RemappedDueToUncorrectableErrors)},
+	} {
+		if c.bucket == nil {
+			continue
+		}
+		ev := c.inc.observe(ctx, op.metricsStore, dev.UUID, c.v, now)
+		if ev == nil {
+			continue
+		}
+
+		log.Logger.Infow("inserting counter increase event to db", "event", ev.Name, "gpu_uuid", dev.UUID, "message", ev.Message)
+		cctx, ccancel := context.WithTimeout(ctx, 15*time.Second)
+		err := c.bucket.Insert(cctx, *ev)
+		ccancel()
+		if err != nil {
+			log.Logger.Warnw("failed to insert counter increase event to db", "event", ev.Name, "gpu_uuid", dev.UUID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+)
+
+func TestCounterIncreaseObserve(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0).UTC()
+
+	// the last values recorded before the restart
+	const metricName = "test_aggregate_total_uncorrected"
+	store := components_metrics_state.NewMemoryStore(0)
+	for _, m := range []components_metrics_state.Metric{
+		{UnixSeconds: now.Unix() - 120, MetricName: metricName, MetricSecondaryName: "GPU-a", Value: 1},
+		{UnixSeconds: now.Unix() - 60, MetricName: metricName, MetricSecondaryName: "GPU-a", Value: 2},
+		{UnixSeconds: now.Unix() - 60, MetricName: metricName, MetricSecondaryName: "GPU-b", Value: 5},
+		{UnixSeconds: now.Unix() - 60, MetricName: "other", MetricSecondaryName: "GPU-c", Value: 1},
+	} {
+		assert.NoError(t, store.Insert(ctx, m))
+	}
+
+	c := &counterIncrease{
+		eventName:  EventNameAggregateTotalUncorrectedIncrease,
+		eventType:  common.EventTypeCritical,
+		message:    "%.0f new uncorrected ECC errors on %s",
+		metricName: metricName,
+	}
+
+	// increased since the last recorded value
+	ev := c.observe(ctx, store, "GPU-a", 5, now)
+	assert.NotNil(t, ev)
+	assert.Equal(t, EventNameAggregateTotalUncorrectedIncrease, ev.Name)
+	assert.Equal(t, common.EventTypeCritical, ev.Type)
+	assert.Equal(t, "3 new uncorrected ECC errors on GPU-a", ev.Message)
+	assert.Equal(t, "GPU-a", ev.ExtraInfo[components.StateKeyGPUUUID])
+	assert.Equal(t, now, ev.Time.Time)
+
+	// unchanged, and then increased since the previous poll
+	assert.Nil(t, c.observe(ctx, store, "GPU-a", 5, now.Add(time.Minute)))
+	ev = c.observe(ctx, store, "GPU-a", 6, now.Add(2*time.Minute))
+	assert.NotNil(t, ev)
+	assert.Equal(t, "1 new uncorrected ECC errors on GPU-a", ev.Message)
+
+	// reset by the driver reload
+	assert.Nil(t, c.observe(ctx, store, "GPU-b", 0, now))
+	assert.NotNil(t, c.observe(ctx, store, "GPU-b", 1, now.Add(time.Minute)))
+
+	// the baseline of the GPU without any recorded value
+	assert.Nil(t, c.observe(ctx, store, "GPU-c", 3, now))
+	assert.NotNil(t, c.observe(ctx, store, "GPU-c", 4, now.Add(time.Minute)))
+
+	// the baseline without the store (e.g., "gpud scan")
+	assert.Nil(t, c.observe(ctx, nil, "GPU-d", 3, now))
+	assert.NotNil(t, c.observe(ctx, nil, "GPU-d", 4, now.Add(time.Minute)))
+}
@@ -3,7 +3,6 @@ package clockspeed
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -86,9 +85,9 @@ var (
 	)
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	graphicsMHzAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_graphics_mhz")
-	memoryMHzAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_memory_mhz")
+func InitAveragers(store components_metrics_state.Store) {
+	graphicsMHzAverager = components_metrics.NewAverager(store, SubSystem+"_graphics_mhz")
+	memoryMHzAverager = components_metrics.NewAverager(store, SubSystem+"_memory_mhz")
 }
 
 func ReadGraphicsMHzs(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -177,8 +176,8 @@ func SetMemoryMHz(ctx context.Context, gpuID string, pct uint32, currentTime tim
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
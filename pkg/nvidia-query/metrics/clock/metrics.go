@@ -3,7 +3,6 @@ package clock
 
 import (
 	"context"
-	"database/sql"
 	"sync"
 	"time"
 
@@ -61,11 +60,11 @@ var (
 	hwSlowdownPowerBrakeAverager = components_metrics.NewNoOpAverager()
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
+func InitAveragers(store components_metrics_state.Store) {
 	initOnce.Do(func() {
-		hwSlowdownAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_hw_slowdown")
-		hwSlowdownThermalAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_hw_slowdown_thermal")
-		hwSlowdownPowerBrakeAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_hw_slowdown_power_brake")
+		hwSlowdownAverager = components_metrics.NewAverager(store, SubSystem+"_hw_slowdown")
+		hwSlowdownThermalAverager = components_metrics.NewAverager(store, SubSystem+"_hw_slowdown_thermal")
+		hwSlowdownPowerBrakeAverager = components_metrics.NewAverager(store, SubSystem+"_hw_slowdown_power_brake")
 	})
 }
 
@@ -142,8 +141,8 @@ func SetHWSlowdownPowerBrake(ctx context.Context, gpuID string, b bool, currentT
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
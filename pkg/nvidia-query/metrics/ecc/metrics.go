@@ -3,7 +3,6 @@ package ecc
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -14,6 +13,11 @@ import (
 
 const SubSystem = "accelerator_nvidia_ecc"
 
+const (
+	MetricNameAggregateTotalCorrected   = SubSystem + "_aggregate_total_corrected"
+	MetricNameAggregateTotalUncorrected = SubSystem + "_aggregate_total_uncorrected"
+)
+
 var (
 	lastUpdateUnixSeconds = prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -69,11 +73,11 @@ var (
 	volatileTotalUncorrectedAverager = components_metrics.NewNoOpAverager()
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	aggregateTotalCorrectedAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_aggregate_total_corrected")
-	aggregateTotalUncorrectedAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_aggregate_total_uncorrected")
-	volatileTotalCorrectedAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_volatile_total_corrected")
-	volatileTotalUncorrectedAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_volatile_total_uncorrected")
+func InitAveragers(store components_metrics_state.Store) {
+	aggregateTotalCorrectedAverager = components_metrics.NewAverager(store, MetricNameAggregateTotalCorrected)
+	aggregateTotalUncorrectedAverager = components_metrics.NewAverager(store, MetricNameAggregateTotalUncorrected)
+	volatileTotalCorrectedAverager = components_metrics.NewAverager(store, SubSystem+"_volatile_total_corrected")
+	volatileTotalUncorrectedAverager = components_metrics.NewAverager(store, SubSystem+"_volatile_total_uncorrected")
 }
 
 func ReadAggregateTotalCorrected(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -156,8 +160,8 @@ func SetVolatileTotalUncorrected(ctx context.Context, gpuID string, cnt float64,
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
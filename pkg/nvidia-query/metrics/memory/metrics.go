@@ -3,7 +3,6 @@ package memory
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -117,10 +116,10 @@ var (
 	)
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	totalBytesAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_total_bytes")
-	usedBytesAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_used_bytes")
-	usedPercentAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_used_percent")
+func InitAveragers(store components_metrics_state.Store) {
+	totalBytesAverager = components_metrics.NewAverager(store, SubSystem+"_total_bytes")
+	usedBytesAverager = components_metrics.NewAverager(store, SubSystem+"_used_bytes")
+	usedPercentAverager = components_metrics.NewAverager(store, SubSystem+"_used_percent")
 }
 
 func ReadTotalBytes(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -131,6 +130,12 @@ func ReadUsedBytes(ctx context.Context, since time.Time) (components_metrics_sta
 	return usedBytesAverager.Read(ctx, components_metrics.WithSince(since))
 }
 
+// UsedPercentAverager returns the averager of the memory used percents,
+// keyed by the GPU UUID (e.g., to evaluate the sustained thresholds).
+func UsedPercentAverager() components_metrics.Averager {
+	return usedPercentAverager
+}
+
 func ReadUsedPercents(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
 	return usedPercentAverager.Read(ctx, components_metrics.WithSince(since))
 }
@@ -236,8 +241,8 @@ func SetUsedPercent(ctx context.Context, gpuID string, pct float64, currentTime
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
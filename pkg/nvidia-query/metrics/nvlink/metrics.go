@@ -3,7 +3,6 @@ package nvlink
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -109,13 +108,13 @@ var (
 	rxBytesAverager = components_metrics.NewNoOpAverager()
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	featureEnabledAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_feature_enabled")
-	replayErrorsAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_replay_errors")
-	recoveryErrorsAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_recovery_errors")
-	crcErrorsAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_crc_errors")
-	rxBytesAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_rx_bytes")
-	txBytesAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_tx_bytes")
+func InitAveragers(store components_metrics_state.Store) {
+	featureEnabledAverager = components_metrics.NewAverager(store, SubSystem+"_feature_enabled")
+	replayErrorsAverager = components_metrics.NewAverager(store, SubSystem+"_replay_errors")
+	recoveryErrorsAverager = components_metrics.NewAverager(store, SubSystem+"_recovery_errors")
+	crcErrorsAverager = components_metrics.NewAverager(store, SubSystem+"_crc_errors")
+	rxBytesAverager = components_metrics.NewAverager(store, SubSystem+"_rx_bytes")
+	txBytesAverager = components_metrics.NewAverager(store, SubSystem+"_tx_bytes")
 }
 
 func ReadFeatureEnabled(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -266,8 +265,8 @@ func SetRxBytes(ctx context.Context, gpuID string, bytes float64, currentTime ti
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
@@ -3,7 +3,6 @@ package power
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -97,10 +96,10 @@ var (
 	)
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	currentUsageMilliWattsAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_current_usage_milli_watts")
-	enforcedLimitMilliWattsAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_enforced_limit_milli_watts")
-	usedPercentAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_used_percent")
+func InitAveragers(store components_metrics_state.Store) {
+	currentUsageMilliWattsAverager = components_metrics.NewAverager(store, SubSystem+"_current_usage_milli_watts")
+	enforcedLimitMilliWattsAverager = components_metrics.NewAverager(store, SubSystem+"_enforced_limit_milli_watts")
+	usedPercentAverager = components_metrics.NewAverager(store, SubSystem+"_used_percent")
 }
 
 func ReadCurrentUsageMilliWatts(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -111,6 +110,12 @@ func ReadEnforcedLimitMilliWatts(ctx context.Context, since time.Time) (componen
 	return enforcedLimitMilliWattsAverager.Read(ctx, components_metrics.WithSince(since))
 }
 
+// UsedPercentAverager returns the averager of the power used percents of the enforced limits,
+// keyed by the GPU UUID (e.g., to evaluate the sustained thresholds).
+func UsedPercentAverager() components_metrics.Averager {
+	return usedPercentAverager
+}
+
 func ReadUsedPercents(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
 	return usedPercentAverager.Read(ctx, components_metrics.WithSince(since))
 }
@@ -208,8 +213,8 @@ func SetUsedPercent(ctx context.Context, gpuID string, pct float64, currentTime
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
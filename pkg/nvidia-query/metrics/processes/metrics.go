@@ -3,7 +3,6 @@ package processes
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -36,8 +35,8 @@ var (
 	runningProcessesTotalAverager = components_metrics.NewNoOpAverager()
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	runningProcessesTotalAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_total")
+func InitAveragers(store components_metrics_state.Store) {
+	runningProcessesTotalAverager = components_metrics.NewAverager(store, SubSystem+"_total")
 }
 
 func ReadRunningProcessesTotal(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -63,8 +62,8 @@ func SetRunningProcessesTotal(ctx context.Context, gpuID string, processes int,
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
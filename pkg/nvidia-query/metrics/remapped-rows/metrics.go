@@ -3,7 +3,6 @@ package remappedrows
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -14,6 +13,8 @@ import (
 
 const SubSystem = "accelerator_nvidia_remapped_rows"
 
+const MetricNameRemappedDueToUncorrectableErrors = SubSystem + "_due_to_uncorrectable_errors"
+
 var (
 	lastUpdateUnixSeconds = prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -58,10 +59,10 @@ var (
 	remappingFailedAverager = components_metrics.NewNoOpAverager()
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	uncorrectableErrorsAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_due_to_uncorrectable_errors")
-	remappingPendingAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_remapping_pending")
-	remappingFailedAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_remapping_failed")
+func InitAveragers(store components_metrics_state.Store) {
+	uncorrectableErrorsAverager = components_metrics.NewAverager(store, MetricNameRemappedDueToUncorrectableErrors)
+	remappingPendingAverager = components_metrics.NewAverager(store, SubSystem+"_remapping_pending")
+	remappingFailedAverager = components_metrics.NewAverager(store, SubSystem+"_remapping_failed")
 }
 
 func ReadRemappedDueToUncorrectableErrors(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -133,8 +134,8 @@ func SetRemappingFailed(ctx context.Context, gpuID string, failed bool, currentT
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
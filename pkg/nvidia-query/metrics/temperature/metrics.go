@@ -3,7 +3,6 @@ package temperature
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -97,10 +96,16 @@ var (
 	)
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	currentCelsiusAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_current_celsius")
-	thresholdSlowdownCelsiusAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_slowdown_threshold_celsius")
-	slowdownUsedPercentAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_slowdown_used_percent")
+func InitAveragers(store components_metrics_state.Store) {
+	currentCelsiusAverager = components_metrics.NewAverager(store, SubSystem+"_current_celsius")
+	thresholdSlowdownCelsiusAverager = components_metrics.NewAverager(store, SubSystem+"_slowdown_threshold_celsius")
+	slowdownUsedPercentAverager = components_metrics.NewAverager(store, SubSystem+"_slowdown_used_percent")
+}
+
+// CurrentCelsiusAverager returns the averager of the current temperatures in celsius,
+// keyed by the GPU UUID (e.g., to evaluate the sustained thresholds).
+func CurrentCelsiusAverager() components_metrics.Averager {
+	return currentCelsiusAverager
 }
 
 func ReadCurrentCelsius(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -208,8 +213,8 @@ func SetSlowdownUsedPercent(ctx context.Context, gpuID string, pct float64, curr
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
@@ -3,7 +3,6 @@ package utilization
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	components_metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
@@ -86,9 +85,15 @@ var (
 	)
 )
 
-func InitAveragers(dbRW *sql.DB, dbRO *sql.DB, tableName string) {
-	gpuUtilPercentAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_gpu_util_percent")
-	memoryUtilPercentAverager = components_metrics.NewAverager(dbRW, dbRO, tableName, SubSystem+"_memory_util_percent")
+func InitAveragers(store components_metrics_state.Store) {
+	gpuUtilPercentAverager = components_metrics.NewAverager(store, SubSystem+"_gpu_util_percent")
+	memoryUtilPercentAverager = components_metrics.NewAverager(store, SubSystem+"_memory_util_percent")
+}
+
+// GPUUtilPercentAverager returns the averager of the GPU utilization percents,
+// keyed by the GPU UUID (e.g., to evaluate the sustained thresholds).
+func GPUUtilPercentAverager() components_metrics.Averager {
+	return gpuUtilPercentAverager
 }
 
 func ReadGPUUtilPercents(ctx context.Context, since time.Time) (components_metrics_state.Metrics, error) {
@@ -177,8 +182,8 @@ func SetMemoryUtilPercent(ctx context.Context, gpuID string, pct uint32, current
 	return nil
 }
 
-func Register(reg *prometheus.Registry, dbRW *sql.DB, dbRO *sql.DB, tableName string) error {
-	InitAveragers(dbRW, dbRO, tableName)
+func Register(reg *prometheus.Registry, store components_metrics_state.Store) error {
+	InitAveragers(store)
 
 	if err := reg.Register(lastUpdateUnixSeconds); err != nil {
 		return err
@@ -0,0 +1,74 @@
+package nvml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/leptonai/gpud/components"
+)
+
+// GPU identifies a GPU device in the per-GPU component states.
+type GPU struct {
+	UUID        string `json:"uuid"`
+	PCIBusID    string `json:"pci_bus_id,omitempty"`
+	MinorNumber int    `json:"minor_number"`
+	// Name is the product name (e.g., "NVIDIA H100 80GB HBM3").
+	Name string `json:"name,omitempty"`
+}
+
+// GPU returns the identifiers of the device.
+func (d *DeviceInfo) GPU() GPU {
+	return GPU{
+		UUID:        d.UUID,
+		PCIBusID:    d.PCIBusID,
+		MinorNumber: d.MinorNumberID,
+		Name:        d.Name,
+	}
+}
+
+// formatPCIBusID formats the PCI location of the GPU (always function 0),
+// as "nvidia-smi --query-gpu=pci.bus_id".
+func formatPCIBusID(domain uint32, bus uint32, device uint32) string {
+	return fmt.Sprintf("%08X:%02X:%02X.0", domain, bus, device)
+}
+
+const (
+	StateKeyDeviceData           = "data"
+	StateKeyDeviceEncoding       = "encoding"
+	StateValueDeviceEncodingJSON = "json"
+)
+
+// DeviceStateName returns the name of the per-GPU state
+// (e.g., "temperature_GPU-...").
+func DeviceStateName(name string, uuid string) string {
+	return name + "_" + uuid
+}
+
+// DeviceState returns the state of a single GPU, with the device identifiers
+// in the extra info (see "components.StateMatchesGPU") and the JSON-encoded data.
+func DeviceState(name string, gpu GPU, healthy bool, reason string, data any) components.State {
+	extraInfo := map[string]string{
+		components.StateKeyGPUUUID:        gpu.UUID,
+		components.StateKeyGPUMinorNumber: strconv.Itoa(gpu.MinorNumber),
+	}
+	if gpu.PCIBusID != "" {
+		extraInfo[components.StateKeyGPUPCIBusID] = gpu.PCIBusID
+	}
+	if data != nil {
+		b, _ := json.Marshal(data)
+		extraInfo[StateKeyDeviceData] = string(b)
+		extraInfo[StateKeyDeviceEncoding] = StateValueDeviceEncodingJSON
+	}
+	health := components.StateHealthy
+	if !healthy {
+		health = components.StateUnhealthy
+	}
+	return components.State{
+		Name:      DeviceStateName(name, gpu.UUID),
+		Healthy:   healthy,
+		Health:    health,
+		Reason:    reason,
+		ExtraInfo: extraInfo,
+	}
+}
@@ -231,6 +231,7 @@ func (inst *instance) Start() error {
 			MinorNumberID: minorNumber,
 			BusID:         pciInfo.Bus,
 			DeviceID:      pciInfo.Device,
+			PCIBusID:      formatPCIBusID(pciInfo.Domain, pciInfo.Bus, pciInfo.Device),
 
 			Name:     name,
 			GPUCores: cores,
@@ -313,6 +314,7 @@ func (inst *instance) Get() (*Output, error) {
 			MinorNumberID: devInfo.MinorNumberID,
 			BusID:         devInfo.BusID,
 			DeviceID:      devInfo.DeviceID,
+			PCIBusID:      devInfo.PCIBusID,
 
 			Name:            devInfo.Name,
 			GPUCores:        devInfo.GPUCores,
@@ -479,6 +481,9 @@ type DeviceInfo struct {
 	BusID uint32 `json:"bus_id"`
 	// DeviceID is the device ID from PCI info API.
 	DeviceID uint32 `json:"device_id"`
+	// PCIBusID is the full PCI bus ID in the "nvidia-smi" format
+	// (e.g., "00000000:0A:00.0").
+	PCIBusID string `json:"pci_bus_id"`
 
 	Name            string `json:"name"`
 	GPUCores        int    `json:"gpu_cores"`
@@ -2,13 +2,17 @@ package query
 
 import (
 	"github.com/leptonai/gpud/pkg/eventstore"
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 )
 
 type Op struct {
-	xidEventsBucket        eventstore.Bucket
-	hwSlowdownEventsBucket eventstore.Bucket
-	ibstatCommand          string
-	debug                  bool
+	xidEventsBucket          eventstore.Bucket
+	hwSlowdownEventsBucket   eventstore.Bucket
+	eccEventsBucket          eventstore.Bucket
+	remappedRowsEventsBucket eventstore.Bucket
+	metricsStore             components_metrics_state.Store
+	ibstatCommand            string
+	debug                    bool
 }
 
 type OpOption func(*Op)
@@ -37,6 +41,28 @@ func WithHWSlowdownEventBucket(bucket eventstore.Bucket) OpOption {
 	}
 }
 
+// Specifies the bucket to record the increases of the aggregate ECC errors.
+func WithECCEventBucket(bucket eventstore.Bucket) OpOption {
+	return func(op *Op) {
+		op.eccEventsBucket = bucket
+	}
+}
+
+// Specifies the bucket to record the increases of the rows remapped due to the uncorrectable errors.
+func WithRemappedRowsEventBucket(bucket eventstore.Bucket) OpOption {
+	return func(op *Op) {
+		op.remappedRowsEventsBucket = bucket
+	}
+}
+
+// Specifies the metrics store to read the last error counters recorded before the restart,
+// as the baselines of the counter increase events.
+func WithMetricsStore(store components_metrics_state.Store) OpOption {
+	return func(op *Op) {
+		op.metricsStore = store
+	}
+}
+
 // Specifies the ibstat binary path to overwrite the default path.
 func WithIbstatCommand(p string) OpOption {
 	return func(op *Op) {
@@ -26,6 +26,8 @@ func TestOpOptions(t *testing.T) {
 		err := op.applyOpts([]OpOption{
 			WithXidEventBucket(mockBucket),
 			WithHWSlowdownEventBucket(mockBucket),
+			WithECCEventBucket(mockBucket),
+			WithRemappedRowsEventBucket(mockBucket),
 			WithIbstatCommand("/custom/ibstat"),
 			WithDebug(true),
 		})
@@ -35,6 +37,8 @@ func TestOpOptions(t *testing.T) {
 		// Check custom values
 		assert.Equal(t, mockBucket, op.xidEventsBucket)
 		assert.Equal(t, mockBucket, op.hwSlowdownEventsBucket)
+		assert.Equal(t, mockBucket, op.eccEventsBucket)
+		assert.Equal(t, mockBucket, op.remappedRowsEventsBucket)
 		assert.Equal(t, "/custom/ibstat", op.ibstatCommand)
 		assert.True(t, op.debug)
 	})
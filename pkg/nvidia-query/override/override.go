@@ -0,0 +1,103 @@
+// Package override merges the overrides of the GPUd-specific fields
+// into the NVIDIA error catalogs compiled into GPUd (e.g., Xid, SXid).
+package override
+
+import (
+	"fmt"
+
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+// Fields are the GPUd-specific fields of a catalog detail.
+// The zero fields of an override are left as in the catalog.
+type Fields struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	SuggestedActionsByGPUd    *common.SuggestedActions `json:"suggested_actions_by_gpud,omitempty"`
+	CriticalErrorMarkedByGPUd *bool                    `json:"critical_error_marked_by_gpud,omitempty"`
+	EventType                 common.EventType         `json:"event_type,omitempty"`
+}
+
+// apply returns the fields "f" with the non-zero fields of the override "o".
+func (f Fields) apply(o Fields) Fields {
+	if o.Name != "" {
+		f.Name = o.Name
+	}
+	if o.Description != "" {
+		f.Description = o.Description
+	}
+	if o.SuggestedActionsByGPUd != nil {
+		f.SuggestedActionsByGPUd = o.SuggestedActionsByGPUd
+	}
+	if o.CriticalErrorMarkedByGPUd != nil {
+		f.CriticalErrorMarkedByGPUd = o.CriticalErrorMarkedByGPUd
+	}
+	if o.EventType != "" {
+		f.EventType = o.EventType
+	}
+	return f
+}
+
+// Override is the override of a code in the catalog (e.g., Xid 13).
+type Override interface {
+	// Code returns the code to override.
+	Code() int
+	// Overrides returns the fields to override.
+	Overrides() Fields
+}
+
+// Catalog converts the details of a catalog (e.g., Xid) from and to the fields.
+type Catalog[D any, O Override] struct {
+	// Kind is the name of the codes in the errors (e.g., "Xid").
+	Kind string
+	// Details are the static details compiled into GPUd, keyed by the code.
+	Details map[int]D
+	// Fields returns the GPUd-specific fields of the detail.
+	Fields func(d D) Fields
+	// Set returns the detail of the code with the fields set.
+	Set func(d D, code int, f Fields) D
+}
+
+// Merge returns the copy of the static details with the overrides applied,
+// keyed by the code. The codes not in the catalog must have the name and
+// the event type, and the critical codes must have the repair actions.
+func (c Catalog[D, O]) Merge(overrides []O) (map[int]D, error) {
+	merged := make(map[int]D, len(c.Details)+len(overrides))
+	for code, d := range c.Details {
+		merged[code] = d
+	}
+
+	seen := make(map[int]struct{}, len(overrides))
+	for _, ov := range overrides {
+		code, o := ov.Code(), ov.Overrides()
+		if code <= 0 {
+			return nil, fmt.Errorf("invalid %s %d", c.Kind, code)
+		}
+		if _, ok := seen[code]; ok {
+			return nil, fmt.Errorf("duplicate override for %s %d", c.Kind, code)
+		}
+		seen[code] = struct{}{}
+
+		d, ok := merged[code]
+		if !ok {
+			if o.Name == "" {
+				return nil, fmt.Errorf("name is required for the new %s %d", c.Kind, code)
+			}
+			if o.EventType == "" {
+				return nil, fmt.Errorf("event type is required for the new %s %d", c.Kind, code)
+			}
+		}
+
+		f := c.Fields(d).apply(o)
+		if f.EventType == common.EventTypeUnknown {
+			return nil, fmt.Errorf("unknown event type for %s %d", c.Kind, code)
+		}
+		if f.CriticalErrorMarkedByGPUd != nil && *f.CriticalErrorMarkedByGPUd &&
+			(f.SuggestedActionsByGPUd == nil || len(f.SuggestedActionsByGPUd.RepairActions) == 0) {
+			return nil, fmt.Errorf("%s %d is marked as critical, but has no repair actions", c.Kind, code)
+		}
+		merged[code] = c.Set(d, code, f)
+	}
+	return merged, nil
+}
@@ -174,6 +174,9 @@ func Get(ctx context.Context, opts ...OpOption) (output any, err error) {
 		metrics_remapped_rows.SetLastUpdateUnixSeconds(nowUnix)
 
 		for _, dev := range o.NVML.DeviceInfos {
+			// compared with the previous values before the metrics are recorded
+			insertCounterEvents(ctx, op, dev, now)
+
 			if err := setMetricsForDevice(ctx, dev, now, o); err != nil {
 				return o, fmt.Errorf("failed to set metrics for device %s: %w", dev.UUID, err)
 			}
@@ -584,7 +587,7 @@ func setProcessMetrics(ctx context.Context, dev *nvml.DeviceInfo, now time.Time)
 }
 
 func setRemappedRowsMetrics(ctx context.Context, dev *nvml.DeviceInfo, now time.Time) error {
-	if err := metrics_remapped_rows.SetRemappedDueToUncorrectableErrors(ctx, dev.UUID, uint32(dev.RemappedRows.RemappedDueToCorrectableErrors), now); err != nil {
+	if err := metrics_remapped_rows.SetRemappedDueToUncorrectableErrors(ctx, dev.UUID, uint32(dev.RemappedRows.RemappedDueToUncorrectableErrors), now); err != nil {
 		return err
 	}
 	if err := metrics_remapped_rows.SetRemappingPending(ctx, dev.UUID, dev.RemappedRows.RemappingPending, now); err != nil {
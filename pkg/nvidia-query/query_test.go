@@ -1,8 +1,12 @@
 package query
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	metrics_remapped_rows "github.com/leptonai/gpud/pkg/nvidia-query/metrics/remapped-rows"
 	"github.com/leptonai/gpud/pkg/nvidia-query/nvml"
 )
 
@@ -44,3 +48,29 @@ func TestOutput_GPUProductName(t *testing.T) {
 		})
 	}
 }
+
+func TestSetRemappedRowsMetrics(t *testing.T) {
+	ctx := context.Background()
+	store := components_metrics_state.NewMemoryStore(0)
+	metrics_remapped_rows.InitAveragers(store)
+
+	dev := &nvml.DeviceInfo{
+		UUID: "GPU-a",
+		RemappedRows: nvml.RemappedRows{
+			RemappedDueToCorrectableErrors:   1,
+			RemappedDueToUncorrectableErrors: 3,
+		},
+	}
+	if err := setRemappedRowsMetrics(ctx, dev, time.Unix(1700000000, 0)); err != nil {
+		t.Fatalf("failed to set remapped rows metrics: %v", err)
+	}
+
+	// the rows remapped due to the uncorrectable errors, not the correctable ones
+	last, err := store.ReadLast(ctx, metrics_remapped_rows.MetricNameRemappedDueToUncorrectableErrors, "GPU-a")
+	if err != nil {
+		t.Fatalf("failed to read metric: %v", err)
+	}
+	if last == nil || last.Value != 3 {
+		t.Fatalf("expected 3 rows remapped due to uncorrectable errors, got %+v", last)
+	}
+}
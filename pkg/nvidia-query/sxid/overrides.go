@@ -0,0 +1,92 @@
+package sxid
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/leptonai/gpud/pkg/nvidia-query/override"
+)
+
+// Override changes the GPUd-specific fields of the SXid detail in the catalog,
+// or adds the SXid not in the catalog (e.g., emitted by the newer fabric manager).
+// The zero fields are left as in the catalog.
+type Override struct {
+	SXid int `json:"sxid"`
+	override.Fields
+}
+
+// Code implements "override.Override".
+func (o Override) Code() int {
+	return o.SXid
+}
+
+// Overrides implements "override.Override".
+func (o Override) Overrides() override.Fields {
+	return o.Fields
+}
+
+// overrideCatalog merges the overrides into the static details
+var overrideCatalog = override.Catalog[Detail, Override]{
+	Kind:    "SXid",
+	Details: details,
+	Fields: func(d Detail) override.Fields {
+		critical := d.CriticalErrorMarkedByGPUd
+		return override.Fields{
+			Name:                      d.Name,
+			Description:               d.Description,
+			SuggestedActionsByGPUd:    d.SuggestedActionsByGPUd,
+			CriticalErrorMarkedByGPUd: &critical,
+			EventType:                 d.EventType,
+		}
+	},
+	Set: func(d Detail, code int, f override.Fields) Detail {
+		d.SXid = code
+		d.Name = f.Name
+		d.Description = f.Description
+		d.SuggestedActionsByGPUd = f.SuggestedActionsByGPUd
+		d.CriticalErrorMarkedByGPUd = *f.CriticalErrorMarkedByGPUd
+		d.EventType = f.EventType
+		return d
+	},
+}
+
+var (
+	catalogMu sync.RWMutex
+	// catalog is the static details with the overrides applied
+	catalog = details
+)
+
+// SetOverrides replaces the overrides of the static catalog.
+// The SXids not in the catalog must have the name and the event type.
+// Nothing is changed if any override is invalid.
+func SetOverrides(overrides []Override) error {
+	merged, err := overrideCatalog.Merge(overrides)
+	if err != nil {
+		return err
+	}
+
+	catalogMu.Lock()
+	catalog = merged
+	catalogMu.Unlock()
+	return nil
+}
+
+// ValidateOverrides returns the error that "SetOverrides" would return,
+// without changing the catalog.
+func ValidateOverrides(overrides []Override) error {
+	_, err := overrideCatalog.Merge(overrides)
+	return err
+}
+
+// Details returns all the SXid details in the catalog, sorted by the SXid.
+func Details() []Detail {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	ds := make([]Detail, 0, len(catalog))
+	for _, d := range catalog {
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].SXid < ds[j].SXid })
+	return ds
+}
@@ -0,0 +1,65 @@
+package sxid
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/nvidia-query/override"
+)
+
+func TestSetOverrides(t *testing.T) {
+	defer func() {
+		if err := SetOverrides(nil); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	orig, ok := GetDetail(11012)
+	if !ok {
+		t.Fatal("expected SXid 11012 in the catalog")
+	}
+
+	critical := true
+	actions := &common.SuggestedActions{RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem}}
+	if err := SetOverrides([]Override{
+		{SXid: 11012, Fields: override.Fields{EventType: common.EventTypeCritical, CriticalErrorMarkedByGPUd: &critical, SuggestedActionsByGPUd: actions}},
+		{SXid: 99999, Fields: override.Fields{Name: "new", EventType: common.EventTypeFatal}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	d, ok := GetDetail(11012)
+	if !ok || d.EventType != common.EventTypeCritical || !d.CriticalErrorMarkedByGPUd || d.SuggestedActionsByGPUd != actions {
+		t.Fatalf("unexpected overridden detail %+v", d)
+	}
+	if d.Name != orig.Name || d.Impact != orig.Impact {
+		t.Fatalf("expected the other fields unchanged, got %+v", d)
+	}
+	if d, ok := GetDetail(99999); !ok || d.Name != "new" || d.SXid != 99999 {
+		t.Fatalf("expected the new SXid, got %+v", d)
+	}
+	ds := Details()
+	if ds[len(ds)-1].SXid != 99999 {
+		t.Fatalf("expected the sorted details, got the last SXid %d", ds[len(ds)-1].SXid)
+	}
+
+	// invalid overrides leave the catalog as is
+	for _, overrides := range [][]Override{
+		{{SXid: -1, Fields: override.Fields{EventType: common.EventTypeWarning}}},
+		{{SXid: 11012}, {SXid: 11012}},
+		{{SXid: 100000, Fields: override.Fields{EventType: common.EventTypeWarning}}},
+		{{SXid: 100000, Fields: override.Fields{Name: "new"}}},
+		{{SXid: 11004, Fields: override.Fields{EventType: common.EventTypeUnknown}}},
+		{{SXid: 11021, Fields: override.Fields{CriticalErrorMarkedByGPUd: &critical}}},
+	} {
+		if err := ValidateOverrides(overrides); err == nil {
+			t.Errorf("expected error for %+v", overrides)
+		}
+		if err := SetOverrides(overrides); err == nil {
+			t.Errorf("expected error for %+v", overrides)
+		}
+	}
+	if _, ok := GetDetail(99999); !ok {
+		t.Fatal("expected the previous overrides to be kept")
+	}
+}
@@ -0,0 +1,62 @@
+package xid
+
+import (
+	"testing"
+
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/nvidia-query/override"
+)
+
+func TestSetOverrides(t *testing.T) {
+	defer func() {
+		if err := SetOverrides(nil); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	orig, ok := GetDetail(13)
+	if !ok {
+		t.Fatal("expected Xid 13 in the catalog")
+	}
+
+	critical := true
+	actions := &common.SuggestedActions{RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem}}
+	if err := SetOverrides([]Override{
+		{Xid: 13, Fields: override.Fields{EventType: common.EventTypeCritical, CriticalErrorMarkedByGPUd: &critical, SuggestedActionsByGPUd: actions}},
+		{Xid: 9999, Fields: override.Fields{Name: "new", EventType: common.EventTypeWarning}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	d, ok := GetDetail(13)
+	if !ok || d.EventType != common.EventTypeCritical || !d.CriticalErrorMarkedByGPUd || d.SuggestedActionsByGPUd != actions {
+		t.Fatalf("unexpected overridden detail %+v", d)
+	}
+	if d.Name != orig.Name || d.PotentialUserAppError != orig.PotentialUserAppError {
+		t.Fatalf("expected the other fields unchanged, got %+v", d)
+	}
+	if d, ok := GetDetail(9999); !ok || d.Name != "new" || d.Xid != 9999 {
+		t.Fatalf("expected the new Xid, got %+v", d)
+	}
+	ds := Details()
+	if ds[len(ds)-1].Xid != 9999 {
+		t.Fatalf("expected the sorted details, got the last Xid %d", ds[len(ds)-1].Xid)
+	}
+
+	// invalid overrides leave the catalog as is
+	for _, overrides := range [][]Override{
+		{{Xid: 0, Fields: override.Fields{EventType: common.EventTypeWarning}}},
+		{{Xid: 13}, {Xid: 13}},
+		{{Xid: 10000, Fields: override.Fields{EventType: common.EventTypeWarning}}},
+		{{Xid: 10000, Fields: override.Fields{Name: "new"}}},
+		{{Xid: 1, Fields: override.Fields{EventType: common.EventTypeUnknown}}},
+		{{Xid: 1, Fields: override.Fields{CriticalErrorMarkedByGPUd: &critical}}},
+	} {
+		if err := SetOverrides(overrides); err == nil {
+			t.Errorf("expected error for %+v", overrides)
+		}
+	}
+	if _, ok := GetDetail(9999); !ok {
+		t.Fatal("expected the previous overrides to be kept")
+	}
+}
@@ -103,8 +103,11 @@ func (d Detail) IsMarkedAsCriticalByGPUd() bool {
 
 // Returns the error if found.
 // Otherwise, returns false.
+// The details are from the static catalog with the overrides (see "SetOverrides").
 func GetDetail(id int) (*Detail, bool) {
-	e, ok := details[id]
+	catalogMu.RLock()
+	e, ok := catalog[id]
+	catalogMu.RUnlock()
 	return &e, ok
 }
 
@@ -0,0 +1,70 @@
+package otlp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultExportInterval  = time.Minute
+	DefaultMaxLogBatchSize = 512
+)
+
+// Config configures the OTLP/HTTP exporter.
+type Config struct {
+	// Endpoint is the OTLP/HTTP base URL of the collector (e.g., "http://localhost:4318").
+	// The metrics and logs are posted to "/v1/metrics" and "/v1/logs" under the endpoint.
+	Endpoint string `json:"endpoint"`
+
+	// Headers are the extra HTTP request headers (e.g., authorization).
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// ExportInterval is the interval to push the metrics and the pending log records.
+	ExportInterval metav1.Duration `json:"export_interval,omitempty"`
+
+	// MaxLogBatchSize is the number of pending log records
+	// that triggers the push before the export interval elapses.
+	MaxLogBatchSize int `json:"max_log_batch_size,omitempty"`
+
+	// Set true to not push the metrics.
+	DisableMetrics bool `json:"disable_metrics,omitempty"`
+	// Set true to not push the events as log records.
+	DisableLogs bool `json:"disable_logs,omitempty"`
+}
+
+// SetDefaults sets the default values for the unset fields.
+func (cfg *Config) SetDefaults() {
+	if cfg.ExportInterval.Duration == 0 {
+		cfg.ExportInterval = metav1.Duration{Duration: DefaultExportInterval}
+	}
+	if cfg.MaxLogBatchSize == 0 {
+		cfg.MaxLogBatchSize = DefaultMaxLogBatchSize
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.Endpoint == "" {
+		return errors.New("endpoint is required")
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q: %w", cfg.Endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid endpoint %q: scheme must be http or https", cfg.Endpoint)
+	}
+	if cfg.ExportInterval.Duration < 0 {
+		return errors.New("export_interval must be non-negative")
+	}
+	if cfg.MaxLogBatchSize < 0 {
+		return errors.New("max_log_batch_size must be non-negative")
+	}
+	if cfg.DisableMetrics && cfg.DisableLogs {
+		return errors.New("both metrics and logs are disabled")
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Package otlp implements the OpenTelemetry OTLP/HTTP exporter that pushes
+// the Prometheus registry metrics and the component events (as log records)
+// to the OTLP collector, for the nodes without the scrape access.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/host"
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/watch"
+	"github.com/leptonai/gpud/version"
+)
+
+const (
+	pathMetrics = "/v1/metrics"
+	pathLogs    = "/v1/logs"
+
+	scopeName = "github.com/leptonai/gpud"
+)
+
+// Exporter pushes the metrics and the events to the OTLP/HTTP collector.
+type Exporter struct {
+	cfg      Config
+	gatherer prometheus.Gatherer
+	cli      *http.Client
+
+	resourceAttrs []keyValue
+	scope         instrumentationScope
+	start         time.Time
+
+	mu      sync.Mutex
+	pending []pendingLog
+
+	// signals the batch is full
+	flushC chan struct{}
+
+	// for testing
+	timeNow func() time.Time
+}
+
+// New creates the exporter that gathers the metrics from the gatherer.
+// The machine ID is attached to every exported resource.
+// The configuration defaults are set for the unset fields.
+func New(cfg Config, gatherer prometheus.Gatherer, machineID string) (*Exporter, error) {
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+
+	attrs := []keyValue{
+		stringAttr(attrServiceName, "gpud"),
+		stringAttr(attrServiceVersion, version.Version),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attrs = append(attrs, stringAttr(attrHostName, hostname))
+	}
+	if machineID != "" {
+		attrs = append(attrs, stringAttr(AttrMachineID, machineID))
+	}
+	bootID, err := host.GetBootID()
+	if err != nil {
+		log.Logger.Warnw("failed to get boot id", "error", err)
+	}
+	if bootID != "" {
+		attrs = append(attrs, stringAttr(AttrBootID, bootID))
+	}
+
+	return &Exporter{
+		cfg:           cfg,
+		gatherer:      gatherer,
+		cli:           &http.Client{Timeout: 30 * time.Second},
+		resourceAttrs: attrs,
+		scope:         instrumentationScope{Name: scopeName, Version: version.Version},
+		start:         time.Now(),
+		flushC:        make(chan struct{}, 1),
+		timeNow:       time.Now,
+	}, nil
+}
+
+// Run subscribes the component events and pushes the metrics and the events
+// every export interval, until the context is canceled.
+// The pending events are pushed once more when the context is canceled.
+func (e *Exporter) Run(ctx context.Context, broker *watch.Broker) {
+	if !e.cfg.DisableLogs {
+		ch, unsubscribe := broker.Subscribe(watch.Filter{Kinds: []v1.WatchEventKind{v1.WatchEventKindEvent}})
+		defer unsubscribe()
+		go e.collect(ctx, ch)
+	}
+
+	ticker := time.NewTicker(e.cfg.ExportInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// best-effort with a fresh context since the parent one is done
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := e.ExportLogs(flushCtx); err != nil {
+				log.Logger.Warnw("failed to export logs on shutdown", "error", err)
+			}
+			cancel()
+			return
+
+		case <-e.flushC:
+			if err := e.ExportLogs(ctx); err != nil {
+				log.Logger.Warnw("failed to export logs", "error", err)
+			}
+
+		case <-ticker.C:
+			if !e.cfg.DisableMetrics {
+				if err := e.ExportMetrics(ctx); err != nil {
+					log.Logger.Warnw("failed to export metrics", "error", err)
+				}
+			}
+			if err := e.ExportLogs(ctx); err != nil {
+				log.Logger.Warnw("failed to export logs", "error", err)
+			}
+		}
+	}
+}
+
+func (e *Exporter) collect(ctx context.Context, ch <-chan v1.WatchEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if ev.Event == nil {
+				continue
+			}
+			e.Enqueue(ev.Component, *ev.Event)
+		}
+	}
+}
+
+// Enqueue adds the component event to the pending log records.
+func (e *Exporter) Enqueue(component string, ev components.Event) {
+	e.mu.Lock()
+	e.pending = append(e.pending, pendingLog{component: component, observed: e.timeNow(), event: ev})
+	full := len(e.pending) >= e.cfg.MaxLogBatchSize
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.flushC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ExportMetrics gathers and pushes the metrics.
+func (e *Exporter) ExportMetrics(ctx context.Context) error {
+	mfs, err := e.gatherer.Gather()
+	if err != nil {
+		// partial results are still exported
+		log.Logger.Warnw("failed to gather some metrics", "error", err)
+	}
+	if len(mfs) == 0 {
+		return nil
+	}
+
+	req := exportMetricsServiceRequest{
+		ResourceMetrics: []resourceMetrics{{
+			Resource: resource{Attributes: e.resourceAttrs},
+			ScopeMetrics: []scopeMetrics{{
+				Scope:   e.scope,
+				Metrics: convertMetricFamilies(mfs, e.start, e.timeNow()),
+			}},
+		}},
+	}
+	return e.post(ctx, pathMetrics, req)
+}
+
+// ExportLogs pushes the pending log records.
+// The records are dropped if the collector rejects them,
+// so that the pending records do not grow unbounded.
+func (e *Exporter) ExportLogs(ctx context.Context) error {
+	e.mu.Lock()
+	pending := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	req := exportLogsServiceRequest{
+		ResourceLogs: convertLogs(pending, e.resourceAttrs, e.scope),
+	}
+	if err := e.post(ctx, pathLogs, req); err != nil {
+		return fmt.Errorf("dropped %d log records: %w", len(pending), err)
+	}
+	return nil
+}
+
+func (e *Exporter) post(ctx context.Context, path string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.Endpoint+path, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.cli.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s rejected with status %d", path, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/watch"
+)
+
+// collector is the httptest stand-in for the OTLP/HTTP collector.
+type collector struct {
+	mu      sync.Mutex
+	metrics []exportMetricsServiceRequest
+	logs    []exportLogsServiceRequest
+	headers []http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := io.ReadAll(r.Body)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers = append(c.headers, r.Header.Clone())
+
+	switch r.URL.Path {
+	case pathMetrics:
+		var req exportMetricsServiceRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.metrics = append(c.metrics, req)
+	case pathLogs:
+		var req exportLogsServiceRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		c.logs = append(c.logs, req)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (c *collector) logRequests() []exportLogsServiceRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]exportLogsServiceRequest(nil), c.logs...)
+}
+
+func findAttr(attrs []keyValue, key string) (string, bool) {
+	for _, a := range attrs {
+		if a.Key == key && a.Value.StringValue != nil {
+			return *a.Value.StringValue, true
+		}
+	}
+	return "", false
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "valid", cfg: Config{Endpoint: "http://localhost:4318"}},
+		{name: "no endpoint", cfg: Config{}, wantErr: true},
+		{name: "invalid scheme", cfg: Config{Endpoint: "grpc://localhost:4317"}, wantErr: true},
+		{name: "negative interval", cfg: Config{Endpoint: "http://localhost:4318", ExportInterval: metav1.Duration{Duration: -time.Second}}, wantErr: true},
+		{name: "all disabled", cfg: Config{Endpoint: "http://localhost:4318", DisableMetrics: true, DisableLogs: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportMetrics(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total", Help: "test counter"}, []string{"gpu"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge"})
+	hist := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Buckets: []float64{1, 5}})
+	reg.MustRegister(counter, gauge, hist)
+
+	counter.WithLabelValues("0").Add(3)
+	gauge.Set(42)
+	hist.Observe(0.5)
+	hist.Observe(2)
+	hist.Observe(10)
+
+	e, err := New(Config{Endpoint: srv.URL + "/", Headers: map[string]string{"Authorization": "Bearer token"}}, reg, "machine-1")
+	require.NoError(t, err)
+	require.NoError(t, e.ExportMetrics(context.Background()))
+
+	require.Len(t, c.metrics, 1)
+	require.Equal(t, "Bearer token", c.headers[0].Get("Authorization"))
+	require.Equal(t, "application/json", c.headers[0].Get("Content-Type"))
+
+	rm := c.metrics[0].ResourceMetrics[0]
+	v, ok := findAttr(rm.Resource.Attributes, AttrMachineID)
+	require.True(t, ok)
+	require.Equal(t, "machine-1", v)
+	v, ok = findAttr(rm.Resource.Attributes, attrServiceName)
+	require.True(t, ok)
+	require.Equal(t, "gpud", v)
+
+	byName := make(map[string]metric)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+
+	require.NotNil(t, byName["test_total"].Sum)
+	require.True(t, byName["test_total"].Sum.IsMonotonic)
+	require.Equal(t, aggregationTemporalityCumulative, byName["test_total"].Sum.AggregationTemporality)
+	require.Equal(t, 3.0, byName["test_total"].Sum.DataPoints[0].AsDouble)
+	v, ok = findAttr(byName["test_total"].Sum.DataPoints[0].Attributes, "gpu")
+	require.True(t, ok)
+	require.Equal(t, "0", v)
+
+	require.NotNil(t, byName["test_gauge"].Gauge)
+	require.Equal(t, 42.0, byName["test_gauge"].Gauge.DataPoints[0].AsDouble)
+
+	require.NotNil(t, byName["test_seconds"].Histogram)
+	dp := byName["test_seconds"].Histogram.DataPoints[0]
+	require.Equal(t, "3", dp.Count)
+	require.Equal(t, []float64{1, 5}, dp.ExplicitBounds)
+	require.Equal(t, []string{"1", "1", "1"}, dp.BucketCounts)
+}
+
+func TestExportLogs(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	e, err := New(Config{Endpoint: srv.URL, DisableMetrics: true}, prometheus.NewRegistry(), "machine-1")
+	require.NoError(t, err)
+
+	now := time.Now()
+	e.Enqueue("accelerator-nvidia-xid", components.Event{
+		Time:      metav1.Time{Time: now},
+		Name:      "xid",
+		Type:      common.EventTypeCritical,
+		Message:   "Xid 79 detected",
+		ExtraInfo: map[string]string{"device_uuid": "GPU-0", "xid": "79"},
+		SuggestedActions: &common.SuggestedActions{
+			RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem},
+		},
+	})
+	e.Enqueue("accelerator-nvidia-xid", components.Event{Time: metav1.Time{Time: now}, Name: "xid", Type: common.EventTypeCritical, Message: "Xid 48 detected", ExtraInfo: map[string]string{"device_uuid": "GPU-1"}})
+	e.Enqueue("os", components.Event{Time: metav1.Time{Time: now}, Name: "reboot", Type: common.EventTypeWarning, Message: "system reboot detected"})
+
+	require.NoError(t, e.ExportLogs(context.Background()))
+	require.NoError(t, e.ExportLogs(context.Background()), "no pending logs")
+
+	reqs := c.logRequests()
+	require.Len(t, reqs, 1)
+	rls := reqs[0].ResourceLogs
+	require.Len(t, rls, 3, "one resource per gpu uuid")
+
+	uuid, ok := findAttr(rls[0].Resource.Attributes, AttrGPUUUID)
+	require.True(t, ok)
+	require.Equal(t, "GPU-0", uuid)
+	_, ok = findAttr(rls[0].Resource.Attributes, AttrMachineID)
+	require.True(t, ok)
+
+	rec := rls[0].ScopeLogs[0].LogRecords[0]
+	require.Equal(t, 17, rec.SeverityNumber)
+	require.Equal(t, "ERROR", rec.SeverityText)
+	require.Equal(t, "Xid 79 detected", *rec.Body.StringValue)
+	v, _ := findAttr(rec.Attributes, attrComponent)
+	require.Equal(t, "accelerator-nvidia-xid", v)
+	v, _ = findAttr(rec.Attributes, attrExtraInfo+"xid")
+	require.Equal(t, "79", v)
+	v, _ = findAttr(rec.Attributes, attrRepairActions)
+	require.Equal(t, string(common.RepairActionTypeRebootSystem), v)
+
+	_, ok = findAttr(rls[2].Resource.Attributes, AttrGPUUUID)
+	require.False(t, ok, "event without gpu uuid")
+	require.Equal(t, 13, rls[2].ScopeLogs[0].LogRecords[0].SeverityNumber)
+}
+
+func TestExportLogsRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	e, err := New(Config{Endpoint: srv.URL}, prometheus.NewRegistry(), "")
+	require.NoError(t, err)
+
+	e.Enqueue("os", components.Event{Name: "reboot", Type: common.EventTypeWarning})
+	require.Error(t, e.ExportLogs(context.Background()))
+
+	e.mu.Lock()
+	require.Empty(t, e.pending, "rejected records are dropped")
+	e.mu.Unlock()
+}
+
+func TestRunBatchFlush(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	e, err := New(Config{
+		Endpoint:        srv.URL,
+		ExportInterval:  metav1.Duration{Duration: time.Hour},
+		MaxLogBatchSize: 2,
+	}, prometheus.NewRegistry(), "machine-1")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := watch.NewBroker()
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx, broker)
+		close(done)
+	}()
+
+	// publishes until the exporter subscribes and the batch is flushed
+	require.Eventually(t, func() bool {
+		broker.Publish(watchEvent("os", components.Event{Name: "reboot", Type: common.EventTypeWarning}))
+		return len(c.logRequests()) > 0
+	}, 10*time.Second, 20*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func watchEvent(component string, ev components.Event) v1.WatchEvent {
+	return v1.WatchEvent{
+		Kind:      v1.WatchEventKindEvent,
+		Component: component,
+		Time:      time.Now(),
+		Event:     &ev,
+	}
+}
@@ -0,0 +1,136 @@
+package otlp
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+const (
+	// AttrMachineID is the resource attribute of the gpud machine ID.
+	AttrMachineID = "gpud.machine_id"
+	// AttrBootID is the resource attribute of the host boot ID.
+	AttrBootID = "host.boot_id"
+	// AttrGPUUUID is the resource attribute of the GPU UUID that the event belongs to.
+	AttrGPUUUID = "gpu.uuid"
+
+	attrServiceName    = "service.name"
+	attrServiceVersion = "service.version"
+	attrHostName       = "host.name"
+
+	attrComponent     = "gpud.component"
+	attrEventName     = "gpud.event.name"
+	attrExtraInfo     = "gpud.event.extra_info."
+	attrRepairActions = "gpud.event.repair_actions"
+)
+
+// gpuUUIDKeys are the event extra info keys that the components
+// use for the GPU UUID (e.g., "device_uuid" for the XID/SXID events).
+var gpuUUIDKeys = []string{"gpu_uuid", "device_uuid"}
+
+// pendingLog is the event waiting to be exported.
+type pendingLog struct {
+	component string
+	observed  time.Time
+	event     components.Event
+}
+
+// eventGPUUUID returns the GPU UUID that the event belongs to, if any.
+func eventGPUUUID(ev components.Event) string {
+	for _, k := range gpuUUIDKeys {
+		if v := ev.ExtraInfo[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// convertLogs converts the events to the OTLP resource logs,
+// one resource per GPU UUID, in the order of the first appearance.
+func convertLogs(pending []pendingLog, resourceAttrs []keyValue, scope instrumentationScope) []resourceLogs {
+	var (
+		order   []string
+		records = make(map[string][]logRecord)
+	)
+	for _, p := range pending {
+		uuid := eventGPUUUID(p.event)
+		if _, ok := records[uuid]; !ok {
+			order = append(order, uuid)
+		}
+		records[uuid] = append(records[uuid], convertEvent(p))
+	}
+
+	rls := make([]resourceLogs, 0, len(order))
+	for _, uuid := range order {
+		attrs := append([]keyValue(nil), resourceAttrs...)
+		if uuid != "" {
+			attrs = append(attrs, stringAttr(AttrGPUUUID, uuid))
+		}
+		rls = append(rls, resourceLogs{
+			Resource: resource{Attributes: attrs},
+			ScopeLogs: []scopeLogs{{
+				Scope:      scope,
+				LogRecords: records[uuid],
+			}},
+		})
+	}
+	return rls
+}
+
+func convertEvent(p pendingLog) logRecord {
+	severityNumber, severityText := severity(p.event.Type)
+
+	attrs := []keyValue{
+		stringAttr(attrComponent, p.component),
+		stringAttr(attrEventName, p.event.Name),
+	}
+	keys := make([]string, 0, len(p.event.ExtraInfo))
+	for k := range p.event.ExtraInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, stringAttr(attrExtraInfo+k, p.event.ExtraInfo[k]))
+	}
+	if p.event.SuggestedActions != nil && len(p.event.SuggestedActions.RepairActions) > 0 {
+		actions := make([]string, 0, len(p.event.SuggestedActions.RepairActions))
+		for _, a := range p.event.SuggestedActions.RepairActions {
+			actions = append(actions, string(a))
+		}
+		attrs = append(attrs, stringAttr(attrRepairActions, strings.Join(actions, ",")))
+	}
+
+	t := p.event.Time.Time
+	if t.IsZero() {
+		t = p.observed
+	}
+	msg := p.event.Message
+	return logRecord{
+		TimeUnixNano:         uint64String(uint64(t.UnixNano())),
+		ObservedTimeUnixNano: uint64String(uint64(p.observed.UnixNano())),
+		SeverityNumber:       severityNumber,
+		SeverityText:         severityText,
+		Body:                 anyValue{StringValue: &msg},
+		Attributes:           attrs,
+	}
+}
+
+// severity maps the event type to the OTLP log severity number and text.
+// ref. https://opentelemetry.io/docs/specs/otel/logs/data-model/#field-severitynumber
+func severity(t common.EventType) (int, string) {
+	switch t {
+	case common.EventTypeInfo:
+		return 9, "INFO"
+	case common.EventTypeWarning:
+		return 13, "WARN"
+	case common.EventTypeCritical:
+		return 17, "ERROR"
+	case common.EventTypeFatal:
+		return 21, "FATAL"
+	default:
+		return 0, string(t)
+	}
+}
@@ -0,0 +1,125 @@
+package otlp
+
+import (
+	"math"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// convertMetricFamilies converts the gathered Prometheus metric families
+// to the OTLP metrics, with the cumulative temporality starting from the start time.
+func convertMetricFamilies(mfs []*dto.MetricFamily, start time.Time, now time.Time) []metric {
+	startNano := uint64String(uint64(start.UnixNano()))
+	nowNano := uint64String(uint64(now.UnixNano()))
+
+	metrics := make([]metric, 0, len(mfs))
+	for _, mf := range mfs {
+		m := metric{
+			Name:        mf.GetName(),
+			Description: mf.GetHelp(),
+		}
+
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			m.Sum = &sum{
+				AggregationTemporality: aggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			}
+			for _, pm := range mf.GetMetric() {
+				m.Sum.DataPoints = append(m.Sum.DataPoints, numberDataPoint{
+					Attributes:        labelAttrs(pm.GetLabel()),
+					StartTimeUnixNano: startNano,
+					TimeUnixNano:      timestampNano(pm, nowNano),
+					AsDouble:          pm.GetCounter().GetValue(),
+				})
+			}
+
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			m.Gauge = &gauge{}
+			for _, pm := range mf.GetMetric() {
+				v := pm.GetGauge().GetValue()
+				if mf.GetType() == dto.MetricType_UNTYPED {
+					v = pm.GetUntyped().GetValue()
+				}
+				m.Gauge.DataPoints = append(m.Gauge.DataPoints, numberDataPoint{
+					Attributes:   labelAttrs(pm.GetLabel()),
+					TimeUnixNano: timestampNano(pm, nowNano),
+					AsDouble:     v,
+				})
+			}
+
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			m.Histogram = &histogram{AggregationTemporality: aggregationTemporalityCumulative}
+			for _, pm := range mf.GetMetric() {
+				dp := convertHistogram(pm.GetHistogram())
+				dp.Attributes = labelAttrs(pm.GetLabel())
+				dp.StartTimeUnixNano = startNano
+				dp.TimeUnixNano = timestampNano(pm, nowNano)
+				m.Histogram.DataPoints = append(m.Histogram.DataPoints, dp)
+			}
+
+		case dto.MetricType_SUMMARY:
+			m.Summary = &summary{}
+			for _, pm := range mf.GetMetric() {
+				s := pm.GetSummary()
+				dp := summaryDataPoint{
+					Attributes:        labelAttrs(pm.GetLabel()),
+					StartTimeUnixNano: startNano,
+					TimeUnixNano:      timestampNano(pm, nowNano),
+					Count:             uint64String(s.GetSampleCount()),
+					Sum:               s.GetSampleSum(),
+				}
+				for _, q := range s.GetQuantile() {
+					dp.QuantileValues = append(dp.QuantileValues, quantileValue{Quantile: q.GetQuantile(), Value: q.GetValue()})
+				}
+				m.Summary.DataPoints = append(m.Summary.DataPoints, dp)
+			}
+
+		default:
+			continue
+		}
+
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// convertHistogram converts the Prometheus cumulative buckets
+// to the OTLP per-bucket counts, with the implicit +Inf bucket last.
+func convertHistogram(h *dto.Histogram) histogramDataPoint {
+	dp := histogramDataPoint{
+		Count: uint64String(h.GetSampleCount()),
+		Sum:   h.GetSampleSum(),
+	}
+
+	var prev uint64
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), 1) {
+			continue
+		}
+		dp.ExplicitBounds = append(dp.ExplicitBounds, b.GetUpperBound())
+		dp.BucketCounts = append(dp.BucketCounts, uint64String(b.GetCumulativeCount()-prev))
+		prev = b.GetCumulativeCount()
+	}
+	dp.BucketCounts = append(dp.BucketCounts, uint64String(h.GetSampleCount()-prev))
+	return dp
+}
+
+func labelAttrs(labels []*dto.LabelPair) []keyValue {
+	if len(labels) == 0 {
+		return nil
+	}
+	attrs := make([]keyValue, 0, len(labels))
+	for _, l := range labels {
+		attrs = append(attrs, stringAttr(l.GetName(), l.GetValue()))
+	}
+	return attrs
+}
+
+func timestampNano(pm *dto.Metric, defaultNano string) string {
+	if pm.TimestampMs == nil {
+		return defaultNano
+	}
+	return uint64String(uint64(pm.GetTimestampMs()) * uint64(time.Millisecond))
+}
@@ -0,0 +1,64 @@
+package pods
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// e.g.,
+	// "pod6a2c2f8e-6a9e-4c1b-9d8f-6f4c2b1a0e3d" (cgroupfs driver)
+	// "kubepods-burstable-pod6a2c2f8e_6a9e_4c1b_9d8f_6f4c2b1a0e3d.slice" (systemd driver)
+	podUIDRegex = regexp.MustCompile(`pod([0-9a-fA-F]{8}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{4}[-_][0-9a-fA-F]{12})(?:\.slice)?$`)
+
+	// e.g.,
+	// "0123...cdef" (cgroupfs driver)
+	// "cri-containerd-0123...cdef.scope", "docker-0123...cdef.scope", "crio-0123...cdef.scope" (systemd driver)
+	containerIDRegex = regexp.MustCompile(`^(?:[a-z-]+-)?([0-9a-f]{64})(?:\.scope)?$`)
+)
+
+// procRoot is the proc filesystem to read the process cgroups, for testing.
+var procRoot = "/proc"
+
+// readCgroup returns the container ID and the pod UID of the process,
+// empty if the process does not run in a container.
+func readCgroup(pid int) (string, string, error) {
+	f, err := os.Open(filepath.Join(procRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	containerID, podUID := parseCgroup(f)
+	return containerID, podUID, nil
+}
+
+// parseCgroup parses the "/proc/[pid]/cgroup" of the cgroup v1 or v2
+// (e.g., "0::/kubepods.slice/.../cri-containerd-0123...cdef.scope").
+func parseCgroup(r io.Reader) (containerID string, podUID string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// "hierarchy-ID:controller-list:cgroup-path"
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, seg := range strings.Split(parts[2], "/") {
+			if m := podUIDRegex.FindStringSubmatch(seg); m != nil && podUID == "" {
+				podUID = strings.ReplaceAll(m[1], "_", "-")
+			}
+			if m := containerIDRegex.FindStringSubmatch(seg); m != nil && containerID == "" {
+				containerID = m[1]
+			}
+		}
+		if containerID != "" {
+			return containerID, podUID
+		}
+	}
+	return containerID, podUID
+}
@@ -0,0 +1,117 @@
+// Package pods resolves the processes to the Kubernetes pods and the containers
+// running them, joining the cgroup of the process with the pods listed by
+// the kubelet, the container runtime (CRI), or the docker.
+package pods
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Pod is the pod and the container running a process.
+// Only "UID" and "ContainerID" are set if the process runs in a container
+// not listed by any source (e.g., the pod listing is not ready yet).
+type Pod struct {
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	UID         string `json:"uid,omitempty"`
+	Container   string `json:"container,omitempty"`
+	ContainerID string `json:"container_id,omitempty"`
+}
+
+const (
+	ExtraInfoKeyPodNamespace = "pod_namespace"
+	ExtraInfoKeyPodName      = "pod_name"
+	ExtraInfoKeyPodUID       = "pod_uid"
+	ExtraInfoKeyContainer    = "container_name"
+	ExtraInfoKeyContainerID  = "container_id"
+)
+
+// ExtraInfo returns the pod identity as the event or the state extra info,
+// without the empty fields.
+func (p Pod) ExtraInfo() map[string]string {
+	m := make(map[string]string, 5)
+	for k, v := range map[string]string{
+		ExtraInfoKeyPodNamespace: p.Namespace,
+		ExtraInfoKeyPodName:      p.Name,
+		ExtraInfoKeyPodUID:       p.UID,
+		ExtraInfoKeyContainer:    p.Container,
+		ExtraInfoKeyContainerID:  p.ContainerID,
+	} {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// String returns the "namespace/name", or the container ID if the pod is unknown.
+func (p Pod) String() string {
+	if p.Name != "" {
+		if p.Namespace != "" {
+			return p.Namespace + "/" + p.Name
+		}
+		return p.Name
+	}
+	if p.ContainerID != "" {
+		return "container " + p.ContainerID
+	}
+	return "pod " + p.UID
+}
+
+// Lister lists the containers of the pods known to a source,
+// one "Pod" per container.
+type Lister interface {
+	ListPods() []Pod
+}
+
+var (
+	listersMu sync.RWMutex
+	listers   = make(map[string]Lister)
+)
+
+// RegisterLister registers the source of the pods (e.g., the kubelet pod component),
+// replacing the one with the same name.
+func RegisterLister(name string, l Lister) {
+	listersMu.Lock()
+	defer listersMu.Unlock()
+	listers[name] = l
+}
+
+// UnregisterLister removes the source of the pods (e.g., the component is closed).
+func UnregisterLister(name string) {
+	listersMu.Lock()
+	defer listersMu.Unlock()
+	delete(listers, name)
+}
+
+// listPods lists the pods of all the sources, in the order of the source names.
+func listPods() []Pod {
+	listersMu.RLock()
+	names := make([]string, 0, len(listers))
+	for name := range listers {
+		names = append(names, name)
+	}
+	ls := make([]Lister, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		ls = append(ls, listers[name])
+	}
+	listersMu.RUnlock()
+
+	var all []Pod
+	for _, l := range ls {
+		all = append(all, l.ListPods()...)
+	}
+	return all
+}
+
+// NormalizeContainerID strips the runtime prefix of the container ID
+// reported by the kubelet (e.g., "containerd://").
+func NormalizeContainerID(id string) string {
+	if i := strings.Index(id, "://"); i >= 0 {
+		return id[i+3:]
+	}
+	return id
+}
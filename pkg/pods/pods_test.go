@@ -0,0 +1,144 @@
+package pods
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testPodUID      = "6a2c2f8e-6a9e-4c1b-9d8f-6f4c2b1a0e3d"
+)
+
+func TestParseCgroup(t *testing.T) {
+	tests := []struct {
+		name            string
+		cgroup          string
+		wantContainerID string
+		wantPodUID      string
+	}{
+		{
+			name:            "cgroup v2 systemd containerd",
+			cgroup:          "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod6a2c2f8e_6a9e_4c1b_9d8f_6f4c2b1a0e3d.slice/cri-containerd-" + testContainerID + ".scope\n",
+			wantContainerID: testContainerID,
+			wantPodUID:      testPodUID,
+		},
+		{
+			name: "cgroup v1 cgroupfs",
+			cgroup: "12:pids:/kubepods/besteffort/pod" + testPodUID + "/" + testContainerID + "\n" +
+				"11:memory:/kubepods/besteffort/pod" + testPodUID + "/" + testContainerID + "\n",
+			wantContainerID: testContainerID,
+			wantPodUID:      testPodUID,
+		},
+		{
+			name:            "docker",
+			cgroup:          "0::/system.slice/docker-" + testContainerID + ".scope\n",
+			wantContainerID: testContainerID,
+		},
+		{
+			name:   "host process",
+			cgroup: "0::/user.slice/user-1000.slice/session-1.scope\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			containerID, podUID := parseCgroup(strings.NewReader(tt.cgroup))
+			require.Equal(t, tt.wantContainerID, containerID)
+			require.Equal(t, tt.wantPodUID, podUID)
+		})
+	}
+}
+
+func TestReadCgroup(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "42"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "42", "cgroup"), []byte("0::/system.slice/docker-"+testContainerID+".scope\n"), 0644))
+
+	orig := procRoot
+	procRoot = root
+	defer func() { procRoot = orig }()
+
+	containerID, podUID, err := readCgroup(42)
+	require.NoError(t, err)
+	require.Equal(t, testContainerID, containerID)
+	require.Empty(t, podUID)
+
+	_, _, err = readCgroup(43)
+	require.Error(t, err)
+}
+
+type testLister []Pod
+
+func (l testLister) ListPods() []Pod { return l }
+
+func TestResolver(t *testing.T) {
+	cgroups := map[int][2]string{
+		// listed container
+		1: {testContainerID, testPodUID},
+		// container not listed, but the pod is
+		2: {strings.Repeat("f", 64), testPodUID},
+		// host process
+		3: {"", ""},
+	}
+	exited := map[int]bool{}
+
+	r := NewResolver(time.Minute)
+	r.readCgroup = func(pid int) (string, string, error) {
+		if exited[pid] {
+			return "", "", errors.New("no such process")
+		}
+		cg, ok := cgroups[pid]
+		if !ok {
+			return "", "", errors.New("no such process")
+		}
+		return cg[0], cg[1], nil
+	}
+	r.listPods = testLister{
+		{Namespace: "default", Name: "other", UID: "other-uid", Container: "main", ContainerID: "containerd://" + strings.Repeat("e", 64)},
+		{Namespace: "team-a", Name: "train-0", UID: testPodUID, Container: "trainer", ContainerID: "containerd://" + testContainerID},
+	}.ListPods
+
+	pod, ok := r.Resolve(1)
+	require.True(t, ok)
+	require.Equal(t, Pod{Namespace: "team-a", Name: "train-0", UID: testPodUID, Container: "trainer", ContainerID: testContainerID}, pod)
+	require.Equal(t, "team-a/train-0", pod.String())
+	require.Equal(t, map[string]string{
+		ExtraInfoKeyPodNamespace: "team-a",
+		ExtraInfoKeyPodName:      "train-0",
+		ExtraInfoKeyPodUID:       testPodUID,
+		ExtraInfoKeyContainer:    "trainer",
+		ExtraInfoKeyContainerID:  testContainerID,
+	}, pod.ExtraInfo())
+
+	pod, ok = r.Resolve(2)
+	require.True(t, ok)
+	require.Equal(t, Pod{Namespace: "team-a", Name: "train-0", UID: testPodUID, ContainerID: strings.Repeat("f", 64)}, pod)
+
+	_, ok = r.Resolve(3)
+	require.False(t, ok)
+	_, ok = r.Resolve(4)
+	require.False(t, ok)
+
+	// still resolved after the process exits
+	exited[1] = true
+	pod, ok = r.Resolve(1)
+	require.True(t, ok)
+	require.Equal(t, "train-0", pod.Name)
+}
+
+func TestRegisterLister(t *testing.T) {
+	RegisterLister("b", testLister{{Name: "pod-b"}})
+	RegisterLister("a", testLister{{Name: "pod-a"}})
+	defer UnregisterLister("a")
+
+	require.Equal(t, []Pod{{Name: "pod-a"}, {Name: "pod-b"}}, listPods())
+
+	UnregisterLister("b")
+	require.Equal(t, []Pod{{Name: "pod-a"}}, listPods())
+}
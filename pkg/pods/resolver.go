@@ -0,0 +1,79 @@
+package pods
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// DefaultCacheExpiration is how long the resolved pods are kept,
+// to resolve the exited processes (e.g., killed by the GPU error)
+// named by the later kernel messages.
+const DefaultCacheExpiration = 30 * time.Minute
+
+// Resolver resolves the process IDs to the pods.
+type Resolver struct {
+	cache *cache.Cache
+
+	readCgroup func(pid int) (string, string, error)
+	listPods   func() []Pod
+}
+
+// NewResolver creates the resolver with the pods listed by the registered sources.
+func NewResolver(exp time.Duration) *Resolver {
+	return &Resolver{
+		cache:      cache.New(exp, exp),
+		readCgroup: readCgroup,
+		listPods:   listPods,
+	}
+}
+
+var defaultResolver = NewResolver(DefaultCacheExpiration)
+
+// Resolve resolves the process with the default resolver.
+func Resolve(pid int) (Pod, bool) {
+	return defaultResolver.Resolve(pid)
+}
+
+// Resolve returns the pod running the process, or false if the process
+// does not run in a container, or has exited without being resolved before.
+// The running processes are always resolved again, since the process IDs are reused.
+func (r *Resolver) Resolve(pid int) (Pod, bool) {
+	key := strconv.Itoa(pid)
+
+	containerID, podUID, err := r.readCgroup(pid)
+	if err != nil {
+		// e.g., exited
+		if v, ok := r.cache.Get(key); ok {
+			return v.(Pod), true
+		}
+		return Pod{}, false
+	}
+	if containerID == "" && podUID == "" {
+		r.cache.Delete(key)
+		return Pod{}, false
+	}
+
+	p := r.match(containerID, podUID)
+	r.cache.SetDefault(key, p)
+	return p, true
+}
+
+// match finds the container, or the pod if the container is not listed.
+func (r *Resolver) match(containerID string, podUID string) Pod {
+	found := Pod{UID: podUID, ContainerID: containerID}
+	for _, p := range r.listPods() {
+		if containerID != "" && NormalizeContainerID(p.ContainerID) == containerID {
+			p.ContainerID = containerID
+			if p.UID == "" {
+				p.UID = podUID
+			}
+			return p
+		}
+		if podUID != "" && p.UID == podUID && found.Name == "" {
+			found.Namespace, found.Name = p.Namespace, p.Name
+		}
+	}
+	return found
+}
@@ -0,0 +1,194 @@
+package remediation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/pkg/common"
+)
+
+const (
+	DefaultMaxReboots       = 1
+	DefaultRebootWindow     = 24 * time.Hour
+	DefaultDecisionInterval = time.Hour
+	DefaultCommandTimeout   = 5 * time.Minute
+)
+
+// Config configures the remediation engine.
+type Config struct {
+	// DryRun set true to only record the decisions without acting on them.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// MaxReboots is the maximum number of reboots within the reboot window.
+	// The reboots decided in the dry-run mode are counted as well.
+	MaxReboots int `json:"max_reboots,omitempty"`
+	// DisableReboots set true to deny all the reboots, regardless of MaxReboots.
+	DisableReboots bool `json:"disable_reboots,omitempty"`
+	// RebootWindow is the sliding time window to count the reboots.
+	RebootWindow metav1.Duration `json:"reboot_window,omitempty"`
+
+	// MaintenanceWindows are the time windows that the actions are allowed in.
+	// If empty, the actions are allowed at any time.
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows,omitempty"`
+
+	// CordonCommand is the bash script to run before acting
+	// (e.g., "kubectl cordon $NODE_NAME && kubectl drain ...").
+	// The action is aborted if the script fails.
+	CordonCommand string `json:"cordon_command,omitempty"`
+
+	// Commands are the bash scripts to run per repair action.
+	// The "REBOOT_SYSTEM" action reboots the system if no script is set,
+	// and the other actions without the script are not acted on.
+	Commands map[common.RepairActionType]string `json:"commands,omitempty"`
+
+	// CommandTimeout is the timeout of the cordon and the action scripts.
+	CommandTimeout metav1.Duration `json:"command_timeout,omitempty"`
+
+	// DecisionInterval is the minimum interval to decide again
+	// on the same suggested action with the same outcome.
+	DecisionInterval metav1.Duration `json:"decision_interval,omitempty"`
+}
+
+// SetDefaults sets the default values for the unset fields.
+func (cfg *Config) SetDefaults() {
+	if cfg.DisableReboots {
+		cfg.MaxReboots = 0
+	} else if cfg.MaxReboots == 0 {
+		cfg.MaxReboots = DefaultMaxReboots
+	}
+	if cfg.RebootWindow.Duration == 0 {
+		cfg.RebootWindow = metav1.Duration{Duration: DefaultRebootWindow}
+	}
+	if cfg.CommandTimeout.Duration == 0 {
+		cfg.CommandTimeout = metav1.Duration{Duration: DefaultCommandTimeout}
+	}
+	if cfg.DecisionInterval.Duration == 0 {
+		cfg.DecisionInterval = metav1.Duration{Duration: DefaultDecisionInterval}
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.MaxReboots < 0 {
+		return errors.New("max_reboots must be non-negative")
+	}
+	if cfg.RebootWindow.Duration < 0 || cfg.CommandTimeout.Duration < 0 || cfg.DecisionInterval.Duration < 0 {
+		return errors.New("durations must be non-negative")
+	}
+	for i, w := range cfg.MaintenanceWindows {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("maintenance_windows[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// MaintenanceWindow is the daily time window, e.g.,
+// {"days": ["Sat", "Sun"], "start": "22:00", "end": "04:00"}.
+// The window may wrap past midnight, in which case the days apply to the start.
+type MaintenanceWindow struct {
+	// Days are the weekdays ("Sun", "Mon", ..., "Sat") the window starts on.
+	// If empty, the window starts every day.
+	Days []string `json:"days,omitempty"`
+	// Start is the start time of the day in "HH:MM" (inclusive).
+	Start string `json:"start"`
+	// End is the end time of the day in "HH:MM" (exclusive).
+	End string `json:"end"`
+	// Location is the IANA time zone name of the window, defaults to UTC.
+	Location string `json:"location,omitempty"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (w MaintenanceWindow) Validate() error {
+	for _, d := range w.Days {
+		if _, ok := weekdays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("invalid day %q", d)
+		}
+	}
+	if _, err := parseMinutes(w.Start); err != nil {
+		return err
+	}
+	if _, err := parseMinutes(w.End); err != nil {
+		return err
+	}
+	if _, err := w.location(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w MaintenanceWindow) location() (*time.Location, error) {
+	if w.Location == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(w.Location)
+}
+
+// Contains returns true if the time is within the window.
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	loc, err := w.location()
+	if err != nil {
+		return false
+	}
+	start, err := parseMinutes(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseMinutes(w.End)
+	if err != nil {
+		return false
+	}
+
+	t = t.In(loc)
+	mins := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	switch {
+	case start == end:
+		// the whole day
+	case start < end:
+		if mins < start || mins >= end {
+			return false
+		}
+	default:
+		// wraps past midnight
+		switch {
+		case mins >= start:
+		case mins < end:
+			day = (day + 6) % 7
+		default:
+			return false
+		}
+	}
+
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMinutes parses "HH:MM" into the minutes of the day.
+func parseMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q (expected HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
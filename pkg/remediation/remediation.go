@@ -0,0 +1,353 @@
+// Package remediation implements the opt-in local remediation engine
+// that acts on the suggested repair actions of the component states
+// (e.g., reboots the system on "REBOOT_SYSTEM"), within the policy guardrails.
+package remediation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/reboot"
+)
+
+// BucketName is the event store bucket name that records every decision.
+const BucketName = "remediation"
+
+// EventName is the name of the decision events.
+const EventName = "remediation"
+
+const (
+	EventKeyComponent = "component"
+	EventKeyState     = "state"
+	EventKeyAction    = "action"
+	EventKeyOutcome   = "outcome"
+)
+
+// Outcome is the result of the remediation decision.
+type Outcome string
+
+const (
+	// OutcomeExecuted means the action was executed (or, for the reboot, triggered).
+	OutcomeExecuted Outcome = "executed"
+	// OutcomeDryRun means the action would have been executed.
+	OutcomeDryRun Outcome = "dry-run"
+	// OutcomeFailed means the action failed.
+	OutcomeFailed Outcome = "failed"
+	// OutcomeCordonFailed means the cordon hook failed, thus the action was aborted.
+	OutcomeCordonFailed Outcome = "cordon-failed"
+	// OutcomeDeniedRateLimit means the action exceeds the max reboots within the window.
+	OutcomeDeniedRateLimit Outcome = "denied-rate-limit"
+	// OutcomeDeniedMaintenanceWindow means the action is outside the maintenance windows.
+	OutcomeDeniedMaintenanceWindow Outcome = "denied-maintenance-window"
+	// OutcomeSkippedNoExecutor means no command is configured for the action.
+	OutcomeSkippedNoExecutor Outcome = "skipped-no-executor"
+)
+
+func (o Outcome) eventType() common.EventType {
+	switch o {
+	case OutcomeExecuted, OutcomeDryRun:
+		return common.EventTypeWarning
+	case OutcomeFailed, OutcomeCordonFailed:
+		return common.EventTypeCritical
+	default:
+		return common.EventTypeInfo
+	}
+}
+
+// Request is the suggested repair action of a component state.
+type Request struct {
+	Component string
+	State     string
+	Action    common.RepairActionType
+	Reason    string
+}
+
+func (r Request) key() string {
+	return r.Component + "/" + r.State + "/" + string(r.Action)
+}
+
+// Decision is the outcome of the request.
+type Decision struct {
+	Request
+	Time    time.Time
+	Outcome Outcome
+	Message string
+}
+
+// defaultQueueSize is the number of pending requests.
+const defaultQueueSize = 64
+
+// Engine decides and acts on the suggested repair actions.
+type Engine struct {
+	cfg    Config
+	bucket eventstore.Bucket
+
+	queue chan Request
+
+	mu sync.Mutex
+	// last recorded decision, keyed by the request
+	last map[string]Decision
+
+	// for testing
+	timeNow   func() time.Time
+	reboot    func(ctx context.Context) error
+	runScript func(ctx context.Context, script string, env []string) error
+}
+
+// New creates the remediation engine that records the decisions in the bucket.
+// The configuration defaults are set for the unset fields.
+func New(cfg Config, bucket eventstore.Bucket) (*Engine, error) {
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Engine{
+		cfg:       cfg,
+		bucket:    bucket,
+		queue:     make(chan Request, defaultQueueSize),
+		last:      make(map[string]Decision),
+		timeNow:   time.Now,
+		reboot:    func(ctx context.Context) error { return reboot.Reboot(ctx) },
+		runScript: runBashScript,
+	}, nil
+}
+
+// ObserveStates enqueues the suggested repair actions of the component states.
+// Never blocks -- the requests are dropped if the queue is full,
+// and decided on the next observation.
+func (e *Engine) ObserveStates(component string, states []components.State) {
+	for _, s := range states {
+		if s.SuggestedActions == nil || len(s.SuggestedActions.RepairActions) == 0 {
+			continue
+		}
+		// only the first action is acted on, the others are the alternatives
+		action := s.SuggestedActions.RepairActions[0]
+		if action == common.RepairActionTypeIgnoreNoActionRequired {
+			continue
+		}
+		req := Request{Component: component, State: s.Name, Action: action, Reason: s.Reason}
+		select {
+		case e.queue <- req:
+		default:
+			log.Logger.Warnw("remediation queue full -- dropping request", "component", component, "action", action)
+		}
+	}
+}
+
+// Run decides on the enqueued requests until the context is canceled.
+func (e *Engine) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-e.queue:
+			if d, ok := e.Decide(ctx, req); ok {
+				log.Logger.Infow("remediation decision", "component", d.Component, "state", d.State, "action", d.Action, "outcome", d.Outcome, "message", d.Message)
+			}
+		}
+	}
+}
+
+// Decide applies the policy to the request, acts on it if allowed,
+// and records the decision as an event.
+// Returns false if the decision is the same as the last one
+// within the decision interval, thus not recorded nor acted on.
+func (e *Engine) Decide(ctx context.Context, req Request) (Decision, bool) {
+	now := e.timeNow()
+	d := Decision{Request: req, Time: now}
+
+	d.Outcome, d.Message = e.check(ctx, req, now)
+	if d.Outcome == "" {
+		d.Outcome = OutcomeExecuted
+		if e.cfg.DryRun {
+			d.Outcome = OutcomeDryRun
+		}
+	}
+	if e.isRepeated(d) {
+		return d, false
+	}
+
+	if d.Outcome != OutcomeExecuted {
+		e.record(ctx, d)
+		return d, true
+	}
+
+	env := []string{
+		"GPUD_COMPONENT=" + req.Component,
+		"GPUD_STATE=" + req.State,
+		"GPUD_ACTION=" + string(req.Action),
+		"GPUD_REASON=" + req.Reason,
+	}
+	if e.cfg.CordonCommand != "" {
+		if err := e.runCommand(ctx, e.cfg.CordonCommand, env); err != nil {
+			d.Outcome, d.Message = OutcomeCordonFailed, fmt.Sprintf("cordon command failed: %v", err)
+			e.record(ctx, d)
+			return d, true
+		}
+	}
+
+	script, hasScript := e.cfg.Commands[req.Action]
+	if !hasScript && req.Action == common.RepairActionTypeRebootSystem {
+		// committed before the reboot, which may not return,
+		// so that the reboot is counted against the limit after the restart
+		d.Message = "rebooting the system"
+		if err := e.recordSync(ctx, d); err != nil {
+			d.Outcome, d.Message = OutcomeFailed, fmt.Sprintf("not rebooting, failed to record the reboot decision: %v", err)
+			e.record(ctx, d)
+			return d, true
+		}
+		if err := e.reboot(ctx); err != nil {
+			d.Outcome, d.Message = OutcomeFailed, fmt.Sprintf("reboot failed: %v", err)
+			e.record(ctx, d)
+		}
+		return d, true
+	}
+
+	if err := e.runCommand(ctx, script, env); err != nil {
+		d.Outcome, d.Message = OutcomeFailed, fmt.Sprintf("command failed: %v", err)
+	} else {
+		d.Message = "command succeeded"
+	}
+	e.record(ctx, d)
+	return d, true
+}
+
+// check returns the outcome if the request is not allowed by the policy,
+// or an empty outcome if allowed.
+func (e *Engine) check(ctx context.Context, req Request, now time.Time) (Outcome, string) {
+	if _, ok := e.cfg.Commands[req.Action]; !ok && req.Action != common.RepairActionTypeRebootSystem {
+		return OutcomeSkippedNoExecutor, fmt.Sprintf("no command configured for %s", req.Action)
+	}
+
+	if len(e.cfg.MaintenanceWindows) > 0 {
+		inWindow := false
+		for _, w := range e.cfg.MaintenanceWindows {
+			if w.Contains(now) {
+				inWindow = true
+				break
+			}
+		}
+		if !inWindow {
+			return OutcomeDeniedMaintenanceWindow, "outside the maintenance windows"
+		}
+	}
+
+	if req.Action == common.RepairActionTypeRebootSystem {
+		if e.cfg.DisableReboots {
+			return OutcomeDeniedRateLimit, "reboots disabled"
+		}
+		count, err := e.countReboots(ctx, now)
+		if err != nil {
+			// fail closed, not to reboot repeatedly
+			return OutcomeDeniedRateLimit, fmt.Sprintf("failed to count the reboots: %v", err)
+		}
+		if count >= e.cfg.MaxReboots {
+			return OutcomeDeniedRateLimit, fmt.Sprintf("%d reboot(s) within %s (max %d)", count, e.cfg.RebootWindow.Duration, e.cfg.MaxReboots)
+		}
+	}
+	return "", ""
+}
+
+// countReboots returns the number of the reboots decided within the reboot window.
+func (e *Engine) countReboots(ctx context.Context, now time.Time) (int, error) {
+	evs, err := e.bucket.Get(ctx, now.Add(-e.cfg.RebootWindow.Duration))
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, ev := range evs {
+		if ev.Name != EventName || ev.ExtraInfo[EventKeyAction] != string(common.RepairActionTypeRebootSystem) {
+			continue
+		}
+		switch Outcome(ev.ExtraInfo[EventKeyOutcome]) {
+		case OutcomeExecuted, OutcomeDryRun:
+			count++
+		}
+	}
+	return count, nil
+}
+
+// isRepeated returns true if the same request had the same outcome
+// within the decision interval, and records the decision otherwise.
+func (e *Engine) isRepeated(d Decision) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := d.key()
+	if last, ok := e.last[key]; ok && last.Outcome == d.Outcome && d.Time.Sub(last.Time) < e.cfg.DecisionInterval.Duration {
+		return true
+	}
+	e.last[key] = d
+	return false
+}
+
+func (e *Engine) record(ctx context.Context, d Decision) {
+	if err := e.bucket.Insert(ctx, d.event()); err != nil {
+		log.Logger.Warnw("failed to record remediation decision", "component", d.Component, "action", d.Action, "outcome", d.Outcome, "error", err)
+	}
+}
+
+// recordSync records the decision committed before returning,
+// if the bucket supports the synchronous inserts.
+func (e *Engine) recordSync(ctx context.Context, d Decision) error {
+	if syncer, ok := e.bucket.(eventstore.Syncer); ok {
+		return syncer.InsertSync(ctx, d.event())
+	}
+	return e.bucket.Insert(ctx, d.event())
+}
+
+func (d Decision) event() components.Event {
+	return components.Event{
+		Time:    metav1.Time{Time: d.Time.UTC()},
+		Name:    EventName,
+		Type:    d.Outcome.eventType(),
+		Message: fmt.Sprintf("%s %s for %s/%s: %s", d.Outcome, d.Action, d.Component, d.State, d.Message),
+		ExtraInfo: map[string]string{
+			EventKeyComponent: d.Component,
+			EventKeyState:     d.State,
+			EventKeyAction:    string(d.Action),
+			EventKeyOutcome:   string(d.Outcome),
+		},
+	}
+}
+
+func (e *Engine) runCommand(ctx context.Context, script string, env []string) error {
+	cctx, cancel := context.WithTimeout(ctx, e.cfg.CommandTimeout.Duration)
+	defer cancel()
+	return e.runScript(cctx, script, env)
+}
+
+func runBashScript(ctx context.Context, script string, env []string) error {
+	cmd := exec.CommandContext(ctx, "bash", "-c", script)
+	cmd.Env = append(os.Environ(), env...)
+	// not to block on the pipes held by the orphaned child processes after the timeout
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// the script exited successfully, only its background child processes hold the output
+		log.Logger.Warnw("script left the background processes holding its output", "output", truncate(string(out), 512))
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w (output: %q)", err, truncate(string(out), 512))
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,313 @@
+package remediation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+type fakeExecutor struct {
+	reboots   int
+	rebootErr error
+	scripts   []string
+	scriptErr map[string]error
+}
+
+func newTestEngine(t *testing.T, cfg Config) (*Engine, *fakeExecutor, eventstore.Bucket) {
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	t.Cleanup(cleanup)
+
+	store, err := eventstore.New(dbRW, dbRO, eventstore.DefaultRetention)
+	require.NoError(t, err)
+	bucket, err := store.Bucket(BucketName)
+	require.NoError(t, err)
+	t.Cleanup(bucket.Close)
+
+	e, err := New(cfg, bucket)
+	require.NoError(t, err)
+
+	exec := &fakeExecutor{scriptErr: make(map[string]error)}
+	e.reboot = func(ctx context.Context) error {
+		exec.reboots++
+		return exec.rebootErr
+	}
+	e.runScript = func(ctx context.Context, script string, env []string) error {
+		exec.scripts = append(exec.scripts, script)
+		return exec.scriptErr[script]
+	}
+	return e, exec, bucket
+}
+
+func rebootRequest() Request {
+	return Request{Component: "accelerator-nvidia-xid", State: "error_xid", Action: common.RepairActionTypeRebootSystem, Reason: "xid 79"}
+}
+
+func recordedOutcomes(t *testing.T, bucket eventstore.Bucket) []Outcome {
+	evs, err := bucket.Get(context.Background(), time.Time{})
+	require.NoError(t, err)
+	outcomes := make([]Outcome, 0, len(evs))
+	// latest first
+	for i := len(evs) - 1; i >= 0; i-- {
+		outcomes = append(outcomes, Outcome(evs[i].ExtraInfo[EventKeyOutcome]))
+	}
+	return outcomes
+}
+
+func TestDecideRebootRateLimit(t *testing.T) {
+	e, exec, bucket := newTestEngine(t, Config{MaxReboots: 1, DecisionInterval: metav1.Duration{Duration: time.Minute}})
+	now := time.Now()
+	e.timeNow = func() time.Time { return now }
+
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeExecuted, d.Outcome)
+	require.Equal(t, 1, exec.reboots)
+
+	// the second reboot within the window is denied
+	now = now.Add(time.Second)
+	d, ok = e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeDeniedRateLimit, d.Outcome)
+	require.Equal(t, 1, exec.reboots)
+
+	// the same denial is not recorded again within the decision interval
+	now = now.Add(time.Second)
+	_, ok = e.Decide(context.Background(), rebootRequest())
+	require.False(t, ok)
+
+	// allowed again once the window elapses
+	now = now.Add(DefaultRebootWindow)
+	d, ok = e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeExecuted, d.Outcome)
+	require.Equal(t, 2, exec.reboots)
+
+	require.Equal(t, []Outcome{OutcomeExecuted, OutcomeDeniedRateLimit, OutcomeExecuted}, recordedOutcomes(t, bucket))
+}
+
+func TestDecideRebootFailed(t *testing.T) {
+	e, exec, bucket := newTestEngine(t, Config{})
+	exec.rebootErr = errors.New("permission denied")
+
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeFailed, d.Outcome)
+	require.Equal(t, []Outcome{OutcomeExecuted, OutcomeFailed}, recordedOutcomes(t, bucket))
+}
+
+// failingSyncBucket fails the synchronous inserts.
+type failingSyncBucket struct {
+	eventstore.Bucket
+}
+
+func (b failingSyncBucket) InsertSync(ctx context.Context, ev components.Event) error {
+	return errors.New("database is locked")
+}
+
+func TestDecideRebootRecordFailed(t *testing.T) {
+	e, exec, bucket := newTestEngine(t, Config{})
+	e.bucket = failingSyncBucket{Bucket: bucket}
+
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeFailed, d.Outcome)
+	require.Zero(t, exec.reboots, "not rebooted without the recorded decision")
+	require.Equal(t, []Outcome{OutcomeFailed}, recordedOutcomes(t, bucket))
+}
+
+func TestDecideRebootCommittedWithWriter(t *testing.T) {
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	t.Cleanup(cleanup)
+
+	// never flushed within the test
+	w, err := eventstore.NewWriter(dbRW, eventstore.WriterConfig{FlushInterval: metav1.Duration{Duration: time.Hour}})
+	require.NoError(t, err)
+	t.Cleanup(w.Close)
+	store, err := eventstore.New(dbRW, dbRO, eventstore.DefaultRetention, eventstore.WithWriter(w))
+	require.NoError(t, err)
+	bucket, err := store.Bucket(BucketName)
+	require.NoError(t, err)
+	t.Cleanup(bucket.Close)
+
+	e, err := New(Config{}, bucket)
+	require.NoError(t, err)
+	var committed []Outcome
+	e.reboot = func(ctx context.Context) error {
+		// read the committed events only, as after the restart
+		syncStore, err := eventstore.New(dbRW, dbRO, eventstore.DefaultRetention)
+		require.NoError(t, err)
+		syncBucket, err := syncStore.Bucket(BucketName)
+		require.NoError(t, err)
+		defer syncBucket.Close()
+		committed = recordedOutcomes(t, syncBucket)
+		return nil
+	}
+
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeExecuted, d.Outcome)
+	require.Equal(t, []Outcome{OutcomeExecuted}, committed)
+}
+
+func TestDecideRebootsDisabled(t *testing.T) {
+	e, exec, bucket := newTestEngine(t, Config{DisableReboots: true, MaxReboots: 3})
+
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeDeniedRateLimit, d.Outcome)
+	require.Zero(t, exec.reboots)
+	require.Equal(t, []Outcome{OutcomeDeniedRateLimit}, recordedOutcomes(t, bucket))
+}
+
+func TestDecideDryRun(t *testing.T) {
+	e, exec, bucket := newTestEngine(t, Config{DryRun: true, CordonCommand: "cordon"})
+
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeDryRun, d.Outcome)
+	require.Zero(t, exec.reboots)
+	require.Empty(t, exec.scripts, "no cordon in the dry-run mode")
+
+	// the dry-run reboots count against the limit
+	e.timeNow = func() time.Time { return time.Now().Add(time.Second) }
+	d, ok = e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeDeniedRateLimit, d.Outcome)
+	require.Equal(t, []Outcome{OutcomeDryRun, OutcomeDeniedRateLimit}, recordedOutcomes(t, bucket))
+}
+
+func TestDecideMaintenanceWindow(t *testing.T) {
+	e, exec, _ := newTestEngine(t, Config{
+		MaintenanceWindows: []MaintenanceWindow{{Start: "22:00", End: "04:00"}},
+	})
+
+	e.timeNow = func() time.Time { return time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC) }
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeDeniedMaintenanceWindow, d.Outcome)
+	require.Zero(t, exec.reboots)
+
+	e.timeNow = func() time.Time { return time.Date(2025, 1, 6, 23, 0, 0, 0, time.UTC) }
+	d, ok = e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeExecuted, d.Outcome)
+	require.Equal(t, 1, exec.reboots)
+}
+
+func TestDecideCordon(t *testing.T) {
+	e, exec, _ := newTestEngine(t, Config{CordonCommand: "cordon"})
+	exec.scriptErr["cordon"] = errors.New("exit status 1")
+
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeCordonFailed, d.Outcome)
+	require.Zero(t, exec.reboots)
+
+	e2, exec2, _ := newTestEngine(t, Config{CordonCommand: "cordon"})
+	d, ok = e2.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeExecuted, d.Outcome)
+	require.Equal(t, []string{"cordon"}, exec2.scripts)
+	require.Equal(t, 1, exec2.reboots)
+}
+
+func TestDecideCommands(t *testing.T) {
+	e, exec, _ := newTestEngine(t, Config{
+		Commands: map[common.RepairActionType]string{
+			common.RepairActionTypeRebootSystem:       "custom-reboot",
+			common.RepairActionTypeCheckUserAppAndGPU: "restart-app",
+		},
+	})
+
+	d, ok := e.Decide(context.Background(), rebootRequest())
+	require.True(t, ok)
+	require.Equal(t, OutcomeExecuted, d.Outcome)
+	require.Zero(t, exec.reboots, "custom command replaces the reboot")
+
+	exec.scriptErr["restart-app"] = errors.New("exit status 2")
+	d, ok = e.Decide(context.Background(), Request{Component: "xid", State: "xid", Action: common.RepairActionTypeCheckUserAppAndGPU})
+	require.True(t, ok)
+	require.Equal(t, OutcomeFailed, d.Outcome)
+
+	d, ok = e.Decide(context.Background(), Request{Component: "xid", State: "xid", Action: common.RepairActionTypeHardwareInspection})
+	require.True(t, ok)
+	require.Equal(t, OutcomeSkippedNoExecutor, d.Outcome)
+
+	require.Equal(t, []string{"custom-reboot", "restart-app"}, exec.scripts)
+}
+
+func TestObserveStates(t *testing.T) {
+	e, _, _ := newTestEngine(t, Config{})
+
+	e.ObserveStates("accelerator-nvidia-xid", []components.State{
+		{Name: "healthy", Healthy: true},
+		{Name: "ignore", SuggestedActions: &common.SuggestedActions{RepairActions: []common.RepairActionType{common.RepairActionTypeIgnoreNoActionRequired}}},
+		{Name: "error_xid", Reason: "xid 79", SuggestedActions: &common.SuggestedActions{RepairActions: []common.RepairActionType{common.RepairActionTypeRebootSystem}}},
+	})
+
+	require.Len(t, e.queue, 1)
+	require.Equal(t, rebootRequest(), <-e.queue)
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	// 2025-01-06 is a Monday
+	mon := func(h, m int) time.Time { return time.Date(2025, 1, 6, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		t      time.Time
+		want   bool
+	}{
+		{name: "within", window: MaintenanceWindow{Start: "01:00", End: "03:00"}, t: mon(2, 0), want: true},
+		{name: "end exclusive", window: MaintenanceWindow{Start: "01:00", End: "03:00"}, t: mon(3, 0), want: false},
+		{name: "wrap before midnight", window: MaintenanceWindow{Start: "22:00", End: "04:00"}, t: mon(23, 0), want: true},
+		{name: "wrap after midnight", window: MaintenanceWindow{Start: "22:00", End: "04:00"}, t: mon(1, 0), want: true},
+		{name: "wrap outside", window: MaintenanceWindow{Start: "22:00", End: "04:00"}, t: mon(12, 0), want: false},
+		{name: "day matches", window: MaintenanceWindow{Days: []string{"Mon"}, Start: "01:00", End: "03:00"}, t: mon(2, 0), want: true},
+		{name: "day does not match", window: MaintenanceWindow{Days: []string{"Tue"}, Start: "01:00", End: "03:00"}, t: mon(2, 0), want: false},
+		{name: "wrap belongs to the start day", window: MaintenanceWindow{Days: []string{"Sun"}, Start: "22:00", End: "04:00"}, t: mon(1, 0), want: true},
+		{name: "whole day", window: MaintenanceWindow{Days: []string{"mon"}, Start: "00:00", End: "00:00"}, t: mon(12, 0), want: true},
+		{name: "location", window: MaintenanceWindow{Start: "01:00", End: "03:00", Location: "Asia/Tokyo"}, t: mon(2, 0), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.window.Validate())
+			require.Equal(t, tt.want, tt.window.Contains(tt.t))
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, (&Config{}).Validate())
+	require.Error(t, (&Config{MaxReboots: -1}).Validate())
+	require.Error(t, (&Config{MaintenanceWindows: []MaintenanceWindow{{Start: "25:00", End: "01:00"}}}).Validate())
+	require.Error(t, (&Config{MaintenanceWindows: []MaintenanceWindow{{Days: []string{"Funday"}, Start: "01:00", End: "02:00"}}}).Validate())
+	require.Error(t, (&Config{MaintenanceWindows: []MaintenanceWindow{{Start: "01:00", End: "02:00", Location: "Nowhere/City"}}}).Validate())
+}
+
+func TestRunBashScriptBackgroundChild(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the background child holds the output pipe after the script exits
+	start := time.Now()
+	require.NoError(t, runBashScript(ctx, "sleep 60 &\necho cordoned", nil))
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	// the timed out script with the background child
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	require.Error(t, runBashScript(ctx, "sleep 60 &\nsleep 60", nil))
+	require.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,148 @@
+package remotewrite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+const (
+	TableNameSamples = "remote_write_samples"
+
+	// ColumnSampleID is the insertion order of the samples,
+	// to replay them in the order they were gathered.
+	ColumnSampleID = "id"
+
+	// ColumnSampleLabels is the JSON object of the series labels, including "__name__".
+	ColumnSampleLabels = "labels"
+
+	ColumnSampleValue       = "value"
+	ColumnSampleTimestampMs = "timestamp_ms"
+)
+
+func CreateTableSamples(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s TEXT NOT NULL,
+	%s REAL,
+	%s INTEGER NOT NULL
+);`, TableNameSamples,
+		ColumnSampleID,
+		ColumnSampleLabels,
+		ColumnSampleValue,
+		ColumnSampleTimestampMs,
+	))
+	return err
+}
+
+// sample is the gathered sample, buffered until pushed.
+type sample struct {
+	id          int64
+	labels      string
+	value       float64
+	timestampMs int64
+}
+
+func bufferSamples(ctx context.Context, dbRW *sql.DB, samples []sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	tx, err := dbRW.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)`,
+		TableNameSamples, ColumnSampleLabels, ColumnSampleValue, ColumnSampleTimestampMs))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range samples {
+		// sqlite stores NaN as NULL, read back as NaN
+		var v any = s.value
+		if math.IsNaN(s.value) {
+			v = nil
+		}
+		if _, err := stmt.ExecContext(ctx, s.labels, v, s.timestampMs); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	err = tx.Commit()
+	sqlite.RecordInsertUpdate(time.Since(start).Seconds())
+	return err
+}
+
+// readSamples reads the oldest buffered samples up to the limit, in the insertion order.
+func readSamples(ctx context.Context, db *sql.DB, limit int) ([]sample, error) {
+	query := fmt.Sprintf(`SELECT %s, %s, %s, %s FROM %s ORDER BY %s ASC LIMIT ?`,
+		ColumnSampleID, ColumnSampleLabels, ColumnSampleValue, ColumnSampleTimestampMs,
+		TableNameSamples,
+		ColumnSampleID,
+	)
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, query, limit)
+	sqlite.RecordSelect(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []sample
+	for rows.Next() {
+		var s sample
+		var v sql.NullFloat64
+		if err := rows.Scan(&s.id, &s.labels, &v, &s.timestampMs); err != nil {
+			return nil, err
+		}
+		s.value = math.NaN()
+		if v.Valid {
+			s.value = v.Float64
+		}
+		samples = append(samples, s)
+	}
+	return samples, rows.Err()
+}
+
+// deleteSamples deletes the buffered samples up to the id (inclusive).
+func deleteSamples(ctx context.Context, dbRW *sql.DB, throughID int64) (int, error) {
+	return execDelete(ctx, dbRW, fmt.Sprintf(`DELETE FROM %s WHERE %s <= ?`, TableNameSamples, ColumnSampleID), throughID)
+}
+
+// trimSamples deletes the oldest buffered samples beyond the latest "maxSamples".
+func trimSamples(ctx context.Context, dbRW *sql.DB, maxSamples int) (int, error) {
+	return execDelete(ctx, dbRW, fmt.Sprintf(`DELETE FROM %s WHERE %s <= (
+	SELECT %s FROM %s ORDER BY %s DESC LIMIT 1 OFFSET ?
+)`, TableNameSamples, ColumnSampleID, ColumnSampleID, TableNameSamples, ColumnSampleID), maxSamples)
+}
+
+func countSamples(ctx context.Context, db *sql.DB) (int, error) {
+	var n int
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, TableNameSamples)).Scan(&n)
+	return n, err
+}
+
+func execDelete(ctx context.Context, dbRW *sql.DB, deleteStatement string, args ...any) (int, error) {
+	start := time.Now()
+	rs, err := dbRW.ExecContext(ctx, deleteStatement, args...)
+	if err != nil {
+		return 0, err
+	}
+	sqlite.RecordDelete(time.Since(start).Seconds())
+
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,72 @@
+package remotewrite
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultPushInterval       = time.Minute
+	DefaultMaxSamplesPerPush  = 5000
+	DefaultMaxBufferedSamples = 1000000
+)
+
+// Config configures the Prometheus remote-write push.
+type Config struct {
+	// URL is the remote-write receiver endpoint
+	// (e.g., "http://prometheus:9090/api/v1/write").
+	URL string `json:"url"`
+
+	// Headers are the extra HTTP request headers (e.g., authorization).
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// PushInterval is the interval to gather the registry and push the samples.
+	PushInterval metav1.Duration `json:"push_interval,omitempty"`
+
+	// MaxSamplesPerPush is the maximum number of samples per remote-write request.
+	MaxSamplesPerPush int `json:"max_samples_per_push,omitempty"`
+
+	// MaxBufferedSamples is the maximum number of samples buffered
+	// in the state file while the endpoint is down, evicting the oldest ones.
+	MaxBufferedSamples int `json:"max_buffered_samples,omitempty"`
+}
+
+// SetDefaults sets the default values for the unset fields.
+func (cfg *Config) SetDefaults() {
+	if cfg.PushInterval.Duration == 0 {
+		cfg.PushInterval = metav1.Duration{Duration: DefaultPushInterval}
+	}
+	if cfg.MaxSamplesPerPush == 0 {
+		cfg.MaxSamplesPerPush = DefaultMaxSamplesPerPush
+	}
+	if cfg.MaxBufferedSamples == 0 {
+		cfg.MaxBufferedSamples = DefaultMaxBufferedSamples
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.URL == "" {
+		return errors.New("url is required")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %w", cfg.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url %q: scheme must be http or https", cfg.URL)
+	}
+	if cfg.PushInterval.Duration < 0 {
+		return errors.New("push_interval must be non-negative")
+	}
+	if cfg.MaxSamplesPerPush < 0 {
+		return errors.New("max_samples_per_push must be non-negative")
+	}
+	if cfg.MaxBufferedSamples < 0 {
+		return errors.New("max_buffered_samples must be non-negative")
+	}
+	return nil
+}
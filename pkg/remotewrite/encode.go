@@ -0,0 +1,209 @@
+package remotewrite
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const labelMetricName = "__name__"
+
+// convertMetricFamilies flattens the gathered Prometheus metric families
+// into the samples, with the same series names as the "/metrics" exposition
+// (e.g., "_bucket", "_sum", and "_count" of the histograms).
+// The samples without the timestamp are timestamped at "nowMs".
+func convertMetricFamilies(mfs []*dto.MetricFamily, nowMs int64) ([]sample, error) {
+	var samples []sample
+	add := func(name string, pm *dto.Metric, v float64, extra ...string) error {
+		labels := make(map[string]string, len(pm.GetLabel())+2)
+		for _, lp := range pm.GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			labels[extra[i]] = extra[i+1]
+		}
+		labels[labelMetricName] = name
+
+		// the object keys are sorted
+		b, err := json.Marshal(labels)
+		if err != nil {
+			return err
+		}
+		ts := nowMs
+		if pm.TimestampMs != nil {
+			ts = pm.GetTimestampMs()
+		}
+		samples = append(samples, sample{labels: string(b), value: v, timestampMs: ts})
+		return nil
+	}
+
+	for _, mf := range mfs {
+		name := mf.GetName()
+		for _, pm := range mf.GetMetric() {
+			var err error
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				err = add(name, pm, pm.GetCounter().GetValue())
+
+			case dto.MetricType_GAUGE:
+				err = add(name, pm, pm.GetGauge().GetValue())
+
+			case dto.MetricType_UNTYPED:
+				err = add(name, pm, pm.GetUntyped().GetValue())
+
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				h := pm.GetHistogram()
+				infSeen := false
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), 1) {
+						infSeen = true
+					}
+					if err = add(name+"_bucket", pm, float64(b.GetCumulativeCount()), "le", formatFloat(b.GetUpperBound())); err != nil {
+						break
+					}
+				}
+				if err == nil && !infSeen {
+					err = add(name+"_bucket", pm, float64(h.GetSampleCount()), "le", "+Inf")
+				}
+				if err == nil {
+					err = add(name+"_sum", pm, h.GetSampleSum())
+				}
+				if err == nil {
+					err = add(name+"_count", pm, float64(h.GetSampleCount()))
+				}
+
+			case dto.MetricType_SUMMARY:
+				s := pm.GetSummary()
+				for _, q := range s.GetQuantile() {
+					if err = add(name, pm, q.GetValue(), "quantile", formatFloat(q.GetQuantile())); err != nil {
+						break
+					}
+				}
+				if err == nil {
+					err = add(name+"_sum", pm, s.GetSampleSum())
+				}
+				if err == nil {
+					err = add(name+"_count", pm, float64(s.GetSampleCount()))
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert %q: %w", name, err)
+			}
+		}
+	}
+	return samples, nil
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	if math.IsInf(f, -1) {
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+// groupSeries groups the samples by the series labels, in the order of the first sample
+// of each series, with the external labels added unless the series has the same label.
+func groupSeries(samples []sample, externalLabels map[string]string) ([]timeSeries, error) {
+	var series []timeSeries
+	index := make(map[string]int)
+	for _, s := range samples {
+		i, ok := index[s.labels]
+		if !ok {
+			var labels map[string]string
+			if err := json.Unmarshal([]byte(s.labels), &labels); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal labels %q: %w", s.labels, err)
+			}
+			for k, v := range externalLabels {
+				if _, ok := labels[k]; !ok {
+					labels[k] = v
+				}
+			}
+
+			ts := timeSeries{labels: make([]label, 0, len(labels))}
+			for k, v := range labels {
+				ts.labels = append(ts.labels, label{name: k, value: v})
+			}
+			// the receivers require the labels sorted by name
+			sort.Slice(ts.labels, func(i, j int) bool { return ts.labels[i].name < ts.labels[j].name })
+
+			i = len(series)
+			index[s.labels] = i
+			series = append(series, ts)
+		}
+		series[i].samples = append(series[i].samples, s)
+	}
+
+	// the receivers require the samples of a series in the time order
+	for i := range series {
+		sort.SliceStable(series[i].samples, func(a, b int) bool {
+			return series[i].samples[a].timestampMs < series[i].samples[b].timestampMs
+		})
+	}
+	return series, nil
+}
+
+// Field numbers of the remote-write protobuf messages (prometheus/prompb).
+const (
+	fieldWriteRequestTimeseries = 1
+
+	fieldTimeSeriesLabels  = 1
+	fieldTimeSeriesSamples = 2
+
+	fieldLabelName  = 1
+	fieldLabelValue = 2
+
+	fieldSampleValue     = 1
+	fieldSampleTimestamp = 2
+)
+
+// encodeWriteRequest encodes the series into the snappy-compressed
+// remote-write "WriteRequest" protobuf.
+func encodeWriteRequest(series []timeSeries) []byte {
+	var b []byte
+	for _, ts := range series {
+		var tsb []byte
+		for _, l := range ts.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, fieldLabelName, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, fieldLabelValue, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+
+			tsb = protowire.AppendTag(tsb, fieldTimeSeriesLabels, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, lb)
+		}
+		for _, s := range ts.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, fieldSampleValue, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(s.value))
+			sb = protowire.AppendTag(sb, fieldSampleTimestamp, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(s.timestampMs))
+
+			tsb = protowire.AppendTag(tsb, fieldTimeSeriesSamples, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, sb)
+		}
+
+		b = protowire.AppendTag(b, fieldWriteRequestTimeseries, protowire.BytesType)
+		b = protowire.AppendBytes(b, tsb)
+	}
+	return snappy.Encode(nil, b)
+}
@@ -0,0 +1,45 @@
+package remotewrite
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	samplesSentTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gpud",
+			Subsystem: "remote_write",
+			Name:      "samples_sent_total",
+			Help:      "total number of samples accepted by the remote-write endpoint",
+		},
+	)
+	samplesDroppedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "gpud",
+			Subsystem: "remote_write",
+			Name:      "samples_dropped_total",
+			Help:      "total number of samples dropped by the buffer limit or rejected by the remote-write endpoint",
+		},
+	)
+	samplesPending = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "gpud",
+			Subsystem: "remote_write",
+			Name:      "samples_pending",
+			Help:      "current number of samples buffered to push",
+		},
+	)
+)
+
+func Register(reg *prometheus.Registry) error {
+	if err := reg.Register(samplesSentTotal); err != nil {
+		return err
+	}
+	if err := reg.Register(samplesDroppedTotal); err != nil {
+		return err
+	}
+	if err := reg.Register(samplesPending); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package remotewrite pushes the Prometheus registry metrics with the
+// Prometheus remote-write protocol, for the nodes that cannot be scraped
+// (e.g., behind NAT). The samples are buffered in the state file
+// while the endpoint is down, and replayed with the original timestamps.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/version"
+)
+
+// LabelMachineID is the external label of the machine ID.
+const LabelMachineID = "machine_id"
+
+// Writer gathers the registry and pushes the samples to the remote-write endpoint.
+type Writer struct {
+	cfg      Config
+	gatherer prometheus.Gatherer
+	dbRW     *sql.DB
+	dbRO     *sql.DB
+	cli      *http.Client
+
+	externalLabels map[string]string
+
+	// for testing
+	timeNow func() time.Time
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// sanitizeLabelName converts the annotation key to the valid label name
+// (e.g., "cluster-name" to "cluster_name").
+func sanitizeLabelName(name string) string {
+	name = invalidLabelChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// New creates the writer that gathers the metrics from the gatherer
+// and buffers the samples in the state database.
+// The annotations and the machine ID are attached as the external labels.
+// The configuration defaults are set for the unset fields.
+func New(ctx context.Context, cfg Config, gatherer prometheus.Gatherer, dbRW *sql.DB, dbRO *sql.DB, annotations map[string]string, machineID string) (*Writer, error) {
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := CreateTableSamples(ctx, dbRW); err != nil {
+		return nil, fmt.Errorf("failed to create remote write samples table: %w", err)
+	}
+
+	externalLabels := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		if name := sanitizeLabelName(k); name != "" {
+			externalLabels[name] = v
+		}
+	}
+	if machineID != "" {
+		externalLabels[LabelMachineID] = machineID
+	}
+
+	return &Writer{
+		cfg:            cfg,
+		gatherer:       gatherer,
+		dbRW:           dbRW,
+		dbRO:           dbRO,
+		cli:            &http.Client{Timeout: 30 * time.Second},
+		externalLabels: externalLabels,
+		timeNow:        time.Now,
+	}, nil
+}
+
+// Run pushes the samples every push interval, until the context is canceled.
+func (w *Writer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PushInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Push(ctx); err != nil {
+				log.Logger.Warnw("failed to push metrics", "url", w.cfg.URL, "error", err)
+			}
+		}
+	}
+}
+
+// Push gathers and buffers the samples, and then pushes the buffered samples
+// oldest first, until the buffer is empty or the endpoint fails.
+// The samples stay buffered for the next push if the endpoint is down.
+func (w *Writer) Push(ctx context.Context) error {
+	mfs, err := w.gatherer.Gather()
+	if err != nil {
+		// partial results are still pushed
+		log.Logger.Warnw("failed to gather some metrics", "error", err)
+	}
+	samples, err := convertMetricFamilies(mfs, w.timeNow().UnixMilli())
+	if err != nil {
+		return err
+	}
+	if err := bufferSamples(ctx, w.dbRW, samples); err != nil {
+		return fmt.Errorf("failed to buffer samples: %w", err)
+	}
+
+	evicted, err := trimSamples(ctx, w.dbRW, w.cfg.MaxBufferedSamples)
+	if err != nil {
+		return fmt.Errorf("failed to trim buffered samples: %w", err)
+	}
+	if evicted > 0 {
+		log.Logger.Warnw("evicted the oldest buffered samples", "evicted", evicted, "maxBufferedSamples", w.cfg.MaxBufferedSamples)
+		samplesDroppedTotal.Add(float64(evicted))
+	}
+
+	defer func() {
+		if pending, err := countSamples(ctx, w.dbRO); err == nil {
+			samplesPending.Set(float64(pending))
+		}
+	}()
+
+	for {
+		batch, err := readSamples(ctx, w.dbRO, w.cfg.MaxSamplesPerPush)
+		if err != nil {
+			return fmt.Errorf("failed to read buffered samples: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err = w.write(ctx, batch)
+		var rejected *rejectedError
+		if err != nil && !errors.As(err, &rejected) {
+			// retried on the next push
+			return err
+		}
+
+		if _, derr := deleteSamples(ctx, w.dbRW, batch[len(batch)-1].id); derr != nil {
+			return fmt.Errorf("failed to delete pushed samples: %w", derr)
+		}
+		if err != nil {
+			// the receiver never accepts the malformed or the out-of-order samples,
+			// so they are dropped not to block the later ones
+			samplesDroppedTotal.Add(float64(len(batch)))
+			return fmt.Errorf("dropped %d samples: %w", len(batch), err)
+		}
+		samplesSentTotal.Add(float64(len(batch)))
+	}
+}
+
+// rejectedError is the non-retryable rejection of the samples.
+type rejectedError struct {
+	status int
+	body   string
+}
+
+func (e *rejectedError) Error() string {
+	return fmt.Sprintf("rejected with status %d: %s", e.status, e.body)
+}
+
+func (w *Writer) write(ctx context.Context, batch []sample) error {
+	series, err := groupSeries(batch, w.externalLabels)
+	if err != nil {
+		return &rejectedError{body: err.Error()}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(encodeWriteRequest(series)))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "gpud/"+version.Version)
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.cli.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post samples: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	// the rate limited and the server errors are retried
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return &rejectedError{status: resp.StatusCode, body: string(body)}
+	}
+	return fmt.Errorf("samples not accepted with status %d: %s", resp.StatusCode, string(body))
+}
@@ -0,0 +1,284 @@
+package remotewrite
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/leptonai/gpud/pkg/sqlite"
+)
+
+// receiver is the httptest stand-in for the remote-write endpoint,
+// which fails with the status while set.
+type receiver struct {
+	mu     sync.Mutex
+	status int
+	series []timeSeries
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+		return
+	}
+	if req.Header.Get("Content-Encoding") != "snappy" || req.Header.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	compressed, _ := io.ReadAll(req.Body)
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	series, err := decodeWriteRequest(b)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.series = append(r.series, series...)
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// samplesOf returns the received samples of the metric name in the received order.
+func (r *receiver) samplesOf(name string) ([]sample, []label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var samples []sample
+	var labels []label
+	for _, ts := range r.series {
+		for _, l := range ts.labels {
+			if l.name == labelMetricName && l.value == name {
+				samples = append(samples, ts.samples...)
+				labels = ts.labels
+			}
+		}
+	}
+	return samples, labels
+}
+
+func decodeWriteRequest(b []byte) ([]timeSeries, error) {
+	var series []timeSeries
+	err := consumeMessage(b, func(num protowire.Number, v []byte) error {
+		var ts timeSeries
+		err := consumeMessage(v, func(num protowire.Number, v []byte) error {
+			switch num {
+			case fieldTimeSeriesLabels:
+				var l label
+				err := consumeMessage(v, func(num protowire.Number, v []byte) error {
+					if num == fieldLabelName {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+					return nil
+				})
+				ts.labels = append(ts.labels, l)
+				return err
+			case fieldTimeSeriesSamples:
+				var s sample
+				for len(v) > 0 {
+					num, typ, n := protowire.ConsumeTag(v)
+					if n < 0 {
+						return protowire.ParseError(n)
+					}
+					v = v[n:]
+					switch {
+					case num == fieldSampleValue && typ == protowire.Fixed64Type:
+						bits, n := protowire.ConsumeFixed64(v)
+						s.value = math.Float64frombits(bits)
+						v = v[n:]
+					case num == fieldSampleTimestamp && typ == protowire.VarintType:
+						ts, n := protowire.ConsumeVarint(v)
+						s.timestampMs = int64(ts)
+						v = v[n:]
+					default:
+						return protowire.ParseError(-1)
+					}
+				}
+				ts.samples = append(ts.samples, s)
+			}
+			return nil
+		})
+		series = append(series, ts)
+		return err
+	})
+	return series, err
+}
+
+// consumeMessage calls the function with each length-delimited field of the message.
+func consumeMessage(b []byte, fn func(num protowire.Number, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			return protowire.ParseError(-1)
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "valid", cfg: Config{URL: "http://localhost:9090/api/v1/write"}},
+		{name: "no url", cfg: Config{}, wantErr: true},
+		{name: "invalid scheme", cfg: Config{URL: "ftp://localhost"}, wantErr: true},
+		{name: "negative max samples per push", cfg: Config{URL: "http://localhost", MaxSamplesPerPush: -1}, wantErr: true},
+		{name: "negative max buffered samples", cfg: Config{URL: "http://localhost", MaxBufferedSamples: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPushBuffersWhileDown(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gpu_temp", Help: "test"}, []string{"gpu"})
+	reg.MustRegister(gauge)
+	hist := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Help: "test", Buckets: []float64{1}})
+	reg.MustRegister(hist)
+	hist.Observe(0.5)
+
+	w, err := New(ctx, Config{URL: srv.URL, MaxSamplesPerPush: 2}, reg, dbRW, dbRO, map[string]string{"cluster-name": "c1", "machine_id": "ignored"}, "m1")
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	w.timeNow = func() time.Time { return now }
+
+	// buffered while down
+	rcv.setStatus(http.StatusServiceUnavailable)
+	for i := 0; i < 3; i++ {
+		gauge.WithLabelValues("0").Set(float64(40 + i))
+		require.Error(t, w.Push(ctx))
+		now = now.Add(time.Minute)
+	}
+	pending, err := countSamples(ctx, dbRO)
+	require.NoError(t, err)
+	// gauge + 2 buckets + sum + count per push
+	require.Equal(t, 3*5, pending)
+
+	// replayed in order with the original timestamps
+	rcv.setStatus(0)
+	gauge.WithLabelValues("0").Set(43)
+	require.NoError(t, w.Push(ctx))
+
+	pending, err = countSamples(ctx, dbRO)
+	require.NoError(t, err)
+	require.Zero(t, pending)
+
+	samples, labels := rcv.samplesOf("gpu_temp")
+	require.Len(t, samples, 4)
+	for i, s := range samples {
+		require.Equal(t, float64(40+i), s.value)
+		require.Equal(t, time.Unix(1700000000, 0).Add(time.Duration(i)*time.Minute).UnixMilli(), s.timestampMs)
+	}
+	require.Equal(t, []label{
+		{name: labelMetricName, value: "gpu_temp"},
+		{name: "cluster_name", value: "c1"},
+		{name: "gpu", value: "0"},
+		{name: LabelMachineID, value: "m1"},
+	}, labels)
+
+	buckets, _ := rcv.samplesOf("latency_seconds_bucket")
+	require.Len(t, buckets, 8)
+}
+
+func TestPushDropsRejected(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dbRW, dbRO, cleanup := sqlite.OpenTestDB(t)
+	defer cleanup()
+
+	rcv := &receiver{status: http.StatusBadRequest}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "requests_total", Help: "test"})
+	reg.MustRegister(counter)
+
+	w, err := New(ctx, Config{URL: srv.URL, MaxBufferedSamples: 2}, reg, dbRW, dbRO, nil, "")
+	require.NoError(t, err)
+
+	// not retried since the receiver never accepts them
+	require.Error(t, w.Push(ctx))
+	pending, err := countSamples(ctx, dbRO)
+	require.NoError(t, err)
+	require.Zero(t, pending)
+
+	// buffer capped while down
+	rcv.setStatus(http.StatusInternalServerError)
+	for i := 0; i < 3; i++ {
+		require.Error(t, w.Push(ctx))
+	}
+	pending, err = countSamples(ctx, dbRO)
+	require.NoError(t, err)
+	require.Equal(t, 2, pending)
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	for in, want := range map[string]string{
+		"cluster":        "cluster",
+		"cluster-name":   "cluster_name",
+		"lepton.ai/zone": "lepton_ai_zone",
+		"1st":            "_1st",
+	} {
+		if got := sanitizeLabelName(in); got != want {
+			t.Fatalf("sanitizeLabelName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
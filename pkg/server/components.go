@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/leptonai/gpud/components"
+	os_id "github.com/leptonai/gpud/components/os/id"
+	"github.com/leptonai/gpud/components/registry"
+	lepconfig "github.com/leptonai/gpud/pkg/config"
+	metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
+	gpud_state "github.com/leptonai/gpud/pkg/gpud-state"
+	"github.com/leptonai/gpud/pkg/log"
+	nvidia_query_catalog "github.com/leptonai/gpud/pkg/nvidia-query/catalog"
+)
+
+// componentConfigs returns the raw component configurations to run
+// keyed by the component name, including the components that are always enabled.
+// The configuration of the factory that creates multiple components
+// is expanded into one configuration per component (e.g., "custom-check-<name>").
+func componentConfigs(cfg *lepconfig.Config) (map[string]any, error) {
+	cfgs := make(map[string]any, len(cfg.Components)+1)
+	for k, v := range cfg.Components {
+		expanded, err := registry.Expand(k, v)
+		if err != nil {
+			return nil, err
+		}
+		for name, c := range expanded {
+			cfgs[name] = c
+		}
+	}
+	if _, ok := cfgs[os_id.Name]; !ok {
+		cfgs[os_id.Name] = nil
+	}
+	return cfgs, nil
+}
+
+// diffComponents returns the sorted component names to start, stop, and re-create,
+// in order to apply the new component configurations.
+func diffComponents(prev map[string]any, next map[string]any) (added []string, removed []string, updated []string) {
+	for name, cfg := range next {
+		prevCfg, ok := prev[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+		if !reflect.DeepEqual(prevCfg, cfg) {
+			updated = append(updated, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(updated)
+	return added, removed, updated
+}
+
+// startComponent creates the component from the raw configuration value,
+// registers the component and its prometheus collectors, and starts the component.
+// The caller must hold "componentsMu".
+func (s *Server) startComponent(ctx context.Context, name string, cfg any) error {
+	c, err := registry.Create(ctx, name, cfg, s.instance)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		log.Logger.Debugw("component has nothing to run -- skipping", "component", name)
+		return nil
+	}
+
+	metrics.SetRegistered(c.Name())
+	c = metrics.NewWatchableComponent(c, s.statesObservers...)
+	if strings.Contains(c.Name(), "nvidia") {
+		s.nvidiaComponentsExist = true
+	}
+
+	// this guarantees no name conflict, thus safe to register handlers by its name
+	if err := components.RegisterComponent(c.Name(), c); err != nil {
+		_ = c.Close()
+		return err
+	}
+
+	if orig, ok := c.(interface{ Unwrap() interface{} }); ok {
+		if prov, ok := orig.Unwrap().(components.PromRegisterer); ok {
+			log.Logger.Debugw("registering prometheus collectors", "component", c.Name())
+			err := prov.RegisterCollectors(s.promReg, s.instance.MetricsStore)
+
+			// the collectors are package-level, thus already registered
+			// if the component is re-created by the configuration reload
+			var are prometheus.AlreadyRegisteredError
+			if err != nil && !errors.As(err, &are) {
+				s.stopComponent(c.Name())
+				return fmt.Errorf("failed to register metrics for component %s: %w", c.Name(), err)
+			}
+		} else {
+			log.Logger.Debugw("component does not implement components.PromRegisterer", "component", c.Name())
+		}
+	} else {
+		log.Logger.Debugw("component does not implement interface{ Unwrap() interface{} }", "component", c.Name())
+	}
+
+	if err := c.Start(); err != nil {
+		log.Logger.Errorw("failed to start component", "name", c.Name(), "error", err)
+		s.stopComponent(c.Name())
+		return fmt.Errorf("failed to start component %s: %w", c.Name(), err)
+	}
+	return nil
+}
+
+// stopComponent deregisters and closes the component, if running.
+// The caller must hold "componentsMu".
+func (s *Server) stopComponent(name string) {
+	c, err := components.DeregisterComponent(name)
+	if err != nil {
+		log.Logger.Debugw("component not running -- skipping", "component", name, "error", err)
+		return
+	}
+	metrics.SetUnregistered(name)
+
+	if err := c.Close(); err != nil {
+		log.Logger.Warnw("failed to close component", "component", name, "error", err)
+	}
+}
+
+// ReloadConfig applies the component configurations without restarting the server.
+// It starts the newly enabled components, closes the removed components,
+// and re-creates the components whose configuration changed.
+// The HTTP server and the state database are not affected.
+// Other configuration changes (e.g., address) require a restart.
+func (s *Server) ReloadConfig(ctx context.Context, config *lepconfig.Config) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	s.componentsMu.Lock()
+	defer s.componentsMu.Unlock()
+
+	next, err := componentConfigs(config)
+	if err != nil {
+		return err
+	}
+	added, removed, updated := diffComponents(s.componentConfigs, next)
+
+	// applied once the component configurations are valid,
+	// and the running components look up the details on the next XID/SXID
+	if err := nvidia_query_catalog.ApplyFile(config.XIDCatalogFile); err != nil {
+		return fmt.Errorf("failed to apply xid catalog file: %w", err)
+	}
+
+	if len(added) == 0 && len(removed) == 0 && len(updated) == 0 {
+		log.Logger.Infow("no component configuration change")
+		return nil
+	}
+	log.Logger.Infow("reloading components", "added", added, "removed", removed, "updated", updated)
+
+	for _, name := range append(removed, updated...) {
+		s.stopComponent(name)
+		delete(s.componentConfigs, name)
+	}
+
+	var errs []error
+	for _, name := range append(added, updated...) {
+		if err := s.startComponent(ctx, name, next[name]); err != nil {
+			// not recorded, so that the next reload retries
+			errs = append(errs, err)
+			continue
+		}
+		s.componentConfigs[name] = next[name]
+	}
+
+	componentNames := runningComponentNames()
+	if s.ghler != nil {
+		s.ghler.setComponentNames(componentNames)
+	}
+	if err := gpud_state.UpdateComponents(ctx, s.dbRW, s.uid, strings.Join(componentNames, ",")); err != nil {
+		errs = append(errs, fmt.Errorf("failed to update components: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// defaultStatesPollInterval is the interval to read the component states,
+// so that the state health transitions are published to the watchers
+// even if no one queries the states.
+const defaultStatesPollInterval = 10 * time.Second
+
+// pollStates periodically reads the states of all the running components
+// through the watchable components, which observe the health transitions.
+func (s *Server) pollStates(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for name, c := range components.GetAllComponents() {
+			if _, err := c.States(ctx); err != nil {
+				log.Logger.Debugw("failed to read component states", "component", name, "error", err)
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func runningComponentNames() []string {
+	all := components.GetAllComponents()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
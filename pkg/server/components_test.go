@@ -0,0 +1,72 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	custom_check_id "github.com/leptonai/gpud/components/custom-check/id"
+	os_id "github.com/leptonai/gpud/components/os/id"
+	"github.com/leptonai/gpud/pkg/config"
+)
+
+func TestComponentConfigs(t *testing.T) {
+	cfgs, err := componentConfigs(&config.Config{Components: map[string]any{"cpu": nil}})
+	require.NoError(t, err)
+	require.Len(t, cfgs, 2)
+	require.Contains(t, cfgs, "cpu")
+	require.Contains(t, cfgs, os_id.Name)
+
+	osCfg := map[string]any{"a": "b"}
+	cfgs, err = componentConfigs(&config.Config{Components: map[string]any{os_id.Name: osCfg}})
+	require.NoError(t, err)
+	require.Len(t, cfgs, 1)
+	require.Equal(t, osCfg, cfgs[os_id.Name])
+
+	// one component per custom check
+	cfgs, err = componentConfigs(&config.Config{Components: map[string]any{custom_check_id.Name: []any{
+		map[string]any{"name": "a", "script": "true"},
+		map[string]any{"name": "b", "script": "true"},
+	}}})
+	require.NoError(t, err)
+	require.Len(t, cfgs, 3)
+	require.Contains(t, cfgs, "custom-check-a")
+	require.Contains(t, cfgs, "custom-check-b")
+	require.NotContains(t, cfgs, custom_check_id.Name)
+
+	_, err = componentConfigs(&config.Config{Components: map[string]any{custom_check_id.Name: []any{
+		map[string]any{"name": "a"},
+	}}})
+	require.Error(t, err)
+}
+
+func TestDiffComponents(t *testing.T) {
+	prev := map[string]any{
+		"cpu":     nil,
+		"memory":  nil,
+		"systemd": map[string]any{"units": []any{"sshd"}},
+		"disk":    map[string]any{"mount_points": []any{"/"}},
+	}
+	next := map[string]any{
+		"cpu":     nil,
+		"systemd": map[string]any{"units": []any{"sshd", "docker"}},
+		"disk":    map[string]any{"mount_points": []any{"/"}},
+		"fd":      nil,
+		"fuse":    nil,
+	}
+
+	added, removed, updated := diffComponents(prev, next)
+	require.Equal(t, []string{"fd", "fuse"}, added)
+	require.Equal(t, []string{"memory"}, removed)
+	require.Equal(t, []string{"systemd"}, updated)
+
+	added, removed, updated = diffComponents(next, next)
+	require.Empty(t, added)
+	require.Empty(t, removed)
+	require.Empty(t, updated)
+
+	added, removed, updated = diffComponents(nil, map[string]any{"cpu": nil})
+	require.Equal(t, []string{"cpu"}, added)
+	require.Empty(t, removed)
+	require.Empty(t, updated)
+}
@@ -13,35 +13,53 @@ import (
 	"time"
 
 	lep_components "github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
 	lep_config "github.com/leptonai/gpud/pkg/config"
+	"github.com/leptonai/gpud/pkg/eventstore"
 	gpud_manager "github.com/leptonai/gpud/pkg/gpud-manager"
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 
 	"github.com/gin-gonic/gin"
 	"sigs.k8s.io/yaml"
 )
 
 type globalHandler struct {
-	cfg        *lep_config.Config
-	components map[string]lep_components.Component
+	cfg *lep_config.Config
+
+	// bucket of the recorded state health transitions
+	historyBucket eventstore.Bucket
+	// store of the component metrics to aggregate
+	metricsStore metrics_state.Store
 
 	componentNamesMu sync.RWMutex
 	componentNames   []string
 }
 
-func newGlobalHandler(cfg *lep_config.Config, components map[string]lep_components.Component) *globalHandler {
-	var componentNames []string
-	for name := range components {
-		componentNames = append(componentNames, name)
-	}
-	sort.Strings(componentNames)
+func newGlobalHandler(cfg *lep_config.Config, componentNames []string, historyBucket eventstore.Bucket, metricsStore metrics_state.Store) *globalHandler {
+	names := make([]string, len(componentNames))
+	copy(names, componentNames)
+	sort.Strings(names)
 
 	return &globalHandler{
 		cfg:            cfg,
-		components:     components,
-		componentNames: componentNames,
+		historyBucket:  historyBucket,
+		metricsStore:   metricsStore,
+		componentNames: names,
 	}
 }
 
+// setComponentNames updates the running component names
+// (e.g., after the configuration reload).
+func (g *globalHandler) setComponentNames(componentNames []string) {
+	names := make([]string, len(componentNames))
+	copy(names, componentNames)
+	sort.Strings(names)
+
+	g.componentNamesMu.Lock()
+	g.componentNames = names
+	g.componentNamesMu.Unlock()
+}
+
 func (g *globalHandler) getReqTime(c *gin.Context) (time.Time, time.Time, error) {
 	startTime := time.Now()
 	endTime := time.Now()
@@ -64,6 +82,48 @@ func (g *globalHandler) getReqTime(c *gin.Context) (time.Time, time.Time, error)
 	return startTime, endTime, nil
 }
 
+// getReqEventQuery parses the event filters and the pagination
+// from the request query parameters, in addition to the time range.
+func (g *globalHandler) getReqEventQuery(c *gin.Context, startTime time.Time, endTime time.Time) (eventstore.Query, error) {
+	q := eventstore.Query{
+		Since:           startTime,
+		MessageContains: c.Query("message"),
+		Cursor:          c.Query("cursor"),
+	}
+	// only bounded if explicitly requested, to include the events inserted during the request
+	if c.Query("endTime") != "" {
+		q.Until = endTime
+	}
+
+	if s := c.Query("eventTypes"); s != "" {
+		for _, t := range strings.Split(s, ",") {
+			q.Types = append(q.Types, common.EventType(t))
+		}
+	}
+	if s := c.Query("names"); s != "" {
+		q.Names = strings.Split(s, ",")
+	}
+	for _, kv := range c.QueryArray("extraInfo") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return eventstore.Query{}, fmt.Errorf("invalid extraInfo %q (expected key=value)", kv)
+		}
+		if q.ExtraInfo == nil {
+			q.ExtraInfo = make(map[string]string)
+		}
+		q.ExtraInfo[k] = v
+	}
+
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return eventstore.Query{}, fmt.Errorf("invalid limit %q", s)
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
+
 func (g *globalHandler) getReqComponents(c *gin.Context) ([]string, error) {
 	components := c.Query("components")
 	if components == "" {
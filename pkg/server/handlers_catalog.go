@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+
+	"github.com/leptonai/gpud/pkg/errdefs"
+	nvidia_query_catalog "github.com/leptonai/gpud/pkg/nvidia-query/catalog"
+)
+
+const (
+	URLPathXIDCatalog     = "/catalog/xid"
+	URLPathXIDCatalogDesc = "Get the NVIDIA XID and SXID catalogs in effect, with the overlay file applied"
+)
+
+// getXIDCatalog godoc
+// @Summary Fetch the NVIDIA XID and SXID catalogs in gpud
+// @Description get the XID and SXID details (severity, suggested actions) that gpud uses, with the configured overlay file applied
+// @ID getXIDCatalog
+// @Produce  json
+// @Success 200 {object} catalog.Catalog
+// @Router /v1/catalog/xid [get]
+func (g *globalHandler) getXIDCatalog(c *gin.Context) {
+	ct := nvidia_query_catalog.Get()
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(ct)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal xid catalog " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, ct)
+			return
+		}
+		c.JSON(http.StatusOK, ct)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
@@ -1,14 +1,15 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"net/http"
-	"sort"
 	"time"
 
 	v1 "github.com/leptonai/gpud/api/v1"
 	lep_components "github.com/leptonai/gpud/components"
 	"github.com/leptonai/gpud/pkg/errdefs"
+	"github.com/leptonai/gpud/pkg/eventstore"
 	"github.com/leptonai/gpud/pkg/log"
 	"github.com/leptonai/gpud/pkg/query"
 
@@ -64,6 +65,36 @@ func (g *globalHandler) registerComponentRoutes(r gin.IRoutes) []componentHandle
 		Desc: URLPathMetricsDesc,
 	})
 
+	r.GET(URLPathMetricsAggregate, g.getMetricsAggregate)
+	paths = append(paths, componentHandlerDescription{
+		Path: URLPathMetricsAggregate,
+		Desc: URLPathMetricsAggregateDesc,
+	})
+
+	r.GET(URLPathHistory, g.getHistory)
+	paths = append(paths, componentHandlerDescription{
+		Path: URLPathHistory,
+		Desc: URLPathHistoryDesc,
+	})
+
+	r.GET(URLPathHealth, g.getHealth)
+	paths = append(paths, componentHandlerDescription{
+		Path: URLPathHealth,
+		Desc: URLPathHealthDesc,
+	})
+
+	r.GET(URLPathXIDCatalog, g.getXIDCatalog)
+	paths = append(paths, componentHandlerDescription{
+		Path: URLPathXIDCatalog,
+		Desc: URLPathXIDCatalogDesc,
+	})
+
+	r.GET(URLPathIncidents, g.getIncidents)
+	paths = append(paths, componentHandlerDescription{
+		Path: URLPathIncidents,
+		Desc: URLPathIncidentsDesc,
+	})
+
 	return paths
 }
 
@@ -80,11 +111,9 @@ const (
 // @Success 200 {object} []string
 // @Router /v1/components [get]
 func (g *globalHandler) getComponents(c *gin.Context) {
-	components := make([]string, 0, len(g.components))
-	for name := range g.components {
-		components = append(components, name)
-	}
-	sort.Strings(components)
+	g.componentNamesMu.RLock()
+	components := g.componentNames
+	g.componentNamesMu.RUnlock()
 
 	switch c.GetHeader(RequestHeaderContentType) {
 	case RequestHeaderYAML:
@@ -117,11 +146,13 @@ const (
 // @Description get component States interface by component name
 // @ID getStates
 // @Param   component     query    string     false        "Component Name, leave empty to query all components"
+// @Param   gpu           query    string     false        "GPU UUID (or PCI bus ID, minor number) to return only the per-GPU states of the device"
 // @Produce  json
 // @Success 200 {object} v1.LeptonStates
 // @Router /v1/states [get]
 func (g *globalHandler) getStates(c *gin.Context) {
 	var states v1.LeptonStates
+	gpu := c.Query("gpu")
 	components, err := g.getReqComponents(c)
 	if err != nil {
 		if errdefs.IsNotFound(err) {
@@ -159,6 +190,12 @@ func (g *globalHandler) getStates(c *gin.Context) {
 			log.Logger.Debugw("successfully got states", "component", componentName)
 			currState.States = state
 		}
+		if gpu != "" {
+			currState.States = filterGPUStates(currState.States, gpu)
+			if len(currState.States) == 0 {
+				continue
+			}
+		}
 		states = append(states, currState)
 	}
 
@@ -183,6 +220,18 @@ func (g *globalHandler) getStates(c *gin.Context) {
 	}
 }
 
+// filterGPUStates returns only the per-GPU states of the device,
+// dropping the roll-up and the other states.
+func filterGPUStates(states []lep_components.State, gpu string) []lep_components.State {
+	var filtered []lep_components.State
+	for _, s := range states {
+		if lep_components.StateMatchesGPU(s, gpu) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
 const (
 	URLPathEvents     = "/events"
 	URLPathEventsDesc = "Get the events of all gpud components"
@@ -193,6 +242,14 @@ const (
 // @Description get component Events interface by component name
 // @ID getEvents
 // @Param   component     query    string     false        "Component Name, leave empty to query all components"
+// @Param   startTime     query    int        false        "Exclusive lower bound of the event time in unix seconds"
+// @Param   endTime       query    int        false        "Inclusive upper bound of the event time in unix seconds"
+// @Param   eventTypes    query    string     false        "Comma-separated event types to match any of"
+// @Param   names         query    string     false        "Comma-separated event names to match any of"
+// @Param   extraInfo     query    []string   false        "Extra info key=value pairs to match all of"
+// @Param   message       query    string     false        "Substring to match the event message"
+// @Param   limit         query    int        false        "Maximum number of events per component"
+// @Param   cursor        query    string     false        "Next cursor of the previous page"
 // @Produce  json
 // @Success 200 {object} v1.LeptonEvents
 // @Router /v1/events [get]
@@ -213,6 +270,11 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse time: " + err.Error()})
 		return
 	}
+	q, err := g.getReqEventQuery(c, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse query: " + err.Error()})
+		return
+	}
 	for _, componentName := range components {
 		currEvent := v1.LeptonComponentEvents{
 			Component: componentName,
@@ -229,12 +291,16 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 			events = append(events, currEvent)
 			continue
 		}
-		event, err := component.Events(c, startTime)
+		page, err := queryComponentEvents(c, component, q)
 		if err != nil {
 			if errors.Is(err, query.ErrNoData) {
 				log.Logger.Debugw("no event found", "component", componentName)
 				continue
 			}
+			if errors.Is(err, eventstore.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid cursor for component " + componentName})
+				return
+			}
 
 			log.Logger.Errorw("failed to invoke component events",
 				"operation", "GetEvents",
@@ -242,7 +308,8 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 				"error", err,
 			)
 		} else {
-			currEvent.Events = event
+			currEvent.Events = page.Events
+			currEvent.NextCursor = page.NextCursor
 		}
 		events = append(events, currEvent)
 	}
@@ -268,6 +335,24 @@ func (g *globalHandler) getEvents(c *gin.Context) {
 	}
 }
 
+// queryComponentEvents queries the events from the component bucket if supported,
+// otherwise filters the events from "since" in memory.
+func queryComponentEvents(ctx context.Context, component lep_components.Component, q eventstore.Query) (eventstore.Page, error) {
+	var orig any = component
+	if w, ok := component.(interface{ Unwrap() interface{} }); ok {
+		orig = w.Unwrap()
+	}
+	if querier, ok := orig.(eventstore.Querier); ok {
+		return querier.QueryEvents(ctx, q)
+	}
+
+	evs, err := component.Events(ctx, q.Since)
+	if err != nil {
+		return eventstore.Page{}, err
+	}
+	return eventstore.FilterEvents(evs, q)
+}
+
 const DefaultQuerySince = 30 * time.Minute
 
 const (
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	lep_components "github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/errdefs"
+	"github.com/leptonai/gpud/pkg/health"
+	"github.com/leptonai/gpud/pkg/log"
+)
+
+const (
+	URLPathHealth     = "/health"
+	URLPathHealthDesc = "Get the node health verdict aggregated from all gpud components"
+)
+
+// getHealth godoc
+// @Summary Query the node health verdict in gpud
+// @Description get the node-level health verdict (Healthy, Degraded, Unhealthy) aggregated from the states of all components, with the contributing components and the merged suggested actions
+// @ID getHealth
+// @Produce  json
+// @Success 200 {object} v1.NodeHealth
+// @Router /v1/health [get]
+func (g *globalHandler) getHealth(c *gin.Context) {
+	g.componentNamesMu.RLock()
+	components := g.componentNames
+	g.componentNamesMu.RUnlock()
+
+	states := make(v1.LeptonStates, 0, len(components))
+	for _, componentName := range components {
+		component, err := lep_components.GetComponent(componentName)
+		if err != nil {
+			log.Logger.Errorw("failed to get component", "operation", "GetHealth", "component", componentName, "error", err)
+			continue
+		}
+		ss, err := component.States(c)
+		if err != nil {
+			log.Logger.Errorw("failed to invoke component state", "operation", "GetHealth", "component", componentName, "error", err)
+			continue
+		}
+		states = append(states, v1.LeptonComponentStates{Component: componentName, States: ss})
+	}
+
+	var cfg health.Config
+	if g.cfg != nil && g.cfg.Health != nil {
+		cfg = *g.cfg.Health
+	}
+	nodeHealth := health.Evaluate(cfg, states)
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(nodeHealth)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal health " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, nodeHealth)
+			return
+		}
+		c.JSON(http.StatusOK, nodeHealth)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
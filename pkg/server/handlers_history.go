@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/errdefs"
+	"github.com/leptonai/gpud/pkg/log"
+	"github.com/leptonai/gpud/pkg/watch"
+)
+
+const (
+	URLPathHistory     = "/history"
+	URLPathHistoryDesc = "Get the state health transition history of the gpud components"
+)
+
+// getHistory godoc
+// @Summary Query the component state health transition history in gpud
+// @Description get the recorded state health transitions by component name (latest first), e.g., to compute the MTTR and flap rates
+// @ID getHistory
+// @Param   component     query    string     false        "Comma-separated component names, leave empty to query all components"
+// @Param   startTime     query    string     false        "Unix seconds to query the history since, leave empty to query all retained history"
+// @Produce  json
+// @Success 200 {object} v1.LeptonHistory
+// @Router /v1/history [get]
+func (g *globalHandler) getHistory(c *gin.Context) {
+	if g.historyBucket == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "health history not enabled"})
+		return
+	}
+
+	components := splitQuery(c.Query("component"))
+	if len(components) == 0 {
+		components = splitQuery(c.Query("components"))
+	}
+	if len(components) == 0 {
+		g.componentNamesMu.RLock()
+		components = g.componentNames
+		g.componentNamesMu.RUnlock()
+	}
+
+	var startTime time.Time
+	if s := c.Query("startTime"); s != "" {
+		unix, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse time: " + err.Error()})
+			return
+		}
+		startTime = time.Unix(unix, 0)
+	}
+	endTime := time.Now()
+
+	transitions, err := watch.ReadHistory(c, g.historyBucket, startTime)
+	if err != nil {
+		log.Logger.Errorw("failed to read health history", "operation", "GetHistory", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to read health history " + err.Error()})
+		return
+	}
+
+	history := make(v1.LeptonHistory, 0, len(components))
+	for _, componentName := range components {
+		ts := transitions[componentName]
+		if ts == nil {
+			ts = []v1.HealthTransition{}
+		}
+		history = append(history, v1.LeptonComponentHistory{
+			Component:   componentName,
+			StartTime:   startTime,
+			EndTime:     endTime,
+			Transitions: ts,
+		})
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(history)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal history " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, history)
+			return
+		}
+		c.JSON(http.StatusOK, history)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	nvidia_component_xid "github.com/leptonai/gpud/components/accelerator/nvidia/xid"
+	"github.com/leptonai/gpud/pkg/errdefs"
+	"github.com/leptonai/gpud/pkg/log"
+	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
+)
+
+const (
+	URLPathIncidents     = "/incidents"
+	URLPathIncidentsDesc = "Get the NVIDIA Xid errors correlated with the related SXid, ECC, row remapping and HW slowdown events"
+)
+
+// getIncidents godoc
+// @Summary Query the NVIDIA Xid incidents in gpud
+// @Description get the Xid errors grouped with the related events of the other components (NVSwitch SXid, ECC errors, remapped rows, HW slowdown) within the window, each with the timeline
+// @ID getIncidents
+// @Param   startTime     query    string     false        "Unix seconds to query the Xid errors since, leave empty to query all retained Xid errors"
+// @Param   window        query    string     false        "Duration before and after the Xid errors to correlate the events within (e.g., 5m), defaults to 5m"
+// @Produce  json
+// @Success 200 {object} []v1.Incident
+// @Router /v1/incidents [get]
+func (g *globalHandler) getIncidents(c *gin.Context) {
+	startTime := time.Now().Add(-nvidia_component_xid.DefaultRetentionPeriod)
+	if s := c.Query("startTime"); s != "" {
+		unix, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse time: " + err.Error()})
+			return
+		}
+		startTime = time.Unix(unix, 0)
+	}
+
+	window := nvidia_component_xid.DefaultIncidentWindow
+	if s := c.Query("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid window " + s})
+			return
+		}
+		window = d
+	}
+
+	incidents, err := nvidia_component_xid.Incidents(c, startTime, window, gpuUUIDsByPCIBusID())
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "xid component not enabled"})
+			return
+		}
+		log.Logger.Errorw("failed to correlate xid incidents", "operation", "GetIncidents", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to correlate xid incidents " + err.Error()})
+		return
+	}
+	if incidents == nil {
+		incidents = []v1.Incident{}
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(incidents)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal incidents " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, incidents)
+			return
+		}
+		c.JSON(http.StatusOK, incidents)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
+
+// gpuUUIDsByPCIBusID returns the GPU UUIDs by the PCI bus ID from the last NVML query,
+// or nil if not queried yet (e.g., no NVIDIA GPU).
+func gpuUUIDsByPCIBusID() map[string]string {
+	poller := nvidia_query.GetDefaultPoller()
+	if poller == nil {
+		return nil
+	}
+	last, err := poller.LastSuccess()
+	if err != nil || last == nil {
+		return nil
+	}
+	output, ok := last.Output.(*nvidia_query.Output)
+	if !ok || output.NVML == nil {
+		return nil
+	}
+
+	gpus := make(map[string]string, len(output.NVML.DeviceInfos))
+	for _, dev := range output.NVML.DeviceInfos {
+		if dev.PCIBusID != "" {
+			gpus[dev.PCIBusID] = dev.UUID
+		}
+	}
+	return gpus
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/errdefs"
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+	"github.com/leptonai/gpud/pkg/log"
+)
+
+const (
+	URLPathMetricsAggregate     = "/metrics/aggregate"
+	URLPathMetricsAggregateDesc = "Get the metric aggregated into the fixed-size steps (avg, min, max, p50, p95, p99, rate, delta)"
+)
+
+// DefaultAggregateStep is the step of the metric aggregation if not set.
+const DefaultAggregateStep = time.Minute
+
+// getMetricsAggregate godoc
+// @Summary Query the metric aggregated into the fixed-size steps in gpud
+// @Description get the metric aggregated per step and per secondary name (e.g., GPU UUID, mount point), instead of the raw metrics
+// @ID getMetricsAggregate
+// @Param   name          query    string     true         "Metric name"
+// @Param   secondaryName query    string     false        "Metric secondary name (e.g., GPU UUID), leave empty to aggregate all series"
+// @Param   func          query    string     false        "Aggregate function, one of avg (default), min, max, p50, p95, p99, rate, and delta (only avg, min, and max for the range older than the raw metrics retention)"
+// @Param   startTime     query    string     false        "Unix seconds of the range start (inclusive), defaults to 30 minutes ago"
+// @Param   endTime       query    string     false        "Unix seconds of the range end (exclusive), defaults to now"
+// @Param   step          query    string     false        "Step duration (e.g., 5m), defaults to 1m"
+// @Produce  json
+// @Success 200 {object} v1.MetricsAggregation
+// @Router /v1/metrics/aggregate [get]
+func (g *globalHandler) getMetricsAggregate(c *gin.Context) {
+	if g.metricsStore == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": errdefs.ErrNotFound, "message": "metrics store not enabled"})
+		return
+	}
+
+	now := time.Now().UTC()
+	q := metrics_state.AggregateQuery{
+		MetricName:          c.Query("name"),
+		MetricSecondaryName: c.Query("secondaryName"),
+		Start:               now.Add(-DefaultQuerySince),
+		End:                 now,
+		Step:                DefaultAggregateStep,
+		Func:                metrics_state.AggregateAvg,
+	}
+	if fn := c.Query("func"); fn != "" {
+		q.Func = metrics_state.AggregateFunc(fn)
+	}
+	for _, p := range []struct {
+		key string
+		t   *time.Time
+	}{{"startTime", &q.Start}, {"endTime", &q.End}} {
+		s := c.Query(p.key)
+		if s == "" {
+			continue
+		}
+		unix, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse " + p.key + ": " + err.Error()})
+			return
+		}
+		*p.t = time.Unix(unix, 0).UTC()
+	}
+	if s := c.Query("step"); s != "" {
+		step, err := time.ParseDuration(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse step: " + err.Error()})
+			return
+		}
+		q.Step = step
+	}
+	if err := q.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid aggregation: " + err.Error()})
+		return
+	}
+
+	series, err := g.metricsStore.Aggregate(c, q)
+	if err != nil {
+		if errors.Is(err, metrics_state.ErrUnsupportedRollupAggregate) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid aggregation: " + err.Error()})
+			return
+		}
+		log.Logger.Errorw("failed to aggregate metrics", "operation", "GetMetricsAggregate", "metric", q.MetricName, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to aggregate metrics " + err.Error()})
+		return
+	}
+
+	agg := v1.MetricsAggregation{
+		MetricName:  q.MetricName,
+		Func:        string(q.Func),
+		StartTime:   q.Start,
+		EndTime:     q.End,
+		StepSeconds: int64(q.Step / time.Second),
+		Series:      make([]v1.AggregatedSeries, 0, len(series)),
+	}
+	for _, s := range series {
+		points := make([]v1.AggregatedPoint, 0, len(s.Metrics))
+		for _, m := range s.Metrics {
+			points = append(points, v1.AggregatedPoint{UnixSeconds: m.UnixSeconds, Value: m.Value})
+		}
+		agg.Series = append(agg.Series, v1.AggregatedSeries{
+			MetricSecondaryName: s.MetricSecondaryName,
+			Points:              points,
+		})
+	}
+
+	switch c.GetHeader(RequestHeaderContentType) {
+	case RequestHeaderYAML:
+		yb, err := yaml.Marshal(agg)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "failed to marshal metrics aggregation " + err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(yb))
+
+	case RequestHeaderJSON, "":
+		if c.GetHeader(RequestHeaderJSONIndent) == "true" {
+			c.IndentedJSON(http.StatusOK, agg)
+			return
+		}
+		c.JSON(http.StatusOK, agg)
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "invalid content type"})
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/errdefs"
+	"github.com/leptonai/gpud/pkg/watch"
+)
+
+const (
+	RequestHeaderAccept      = "Accept"
+	RequestHeaderEventStream = "text/event-stream"
+	RequestHeaderNDJSON      = "application/x-ndjson"
+)
+
+const (
+	URLPathWatch     = "/watch"
+	URLPathWatchDesc = "Stream the component events and state health transitions"
+)
+
+// defaultWatchKeepAliveInterval is the interval to write the keep-alive message
+// (empty line for NDJSON, comment for SSE), so that the idle connections
+// are not closed by the proxies.
+const defaultWatchKeepAliveInterval = 30 * time.Second
+
+// parseWatchFilter parses the watch filter from the request query parameters.
+func parseWatchFilter(c *gin.Context) (watch.Filter, error) {
+	filter := watch.Filter{
+		Components: splitQuery(c.Query("components")),
+	}
+	for _, kind := range splitQuery(c.Query("kinds")) {
+		switch k := v1.WatchEventKind(kind); k {
+		case v1.WatchEventKindEvent, v1.WatchEventKindState:
+			filter.Kinds = append(filter.Kinds, k)
+		default:
+			return watch.Filter{}, fmt.Errorf("invalid kind %q", kind)
+		}
+	}
+	for _, eventType := range splitQuery(c.Query("eventTypes")) {
+		filter.EventTypes = append(filter.EventTypes, common.EventType(eventType))
+	}
+	return filter, nil
+}
+
+func splitQuery(s string) []string {
+	var ss []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			ss = append(ss, v)
+		}
+	}
+	return ss
+}
+
+// createWatchHandler godoc
+// @Summary Stream the component event inserts and state health transitions
+// @Description streams the changes as newline-delimited JSON, or as server-sent events if the request header "Accept" is "text/event-stream"
+// @ID watch
+// @Param   components     query    string     false        "Comma-separated component names, leave empty to watch all components"
+// @Param   kinds          query    string     false        "Comma-separated change kinds (event, state), leave empty to watch all kinds"
+// @Param   eventTypes     query    string     false        "Comma-separated event types (e.g., Warning,Critical), leave empty to watch all event types"
+// @Produce  json
+// @Success 200 {object} v1.WatchEvent
+// @Router /v1/watch [get]
+func createWatchHandler(broker *watch.Broker) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		filter, err := parseWatchFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": errdefs.ErrInvalidArgument, "message": "failed to parse watch filter: " + err.Error()})
+			return
+		}
+
+		ch, unsubscribe := broker.Subscribe(filter)
+		defer unsubscribe()
+
+		sse := c.GetHeader(RequestHeaderAccept) == RequestHeaderEventStream
+		if sse {
+			c.Header(RequestHeaderContentType, RequestHeaderEventStream)
+		} else {
+			c.Header(RequestHeaderContentType, RequestHeaderNDJSON)
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		ticker := time.NewTicker(defaultWatchKeepAliveInterval)
+		defer ticker.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+
+			case <-ticker.C:
+				if sse {
+					_, err = io.WriteString(w, ": keep-alive\n\n")
+				} else {
+					_, err = io.WriteString(w, "\n")
+				}
+				return err == nil
+
+			case ev, ok := <-ch:
+				if !ok {
+					return false
+				}
+				if sse {
+					c.SSEvent(string(ev.Kind), ev)
+					return true
+				}
+
+				b, err := json.Marshal(ev)
+				if err != nil {
+					return false
+				}
+				_, err = w.Write(append(b, '\n'))
+				return err == nil
+			}
+		})
+	}
+}
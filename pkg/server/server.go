@@ -19,6 +19,7 @@ import (
 	"path"
 	"runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -30,81 +31,32 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"github.com/leptonai/gpud/components"
-	nvidia_badenvs "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs"
-	nvidia_badenvs_id "github.com/leptonai/gpud/components/accelerator/nvidia/bad-envs/id"
-	nvidia_clock_speed "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed"
-	nvidia_clock_speed_id "github.com/leptonai/gpud/components/accelerator/nvidia/clock-speed/id"
-	nvidia_ecc "github.com/leptonai/gpud/components/accelerator/nvidia/ecc"
 	nvidia_ecc_id "github.com/leptonai/gpud/components/accelerator/nvidia/ecc/id"
-	nvidia_fabric_manager "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager"
-	nvidia_fabric_manager_id "github.com/leptonai/gpud/components/accelerator/nvidia/fabric-manager/id"
-	nvidia_gpm "github.com/leptonai/gpud/components/accelerator/nvidia/gpm"
-	nvidia_gsp_firmware_mode "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode"
-	nvidia_gsp_firmware_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/gsp-firmware-mode/id"
-	nvidia_hw_slowdown "github.com/leptonai/gpud/components/accelerator/nvidia/hw-slowdown"
 	nvidia_hw_slowdown_id "github.com/leptonai/gpud/components/accelerator/nvidia/hw-slowdown/id"
-	nvidia_infiniband "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband"
-	nvidia_infiniband_id "github.com/leptonai/gpud/components/accelerator/nvidia/infiniband/id"
-	nvidia_info "github.com/leptonai/gpud/components/accelerator/nvidia/info"
-	nvidia_memory "github.com/leptonai/gpud/components/accelerator/nvidia/memory"
-	nvidia_nccl "github.com/leptonai/gpud/components/accelerator/nvidia/nccl"
-	nvidia_nccl_id "github.com/leptonai/gpud/components/accelerator/nvidia/nccl/id"
-	nvidia_nvlink "github.com/leptonai/gpud/components/accelerator/nvidia/nvlink"
-	nvidia_peermem "github.com/leptonai/gpud/components/accelerator/nvidia/peermem"
-	nvidia_peermem_id "github.com/leptonai/gpud/components/accelerator/nvidia/peermem/id"
-	nvidia_persistence_mode "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode"
-	nvidia_persistence_mode_id "github.com/leptonai/gpud/components/accelerator/nvidia/persistence-mode/id"
-	nvidia_power "github.com/leptonai/gpud/components/accelerator/nvidia/power"
-	nvidia_power_id "github.com/leptonai/gpud/components/accelerator/nvidia/power/id"
-	nvidia_processes "github.com/leptonai/gpud/components/accelerator/nvidia/processes"
 	nvidia_remapped_rows "github.com/leptonai/gpud/components/accelerator/nvidia/remapped-rows"
-	nvidia_sxid "github.com/leptonai/gpud/components/accelerator/nvidia/sxid"
-	nvidia_temperature "github.com/leptonai/gpud/components/accelerator/nvidia/temperature"
-	nvidia_utilization "github.com/leptonai/gpud/components/accelerator/nvidia/utilization"
 	nvidia_component_xid "github.com/leptonai/gpud/components/accelerator/nvidia/xid"
-	nvidia_xid "github.com/leptonai/gpud/components/accelerator/nvidia/xid"
-	containerd_pod "github.com/leptonai/gpud/components/containerd/pod"
-	"github.com/leptonai/gpud/components/cpu"
-	"github.com/leptonai/gpud/components/disk"
-	disk_id "github.com/leptonai/gpud/components/disk/id"
-	docker_container "github.com/leptonai/gpud/components/docker/container"
-	"github.com/leptonai/gpud/components/fd"
-	"github.com/leptonai/gpud/components/file"
-	file_id "github.com/leptonai/gpud/components/file/id"
-	"github.com/leptonai/gpud/components/fuse"
-	fuse_id "github.com/leptonai/gpud/components/fuse/id"
-	"github.com/leptonai/gpud/components/info"
-	info_id "github.com/leptonai/gpud/components/info/id"
-	kernel_module "github.com/leptonai/gpud/components/kernel-module"
-	kernel_module_id "github.com/leptonai/gpud/components/kernel-module/id"
-	kubelet_pod "github.com/leptonai/gpud/components/kubelet/pod"
-	"github.com/leptonai/gpud/components/library"
-	library_id "github.com/leptonai/gpud/components/library/id"
-	"github.com/leptonai/gpud/components/memory"
-	network_latency "github.com/leptonai/gpud/components/network/latency"
-	network_latency_id "github.com/leptonai/gpud/components/network/latency/id"
-	"github.com/leptonai/gpud/components/os"
-	os_id "github.com/leptonai/gpud/components/os/id"
-	"github.com/leptonai/gpud/components/pci"
-	pci_id "github.com/leptonai/gpud/components/pci/id"
-	component_systemd "github.com/leptonai/gpud/components/systemd"
-	systemd_id "github.com/leptonai/gpud/components/systemd/id"
-	"github.com/leptonai/gpud/components/tailscale"
+	_ "github.com/leptonai/gpud/components/all"
+	"github.com/leptonai/gpud/components/registry"
 	_ "github.com/leptonai/gpud/docs/apis"
 	lepconfig "github.com/leptonai/gpud/pkg/config"
-	nvidia_common "github.com/leptonai/gpud/pkg/config/common"
 	"github.com/leptonai/gpud/pkg/eventstore"
 	gpud_manager "github.com/leptonai/gpud/pkg/gpud-manager"
 	metrics "github.com/leptonai/gpud/pkg/gpud-metrics"
 	components_metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
 	gpud_state "github.com/leptonai/gpud/pkg/gpud-state"
+	"github.com/leptonai/gpud/pkg/k8s"
 	"github.com/leptonai/gpud/pkg/log"
 	"github.com/leptonai/gpud/pkg/login"
+	"github.com/leptonai/gpud/pkg/notifier"
 	nvidia_query "github.com/leptonai/gpud/pkg/nvidia-query"
+	nvidia_query_catalog "github.com/leptonai/gpud/pkg/nvidia-query/catalog"
 	nvidia_query_nvml "github.com/leptonai/gpud/pkg/nvidia-query/nvml"
-	query_config "github.com/leptonai/gpud/pkg/query/config"
+	"github.com/leptonai/gpud/pkg/otlp"
+	"github.com/leptonai/gpud/pkg/remediation"
+	"github.com/leptonai/gpud/pkg/remotewrite"
 	"github.com/leptonai/gpud/pkg/session"
 	"github.com/leptonai/gpud/pkg/sqlite"
+	"github.com/leptonai/gpud/pkg/watch"
 )
 
 // Server is the gpud main daemon
@@ -112,6 +64,9 @@ type Server struct {
 	dbRW *sql.DB
 	dbRO *sql.DB
 
+	// nil with the memory backend, or if disabled
+	eventWriter *eventstore.Writer
+
 	nvidiaComponentsExist bool
 	uid                   string
 	fifoPath              string
@@ -119,6 +74,22 @@ type Server struct {
 	session               *session.Session
 	enableAutoUpdate      bool
 	autoUpdateExitCode    int
+
+	instance *registry.GPUdInstance
+	promReg  *prometheus.Registry
+	ghler    *globalHandler
+	broker   *watch.Broker
+
+	// observe the component states on every successful read
+	// (e.g., to detect the health transitions)
+	statesObservers []metrics.StatesObserver
+
+	// componentsMu serializes the component start/stop
+	// on the configuration reload.
+	componentsMu sync.Mutex
+	// componentConfigs is the raw configuration value
+	// of each running component, to diff on the configuration reload.
+	componentConfigs map[string]any
 }
 
 func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID string, packageManager *gpud_manager.Manager) (_ *Server, retErr error) {
@@ -126,9 +97,13 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		return nil, fmt.Errorf("failed to validate config: %w", err)
 	}
 
+	// the memory backend never opens the state file (e.g., on the read-only root)
 	stateFile := ":memory:"
-	if config.State != "" {
+	stateFileExists := false
+	if config.State != "" && !config.Storage.IsMemory() {
 		stateFile = config.State
+		_, err := goOS.Stat(stateFile)
+		stateFileExists = err == nil
 	}
 	dbRW, err := sqlite.Open(stateFile)
 	if err != nil {
@@ -139,23 +114,140 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		return nil, fmt.Errorf("failed to open state file (for read-only): %w", err)
 	}
 
-	eventStore, err := eventstore.New(dbRW, dbRO, 0)
+	// backed up and migrated before any package creates or alters its tables,
+	// so that the backup is the unchanged state file, and the existing tables
+	// are migrated before the ones of a new schema version are created
+	pendingMigrations, err := gpud_state.PendingMigrations(ctx, dbRO)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open events database: %w", err)
+		return nil, fmt.Errorf("failed to read schema migrations: %w", err)
+	}
+	if len(pendingMigrations) > 0 && stateFileExists {
+		backup, err := gpud_state.BackupStateFile(ctx, dbRW, stateFile)
+		if err != nil {
+			return nil, err
+		}
+		log.Logger.Infow("backed up state file before schema migrations", "backup", backup, "pending", len(pendingMigrations))
+	}
+	if _, err := gpud_state.Migrate(ctx, dbRW); err != nil {
+		return nil, fmt.Errorf("failed to migrate state schema: %w", err)
+	}
+
+	var eventStore, historyStore eventstore.Store
+	var eventWriter *eventstore.Writer
+	var metricsStore components_metrics_state.Store
+	if config.Storage.IsMemory() {
+		config.Storage.SetDefaults()
+		log.Logger.Infow("keeping events and metrics in memory", "maxEventsPerBucket", config.Storage.MaxEventsPerBucket, "maxMetricsPerSeries", config.Storage.MaxMetricsPerSeries)
+		eventStore, err = eventstore.NewMemory(config.Storage.MaxEventsPerBucket, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create in-memory events store: %w", err)
+		}
+		historyStore, err = eventstore.NewMemory(config.Storage.MaxEventsPerBucket, eventstore.DefaultRetention)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create in-memory health history store: %w", err)
+		}
+		metricsStore = components_metrics_state.NewMemoryStore(config.Storage.MaxMetricsPerSeries)
+	} else {
+		metricsStore = components_metrics_state.NewSQLiteStore(dbRW, dbRO, components_metrics_state.DefaultTableName)
+
+		writerCfg := eventstore.WriterConfig{}
+		if config.Storage != nil && config.Storage.Writer != nil {
+			writerCfg = *config.Storage.Writer
+		}
+		if writerCfg.SpillFile == "" && config.State != "" {
+			writerCfg.SpillFile = config.State + ".events-spill"
+		}
+
+		var opts, historyOpts []eventstore.OpOption
+		if !writerCfg.Disabled {
+			// the events are queued while the state file is locked
+			// (e.g., compacted), not to block the component watch loops
+			eventWriter, err = eventstore.NewWriter(dbRW, writerCfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create events writer: %w", err)
+			}
+			opts = append(opts, eventstore.WithWriter(eventWriter))
+			historyOpts = append(historyOpts, eventstore.WithWriter(eventWriter))
+		}
+		if config.Storage != nil {
+			opts = append(opts, eventstore.WithBucketConfigs(config.Storage.Buckets))
+		}
+		eventStore, err = eventstore.New(dbRW, dbRO, 0, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open events database: %w", err)
+		}
+
+		// the health transitions are recorded with its own retention,
+		// and not published to the watchers as the event inserts
+		historyStore, err = eventstore.New(dbRW, dbRO, eventstore.DefaultRetention, historyOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open health history database: %w", err)
+		}
+	}
+	historyBucket, err := historyStore.Bucket(watch.HistoryBucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open health history bucket: %w", err)
+	}
+
+	// publishes the event inserts to the watchers
+	broker := watch.NewBroker()
+	eventStore = broker.WrapStore(eventStore)
+	go watch.RecordHistory(ctx, broker, historyBucket)
+
+	if config.Notifier != nil {
+		n, err := notifier.New(*config.Notifier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create notifier: %w", err)
+		}
+		go n.Run(ctx, broker)
+	}
+
+	statesObservers := []metrics.StatesObserver{broker.ObserveStates}
+	if config.Kubernetes != nil {
+		cli, err := k8s.NewClient(config.Kubernetes.Kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+		}
+		kubePublisher, err := k8s.New(*config.Kubernetes, cli)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes publisher: %w", err)
+		}
+		go kubePublisher.Run(ctx, broker)
+		statesObservers = append(statesObservers, kubePublisher.ObserveStates)
+	}
+
+	if config.Remediation != nil {
+		remediationBucket, err := eventStore.Bucket(remediation.BucketName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remediation bucket: %w", err)
+		}
+		engine, err := remediation.New(*config.Remediation, remediationBucket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remediation engine: %w", err)
+		}
+		go engine.Run(ctx)
+		statesObservers = append(statesObservers, engine.ObserveStates)
 	}
 
 	promReg := prometheus.NewRegistry()
 	if err := sqlite.Register(promReg); err != nil {
 		return nil, fmt.Errorf("failed to register sqlite metrics: %w", err)
 	}
+	if err := eventstore.Register(promReg); err != nil {
+		return nil, fmt.Errorf("failed to register eventstore metrics: %w", err)
+	}
 
 	fifoPath, err := lepconfig.DefaultFifoFile()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get fifo path: %w", err)
 	}
 	s := &Server{
-		dbRW: dbRW,
-		dbRO: dbRO,
+		dbRW:        dbRW,
+		dbRO:        dbRO,
+		eventWriter: eventWriter,
+
+		broker:          broker,
+		statesObservers: statesObservers,
 
 		fifoPath:           fifoPath,
 		enableAutoUpdate:   config.EnableAutoUpdate,
@@ -173,6 +265,8 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 	}
 	var xidEventBucket eventstore.Bucket
 	var hwSlowdownEventBucket eventstore.Bucket
+	var eccEventBucket eventstore.Bucket
+	var remappedRowsEventBucket eventstore.Bucket
 	if runtime.GOOS == "linux" && nvidiaInstalled {
 		xidEventBucket, err = eventStore.Bucket(nvidia_component_xid.Name)
 		if err != nil {
@@ -182,9 +276,20 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		if err != nil {
 			return nil, err
 		}
+		eccEventBucket, err = eventStore.Bucket(nvidia_ecc_id.Name)
+		if err != nil {
+			return nil, err
+		}
+		remappedRowsEventBucket, err = eventStore.Bucket(nvidia_remapped_rows.Name)
+		if err != nil {
+			return nil, err
+		}
 		nvidia_query.SetDefaultPoller(
 			nvidia_query.WithXidEventBucket(xidEventBucket),
 			nvidia_query.WithHWSlowdownEventBucket(hwSlowdownEventBucket),
+			nvidia_query.WithECCEventBucket(eccEventBucket),
+			nvidia_query.WithRemappedRowsEventBucket(remappedRowsEventBucket),
+			nvidia_query.WithMetricsStore(metricsStore),
 			nvidia_query.WithIbstatCommand(config.NvidiaToolOverwrites.IbstatCommand),
 		)
 	}
@@ -207,6 +312,17 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 	if err := components_metrics_state.CreateTableMetrics(ctx, dbRW, components_metrics_state.DefaultTableName); err != nil {
 		return nil, fmt.Errorf("failed to create metrics table: %w", err)
 	}
+
+	// the in-memory metrics are capped per series, and not rolled up
+	if !config.Storage.IsMemory() {
+		if err := components_metrics_state.EnableRollups(ctx, dbRW, components_metrics_state.DefaultTableName, components_metrics_state.RollupConfig{
+			RawRetention: config.RetentionPeriod.Duration,
+			Tiers:        components_metrics_state.DefaultRollupTiers,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to enable metrics rollups: %w", err)
+		}
+		go components_metrics_state.RunRollups(ctx, dbRW, components_metrics_state.DefaultTableName, time.Minute)
+	}
 	go func() {
 		dur := config.RetentionPeriod.Duration
 		for {
@@ -216,7 +332,7 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 			case <-time.After(dur):
 				now := time.Now().UTC()
 				before := now.Add(-dur)
-				purged, err := components_metrics_state.PurgeMetrics(ctx, dbRW, components_metrics_state.DefaultTableName, before)
+				purged, err := metricsStore.Purge(ctx, before)
 				if err != nil {
 					log.Logger.Warnw("failed to purge metrics", "error", err)
 				} else {
@@ -226,521 +342,23 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		}
 	}()
 
-	defaultQueryCfg := query_config.Config{
-		State: &query_config.State{
-			DBRW: dbRW,
-			DBRO: dbRO,
-		},
-	}
-
-	allComponents := make([]components.Component, 0)
-	if _, ok := config.Components[os_id.Name]; !ok {
-		c, err := os.New(ctx, os.Config{Query: defaultQueryCfg}, eventStore)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create component %s: %w", os_id.Name, err)
-		}
-		allComponents = append(allComponents, c)
-	}
-
-	for k, configValue := range config.Components {
-		switch k {
-		case cpu.Name:
-			c, err := cpu.New(ctx, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case disk_id.Name:
-			cfg := disk.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := disk.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, disk.New(ctx, cfg))
-
-		case fuse_id.Name:
-			cfg := fuse.Config{
-				Query:                                defaultQueryCfg,
-				CongestedPercentAgainstThreshold:     fuse.DefaultCongestedPercentAgainstThreshold,
-				MaxBackgroundPercentAgainstThreshold: fuse.DefaultMaxBackgroundPercentAgainstThreshold,
-			}
-			if configValue != nil {
-				parsed, err := fuse.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := fuse.New(ctx, cfg, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case pci_id.Name:
-			cfg := pci.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := pci.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			c, err := pci.New(ctx, cfg, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case fd.Name:
-			c, err := fd.New(ctx, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case file_id.Name:
-			if configValue != nil {
-				filesToCheck, ok := configValue.([]string)
-				if !ok {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				allComponents = append(allComponents, file.New(filesToCheck))
-			}
-
-		case kernel_module_id.Name:
-			kernelModulesToCheck := []string{}
-			if configValue != nil {
-				var ok bool
-				kernelModulesToCheck, ok = configValue.([]string)
-				if !ok {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-			}
-			allComponents = append(allComponents, kernel_module.New(kernelModulesToCheck))
-
-		case library_id.Name:
-			if configValue != nil {
-				libCfg, ok := configValue.(library.Config)
-				if !ok {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				allComponents = append(allComponents, library.New(libCfg))
-			}
-
-		case info_id.Name:
-			allComponents = append(allComponents, info.New(config.Annotations, dbRO, promReg))
-
-		case memory.Name:
-			c, err := memory.New(ctx, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case os_id.Name:
-			cfg := os.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := os.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := os.New(ctx, cfg, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case systemd_id.Name:
-			cfg := component_systemd.Config{Query: defaultQueryCfg}
-			if configValue != nil {
-				parsed, err := component_systemd.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := component_systemd.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case tailscale.Name:
-			allComponents = append(allComponents, tailscale.New(ctx))
-
-		case nvidia_info.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_info.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_badenvs_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_badenvs.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_xid.Name:
-			allComponents = append(allComponents, nvidia_xid.New(ctx, eventStore))
-
-		case nvidia_sxid.Name:
-			// db object to read sxid events (read-only, writes are done in poller)
-			allComponents = append(allComponents, nvidia_sxid.New(ctx, eventStore))
-
-		case nvidia_hw_slowdown_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_hw_slowdown.New(ctx, cfg, hwSlowdownEventBucket)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_clock_speed_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_clock_speed.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_ecc_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_ecc.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_memory.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_memory.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_gpm.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, nvidia_gpm.New(ctx, cfg))
-
-		case nvidia_nvlink.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_nvlink.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_power_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_power.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_temperature.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_temperature.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
+	s.instance = &registry.GPUdInstance{
+		DBRW:         dbRW,
+		DBRO:         dbRO,
+		EventStore:   eventStore,
+		MetricsStore: metricsStore,
+		PromRegistry: promReg,
 
-		case nvidia_utilization.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_utilization.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_processes.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_processes.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_remapped_rows.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_remapped_rows.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_fabric_manager_id.Name:
-			fabricManagerLogComponent, err := nvidia_fabric_manager.New(ctx, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, fabricManagerLogComponent)
+		Annotations:          config.Annotations,
+		NvidiaToolOverwrites: config.NvidiaToolOverwrites,
 
-		case nvidia_gsp_firmware_mode_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_gsp_firmware_mode.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_infiniband_id.Name:
-			c, err := nvidia_infiniband.New(ctx, eventStore, config.NvidiaToolOverwrites)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_peermem_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_peermem.New(ctx, cfg, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_persistence_mode_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_persistence_mode.New(ctx, cfg)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case nvidia_nccl_id.Name:
-			cfg := nvidia_common.Config{Query: defaultQueryCfg, ToolOverwrites: config.NvidiaToolOverwrites}
-			if configValue != nil {
-				parsed, err := nvidia_common.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			c, err := nvidia_nccl.New(ctx, eventStore)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create component %s: %w", k, err)
-			}
-			allComponents = append(allComponents, c)
-
-		case containerd_pod.Name:
-			allComponents = append(allComponents, containerd_pod.New(ctx))
-
-		case docker_container.Name:
-			allComponents = append(allComponents, docker_container.New(ctx, config.DockerIgnoreConnectionErrors))
-
-		case kubelet_pod.Name:
-			allComponents = append(allComponents, kubelet_pod.New(ctx, kubelet_pod.DefaultKubeletReadOnlyPort, config.KubeletIgnoreConnectionErrors))
-
-		case network_latency_id.Name:
-			cfg := network_latency.Config{
-				Query:                      defaultQueryCfg,
-				GlobalMillisecondThreshold: network_latency.DefaultGlobalMillisecondThreshold,
-			}
-			if configValue != nil {
-				parsed, err := network_latency.ParseConfig(configValue, dbRW, dbRO)
-				if err != nil {
-					return nil, fmt.Errorf("failed to parse component %s config: %w", k, err)
-				}
-				cfg = *parsed
-			}
-			if err := cfg.Validate(); err != nil {
-				return nil, fmt.Errorf("failed to validate component %s config: %w", k, err)
-			}
-			allComponents = append(allComponents, network_latency.New(ctx, cfg))
-
-		default:
-			return nil, fmt.Errorf("unknown component %s", k)
-		}
+		DockerIgnoreConnectionErrors:  config.DockerIgnoreConnectionErrors,
+		KubeletIgnoreConnectionErrors: config.KubeletIgnoreConnectionErrors,
 	}
 
+	s.promReg = promReg
+	s.componentConfigs = make(map[string]any)
+
 	if err := metrics.Register(promReg); err != nil {
 		return nil, fmt.Errorf("failed to register metrics: %w", err)
 	}
@@ -824,46 +442,30 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		log.Logger.Debugw("compact period is not set, skipping compacting")
 	}
 
-	for i := range allComponents {
-		metrics.SetRegistered(allComponents[i].Name())
-		allComponents[i] = metrics.NewWatchableComponent(allComponents[i])
+	cfgs, err := componentConfigs(config)
+	if err != nil {
+		return nil, err
 	}
 
-	var componentNames []string
-	componentSet := make(map[string]struct{})
-	for _, c := range allComponents {
-		componentSet[c.Name()] = struct{}{}
-		componentNames = append(componentNames, c.Name())
-		if strings.Contains(c.Name(), "nvidia") {
-			s.nvidiaComponentsExist = true
-		}
-
-		// this guarantees no name conflict, thus safe to register handlers by its name
-		if err := components.RegisterComponent(c.Name(), c); err != nil {
-			log.Logger.Debugw("failed to register component", "name", c.Name(), "error", err)
-			continue
-		}
-
-		if orig, ok := c.(interface{ Unwrap() interface{} }); ok {
-			if prov, ok := orig.Unwrap().(components.PromRegisterer); ok {
-				log.Logger.Debugw("registering prometheus collectors", "component", c.Name())
-				if err := prov.RegisterCollectors(promReg, dbRW, dbRO, components_metrics_state.DefaultTableName); err != nil {
-					return nil, fmt.Errorf("failed to register metrics for component %s: %w", c.Name(), err)
-				}
-			} else {
-				log.Logger.Debugw("component does not implement components.PromRegisterer", "component", c.Name())
-			}
-		} else {
-			log.Logger.Debugw("component does not implement interface{ Unwrap() interface{} }", "component", c.Name())
-		}
+	// applied before the components look up the XID/SXID details
+	if err := nvidia_query_catalog.ApplyFile(config.XIDCatalogFile); err != nil {
+		return nil, fmt.Errorf("failed to apply xid catalog file: %w", err)
+	}
+	if config.XIDCatalogFile != "" {
+		log.Logger.Infow("applied xid catalog overlay", "file", config.XIDCatalogFile)
 	}
 
-	for _, c := range allComponents {
-		if err = c.Start(); err != nil {
-			log.Logger.Errorw("failed to start component", "name", c.Name(), "error", err)
-			return nil, fmt.Errorf("failed to start component %s: %w", c.Name(), err)
+	s.componentsMu.Lock()
+	for _, name := range sortedKeys(cfgs) {
+		if err := s.startComponent(ctx, name, cfgs[name]); err != nil {
+			s.componentsMu.Unlock()
+			return nil, err
 		}
+		s.componentConfigs[name] = cfgs[name]
 	}
+	s.componentsMu.Unlock()
+	componentNames := runningComponentNames()
+	go s.pollStates(ctx, defaultStatesPollInterval)
 
 	// to not start healthz until the initial gpu data is ready
 	if s.nvidiaComponentsExist {
@@ -888,7 +490,30 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 		return nil, fmt.Errorf("failed to update components: %w", err)
 	}
 
-	// TODO: implement configuration file refresh + apply
+	if config.OTLP != nil {
+		exporter, err := otlp.New(*config.OTLP, promReg, uid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		go exporter.Run(ctx, broker)
+	}
+
+	if config.RemoteWrite != nil {
+		if err := remotewrite.Register(promReg); err != nil {
+			return nil, fmt.Errorf("failed to register remote write metrics: %w", err)
+		}
+		// the read-only handle of the in-memory database opens another, empty database,
+		// so the buffered samples are read through the read-write handle
+		bufferRO := dbRO
+		if stateFile == ":memory:" {
+			bufferRO = dbRW
+		}
+		writer, err := remotewrite.New(ctx, *config.RemoteWrite, promReg, dbRW, bufferRO, config.Annotations, uid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remote writer: %w", err)
+		}
+		go writer.Run(ctx)
+	}
 
 	router := gin.Default()
 	router.SetHTMLTemplate(rootTmpl)
@@ -905,14 +530,21 @@ func New(ctx context.Context, config *lepconfig.Config, endpoint string, cliUID
 
 	// if the request header is set "Accept-Encoding: gzip",
 	// the middleware automatically gzip-compresses the response with the response header "Content-Encoding: gzip"
-	v1.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/update/"})))
+	v1.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/update/", path.Join(v1.BasePath(), URLPathWatch)})))
 
-	ghler := newGlobalHandler(config, components.GetAllComponents())
+	ghler := newGlobalHandler(config, componentNames, historyBucket, metricsStore)
+	s.ghler = ghler
 	registeredPaths := ghler.registerComponentRoutes(v1)
 	for i := range registeredPaths {
 		registeredPaths[i].Path = path.Join(v1.BasePath(), registeredPaths[i].Path)
 	}
 
+	v1.GET(URLPathWatch, createWatchHandler(broker))
+	registeredPaths = append(registeredPaths, componentHandlerDescription{
+		Path: path.Join(v1.BasePath(), URLPathWatch),
+		Desc: URLPathWatchDesc,
+	})
+
 	registeredPaths = append(registeredPaths, componentHandlerDescription{
 		Path: "/metrics",
 		Desc: "Prometheus metrics",
@@ -1008,6 +640,11 @@ func (s *Server) Stop() {
 		}
 	}
 
+	// commit the queued events before closing the state file
+	if s.eventWriter != nil {
+		s.eventWriter.Close()
+	}
+
 	if cerr := s.dbRW.Close(); cerr != nil {
 		log.Logger.Debugw("failed to close read-write db", "error", cerr)
 	} else {
@@ -0,0 +1,94 @@
+// Package storage configures the storage backend of the events and the metrics.
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leptonai/gpud/pkg/eventstore"
+	metrics_state "github.com/leptonai/gpud/pkg/gpud-metrics/state"
+)
+
+// Backend is the storage backend type.
+type Backend string
+
+const (
+	// BackendSQLite stores the events and the metrics in the sqlite state file
+	// (or in the in-memory sqlite database without the state file, which has no size cap).
+	BackendSQLite Backend = "sqlite"
+	// BackendMemory keeps a fixed number of the latest events and metrics in memory,
+	// for the diskless nodes (e.g., PXE-booted), without opening the state file.
+	BackendMemory Backend = "memory"
+)
+
+// Config configures the storage backend.
+type Config struct {
+	// Backend is the storage backend, defaults to "sqlite".
+	Backend Backend `json:"backend,omitempty"`
+
+	// MaxEventsPerBucket is the number of the latest events
+	// kept per component with the memory backend.
+	MaxEventsPerBucket int `json:"max_events_per_bucket,omitempty"`
+
+	// MaxMetricsPerSeries is the number of the latest data points
+	// kept per metric (and its secondary name) with the memory backend.
+	MaxMetricsPerSeries int `json:"max_metrics_per_series,omitempty"`
+
+	// Buckets configures the retention and the quota of the events per component
+	// (e.g., keep "accelerator-nvidia-error-xid" events for 90 days, and "memory" events for 3 days).
+	// Only applies to the sqlite backend, and rejected with the memory backend.
+	Buckets map[string]eventstore.BucketConfig `json:"buckets,omitempty"`
+
+	// Writer configures the batched async writes of the events,
+	// enabled with the defaults if not set.
+	// Only applies to the sqlite backend.
+	Writer *eventstore.WriterConfig `json:"writer,omitempty"`
+}
+
+// SetDefaults sets the default values for the unset fields.
+func (cfg *Config) SetDefaults() {
+	if cfg.Backend == "" {
+		cfg.Backend = BackendSQLite
+	}
+	if cfg.MaxEventsPerBucket == 0 {
+		cfg.MaxEventsPerBucket = eventstore.DefaultMaxEventsPerBucket
+	}
+	if cfg.MaxMetricsPerSeries == 0 {
+		cfg.MaxMetricsPerSeries = metrics_state.DefaultMaxMetricsPerSeries
+	}
+}
+
+func (cfg *Config) Validate() error {
+	switch cfg.Backend {
+	case "", BackendSQLite, BackendMemory:
+	default:
+		return fmt.Errorf("unknown backend %q", cfg.Backend)
+	}
+	if cfg.MaxEventsPerBucket < 0 {
+		return fmt.Errorf("max_events_per_bucket must be non-negative, got %d", cfg.MaxEventsPerBucket)
+	}
+	if cfg.MaxMetricsPerSeries < 0 {
+		return fmt.Errorf("max_metrics_per_series must be non-negative, got %d", cfg.MaxMetricsPerSeries)
+	}
+	// the memory backend keeps the latest events per bucket,
+	// without the retention and the quota of each bucket
+	if cfg.Backend == BackendMemory && len(cfg.Buckets) > 0 {
+		return errors.New("buckets are not supported with the memory backend")
+	}
+	for name, bucket := range cfg.Buckets {
+		if err := bucket.Validate(); err != nil {
+			return fmt.Errorf("invalid bucket %q: %w", name, err)
+		}
+	}
+	if cfg.Writer != nil {
+		if err := cfg.Writer.Validate(); err != nil {
+			return fmt.Errorf("invalid writer: %w", err)
+		}
+	}
+	return nil
+}
+
+// IsMemory returns true if the events and the metrics are kept in memory.
+func (cfg *Config) IsMemory() bool {
+	return cfg != nil && cfg.Backend == BackendMemory
+}
@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	"github.com/leptonai/gpud/pkg/log"
+)
+
+// HistoryBucketName is the event store bucket name
+// that persists the component state health transitions.
+const HistoryBucketName = "health-history"
+
+const (
+	historyKeyState          = "state"
+	historyKeyPreviousHealth = "previous_health"
+	historyKeyHealth         = "health"
+	historyKeyReason         = "reason"
+)
+
+// RecordHistory persists every state health transition published to the broker
+// into the bucket, until the context is canceled.
+// The event name is the component name.
+func RecordHistory(ctx context.Context, broker *Broker, bucket eventstore.Bucket) {
+	ch, unsubscribe := broker.Subscribe(Filter{Kinds: []v1.WatchEventKind{v1.WatchEventKindState}})
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if ev.State == nil {
+				continue
+			}
+			if err := bucket.Insert(ctx, historyEvent(ev)); err != nil {
+				log.Logger.Warnw("failed to record health transition", "component", ev.Component, "error", err)
+			}
+		}
+	}
+}
+
+func historyEvent(ev v1.WatchEvent) components.Event {
+	return components.Event{
+		Time:    metav1.Time{Time: ev.Time},
+		Name:    ev.Component,
+		Type:    historyEventType(ev.State.Health),
+		Message: fmt.Sprintf("%s: %s -> %s", ev.State.Name, ev.State.PreviousHealth, ev.State.Health),
+		ExtraInfo: map[string]string{
+			historyKeyState:          ev.State.Name,
+			historyKeyPreviousHealth: ev.State.PreviousHealth,
+			historyKeyHealth:         ev.State.Health,
+			historyKeyReason:         ev.State.Reason,
+		},
+	}
+}
+
+func historyEventType(health string) common.EventType {
+	switch health {
+	case components.StateDegraded:
+		return common.EventTypeWarning
+	case components.StateUnhealthy:
+		return common.EventTypeCritical
+	default:
+		return common.EventTypeInfo
+	}
+}
+
+// ReadHistory returns the recorded state health transitions since the given time,
+// keyed by the component name, in the descending order of time (latest first).
+func ReadHistory(ctx context.Context, bucket eventstore.Bucket, since time.Time) (map[string][]v1.HealthTransition, error) {
+	evs, err := bucket.Get(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(map[string][]v1.HealthTransition)
+	for _, ev := range evs {
+		history[ev.Name] = append(history[ev.Name], v1.HealthTransition{
+			Time: ev.Time.Time,
+			StateTransition: v1.StateTransition{
+				Name:           ev.ExtraInfo[historyKeyState],
+				PreviousHealth: ev.ExtraInfo[historyKeyPreviousHealth],
+				Health:         ev.ExtraInfo[historyKeyHealth],
+				Reason:         ev.ExtraInfo[historyKeyReason],
+			},
+		})
+	}
+	return history, nil
+}
@@ -0,0 +1,131 @@
+package watch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+)
+
+type memoryBucket struct {
+	eventstore.Bucket
+
+	mu  sync.Mutex
+	evs []components.Event
+}
+
+func (b *memoryBucket) Insert(ctx context.Context, ev components.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.evs = append([]components.Event{ev}, b.evs...)
+	return nil
+}
+
+func (b *memoryBucket) Get(ctx context.Context, since time.Time) ([]components.Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var evs []components.Event
+	for _, ev := range b.evs {
+		if !ev.Time.Time.Before(since) {
+			evs = append(evs, ev)
+		}
+	}
+	return evs, nil
+}
+
+func (b *memoryBucket) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.evs)
+}
+
+func TestRecordHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := NewBroker()
+	bucket := &memoryBucket{}
+
+	done := make(chan struct{})
+	go func() {
+		RecordHistory(ctx, broker, bucket)
+		close(done)
+	}()
+
+	// wait for the recorder to subscribe
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		broker.mu.RLock()
+		n := len(broker.subs)
+		broker.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the recorder")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// event inserts are not recorded
+	broker.Publish(v1.WatchEvent{Kind: v1.WatchEventKindEvent, Component: "cpu", Event: &components.Event{}})
+
+	broker.ObserveStates("cpu", []components.State{{Name: "cpu", Healthy: true}})
+	broker.ObserveStates("cpu", []components.State{{Name: "cpu", Health: components.StateDegraded, Reason: "load average high"}})
+	broker.ObserveStates("memory", []components.State{{Name: "memory", Healthy: true}})
+	broker.ObserveStates("memory", []components.State{{Name: "memory", Healthy: false, Reason: "oom"}})
+
+	for bucket.len() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the history, got %d", bucket.len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	history, err := ReadHistory(ctx, bucket, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 components, got %v", history)
+	}
+
+	cpu := history["cpu"]
+	if len(cpu) != 1 {
+		t.Fatalf("expected 1 cpu transition, got %v", cpu)
+	}
+	if cpu[0].Name != "cpu" || cpu[0].PreviousHealth != components.StateHealthy || cpu[0].Health != components.StateDegraded || cpu[0].Reason != "load average high" {
+		t.Errorf("unexpected cpu transition %+v", cpu[0])
+	}
+
+	memory := history["memory"]
+	if len(memory) != 1 || memory[0].Health != components.StateUnhealthy || memory[0].Reason != "oom" {
+		t.Errorf("unexpected memory transition %+v", memory)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestHistoryEventType(t *testing.T) {
+	tests := []struct {
+		health string
+		want   common.EventType
+	}{
+		{components.StateHealthy, common.EventTypeInfo},
+		{components.StateInitializing, common.EventTypeInfo},
+		{components.StateDegraded, common.EventTypeWarning},
+		{components.StateUnhealthy, common.EventTypeCritical},
+	}
+	for _, tt := range tests {
+		if got := historyEventType(tt.health); got != tt.want {
+			t.Errorf("historyEventType(%q) = %q, want %q", tt.health, got, tt.want)
+		}
+	}
+}
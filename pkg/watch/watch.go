@@ -0,0 +1,212 @@
+// Package watch implements the broker that fans out the component event inserts
+// and the component state health transitions to the watch subscribers.
+package watch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+	"github.com/leptonai/gpud/pkg/log"
+)
+
+// DefaultBufferSize is the number of watch events buffered per subscriber.
+// The events are dropped for the subscriber if the buffer is full
+// (i.e., the subscriber is too slow to consume).
+const DefaultBufferSize = 256
+
+// Filter selects the watch events to receive.
+// Empty fields match all.
+type Filter struct {
+	Components []string
+	Kinds      []v1.WatchEventKind
+	// EventTypes only applies to the event inserts.
+	EventTypes []common.EventType
+}
+
+// Match returns true if the watch event matches the filter.
+func (f Filter) Match(ev v1.WatchEvent) bool {
+	if len(f.Components) > 0 && !contains(f.Components, ev.Component) {
+		return false
+	}
+	if len(f.Kinds) > 0 && !contains(f.Kinds, ev.Kind) {
+		return false
+	}
+	if len(f.EventTypes) > 0 && ev.Kind == v1.WatchEventKindEvent {
+		if ev.Event == nil || !contains(f.EventTypes, ev.Event.Type) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains[T comparable](vs []T, v T) bool {
+	for _, x := range vs {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+type subscriber struct {
+	filter Filter
+	ch     chan v1.WatchEvent
+}
+
+// Broker fans out the watch events to the subscribers.
+type Broker struct {
+	mu   sync.RWMutex
+	subs map[*subscriber]struct{}
+
+	healthMu sync.Mutex
+	// last observed health, keyed by component and state name
+	health map[string]map[string]string
+}
+
+// NewBroker creates a new watch event broker.
+func NewBroker() *Broker {
+	return &Broker{
+		subs:   make(map[*subscriber]struct{}),
+		health: make(map[string]map[string]string),
+	}
+}
+
+// Subscribe returns the channel that receives the watch events matching the filter.
+// The returned function must be called to unsubscribe, which closes the channel.
+func (b *Broker) Subscribe(filter Filter) (<-chan v1.WatchEvent, func()) {
+	sub := &subscriber{
+		filter: filter,
+		ch:     make(chan v1.WatchEvent, DefaultBufferSize),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish sends the watch event to all the matching subscribers.
+// Never blocks -- the event is dropped for the subscribers with the full buffer.
+func (b *Broker) Publish(ev v1.WatchEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if !sub.filter.Match(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			log.Logger.Warnw("watch subscriber buffer full -- dropping event", "component", ev.Component, "kind", ev.Kind)
+		}
+	}
+}
+
+// ObserveStates compares the component states with the last observed ones,
+// and publishes the health transitions.
+// The first observation of each state is recorded without publishing.
+func (b *Broker) ObserveStates(component string, states []components.State) {
+	now := time.Now().UTC()
+
+	var transitions []v1.StateTransition
+
+	b.healthMu.Lock()
+	last, ok := b.health[component]
+	if !ok {
+		last = make(map[string]string)
+		b.health[component] = last
+	}
+	for _, s := range states {
+		curr := StateHealth(s)
+		prev, observed := last[s.Name]
+		last[s.Name] = curr
+		if !observed || prev == curr {
+			continue
+		}
+		transitions = append(transitions, v1.StateTransition{
+			Name:           s.Name,
+			PreviousHealth: prev,
+			Health:         curr,
+			Reason:         s.Reason,
+		})
+	}
+	b.healthMu.Unlock()
+
+	for i := range transitions {
+		b.Publish(v1.WatchEvent{
+			Kind:      v1.WatchEventKindState,
+			Component: component,
+			Time:      now,
+			State:     &transitions[i],
+		})
+	}
+}
+
+// StateHealth returns the health of the state,
+// derived from the healthy flag if the component does not set the health.
+func StateHealth(s components.State) string {
+	if s.Health != "" {
+		return s.Health
+	}
+	if s.Healthy {
+		return components.StateHealthy
+	}
+	return components.StateUnhealthy
+}
+
+// WrapStore returns the event store whose buckets publish
+// every successful event insert to the broker.
+func (b *Broker) WrapStore(store eventstore.Store) eventstore.Store {
+	return &publishingStore{Store: store, broker: b}
+}
+
+type publishingStore struct {
+	eventstore.Store
+	broker *Broker
+}
+
+func (s *publishingStore) Bucket(name string, opts ...eventstore.OpOption) (eventstore.Bucket, error) {
+	bucket, err := s.Store.Bucket(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &publishingBucket{Bucket: bucket, component: name, broker: s.broker}, nil
+}
+
+type publishingBucket struct {
+	eventstore.Bucket
+	// the bucket name passed to the store, which is the component name
+	// ("Name" returns the underlying table name)
+	component string
+	broker    *Broker
+}
+
+func (b *publishingBucket) Insert(ctx context.Context, ev components.Event) error {
+	if err := b.Bucket.Insert(ctx, ev); err != nil {
+		return err
+	}
+
+	inserted := ev
+	b.broker.Publish(v1.WatchEvent{
+		Kind:      v1.WatchEventKindEvent,
+		Component: b.component,
+		Time:      ev.Time.Time,
+		Event:     &inserted,
+	})
+	return nil
+}
@@ -0,0 +1,178 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/leptonai/gpud/api/v1"
+	"github.com/leptonai/gpud/components"
+	"github.com/leptonai/gpud/pkg/common"
+	"github.com/leptonai/gpud/pkg/eventstore"
+)
+
+func TestFilterMatch(t *testing.T) {
+	warning := v1.WatchEvent{
+		Kind:      v1.WatchEventKindEvent,
+		Component: "cpu",
+		Event:     &components.Event{Type: common.EventTypeWarning},
+	}
+	transition := v1.WatchEvent{
+		Kind:      v1.WatchEventKindState,
+		Component: "cpu",
+		State:     &v1.StateTransition{Health: components.StateUnhealthy},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		ev     v1.WatchEvent
+		want   bool
+	}{
+		{"empty filter", Filter{}, warning, true},
+		{"component match", Filter{Components: []string{"memory", "cpu"}}, warning, true},
+		{"component mismatch", Filter{Components: []string{"memory"}}, warning, false},
+		{"kind match", Filter{Kinds: []v1.WatchEventKind{v1.WatchEventKindState}}, transition, true},
+		{"kind mismatch", Filter{Kinds: []v1.WatchEventKind{v1.WatchEventKindState}}, warning, false},
+		{"event type match", Filter{EventTypes: []common.EventType{common.EventTypeWarning}}, warning, true},
+		{"event type mismatch", Filter{EventTypes: []common.EventType{common.EventTypeCritical}}, warning, false},
+		{"event type ignored for state", Filter{EventTypes: []common.EventType{common.EventTypeCritical}}, transition, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.ev); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrokerSubscribe(t *testing.T) {
+	b := NewBroker()
+
+	all, unsubscribeAll := b.Subscribe(Filter{})
+	defer unsubscribeAll()
+	memory, unsubscribeMemory := b.Subscribe(Filter{Components: []string{"memory"}})
+
+	b.Publish(v1.WatchEvent{Kind: v1.WatchEventKindEvent, Component: "cpu"})
+
+	select {
+	case ev := <-all:
+		if ev.Component != "cpu" {
+			t.Errorf("unexpected component %q", ev.Component)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	select {
+	case ev := <-memory:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+
+	unsubscribeMemory()
+	unsubscribeMemory() // no-op
+	if _, ok := <-memory; ok {
+		t.Error("expected channel to be closed")
+	}
+}
+
+func TestBrokerPublishFullBuffer(t *testing.T) {
+	b := NewBroker()
+	ch, unsubscribe := b.Subscribe(Filter{})
+	defer unsubscribe()
+
+	// must not block
+	for i := 0; i < DefaultBufferSize+10; i++ {
+		b.Publish(v1.WatchEvent{Kind: v1.WatchEventKindEvent, Component: "cpu"})
+	}
+	if len(ch) != DefaultBufferSize {
+		t.Errorf("expected %d buffered events, got %d", DefaultBufferSize, len(ch))
+	}
+}
+
+func TestBrokerObserveStates(t *testing.T) {
+	b := NewBroker()
+	ch, unsubscribe := b.Subscribe(Filter{})
+	defer unsubscribe()
+
+	b.ObserveStates("cpu", []components.State{{Name: "cpu", Healthy: true}})
+	b.ObserveStates("cpu", []components.State{{Name: "cpu", Healthy: true}})
+	if len(ch) != 0 {
+		t.Fatalf("expected no transition, got %d events", len(ch))
+	}
+
+	b.ObserveStates("cpu", []components.State{{Name: "cpu", Healthy: false, Reason: "too hot"}})
+	if len(ch) != 1 {
+		t.Fatalf("expected 1 transition, got %d events", len(ch))
+	}
+	ev := <-ch
+	if ev.Kind != v1.WatchEventKindState || ev.Component != "cpu" || ev.State == nil {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if ev.State.PreviousHealth != components.StateHealthy || ev.State.Health != components.StateUnhealthy || ev.State.Reason != "too hot" {
+		t.Errorf("unexpected transition %+v", ev.State)
+	}
+
+	b.ObserveStates("cpu", []components.State{{Name: "cpu", Health: components.StateDegraded}})
+	ev = <-ch
+	if ev.State.PreviousHealth != components.StateUnhealthy || ev.State.Health != components.StateDegraded {
+		t.Errorf("unexpected transition %+v", ev.State)
+	}
+}
+
+type testStore struct {
+	err error
+}
+
+func (s *testStore) Bucket(name string, _ ...eventstore.OpOption) (eventstore.Bucket, error) {
+	return &testBucket{name: name, err: s.err}, nil
+}
+
+type testBucket struct {
+	eventstore.Bucket
+	name string
+	err  error
+}
+
+func (b *testBucket) Name() string { return "components_" + b.name + "_events" }
+func (b *testBucket) Insert(ctx context.Context, ev components.Event) error {
+	return b.err
+}
+
+func TestWrapStore(t *testing.T) {
+	b := NewBroker()
+	ch, unsubscribe := b.Subscribe(Filter{})
+	defer unsubscribe()
+
+	bucket, err := b.WrapStore(&testStore{}).Bucket("xid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := metav1.Now()
+	if err := bucket.Insert(context.Background(), components.Event{Time: now, Name: "xid", Type: common.EventTypeCritical}); err != nil {
+		t.Fatal(err)
+	}
+	ev := <-ch
+	if ev.Kind != v1.WatchEventKindEvent || ev.Component != "xid" || ev.Event == nil || ev.Event.Name != "xid" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if !ev.Time.Equal(now.Time) {
+		t.Errorf("unexpected time %v", ev.Time)
+	}
+
+	// failed inserts are not published
+	bucket, err = b.WrapStore(&testStore{err: errors.New("insert failed")}).Bucket("xid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.Insert(context.Background(), components.Event{Time: now}); err == nil {
+		t.Fatal("expected insert error")
+	}
+	if len(ch) != 0 {
+		t.Errorf("expected no event, got %d", len(ch))
+	}
+}